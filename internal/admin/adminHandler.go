@@ -2,6 +2,7 @@ package admin
 
 import (
 	"medassist/internal/admin/dto"
+	"medassist/internal/lifecycle"
 	"medassist/utils"
 	"net/http"
 
@@ -266,13 +267,15 @@ func (h *AdminHandler) DeleteUser(c *gin.Context){
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "ID da Visita a ser atualizada"
-// @Param payload body object true "Campos para atualizar (JSON arbitrário). Ex: {\"status\": \"COMPLETED\", \"nurse_id\": \"...\"}"
+// @Param payload body object true "Campos para atualizar (JSON arbitrário). Ex: {\"status\": \"CANCELED\", \"cancel_reason\": \"...\"}"
 // @Success 200 {object} utils.SuccessVisitTypeResponse "Visita atualizada com sucesso"
 // @Failure 400 {object} utils.ErrorResponse "JSON inválido, ID não encontrado ou campo protegido"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Administrador)"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
 // @Router /admin/visit/{id} [patch]
 func (h *AdminHandler) UpdateVisit(c *gin.Context) {
+	adminId := utils.GetUserId(c)
 	visitId := c.Param("id")
 
 	var updates map[string]interface{}
@@ -282,9 +285,10 @@ func (h *AdminHandler) UpdateVisit(c *gin.Context) {
 	}
 
 	protectedFields := map[string]bool{
-		"id":         true,
-		"created_at": true,
-		"updated_at": true,
+		"id":             true,
+		"created_at":     true,
+		"updated_at":     true,
+		"status_history": true,
 	}
 
 	for key := range updates {
@@ -294,9 +298,10 @@ func (h *AdminHandler) UpdateVisit(c *gin.Context) {
 		}
 	}
 	
-	visit, err := h.adminService.UpdateVisit(visitId, updates)
+	visit, err := h.adminService.UpdateVisit(adminId, visitId, updates)
 	if err != nil{
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
 	}
 
 	utils.SendSuccessResponse(c, "Usuário atualizado com sucesso.", visit)
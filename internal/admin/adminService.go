@@ -3,6 +3,8 @@ package admin
 import (
 	"fmt"
 	"medassist/internal/admin/dto"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
 	"medassist/internal/repository"
 	"medassist/utils"
 	"os"
@@ -23,18 +25,19 @@ type AdminService interface {
 	UserLists() (dto.UserListsResponse, error)
 	UpdateUser(userId string, updates map[string]interface{}) (dto.UserTypeResponse, error)
 	DeleteNurseOrUser(userId string) error
-	UpdateVisit(visitId string, updates map[string]interface{}) (dto.VisitTypeResponse, error)
+	UpdateVisit(adminId, visitId string, updates map[string]interface{}) (dto.VisitTypeResponse, error)
 	DeleteVisit(visitId string) error
 }
 
 type adminService struct {
-	userRepository  repository.UserRepository
-	nurseRepository repository.NurseRepository
-	visitRepository repository.VisitRepository
+	userRepository    repository.UserRepository
+	nurseRepository   repository.NurseRepository
+	visitRepository   repository.VisitRepository
+	visitStateMachine lifecycle.VisitStateMachine
 }
 
-func NewAdminService(userRepository repository.UserRepository, nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository) AdminService {
-	return &adminService{userRepository: userRepository, nurseRepository: nurseRepository, visitRepository: visitRepository}
+func NewAdminService(userRepository repository.UserRepository, nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository, refunder lifecycle.Refunder) AdminService {
+	return &adminService{userRepository: userRepository, nurseRepository: nurseRepository, visitRepository: visitRepository, visitStateMachine: lifecycle.NewVisitStateMachine(visitRepository, refunder)}
 }
 
 func (s *adminService) ApproveNurseRegister(approvedNurseId string) (string, error) {
@@ -418,7 +421,7 @@ func (s *adminService) UserLists() (dto.UserListsResponse, error) {
 	for _, visit := range visits {
 		userLists.Visits = append(userLists.Visits, dto.VisitTypeResponse{
 			ID:           visit.ID.Hex(),
-			Status:       visit.Status,
+			Status:       string(visit.Status),
 			PatientId:    visit.PatientId,
 			PatientName:  visit.PatientName,
 			PatientEmail: visit.PatientEmail,
@@ -501,14 +504,39 @@ func (s *adminService) UpdateUser(userId string, updates map[string]interface{})
 	return dto.UserTypeResponse{}, fmt.Errorf("usuário não encontrado")
 }
 
-func (s *adminService) UpdateVisit(visitId string, updates map[string]interface{}) (dto.VisitTypeResponse, error) {
-	updated, err := s.visitRepository.UpdateVisitFields(visitId, updates)
-	if err != nil {
-		return dto.VisitTypeResponse{}, fmt.Errorf("erro ao atualizar campos do enfermeiro(a): %w", err)
+func (s *adminService) UpdateVisit(adminId, visitId string, updates map[string]interface{}) (dto.VisitTypeResponse, error) {
+	var updated model.Visit
+
+	// mudanças de status passam pela máquina de estados; o restante é atualizado diretamente
+	if statusRaw, ok := updates["status"]; ok {
+		status, ok := statusRaw.(string)
+		if !ok {
+			return dto.VisitTypeResponse{}, fmt.Errorf("Status da visita inválido.")
+		}
+		delete(updates, "status")
+
+		visit, err := s.visitRepository.FindVisitById(visitId)
+		if err != nil {
+			return dto.VisitTypeResponse{}, err
+		}
+
+		actor := lifecycle.Actor{ID: adminId, Role: lifecycle.RoleAdmin}
+		reason, _ := updates["cancel_reason"].(string)
+
+		updated, err = s.visitStateMachine.Transition(visit, model.VisitStatus(status), actor, reason, updates)
+		if err != nil {
+			return dto.VisitTypeResponse{}, err
+		}
+	} else {
+		var err error
+		updated, err = s.visitRepository.UpdateVisitFields(visitId, updates)
+		if err != nil {
+			return dto.VisitTypeResponse{}, fmt.Errorf("erro ao atualizar campos do enfermeiro(a): %w", err)
+		}
 	}
 
 	updatedVisit := dto.VisitTypeResponse{
-		Status:       updated.Status,
+		Status:       string(updated.Status),
 		PatientId:    updated.PatientId,
 		PatientName:  updated.PatientName,
 		PatientEmail: updated.PatientEmail,
@@ -22,7 +22,7 @@ func TestAdminService_DeleteVisit(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		mockVisitRepo.EXPECT().DeleteVisit("123").Return(fmt.Errorf("visita não encontrada"))
 
@@ -40,7 +40,7 @@ func TestAdminService_DeleteVisit(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		mockVisitRepo.EXPECT().DeleteVisit("123").Return(nil)
 
@@ -59,7 +59,7 @@ func TestAdminService_UserLists(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		mockUserRepo.EXPECT().FindAllUsers().Return(nil, fmt.Errorf("banco caiu"))
 
@@ -76,7 +76,7 @@ func TestAdminService_UserLists(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		fakeUser1 := model.User{
 			Role: "PATIENT",
@@ -107,7 +107,7 @@ func TestAdminService_DeleteNurseOrUser(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		fakeUser := model.User{Role: "PATIENT"}
 		
@@ -130,7 +130,7 @@ func TestAdminService_UpdateUser(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		updates := map[string]interface{}{"email": "existente@test.com"}
 		
@@ -151,7 +151,7 @@ func TestAdminService_UpdateUser(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		mockUserRepo.EXPECT().FindUserById("id-1234").Return(model.User{}, fmt.Errorf("n existe"))
 		mockNurseRepo.EXPECT().FindNurseById("id-1234").Return(model.Nurse{}, fmt.Errorf("n existe"))
@@ -170,7 +170,7 @@ func TestAdminService_UpdateUser(t *testing.T) {
 		mockNurseRepo := repmocks.NewMockNurseRepository(ctrl)
 		mockVisitRepo := repmocks.NewMockVisitRepository(ctrl)
 
-		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo)
+		service := NewAdminService(mockUserRepo, mockNurseRepo, mockVisitRepo, nil)
 
 		fakeUser := model.User{Role: "PATIENT"}
 
@@ -161,18 +161,18 @@ func (mr *MockAdminServiceMockRecorder) UpdateUser(userId, updates any) *gomock.
 }
 
 // UpdateVisit mocks base method.
-func (m *MockAdminService) UpdateVisit(visitId string, updates map[string]any) (dto.VisitTypeResponse, error) {
+func (m *MockAdminService) UpdateVisit(adminId, visitId string, updates map[string]any) (dto.VisitTypeResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateVisit", visitId, updates)
+	ret := m.ctrl.Call(m, "UpdateVisit", adminId, visitId, updates)
 	ret0, _ := ret[0].(dto.VisitTypeResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateVisit indicates an expected call of UpdateVisit.
-func (mr *MockAdminServiceMockRecorder) UpdateVisit(visitId, updates any) *gomock.Call {
+func (mr *MockAdminServiceMockRecorder) UpdateVisit(adminId, visitId, updates any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisit", reflect.TypeOf((*MockAdminService)(nil).UpdateVisit), visitId, updates)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisit", reflect.TypeOf((*MockAdminService)(nil).UpdateVisit), adminId, visitId, updates)
 }
 
 // UserLists mocks base method.
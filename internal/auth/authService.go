@@ -118,6 +118,7 @@ func (s *authService) UserRegister(registerRequestDTO dto.UserRegisterRequestDTO
 	} else {
 		user.Latitude = lat
 		user.Longitude = lon
+		user.GeoLocation = model.NewGeoPoint(lat, lon)
 	}
 
 	if fileHeaders, ok := files["image_profile"]; ok && len(fileHeaders) > 0 {
@@ -259,6 +260,7 @@ func (s *authService) NurseRegister(nurseRequestDTO dto.NurseRegisterRequestDTO,
 	} else {
 		nurse.Latitude = lat
 		nurse.Longitude = lon
+		nurse.GeoLocation = model.NewGeoPoint(lat, lon)
 	}
 
 	// faz o upload de todos os arquivos e preenche os IDs no objeto nurse
@@ -3,12 +3,9 @@ package chat
 import (
 	"encoding/json"
 	"log"
-	"medassist/internal/model"
 	"net/http"
-	"time"
 
 	"github.com/gorilla/websocket"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Client agora tem os dados do usuário
@@ -34,6 +31,7 @@ var upgrader = websocket.Upgrader{
 type WebSocketMessage struct {
 	ID         string `json:"id"`
 	SenderID   string `json:"sender_id"`
+	ReceiverID string `json:"receiver_id"`
 	SenderName string `json:"sender_name"`
 	SenderRole string `json:"sender_role"`
 	Message    string `json:"message"`
@@ -42,11 +40,18 @@ type WebSocketMessage struct {
 
 // readPump agora enriquece a mensagem antes de enviá-la
 type ClientMessage struct {
+	Type       string `json:"type"`
 	ReceiverID string `json:"receiver_id"`
 	Message    string `json:"message"`
+
+	// Preenchidos apenas em mensagens LOCATION_UPDATE
+	VisitID   string  `json:"visit_id"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
-// Substitua sua função readPump inteira por esta versão corrigida
+// readPump lê as mensagens do websocket: posições do enfermeiro e mensagens de chat, que o hub
+// entrega apenas aos participantes da conversa.
 func (c *Client) readPump() {
 	defer func() {
 		c.hub.unregister <- c
@@ -66,38 +71,12 @@ func (c *Client) readPump() {
 			continue
 		}
 
-		// 2. Converte os IDs de string para ObjectID
-		senderID, _ := primitive.ObjectIDFromHex(c.UserID)
-		receiverID, _ := primitive.ObjectIDFromHex(clientMsg.ReceiverID)
-
-		// 3. Cria uma instância do modelo de MENSAGEM PARA O BANCO DE DADOS
-		dbMessage := &model.Message{
-			SenderID:   senderID,
-			ReceiverID: receiverID,
-			Content:    clientMsg.Message,
-			Read:       false,
-		}
-
-		// 4. SALVA A MENSAGEM NO BANCO DE DADOS
-		err = c.hub.msgRepo.Save(dbMessage)
-		if err != nil {
-			log.Printf("error saving message to db: %v", err)
+		if clientMsg.Type == MessageTypeLocation {
+			c.hub.handleLocationUpdate(c, clientMsg)
 			continue
 		}
-		// Após salvar, dbMessage agora contém o ID e o Timestamp gerados pelo banco
-
-		// 5. Cria a mensagem para transmitir via WebSocket com os dados salvos
-		broadcastMessage := WebSocketMessage{
-			ID:         dbMessage.ID.Hex(),
-			SenderID:   dbMessage.SenderID.Hex(),
-			SenderName: c.Name,
-			SenderRole: c.Role,
-			Message:    dbMessage.Content,
-			Timestamp:  dbMessage.Timestamp.Format(time.RFC3339),
-		}
 
-		jsonMessage, _ := json.Marshal(broadcastMessage)
-		c.hub.broadcast <- jsonMessage
+		c.hub.handleChatMessage(c, clientMsg)
 	}
 }
 
@@ -13,4 +13,21 @@ type ConversationDTO struct {
 	PartnerImageID       primitive.ObjectID `bson:"partner_image_id,omitempty" json:"partner_image_id,omitempty"`
 	LastMessage          string             `bson:"last_message" json:"last_message"`
 	LastMessageTimestamp time.Time          `bson:"last_message_timestamp" json:"last_message_timestamp"`
-}
\ No newline at end of file
+}
+// VisitEventDTO é enviado pelo WebSocket quando algo muda em uma visita do usuário.
+type VisitEventDTO struct {
+	Type    string      `json:"type"`
+	VisitID string      `json:"visit_id"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NurseLocationDTO é a posição do enfermeiro a caminho da visita. DistanceKm e EtaMinutes
+// ficam vazios quando o endereço da visita não tem coordenadas.
+type NurseLocationDTO struct {
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	DistanceKm *float64  `json:"distance_km,omitempty"`
+	EtaMinutes *int      `json:"eta_minutes,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -1,60 +1,137 @@
 package chat
 
 import (
+	"encoding/json"
 	"log" // Adicionado para logs
+	"medassist/internal/chat/dto"
 	"medassist/internal/repository"
+	"sync"
 )
 
 type Hub struct {
-	// 1. ALTERADO: Mapeia UserID (string) para *Client
-	// Isso permite encontrar um cliente específico pelo ID
-	clients map[string]*Client
+	// 1. ALTERADO: Mapeia UserID (string) para as conexões do usuário
+	// Isso permite encontrar os clientes de um usuário pelo ID; cada aba ou aparelho
+	// aberto é uma conexão própria
+	clients map[string]map[*Client]struct{}
+	// Protege clients: as mensagens de chat são entregues pelas goroutines de leitura de cada
+	// cliente, em paralelo com o registro e a saída de clientes em Run
+	clientsMu sync.Mutex
 
-	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
 	msgRepo    repository.MessageRepository
+
+	// Usados para validar e repassar a localização do enfermeiro durante uma visita e o
+	// destinatário das mensagens de chat
+	visitRepo        repository.VisitRepository
+	userRepo         repository.UserRepository
+	nurseRepo        repository.NurseRepository
+	locationMu       sync.Mutex
+	locationSessions map[string]locationSession
 }
 
-func NewHub(msgRepo repository.MessageRepository) *Hub {
+func NewHub(msgRepo repository.MessageRepository, visitRepo repository.VisitRepository, userRepo repository.UserRepository, nurseRepo repository.NurseRepository) *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		// Inicializa o mapa modificado
-		clients: make(map[string]*Client),
+		clients: make(map[string]map[*Client]struct{}),
 		msgRepo: msgRepo,
+
+		visitRepo:        visitRepo,
+		userRepo:         userRepo,
+		nurseRepo:        nurseRepo,
+		locationSessions: make(map[string]locationSession),
 	}
 }
 
-// 2. NOVO MÉTODO: SendToNurse
-// Envia uma mensagem para um cliente específico baseado no UserID.
-// Retorna true se o cliente foi encontrado e a mensagem enfileirada, false caso contrário.
-func (h *Hub) SendToNurse(userID string, message []byte) bool {
-	// Procura o cliente no mapa usando o UserID
-	client, ok := h.clients[userID]
+// 2. NOVO MÉTODO: SendToUser
+// Envia uma mensagem para todas as conexões do usuário (paciente ou enfermeiro) baseado no UserID.
+// Retorna true se a mensagem foi enfileirada em ao menos uma conexão, false caso contrário.
+func (h *Hub) SendToUser(userID string, message []byte) bool {
+	// O lock vale até o envio para o canal não ser fechado por Run no meio dele
+	h.clientsMu.Lock()
+	defer h.clientsMu.Unlock()
+
+	// Procura as conexões do usuário no mapa usando o UserID
+	clients, ok := h.clients[userID]
 	if !ok {
 		// Cliente não está conectado ao WebSocket
-		log.Printf("[Hub SendToNurse] Tentativa de enviar para usuário offline ou não conectado: %s", userID)
+		log.Printf("[Hub SendToUser] Tentativa de enviar para usuário offline ou não conectado: %s", userID)
+		return false
+	}
+
+	sent := false
+	for client := range clients {
+		// Tenta enviar a mensagem para o canal 'send' de cada conexão
+		select {
+		case client.send <- message:
+			sent = true // Mensagem enfileirada
+		default:
+			// O canal 'send' do cliente está cheio ou fechado (cliente lento/desconectado)
+			// Remove só esta conexão para evitar tentar enviar novamente
+			log.Printf("[Hub SendToUser] Canal do usuário %s cheio ou fechado. Removendo cliente.", userID)
+			h.removeClient(client)
+			// IMPORTANTE: Aqui NÃO chamamos SetNurseOffline, pois este Hub é genérico.
+			// A lógica de SetNurseOffline deve ficar no hub específico de visitas (se você o criar)
+			// ou ser tratada na desconexão (unregister).
+		}
+	}
+
+	if sent {
+		log.Printf("[Hub SendToUser] Mensagem enviada com sucesso para: %s", userID)
+	}
+	return sent
+}
+
+// addClient registra mais uma conexão do usuário. Deve ser chamado com clientsMu travado.
+func (h *Hub) addClient(client *Client) {
+	clients, ok := h.clients[client.UserID]
+	if !ok {
+		clients = make(map[*Client]struct{})
+		h.clients[client.UserID] = clients
+	}
+	clients[client] = struct{}{}
+}
+
+// removeClient remove a conexão e fecha o canal dela, mantendo as demais conexões do usuário.
+// Retorna false se ela já tinha sido removida. Deve ser chamado com clientsMu travado.
+func (h *Hub) removeClient(client *Client) bool {
+	clients, ok := h.clients[client.UserID]
+	if !ok {
+		return false
+	}
+	if _, ok := clients[client]; !ok {
+		return false
+	}
+
+	delete(clients, client)
+	if len(clients) == 0 {
+		delete(h.clients, client.UserID)
+	}
+	close(client.send)
+	return true
+}
+
+// SendToNurse envia uma mensagem ao enfermeiro conectado; é o mesmo envio de SendToUser.
+func (h *Hub) SendToNurse(nurseID string, message []byte) bool {
+	return h.SendToUser(nurseID, message)
+}
+
+// NotifyVisitEvent serializa o evento e envia ao usuário, se ele estiver conectado.
+// Aceita Hub nil para que os serviços funcionem sem WebSocket (ex: testes).
+func (h *Hub) NotifyVisitEvent(userID string, event dto.VisitEventDTO) bool {
+	if h == nil {
 		return false
 	}
 
-	// Tenta enviar a mensagem para o canal 'send' do cliente
-	select {
-	case client.send <- message:
-		log.Printf("[Hub SendToNurse] Mensagem enviada com sucesso para: %s", userID)
-		return true // Mensagem enfileirada
-	default:
-		// O canal 'send' do cliente está cheio ou fechado (cliente lento/desconectado)
-		// Remove o cliente do hub para evitar tentar enviar novamente
-		log.Printf("[Hub SendToNurse] Canal do usuário %s cheio ou fechado. Removendo cliente.", userID)
-		delete(h.clients, userID) // Usa o ID para send
-		close(client.send)
-		// IMPORTANTE: Aqui NÃO chamamos SetNurseOffline, pois este Hub é genérico.
-		// A lógica de SetNurseOffline deve ficar no hub específico de visitas (se você o criar)
-		// ou ser tratada na desconexão (unregister).
-		return false // Falha ao enviar
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[Hub NotifyVisitEvent] Erro ao serializar evento %s da visita %s: %v", event.Type, event.VisitID, err)
+		return false
 	}
+
+	return h.SendToUser(userID, payload)
 }
 
 func (h *Hub) Run() {
@@ -64,7 +141,9 @@ func (h *Hub) Run() {
 		case client := <-h.register:
 			// 3. ALTERADO: Usa UserID como chave
 			log.Printf("[Hub Run] Registrando cliente: ID=%s, Nome=%s, Role=%s", client.UserID, client.Name, client.Role)
-			h.clients[client.UserID] = client
+			h.clientsMu.Lock()
+			h.addClient(client)
+			h.clientsMu.Unlock()
 			// NOTA: A lógica de SetNurseOnline NÃO entra aqui, pois este Hub é para CHAT.
 			// Se você quiser que a conexão ao chat marque o enfermeiro como online,
 			// você precisaria injetar o NurseService aqui e chamar SetNurseOnline.
@@ -72,31 +151,16 @@ func (h *Hub) Run() {
 
 		// Caso um cliente se desconecte
 		case client := <-h.unregister:
-			// 4. ALTERADO: Verifica e remove usando UserID
-			if _, ok := h.clients[client.UserID]; ok {
+			// 4. ALTERADO: Remove só a conexão que saiu, mantendo as outras do mesmo usuário
+			// SendToUser pode já ter removido e fechado o canal dela
+			h.clientsMu.Lock()
+			if h.removeClient(client) {
 				log.Printf("[Hub Run] Desregistrando cliente: ID=%s", client.UserID)
-				delete(h.clients, client.UserID)
-				close(client.send)
 				// NOTA: A lógica de SetNurseOffline NÃO entra aqui pelo mesmo motivo acima.
 				// A desconexão do CHAT não deveria necessariamente marcar o enfermeiro como
 				// indisponível para VISITAS, a menos que seja essa sua regra de negócio.
 			}
-
-		// Caso chegue uma nova mensagem para ser enviada a TODOS (Broadcast - Lógica de Chat)
-		case message := <-h.broadcast:
-			// Esta parte permanece igual, envia para todos no mapa
-			log.Printf("[Hub Run] Broadcasting mensagem para %d clientes", len(h.clients))
-			for userID, client := range h.clients { // Itera sobre o mapa modificado
-				select {
-				case client.send <- message:
-					// Enviado com sucesso
-				default:
-					// Cliente lento/desconectado durante o broadcast
-					log.Printf("[Hub Run - Broadcast] Canal do usuário %s cheio ou fechado. Removendo cliente.", userID)
-					delete(h.clients, userID)
-					close(client.send)
-				}
-			}
+			h.clientsMu.Unlock()
 		}
 	}
 }
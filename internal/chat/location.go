@@ -0,0 +1,169 @@
+package chat
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"medassist/internal/chat/dto"
+	"medassist/internal/model"
+	"medassist/utils"
+	"time"
+)
+
+// Tipos de mensagem aceitos pelo WebSocket. Mensagens sem type são tratadas como chat.
+const (
+	MessageTypeChat     = "CHAT"
+	MessageTypeLocation = "LOCATION_UPDATE"
+)
+
+// Eventos enviados durante o compartilhamento de localização do enfermeiro.
+const (
+	EventNurseLocation   = "NURSE_LOCATION"          // nova posição e ETA para o paciente
+	EventLocationStopped = "NURSE_LOCATION_STOPPED"  // a visita terminou ou foi encerrada
+	EventLocationDenied  = "NURSE_LOCATION_REJECTED" // o enfermeiro não pode compartilhar nesta visita
+)
+
+// averageSpeedKmh é a velocidade média em deslocamento urbano usada para estimar a chegada.
+const averageSpeedKmh = 30.0
+
+// locationSessionTTL é por quanto tempo a sessão em cache vale antes de o status da visita ser
+// consultado de novo. Assim o compartilhamento também para quando a visita deixa de estar
+// CONFIRMED por caminhos que não chamam StopLocationSharing (admin, webhook, expiração).
+const locationSessionTTL = 30 * time.Second
+
+// locationSession guarda os dados da visita em andamento para não consultar o banco a cada posição enviada.
+type locationSession struct {
+	NurseID   string
+	PatientID string
+	Latitude  float64
+	Longitude float64
+	CheckedAt time.Time
+}
+
+// EstimateArrival estima o tempo de deslocamento para a distância informada.
+func EstimateArrival(distanceKm float64) time.Duration {
+	minutes := math.Ceil(distanceKm / averageSpeedKmh * 60)
+	return time.Duration(minutes) * time.Minute
+}
+
+// handleLocationUpdate repassa a posição do enfermeiro ao paciente da visita, com a distância
+// e o ETA até o endereço da visita calculados no servidor.
+func (h *Hub) handleLocationUpdate(c *Client, msg ClientMessage) {
+	session, err := h.locationSession(msg.VisitID, c)
+	if err != nil {
+		h.NotifyVisitEvent(c.UserID, dto.VisitEventDTO{
+			Type:    EventLocationDenied,
+			VisitID: msg.VisitID,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	update := dto.NurseLocationDTO{
+		Latitude:  msg.Latitude,
+		Longitude: msg.Longitude,
+		UpdatedAt: time.Now(),
+	}
+	if session.Latitude != 0 || session.Longitude != 0 {
+		distance := utils.DistanceKm(msg.Latitude, msg.Longitude, session.Latitude, session.Longitude)
+		eta := int(EstimateArrival(distance).Minutes())
+		update.DistanceKm = &distance
+		update.EtaMinutes = &eta
+	}
+
+	h.NotifyVisitEvent(session.PatientID, dto.VisitEventDTO{
+		Type:    EventNurseLocation,
+		VisitID: msg.VisitID,
+		Message: fmt.Sprintf("%s está a caminho.", c.Name),
+		Data:    update,
+	})
+}
+
+// locationSession valida que o cliente é o enfermeiro de uma visita CONFIRMED e devolve a
+// sessão em cache, criando-a na primeira posição enviada. Depois de locationSessionTTL o
+// status é conferido de novo e, se a visita não estiver mais CONFIRMED, o compartilhamento
+// é encerrado.
+func (h *Hub) locationSession(visitId string, c *Client) (locationSession, error) {
+	if c.Role != "NURSE" {
+		return locationSession{}, fmt.Errorf("Apenas enfermeiros podem compartilhar a localização.")
+	}
+
+	h.locationMu.Lock()
+	session, cached := h.locationSessions[visitId]
+	h.locationMu.Unlock()
+	if cached && session.NurseID != c.UserID {
+		return locationSession{}, fmt.Errorf("Essa visita é pertencente à outro enfermeiro.")
+	}
+	if cached && time.Since(session.CheckedAt) < locationSessionTTL {
+		return session, nil
+	}
+
+	if h.visitRepo == nil {
+		return locationSession{}, fmt.Errorf("Compartilhamento de localização indisponível.")
+	}
+
+	visit, err := h.visitRepo.FindVisitById(visitId)
+	if err != nil {
+		return locationSession{}, fmt.Errorf("Visita não encontrada.")
+	}
+	if visit.NurseId != c.UserID {
+		return locationSession{}, fmt.Errorf("Essa visita é pertencente à outro enfermeiro.")
+	}
+	if visit.Status != model.VisitStatusConfirmed {
+		if cached {
+			h.StopLocationSharing(visit)
+		}
+		return locationSession{}, fmt.Errorf("A localização só pode ser compartilhada em visitas confirmadas.")
+	}
+
+	if !cached {
+		session = locationSession{NurseID: visit.NurseId, PatientID: visit.PatientId}
+		session.Latitude, session.Longitude = h.visitDestination(visit)
+	}
+	session.CheckedAt = time.Now()
+
+	h.locationMu.Lock()
+	h.locationSessions[visitId] = session
+	h.locationMu.Unlock()
+
+	return session, nil
+}
+
+// visitDestination retorna as coordenadas do endereço da visita: as do pedido de visita imediata,
+// quando existirem, ou as do cadastro do paciente.
+func (h *Hub) visitDestination(visit model.Visit) (float64, float64) {
+	if visit.Dispatch != nil && (visit.Dispatch.Latitude != 0 || visit.Dispatch.Longitude != 0) {
+		return visit.Dispatch.Latitude, visit.Dispatch.Longitude
+	}
+	if h.userRepo == nil {
+		return 0, 0
+	}
+
+	patient, err := h.userRepo.FindUserById(visit.PatientId)
+	if err != nil {
+		log.Printf("[Hub Location] Erro ao buscar endereço do paciente da visita %s: %v", visit.ID.Hex(), err)
+		return 0, 0
+	}
+	return patient.Latitude, patient.Longitude
+}
+
+// StopLocationSharing encerra o compartilhamento da visita e avisa o paciente e o enfermeiro.
+// Posições enviadas depois disso são recusadas, pois a visita deixa de estar CONFIRMED.
+func (h *Hub) StopLocationSharing(visit model.Visit) {
+	if h == nil {
+		return
+	}
+
+	visitId := visit.ID.Hex()
+	h.locationMu.Lock()
+	delete(h.locationSessions, visitId)
+	h.locationMu.Unlock()
+
+	event := dto.VisitEventDTO{
+		Type:    EventLocationStopped,
+		VisitID: visitId,
+		Message: "O compartilhamento de localização foi encerrado.",
+	}
+	h.NotifyVisitEvent(visit.PatientId, event)
+	h.NotifyVisitEvent(visit.NurseId, event)
+}
@@ -0,0 +1,191 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"medassist/internal/chat/dto"
+	"medassist/internal/model"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestClient(hub *Hub, userID, role string) *Client {
+	client := &Client{hub: hub, send: make(chan []byte, 8), UserID: userID, Name: userID, Role: role}
+	hub.clientsMu.Lock()
+	hub.addClient(client)
+	hub.clientsMu.Unlock()
+	return client
+}
+
+func receivedEvent(t *testing.T, client *Client) (dto.VisitEventDTO, bool) {
+	select {
+	case payload := <-client.send:
+		var event dto.VisitEventDTO
+		assert.NoError(t, json.Unmarshal(payload, &event))
+		return event, true
+	default:
+		return dto.VisitEventDTO{}, false
+	}
+}
+
+func TestEstimateArrival(t *testing.T) {
+	assert.Equal(t, 30*time.Minute, EstimateArrival(15))
+	assert.Equal(t, time.Minute, EstimateArrival(0.1))
+}
+
+func TestHub_HandleLocationUpdate(t *testing.T) {
+	visitID := primitive.NewObjectID()
+	confirmedVisit := model.Visit{ID: visitID, Status: model.VisitStatusConfirmed, NurseId: "nurse-1", PatientId: "patient-1"}
+	location := ClientMessage{Type: MessageTypeLocation, VisitID: visitID.Hex(), Latitude: -23.5595, Longitude: -46.6333}
+
+	t.Run("Sucesso_Repassa_Posicao_Com_ETA_Apenas_Ao_Paciente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		hub := NewHub(nil, visitRepo, userRepo, nil)
+
+		nurse := newTestClient(hub, "nurse-1", "NURSE")
+		patient := newTestClient(hub, "patient-1", "PATIENT")
+		other := newTestClient(hub, "patient-2", "PATIENT")
+
+		// a visita só é consultada na primeira posição enviada
+		visitRepo.EXPECT().FindVisitById(visitID.Hex()).Return(confirmedVisit, nil).Times(1)
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{Latitude: -23.5505, Longitude: -46.6333}, nil).Times(1)
+
+		hub.handleLocationUpdate(nurse, location)
+		hub.handleLocationUpdate(nurse, location)
+
+		for i := 0; i < 2; i++ {
+			event, ok := receivedEvent(t, patient)
+			assert.True(t, ok)
+			assert.Equal(t, EventNurseLocation, event.Type)
+			data := event.Data.(map[string]interface{})
+			assert.InDelta(t, 1.0, data["distance_km"], 0.01)
+			assert.Equal(t, 3.0, data["eta_minutes"])
+		}
+		_, ok := receivedEvent(t, other)
+		assert.False(t, ok)
+		_, ok = receivedEvent(t, nurse)
+		assert.False(t, ok)
+	})
+
+	t.Run("Erro_Visita_Nao_Confirmada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		hub := NewHub(nil, visitRepo, nil, nil)
+
+		nurse := newTestClient(hub, "nurse-1", "NURSE")
+		patient := newTestClient(hub, "patient-1", "PATIENT")
+
+		pending := confirmedVisit
+		pending.Status = model.VisitStatusPending
+		visitRepo.EXPECT().FindVisitById(visitID.Hex()).Return(pending, nil)
+
+		hub.handleLocationUpdate(nurse, location)
+
+		event, ok := receivedEvent(t, nurse)
+		assert.True(t, ok)
+		assert.Equal(t, EventLocationDenied, event.Type)
+		_, ok = receivedEvent(t, patient)
+		assert.False(t, ok)
+	})
+
+	t.Run("Erro_Paciente_Nao_Pode_Enviar_Localizacao", func(t *testing.T) {
+		hub := NewHub(nil, nil, nil, nil)
+		patient := newTestClient(hub, "patient-1", "PATIENT")
+
+		hub.handleLocationUpdate(patient, location)
+
+		event, ok := receivedEvent(t, patient)
+		assert.True(t, ok)
+		assert.Equal(t, EventLocationDenied, event.Type)
+	})
+
+	t.Run("Sucesso_Para_Apos_Conclusao_Da_Visita", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		hub := NewHub(nil, visitRepo, userRepo, nil)
+
+		nurse := newTestClient(hub, "nurse-1", "NURSE")
+		patient := newTestClient(hub, "patient-1", "PATIENT")
+
+		completed := confirmedVisit
+		completed.Status = model.VisitStatusCompleted
+		gomock.InOrder(
+			visitRepo.EXPECT().FindVisitById(visitID.Hex()).Return(confirmedVisit, nil),
+			visitRepo.EXPECT().FindVisitById(visitID.Hex()).Return(completed, nil),
+		)
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{}, nil)
+
+		hub.handleLocationUpdate(nurse, location)
+		hub.StopLocationSharing(completed)
+		hub.handleLocationUpdate(nurse, location)
+
+		first, _ := receivedEvent(t, patient)
+		stopped, _ := receivedEvent(t, patient)
+		assert.Equal(t, EventNurseLocation, first.Type)
+		assert.Nil(t, first.Data.(map[string]interface{})["eta_minutes"])
+		assert.Equal(t, EventLocationStopped, stopped.Type)
+		_, ok := receivedEvent(t, patient)
+		assert.False(t, ok)
+
+		stopped, _ = receivedEvent(t, nurse)
+		denied, _ := receivedEvent(t, nurse)
+		assert.Equal(t, EventLocationStopped, stopped.Type)
+		assert.Equal(t, EventLocationDenied, denied.Type)
+	})
+
+	t.Run("Sucesso_Para_Quando_Status_Muda_Fora_Do_Hub", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		hub := NewHub(nil, visitRepo, userRepo, nil)
+
+		nurse := newTestClient(hub, "nurse-1", "NURSE")
+		patient := newTestClient(hub, "patient-1", "PATIENT")
+
+		// a visita é cancelada pelo admin sem passar por StopLocationSharing
+		canceled := confirmedVisit
+		canceled.Status = model.VisitStatusCanceled
+		gomock.InOrder(
+			visitRepo.EXPECT().FindVisitById(visitID.Hex()).Return(confirmedVisit, nil),
+			visitRepo.EXPECT().FindVisitById(visitID.Hex()).Return(canceled, nil),
+		)
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{}, nil)
+
+		hub.handleLocationUpdate(nurse, location)
+		hub.locationMu.Lock()
+		session := hub.locationSessions[visitID.Hex()]
+		session.CheckedAt = time.Now().Add(-locationSessionTTL)
+		hub.locationSessions[visitID.Hex()] = session
+		hub.locationMu.Unlock()
+		hub.handleLocationUpdate(nurse, location)
+
+		first, _ := receivedEvent(t, patient)
+		stopped, _ := receivedEvent(t, patient)
+		assert.Equal(t, EventNurseLocation, first.Type)
+		assert.Equal(t, EventLocationStopped, stopped.Type)
+		_, ok := receivedEvent(t, patient)
+		assert.False(t, ok)
+
+		stopped, _ = receivedEvent(t, nurse)
+		denied, _ := receivedEvent(t, nurse)
+		assert.Equal(t, EventLocationStopped, stopped.Type)
+		assert.Equal(t, EventLocationDenied, denied.Type)
+		assert.Empty(t, hub.locationSessions)
+	})
+}
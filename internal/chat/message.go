@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"medassist/internal/chat/dto"
+	"medassist/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventMessageRejected avisa o remetente que a mensagem de chat não foi entregue.
+const EventMessageRejected = "CHAT_MESSAGE_REJECTED"
+
+// handleChatMessage salva a mensagem e a entrega apenas ao destinatário e ao próprio remetente,
+// nunca aos demais usuários conectados: as conversas trazem dados de saúde do paciente.
+func (h *Hub) handleChatMessage(c *Client, msg ClientMessage) {
+	senderID, err := primitive.ObjectIDFromHex(c.UserID)
+	if err != nil {
+		log.Printf("[Hub Chat] ID de remetente inválido: %s", c.UserID)
+		return
+	}
+	receiverID, err := h.validateReceiver(c.UserID, msg.ReceiverID)
+	if err != nil {
+		h.NotifyVisitEvent(c.UserID, dto.VisitEventDTO{Type: EventMessageRejected, Message: err.Error()})
+		return
+	}
+
+	dbMessage := &model.Message{
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Content:    msg.Message,
+		Read:       false,
+	}
+	if err := h.msgRepo.Save(dbMessage); err != nil {
+		log.Printf("error saving message to db: %v", err)
+		return
+	}
+
+	// Após salvar, dbMessage contém o ID e o Timestamp gerados pelo banco
+	payload, err := json.Marshal(WebSocketMessage{
+		ID:         dbMessage.ID.Hex(),
+		SenderID:   dbMessage.SenderID.Hex(),
+		ReceiverID: dbMessage.ReceiverID.Hex(),
+		SenderName: c.Name,
+		SenderRole: c.Role,
+		Message:    dbMessage.Content,
+		Timestamp:  dbMessage.Timestamp.Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("[Hub Chat] Erro ao serializar mensagem %s: %v", dbMessage.ID.Hex(), err)
+		return
+	}
+
+	h.SendToUser(msg.ReceiverID, payload)
+	h.SendToUser(c.UserID, payload)
+}
+
+// validateReceiver confere que o destinatário é outro usuário cadastrado, paciente ou enfermeiro.
+func (h *Hub) validateReceiver(senderId, receiverId string) (primitive.ObjectID, error) {
+	receiverID, err := primitive.ObjectIDFromHex(receiverId)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("Destinatário inválido.")
+	}
+	if receiverId == senderId {
+		return primitive.NilObjectID, fmt.Errorf("Não é possível enviar mensagem para você mesmo.")
+	}
+
+	if h.userRepo != nil {
+		if _, err := h.userRepo.FindUserById(receiverId); err == nil {
+			return receiverID, nil
+		}
+	}
+	if h.nurseRepo != nil {
+		if _, err := h.nurseRepo.FindNurseById(receiverId); err == nil {
+			return receiverID, nil
+		}
+	}
+	return primitive.NilObjectID, fmt.Errorf("Destinatário não encontrado.")
+}
@@ -0,0 +1,184 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func receivedMessage(t *testing.T, client *Client) (WebSocketMessage, bool) {
+	select {
+	case payload := <-client.send:
+		var message WebSocketMessage
+		assert.NoError(t, json.Unmarshal(payload, &message))
+		return message, true
+	default:
+		return WebSocketMessage{}, false
+	}
+}
+
+func TestHub_HandleChatMessage(t *testing.T) {
+	patientID := primitive.NewObjectID().Hex()
+	nurseID := primitive.NewObjectID().Hex()
+	otherID := primitive.NewObjectID().Hex()
+
+	type fixture struct {
+		hub       *Hub
+		msgRepo   *repmocks.MockMessageRepository
+		userRepo  *repmocks.MockUserRepository
+		nurseRepo *repmocks.MockNurseRepository
+		patient   *Client
+		nurse     *Client
+		other     *Client
+	}
+
+	setup := func(t *testing.T) fixture {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		f := fixture{
+			msgRepo:   repmocks.NewMockMessageRepository(ctrl),
+			userRepo:  repmocks.NewMockUserRepository(ctrl),
+			nurseRepo: repmocks.NewMockNurseRepository(ctrl),
+		}
+		f.hub = NewHub(f.msgRepo, nil, f.userRepo, f.nurseRepo)
+		f.patient = newTestClient(f.hub, patientID, "PATIENT")
+		f.nurse = newTestClient(f.hub, nurseID, "NURSE")
+		f.other = newTestClient(f.hub, otherID, "PATIENT")
+		return f
+	}
+
+	t.Run("Sucesso_Entrega_Apenas_Ao_Remetente_E_Destinatario", func(t *testing.T) {
+		f := setup(t)
+
+		f.userRepo.EXPECT().FindUserById(nurseID).Return(model.User{}, errors.New("not found"))
+		f.nurseRepo.EXPECT().FindNurseById(nurseID).Return(model.Nurse{}, nil)
+		f.msgRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(message *model.Message) error {
+			message.ID = primitive.NewObjectID()
+			message.Timestamp = time.Now()
+			return nil
+		})
+
+		f.hub.handleChatMessage(f.patient, ClientMessage{ReceiverID: nurseID, Message: "Estou com febre desde ontem."})
+
+		for _, client := range []*Client{f.nurse, f.patient} {
+			message, ok := receivedMessage(t, client)
+			assert.True(t, ok)
+			assert.Equal(t, patientID, message.SenderID)
+			assert.Equal(t, nurseID, message.ReceiverID)
+			assert.Equal(t, "Estou com febre desde ontem.", message.Message)
+		}
+		_, ok := receivedMessage(t, f.other)
+		assert.False(t, ok)
+	})
+
+	t.Run("Sucesso_Duas_Conexoes_Do_Mesmo_Usuario", func(t *testing.T) {
+		f := setup(t)
+		secondNurse := newTestClient(f.hub, nurseID, "NURSE")
+
+		f.userRepo.EXPECT().FindUserById(nurseID).Return(model.User{}, errors.New("not found")).Times(2)
+		f.nurseRepo.EXPECT().FindNurseById(nurseID).Return(model.Nurse{}, nil).Times(2)
+		f.msgRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(message *model.Message) error {
+			message.ID = primitive.NewObjectID()
+			message.Timestamp = time.Now()
+			return nil
+		}).Times(2)
+
+		f.hub.handleChatMessage(f.patient, ClientMessage{ReceiverID: nurseID, Message: "Olá"})
+
+		for _, client := range []*Client{f.nurse, secondNurse} {
+			_, ok := receivedMessage(t, client)
+			assert.True(t, ok)
+		}
+
+		// a primeira conexão sai; só o canal dela é fechado
+		go f.hub.Run()
+		f.hub.unregister <- f.nurse
+		_, open := <-f.nurse.send
+		assert.False(t, open)
+
+		f.hub.handleChatMessage(f.patient, ClientMessage{ReceiverID: nurseID, Message: "Ainda está aí?"})
+
+		message, ok := receivedMessage(t, secondNurse)
+		assert.True(t, ok)
+		assert.Equal(t, "Ainda está aí?", message.Message)
+	})
+
+	t.Run("Erro_Destinatario_Inexistente", func(t *testing.T) {
+		f := setup(t)
+		unknownID := primitive.NewObjectID().Hex()
+
+		f.userRepo.EXPECT().FindUserById(unknownID).Return(model.User{}, errors.New("not found"))
+		f.nurseRepo.EXPECT().FindNurseById(unknownID).Return(model.Nurse{}, errors.New("not found"))
+
+		f.hub.handleChatMessage(f.patient, ClientMessage{ReceiverID: unknownID, Message: "Olá"})
+
+		event, ok := receivedEvent(t, f.patient)
+		assert.True(t, ok)
+		assert.Equal(t, EventMessageRejected, event.Type)
+		_, ok = receivedMessage(t, f.nurse)
+		assert.False(t, ok)
+		_, ok = receivedMessage(t, f.other)
+		assert.False(t, ok)
+	})
+
+	t.Run("Erro_Destinatario_Invalido", func(t *testing.T) {
+		f := setup(t)
+
+		f.hub.handleChatMessage(f.patient, ClientMessage{ReceiverID: "nao-e-um-id", Message: "Olá"})
+		f.hub.handleChatMessage(f.patient, ClientMessage{ReceiverID: patientID, Message: "Olá"})
+
+		for i := 0; i < 2; i++ {
+			event, ok := receivedEvent(t, f.patient)
+			assert.True(t, ok)
+			assert.Equal(t, EventMessageRejected, event.Type)
+		}
+		_, ok := receivedMessage(t, f.other)
+		assert.False(t, ok)
+	})
+
+	t.Run("Sucesso_Remetentes_Simultaneos_Enquanto_Clientes_Entram_E_Saem", func(t *testing.T) {
+		f := setup(t)
+		go f.hub.Run()
+
+		f.userRepo.EXPECT().FindUserById(nurseID).Return(model.User{}, errors.New("not found")).AnyTimes()
+		f.nurseRepo.EXPECT().FindNurseById(nurseID).Return(model.Nurse{}, nil).AnyTimes()
+		f.msgRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(message *model.Message) error {
+			message.ID = primitive.NewObjectID()
+			message.Timestamp = time.Now()
+			return nil
+		}).AnyTimes()
+
+		var wg sync.WaitGroup
+		for _, sender := range []*Client{f.patient, f.other} {
+			wg.Add(1)
+			go func(sender *Client) {
+				defer wg.Done()
+				for i := 0; i < 3; i++ {
+					f.hub.handleChatMessage(sender, ClientMessage{ReceiverID: nurseID, Message: "Olá"})
+				}
+			}(sender)
+		}
+		// outro usuário conecta e desconecta enquanto as mensagens são entregues
+		for i := 0; i < 20; i++ {
+			client := &Client{hub: f.hub, send: make(chan []byte, 8), UserID: primitive.NewObjectID().Hex()}
+			f.hub.register <- client
+			f.hub.unregister <- client
+		}
+		wg.Wait()
+
+		for i := 0; i < 6; i++ {
+			_, ok := receivedMessage(t, f.nurse)
+			assert.True(t, ok)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package commission
+
+import (
+	"fmt"
+	"math"
+	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"strings"
+	"time"
+)
+
+// DefaultRatePercent é a comissão usada enquanto nenhuma regra DEFAULT for cadastrada.
+const DefaultRatePercent = 10.0
+
+// scopePriority ordena os escopos do menos para o mais específico.
+var scopePriority = map[string]int{
+	model.CommissionScopeDefault:        0,
+	model.CommissionScopeSpecialization: 1,
+	model.CommissionScopeVisitType:      2,
+	model.CommissionScopeCampaign:       3,
+	model.CommissionScopeNurse:          4,
+}
+
+// Resolve escolhe, entre as regras, a que vale para a visita do enfermeiro em at: a de escopo
+// mais específico e, entre regras do mesmo escopo, a criada por último. Sem nenhuma regra
+// válida, retorna a regra padrão de DefaultRatePercent.
+func Resolve(rules []model.CommissionRule, visit model.Visit, nurse model.Nurse, at time.Time) model.CommissionRule {
+	best := model.CommissionRule{Name: "Padrão da plataforma", Scope: model.CommissionScopeDefault, RatePercent: DefaultRatePercent}
+	found := false
+
+	for _, rule := range rules {
+		if !Matches(rule, visit, nurse, at) {
+			continue
+		}
+		if !found || scopePriority[rule.Scope] > scopePriority[best.Scope] ||
+			(scopePriority[rule.Scope] == scopePriority[best.Scope] && rule.CreatedAt.After(best.CreatedAt)) {
+			best = rule
+			found = true
+		}
+	}
+
+	return best
+}
+
+// Matches indica se a regra está ativa em at e se todos os seus critérios valem para a visita.
+func Matches(rule model.CommissionRule, visit model.Visit, nurse model.Nurse, at time.Time) bool {
+	if !rule.Active {
+		return false
+	}
+	if rule.StartsAt != nil && at.Before(*rule.StartsAt) {
+		return false
+	}
+	if rule.EndsAt != nil && !at.Before(*rule.EndsAt) {
+		return false
+	}
+	if rule.Specialization != "" && !strings.EqualFold(rule.Specialization, nurse.Specialization) {
+		return false
+	}
+	if rule.VisitType != "" && !strings.EqualFold(rule.VisitType, visit.VisitType) {
+		return false
+	}
+	if rule.NurseID != "" && rule.NurseID != visit.NurseId {
+		return false
+	}
+	return true
+}
+
+// Apply calcula a comissão da regra sobre o valor da visita. O que sobra é repassado ao enfermeiro.
+func Apply(rule model.CommissionRule, visitValue float64, at time.Time) model.VisitCommission {
+	valueInCents := pricing.ToCents(visitValue)
+	amountInCents := int64(math.Round(float64(valueInCents) * rule.RatePercent / 100))
+
+	applied := model.VisitCommission{
+		RuleName:           rule.Name,
+		Scope:              rule.Scope,
+		RatePercent:        rule.RatePercent,
+		AmountInCents:      amountInCents,
+		NurseAmountInCents: valueInCents - amountInCents,
+		AppliedAt:          at,
+	}
+	if !rule.ID.IsZero() {
+		applied.RuleID = rule.ID.Hex()
+	}
+	return applied
+}
+
+// Validate confere a taxa, o período e se a regra tem o critério exigido pelo seu escopo.
+func Validate(rule model.CommissionRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return invalidRule("Informe o nome da regra.")
+	}
+	if rule.RatePercent < 0 || rule.RatePercent > 100 {
+		return invalidRule("A comissão deve estar entre 0% e 100%.")
+	}
+	if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+		return invalidRule("O fim da regra deve ser posterior ao início.")
+	}
+
+	switch rule.Scope {
+	case model.CommissionScopeDefault:
+		if rule.Specialization != "" || rule.VisitType != "" || rule.NurseID != "" {
+			return invalidRule("A regra padrão não pode ter critérios.")
+		}
+	case model.CommissionScopeSpecialization:
+		if rule.Specialization == "" {
+			return invalidRule("Informe a especialização da regra.")
+		}
+	case model.CommissionScopeVisitType:
+		if rule.VisitType == "" {
+			return invalidRule("Informe o tipo de visita da regra.")
+		}
+	case model.CommissionScopeNurse:
+		if rule.NurseID == "" {
+			return invalidRule("Informe o enfermeiro da regra.")
+		}
+	case model.CommissionScopeCampaign:
+		if rule.StartsAt == nil || rule.EndsAt == nil {
+			return invalidRule("Campanhas precisam de início e fim.")
+		}
+	default:
+		return invalidRule(fmt.Sprintf("Escopo %q desconhecido.", rule.Scope))
+	}
+	return nil
+}
+
+func invalidRule(message string) error {
+	return fmt.Errorf("%w %s", ErrInvalidCommissionRule, message)
+}
@@ -0,0 +1,126 @@
+package commission
+
+import (
+	"errors"
+	"medassist/internal/commission/dto"
+	"medassist/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommissionHandler struct {
+	commissionService CommissionService
+}
+
+func NewCommissionHandler(commissionService CommissionService) *CommissionHandler {
+	return &CommissionHandler{commissionService: commissionService}
+}
+
+// @Summary Lista as regras de comissão
+// @Description Lista todas as regras de comissão, ativas ou não, das mais recentes para as mais antigas. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.SuccessResponseNoData "Regras de comissão encontradas"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao buscar regras"
+// @Router /admin/commission-rules [get]
+func (h *CommissionHandler) ListRules(c *gin.Context) {
+	rules, err := h.commissionService.ListRules()
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Regras de comissão encontradas.", rules)
+}
+
+// @Summary Cria uma regra de comissão
+// @Description Cria uma regra de comissão. Escopos: DEFAULT, SPECIALIZATION (exige specialization), VISIT_TYPE (exige visit_type), NURSE (exige nurse_id) e CAMPAIGN (exige starts_at e ends_at). A regra de escopo mais específico vale sobre as demais. Requer autenticação de Administrador.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body dto.CommissionRuleDto true "Regra de comissão"
+// @Success 200 {object} utils.SuccessResponseNoData "Regra de comissão criada"
+// @Failure 400 {object} utils.ErrorResponse "Regra inválida"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Router /admin/commission-rules [post]
+func (h *CommissionHandler) CreateRule(c *gin.Context) {
+	adminId := utils.GetUserId(c)
+
+	var ruleDto dto.CommissionRuleDto
+	if err := c.ShouldBindJSON(&ruleDto); err != nil {
+		utils.SendErrorResponse(c, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.commissionService.CreateRule(adminId, ruleDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Regra de comissão criada.", rule)
+}
+
+// @Summary Altera uma regra de comissão
+// @Description Substitui os dados da regra. Visitas já pagas mantêm a comissão aplicada no repasse. Requer autenticação de Administrador.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da regra"
+// @Param payload body dto.CommissionRuleDto true "Regra de comissão"
+// @Success 200 {object} utils.SuccessResponseNoData "Regra de comissão atualizada"
+// @Failure 400 {object} utils.ErrorResponse "Regra inválida"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Regra não encontrada"
+// @Router /admin/commission-rules/{id} [put]
+func (h *CommissionHandler) UpdateRule(c *gin.Context) {
+	ruleId := c.Param("id")
+
+	var ruleDto dto.CommissionRuleDto
+	if err := c.ShouldBindJSON(&ruleDto); err != nil {
+		utils.SendErrorResponse(c, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.commissionService.UpdateRule(ruleId, ruleDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Regra de comissão atualizada.", rule)
+}
+
+// @Summary Remove uma regra de comissão
+// @Description Remove a regra. Visitas já pagas mantêm a comissão aplicada no repasse. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da regra"
+// @Success 200 {object} utils.SuccessResponseNoData "Regra de comissão removida"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Regra não encontrada"
+// @Router /admin/commission-rules/{id} [delete]
+func (h *CommissionHandler) DeleteRule(c *gin.Context) {
+	if err := h.commissionService.DeleteRule(c.Param("id")); err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Regra de comissão removida.", nil)
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidCommissionRule):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrCommissionRuleNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,120 @@
+package commission
+
+import (
+	"errors"
+	"fmt"
+	"medassist/internal/commission/dto"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCommissionRule indica uma regra de comissão com dados inválidos.
+var ErrInvalidCommissionRule = errors.New("Regra de comissão inválida.")
+
+// ErrCommissionRuleNotFound indica que a regra de comissão não existe.
+var ErrCommissionRuleNotFound = errors.New("Regra de comissão não encontrada.")
+
+// CommissionService mantém as regras de comissão e calcula a comissão de cada repasse.
+type CommissionService interface {
+	CreateRule(adminId string, ruleDto dto.CommissionRuleDto) (model.CommissionRule, error)
+	ListRules() ([]model.CommissionRule, error)
+	UpdateRule(ruleId string, ruleDto dto.CommissionRuleDto) (model.CommissionRule, error)
+	DeleteRule(ruleId string) error
+	ForVisit(visit model.Visit, nurse model.Nurse, at time.Time) (model.VisitCommission, error)
+}
+
+type commissionService struct {
+	commissionRuleRepository repository.CommissionRuleRepository
+}
+
+func NewCommissionService(commissionRuleRepository repository.CommissionRuleRepository) CommissionService {
+	return &commissionService{commissionRuleRepository: commissionRuleRepository}
+}
+
+func (s *commissionService) CreateRule(adminId string, ruleDto dto.CommissionRuleDto) (model.CommissionRule, error) {
+	now := time.Now()
+	rule := model.CommissionRule{Active: true, CreatedBy: adminId, CreatedAt: now}
+	applyDto(&rule, ruleDto)
+	rule.UpdatedAt = now
+
+	if err := Validate(rule); err != nil {
+		return model.CommissionRule{}, err
+	}
+
+	created, err := s.commissionRuleRepository.CreateRule(rule)
+	if err != nil {
+		return model.CommissionRule{}, fmt.Errorf("Erro ao criar regra de comissão: %w", err)
+	}
+	return created, nil
+}
+
+func (s *commissionService) ListRules() ([]model.CommissionRule, error) {
+	rules, err := s.commissionRuleRepository.FindAllRules()
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar regras de comissão: %w", err)
+	}
+	return rules, nil
+}
+
+// UpdateRule altera a regra. Visitas já pagas guardam a comissão aplicada e não são afetadas.
+func (s *commissionService) UpdateRule(ruleId string, ruleDto dto.CommissionRuleDto) (model.CommissionRule, error) {
+	rule, err := s.commissionRuleRepository.FindRuleById(ruleId)
+	if err != nil {
+		return model.CommissionRule{}, notFound(err)
+	}
+
+	applyDto(&rule, ruleDto)
+	rule.UpdatedAt = time.Now()
+
+	if err := Validate(rule); err != nil {
+		return model.CommissionRule{}, err
+	}
+
+	updated, err := s.commissionRuleRepository.UpdateRule(rule)
+	if err != nil {
+		return model.CommissionRule{}, notFound(err)
+	}
+	return updated, nil
+}
+
+func (s *commissionService) DeleteRule(ruleId string) error {
+	if err := s.commissionRuleRepository.DeleteRule(ruleId); err != nil {
+		return notFound(err)
+	}
+	return nil
+}
+
+// ForVisit calcula a comissão do repasse da visita com as regras válidas em at.
+func (s *commissionService) ForVisit(visit model.Visit, nurse model.Nurse, at time.Time) (model.VisitCommission, error) {
+	rules, err := s.commissionRuleRepository.FindActiveRules(at)
+	if err != nil {
+		return model.VisitCommission{}, fmt.Errorf("Erro ao buscar regras de comissão: %w", err)
+	}
+
+	return Apply(Resolve(rules, visit, nurse, at), visit.VisitValue, at), nil
+}
+
+func applyDto(rule *model.CommissionRule, ruleDto dto.CommissionRuleDto) {
+	rule.Name = strings.TrimSpace(ruleDto.Name)
+	rule.Scope = strings.ToUpper(strings.TrimSpace(ruleDto.Scope))
+	if ruleDto.RatePercent != nil {
+		rule.RatePercent = *ruleDto.RatePercent
+	}
+	rule.Specialization = strings.TrimSpace(ruleDto.Specialization)
+	rule.VisitType = strings.TrimSpace(ruleDto.VisitType)
+	rule.NurseID = strings.TrimSpace(ruleDto.NurseID)
+	rule.StartsAt = ruleDto.StartsAt
+	rule.EndsAt = ruleDto.EndsAt
+	if ruleDto.Active != nil {
+		rule.Active = *ruleDto.Active
+	}
+}
+
+func notFound(err error) error {
+	if errors.Is(err, repository.ErrCommissionRuleNotFound) {
+		return ErrCommissionRuleNotFound
+	}
+	return fmt.Errorf("Erro ao salvar regra de comissão: %w", err)
+}
@@ -0,0 +1,100 @@
+package commission
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"medassist/internal/commission/dto"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCommissionService_ForVisit(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Sucesso_Aplica_Regra_Vigente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCommissionRuleRepository(ctrl)
+		s := NewCommissionService(repo)
+
+		repo.EXPECT().FindActiveRules(now).Return([]model.CommissionRule{
+			{Name: "Curativos", Scope: model.CommissionScopeVisitType, VisitType: "curativo", RatePercent: 15, Active: true},
+		}, nil)
+
+		applied, err := s.ForVisit(model.Visit{VisitType: "curativo", VisitValue: 100}, model.Nurse{}, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Curativos", applied.RuleName)
+		assert.Equal(t, int64(1500), applied.AmountInCents)
+		assert.Equal(t, int64(8500), applied.NurseAmountInCents)
+		assert.Equal(t, now, applied.AppliedAt)
+	})
+
+	t.Run("Erro_Busca_Regras", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCommissionRuleRepository(ctrl)
+		s := NewCommissionService(repo)
+
+		repo.EXPECT().FindActiveRules(now).Return(nil, errors.New("mongo indisponível"))
+
+		_, err := s.ForVisit(model.Visit{VisitValue: 100}, model.Nurse{}, now)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestCommissionService_Rules(t *testing.T) {
+	rate := 0.0
+
+	t.Run("Erro_Regra_Invalida_Nao_E_Salva", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewCommissionService(repmocks.NewMockCommissionRuleRepository(ctrl))
+
+		_, err := s.CreateRule("admin-1", dto.CommissionRuleDto{Name: "Primeiro mês", Scope: "nurse", RatePercent: &rate})
+
+		assert.ErrorIs(t, err, ErrInvalidCommissionRule)
+	})
+
+	t.Run("Sucesso_Cria_Regra_Ativa", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCommissionRuleRepository(ctrl)
+		s := NewCommissionService(repo)
+
+		repo.EXPECT().CreateRule(gomock.Any()).DoAndReturn(func(rule model.CommissionRule) (model.CommissionRule, error) {
+			assert.Equal(t, model.CommissionScopeNurse, rule.Scope)
+			assert.Equal(t, "nurse-1", rule.NurseID)
+			assert.True(t, rule.Active)
+			assert.Equal(t, "admin-1", rule.CreatedBy)
+			return rule, nil
+		})
+
+		_, err := s.CreateRule("admin-1", dto.CommissionRuleDto{Name: "Primeiro mês", Scope: "nurse", RatePercent: &rate, NurseID: "nurse-1"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Erro_Regra_Nao_Encontrada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCommissionRuleRepository(ctrl)
+		s := NewCommissionService(repo)
+
+		repo.EXPECT().DeleteRule("rule-1").Return(repository.ErrCommissionRuleNotFound)
+
+		assert.ErrorIs(t, s.DeleteRule("rule-1"), ErrCommissionRuleNotFound)
+	})
+}
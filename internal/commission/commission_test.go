@@ -0,0 +1,86 @@
+package commission
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolve(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	lastWeek := now.AddDate(0, 0, -7)
+	nextWeek := now.AddDate(0, 0, 7)
+	visit := model.Visit{NurseId: "nurse-1", VisitType: "curativo", VisitValue: 200}
+	nurse := model.Nurse{Specialization: "Pediatria"}
+
+	defaultRule := model.CommissionRule{Name: "Padrão", Scope: model.CommissionScopeDefault, RatePercent: 12, Active: true, CreatedAt: lastWeek}
+	specialization := model.CommissionRule{Name: "Pediatria", Scope: model.CommissionScopeSpecialization, RatePercent: 8, Specialization: "pediatria", Active: true}
+	campaign := model.CommissionRule{Name: "Março", Scope: model.CommissionScopeCampaign, RatePercent: 5, StartsAt: &lastWeek, EndsAt: &nextWeek, Active: true}
+	nurseRule := model.CommissionRule{Name: "Primeiro mês", Scope: model.CommissionScopeNurse, RatePercent: 0, NurseID: "nurse-1", EndsAt: &nextWeek, Active: true}
+
+	t.Run("Sucesso_Sem_Regras_Usa_Taxa_Padrao", func(t *testing.T) {
+		rule := Resolve(nil, visit, nurse, now)
+		assert.Equal(t, DefaultRatePercent, rule.RatePercent)
+	})
+
+	t.Run("Sucesso_Regra_Mais_Especifica_Vence", func(t *testing.T) {
+		rules := []model.CommissionRule{defaultRule, specialization, campaign}
+		assert.Equal(t, "Março", Resolve(rules, visit, nurse, now).Name)
+
+		rules = append(rules, nurseRule)
+		assert.Equal(t, "Primeiro mês", Resolve(rules, visit, nurse, now).Name)
+	})
+
+	t.Run("Sucesso_Ignora_Regras_Fora_Do_Periodo_Ou_Inativas", func(t *testing.T) {
+		inactive := nurseRule
+		inactive.Active = false
+		rules := []model.CommissionRule{defaultRule, specialization, campaign, inactive}
+
+		assert.Equal(t, "Pediatria", Resolve(rules, visit, nurse, nextWeek).Name)
+		assert.Equal(t, "Padrão", Resolve(rules, visit, model.Nurse{Specialization: "Geriatria"}, nextWeek).Name)
+	})
+
+	t.Run("Sucesso_Mesmo_Escopo_Usa_Regra_Mais_Recente", func(t *testing.T) {
+		newer := defaultRule
+		newer.Name = "Padrão novo"
+		newer.CreatedAt = now
+		assert.Equal(t, "Padrão novo", Resolve([]model.CommissionRule{newer, defaultRule}, visit, nurse, now).Name)
+	})
+}
+
+func TestApply(t *testing.T) {
+	now := time.Now()
+	rule := model.CommissionRule{ID: primitive.NewObjectID(), Name: "Padrão", Scope: model.CommissionScopeDefault, RatePercent: 12.5}
+
+	applied := Apply(rule, 199.99, now)
+
+	assert.Equal(t, rule.ID.Hex(), applied.RuleID)
+	assert.Equal(t, int64(2500), applied.AmountInCents)
+	assert.Equal(t, int64(17499), applied.NurseAmountInCents)
+	assert.Equal(t, 12.5, applied.RatePercent)
+}
+
+func TestValidate(t *testing.T) {
+	start := time.Now()
+	end := start.Add(time.Hour)
+
+	assert.NoError(t, Validate(model.CommissionRule{Name: "Padrão", Scope: model.CommissionScopeDefault, RatePercent: 10}))
+	assert.NoError(t, Validate(model.CommissionRule{Name: "Campanha", Scope: model.CommissionScopeCampaign, RatePercent: 5, StartsAt: &start, EndsAt: &end}))
+
+	invalid := []model.CommissionRule{
+		{Name: "Taxa", Scope: model.CommissionScopeDefault, RatePercent: 101},
+		{Name: "Padrão", Scope: model.CommissionScopeDefault, RatePercent: 10, NurseID: "nurse-1"},
+		{Name: "Enfermeiro", Scope: model.CommissionScopeNurse, RatePercent: 0},
+		{Name: "Campanha", Scope: model.CommissionScopeCampaign, RatePercent: 5, StartsAt: &start},
+		{Name: "Campanha", Scope: model.CommissionScopeCampaign, RatePercent: 5, StartsAt: &end, EndsAt: &start},
+		{Name: "Outro", Scope: "CITY", RatePercent: 5},
+		{Scope: model.CommissionScopeDefault, RatePercent: 5},
+	}
+	for _, rule := range invalid {
+		assert.ErrorIs(t, Validate(rule), ErrInvalidCommissionRule, rule.Name)
+	}
+}
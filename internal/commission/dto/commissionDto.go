@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+// CommissionRuleDto é o corpo usado para criar ou alterar uma regra de comissão.
+type CommissionRuleDto struct {
+	Name           string     `json:"name" binding:"required"`
+	Scope          string     `json:"scope" binding:"required"`
+	RatePercent    *float64   `json:"rate_percent" binding:"required"`
+	Specialization string     `json:"specialization"`
+	VisitType      string     `json:"visit_type"`
+	NurseID        string     `json:"nurse_id"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Active         *bool      `json:"active"`
+}
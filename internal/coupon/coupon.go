@@ -0,0 +1,149 @@
+package coupon
+
+import (
+	"fmt"
+	"math"
+	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"strings"
+	"time"
+)
+
+// MinimumChargeInCents é o menor valor que o Stripe aceita cobrar em reais. O desconto nunca
+// deixa o pagamento abaixo disso.
+const MinimumChargeInCents = 50
+
+// Purchase descreve o pagamento em que o paciente quer usar o cupom.
+type Purchase struct {
+	PatientRedemptions int    // quantas vezes o paciente já usou o cupom
+	Specialization     string // especialização do enfermeiro; vazia quando ainda não se sabe quem vai atender
+	City               string // cidade do atendimento
+	AmountInCents      int64
+	At                 time.Time
+}
+
+// Normalize padroniza o código digitado pelo paciente ou pelo administrador.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Check confere se o cupom pode ser usado no pagamento: se está ativo e dentro da validade,
+// se ainda há usos disponíveis e se a especialização e a cidade são aceitas.
+func Check(coupon model.Coupon, purchase Purchase) error {
+	if !coupon.Active {
+		return notApplicable("Este cupom não está mais disponível.")
+	}
+	if coupon.StartsAt != nil && purchase.At.Before(*coupon.StartsAt) {
+		return notApplicable("Este cupom ainda não está valendo.")
+	}
+	if coupon.EndsAt != nil && !purchase.At.Before(*coupon.EndsAt) {
+		return notApplicable("Este cupom expirou.")
+	}
+	if coupon.MaxRedemptions > 0 && coupon.TimesRedeemed >= coupon.MaxRedemptions {
+		return notApplicable("Este cupom já atingiu o limite de usos.")
+	}
+	if coupon.MaxRedemptionsPerPatient > 0 && purchase.PatientRedemptions >= coupon.MaxRedemptionsPerPatient {
+		return notApplicable("Você já usou este cupom o número máximo de vezes.")
+	}
+	if len(coupon.Specializations) > 0 && !contains(coupon.Specializations, purchase.Specialization) {
+		return notApplicable(fmt.Sprintf("Este cupom vale apenas para: %s.", strings.Join(coupon.Specializations, ", ")))
+	}
+	if len(coupon.Cities) > 0 && !contains(coupon.Cities, purchase.City) {
+		return notApplicable(fmt.Sprintf("Este cupom vale apenas em: %s.", strings.Join(coupon.Cities, ", ")))
+	}
+	if purchase.AmountInCents <= MinimumChargeInCents {
+		return notApplicable("O valor desta visita não permite desconto.")
+	}
+	return nil
+}
+
+// Discount calcula o desconto do cupom sobre amountInCents, limitado para que o pagamento não
+// fique abaixo de MinimumChargeInCents.
+func Discount(coupon model.Coupon, amountInCents int64) int64 {
+	var discount int64
+	switch coupon.DiscountType {
+	case model.CouponDiscountPercent:
+		discount = int64(math.Round(float64(amountInCents) * coupon.DiscountValue / 100))
+	case model.CouponDiscountFixed:
+		discount = pricing.ToCents(coupon.DiscountValue)
+	}
+
+	if limit := amountInCents - MinimumChargeInCents; discount > limit {
+		discount = limit
+	}
+	if discount < 0 {
+		return 0
+	}
+	return discount
+}
+
+// Split divide o desconto entre as visitas de um pagamento proporcionalmente ao valor de cada
+// uma. A última recebe o que sobrar do arredondamento, para que as partes somem o desconto.
+func Split(discountInCents int64, valuesInCents []int64) []int64 {
+	shares := make([]int64, len(valuesInCents))
+	if len(valuesInCents) == 0 {
+		return shares
+	}
+
+	var total int64
+	for _, value := range valuesInCents {
+		total += value
+	}
+	if total <= 0 {
+		return shares
+	}
+
+	remaining := discountInCents
+	for i, value := range valuesInCents[:len(valuesInCents)-1] {
+		shares[i] = int64(math.Round(float64(discountInCents) * float64(value) / float64(total)))
+		remaining -= shares[i]
+	}
+	shares[len(shares)-1] = remaining
+	return shares
+}
+
+// Validate confere o código, o desconto, a validade e os limites de um cupom.
+func Validate(coupon model.Coupon) error {
+	if coupon.Code == "" || strings.ContainsAny(coupon.Code, " \t\n") {
+		return invalidCoupon("Informe um código sem espaços.")
+	}
+
+	switch coupon.DiscountType {
+	case model.CouponDiscountPercent:
+		if coupon.DiscountValue <= 0 || coupon.DiscountValue > 100 {
+			return invalidCoupon("O desconto percentual deve ser maior que 0% e no máximo 100%.")
+		}
+	case model.CouponDiscountFixed:
+		if coupon.DiscountValue <= 0 {
+			return invalidCoupon("O desconto fixo deve ser maior que zero.")
+		}
+	default:
+		return invalidCoupon(fmt.Sprintf("Tipo de desconto %q desconhecido. Use PERCENT ou FIXED.", coupon.DiscountType))
+	}
+
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return invalidCoupon("O fim da validade deve ser posterior ao início.")
+	}
+	if coupon.MaxRedemptions < 0 || coupon.MaxRedemptionsPerPatient < 0 {
+		return invalidCoupon("Os limites de uso não podem ser negativos.")
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func notApplicable(message string) error {
+	return fmt.Errorf("%w %s", ErrCouponNotApplicable, message)
+}
+
+func invalidCoupon(message string) error {
+	return fmt.Errorf("%w %s", ErrInvalidCoupon, message)
+}
@@ -0,0 +1,126 @@
+package coupon
+
+import (
+	"errors"
+	"medassist/internal/coupon/dto"
+	"medassist/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CouponHandler struct {
+	couponService CouponService
+}
+
+func NewCouponHandler(couponService CouponService) *CouponHandler {
+	return &CouponHandler{couponService: couponService}
+}
+
+// @Summary Lista os cupons
+// @Description Lista todos os cupons, ativos ou não, com a quantidade de usos, dos mais recentes para os mais antigos. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.SuccessResponseNoData "Cupons encontrados"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao buscar cupons"
+// @Router /admin/coupons [get]
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	coupons, err := h.couponService.ListCoupons()
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cupons encontrados.", coupons)
+}
+
+// @Summary Cria um cupom
+// @Description Cria um cupom de desconto percentual (PERCENT) ou fixo em reais (FIXED), com validade, limites de uso (total e por paciente) e restrição opcional por especialização do enfermeiro ou cidade do atendimento. O desconto é absorvido pela plataforma: o repasse ao enfermeiro não muda. Requer autenticação de Administrador.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body dto.CouponDto true "Cupom"
+// @Success 200 {object} utils.SuccessResponseNoData "Cupom criado"
+// @Failure 400 {object} utils.ErrorResponse "Cupom inválido ou código já usado"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Router /admin/coupons [post]
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	adminId := utils.GetUserId(c)
+
+	var couponDto dto.CouponDto
+	if err := c.ShouldBindJSON(&couponDto); err != nil {
+		utils.SendErrorResponse(c, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(adminId, couponDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cupom criado.", coupon)
+}
+
+// @Summary Altera um cupom
+// @Description Substitui os dados do cupom, mantendo a contagem de usos. Pagamentos já feitos mantêm o desconto aplicado. Requer autenticação de Administrador.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID do cupom"
+// @Param payload body dto.CouponDto true "Cupom"
+// @Success 200 {object} utils.SuccessResponseNoData "Cupom atualizado"
+// @Failure 400 {object} utils.ErrorResponse "Cupom inválido ou código já usado"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Cupom não encontrado"
+// @Router /admin/coupons/{id} [put]
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	couponId := c.Param("id")
+
+	var couponDto dto.CouponDto
+	if err := c.ShouldBindJSON(&couponDto); err != nil {
+		utils.SendErrorResponse(c, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(couponId, couponDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cupom atualizado.", coupon)
+}
+
+// @Summary Remove um cupom
+// @Description Remove o cupom. Pagamentos já feitos mantêm o desconto aplicado. Para apenas suspender o cupom, altere-o com active false. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID do cupom"
+// @Success 200 {object} utils.SuccessResponseNoData "Cupom removido"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Cupom não encontrado"
+// @Router /admin/coupons/{id} [delete]
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	if err := h.couponService.DeleteCoupon(c.Param("id")); err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cupom removido.", nil)
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidCoupon):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrCouponNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,176 @@
+package coupon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/coupon/dto"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCoupon indica um cupom com dados inválidos.
+var ErrInvalidCoupon = errors.New("Cupom inválido.")
+
+// ErrCouponNotFound indica que o cupom não existe.
+var ErrCouponNotFound = errors.New("Cupom não encontrado.")
+
+// ErrCouponNotApplicable indica que o cupom informado pelo paciente não vale para o pagamento.
+var ErrCouponNotApplicable = errors.New("Cupom não aplicável.")
+
+// CouponService mantém os cupons promocionais e calcula o desconto de cada pagamento.
+type CouponService interface {
+	CreateCoupon(adminId string, couponDto dto.CouponDto) (model.Coupon, error)
+	ListCoupons() ([]model.Coupon, error)
+	UpdateCoupon(couponId string, couponDto dto.CouponDto) (model.Coupon, error)
+	DeleteCoupon(couponId string) error
+	Apply(code, patientId string, purchase Purchase) (model.Coupon, int64, error)
+	Redeem(redemption model.CouponRedemption) error
+}
+
+type couponService struct {
+	couponRepository  repository.CouponRepository
+	paymentRepository repository.PaymentRepository
+}
+
+func NewCouponService(couponRepository repository.CouponRepository, paymentRepository repository.PaymentRepository) CouponService {
+	return &couponService{couponRepository: couponRepository, paymentRepository: paymentRepository}
+}
+
+func (s *couponService) CreateCoupon(adminId string, couponDto dto.CouponDto) (model.Coupon, error) {
+	now := time.Now()
+	coupon := model.Coupon{Active: true, CreatedBy: adminId, CreatedAt: now}
+	applyDto(&coupon, couponDto)
+	coupon.UpdatedAt = now
+
+	if err := Validate(coupon); err != nil {
+		return model.Coupon{}, err
+	}
+
+	created, err := s.couponRepository.CreateCoupon(coupon)
+	if err != nil {
+		return model.Coupon{}, repositoryError(err)
+	}
+	return created, nil
+}
+
+func (s *couponService) ListCoupons() ([]model.Coupon, error) {
+	coupons, err := s.couponRepository.FindAllCoupons()
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar cupons: %w", err)
+	}
+	return coupons, nil
+}
+
+// UpdateCoupon altera o cupom. Pagamentos já feitos mantêm o desconto aplicado.
+func (s *couponService) UpdateCoupon(couponId string, couponDto dto.CouponDto) (model.Coupon, error) {
+	coupon, err := s.couponRepository.FindCouponById(couponId)
+	if err != nil {
+		return model.Coupon{}, repositoryError(err)
+	}
+
+	applyDto(&coupon, couponDto)
+	coupon.UpdatedAt = time.Now()
+
+	if err := Validate(coupon); err != nil {
+		return model.Coupon{}, err
+	}
+
+	updated, err := s.couponRepository.UpdateCoupon(coupon)
+	if err != nil {
+		return model.Coupon{}, repositoryError(err)
+	}
+	return updated, nil
+}
+
+func (s *couponService) DeleteCoupon(couponId string) error {
+	if err := s.couponRepository.DeleteCoupon(couponId); err != nil {
+		return repositoryError(err)
+	}
+	return nil
+}
+
+// Apply confere se o cupom vale para o pagamento do paciente e retorna o desconto em centavos.
+// O uso só é contado em Redeem, quando a visita é criada; por isso pagamentos simultâneos
+// podem ultrapassar o limite global em poucas unidades.
+func (s *couponService) Apply(code, patientId string, purchase Purchase) (model.Coupon, int64, error) {
+	coupon, err := s.couponRepository.FindCouponByCode(Normalize(code))
+	if errors.Is(err, repository.ErrCouponNotFound) {
+		return model.Coupon{}, 0, notApplicable("Cupom não encontrado.")
+	}
+	if err != nil {
+		return model.Coupon{}, 0, fmt.Errorf("Erro ao buscar cupom: %w", err)
+	}
+
+	purchase.PatientRedemptions, err = s.couponRepository.CountPatientRedemptions(coupon.ID.Hex(), patientId)
+	if err != nil {
+		return model.Coupon{}, 0, fmt.Errorf("Erro ao buscar usos do cupom: %w", err)
+	}
+
+	if err := Check(coupon, purchase); err != nil {
+		return model.Coupon{}, 0, err
+	}
+	return coupon, Discount(coupon, purchase.AmountInCents), nil
+}
+
+// Redeem conta o uso do cupom no pagamento e registra no livro-razão o desconto absorvido pela
+// plataforma. Chamar de novo com o mesmo PaymentIntent não conta outra vez.
+func (s *couponService) Redeem(redemption model.CouponRedemption) error {
+	recorded, err := s.couponRepository.RecordRedemption(redemption)
+	if err != nil {
+		return fmt.Errorf("Erro ao registrar uso do cupom %s: %w", redemption.Code, err)
+	}
+	if !recorded {
+		return nil
+	}
+
+	if _, err := s.paymentRepository.RecordEntry(model.PaymentEntry{
+		Type:            model.PaymentEntryDiscount,
+		Status:          model.PaymentEntryStatusSucceeded,
+		AmountInCents:   redemption.DiscountInCents,
+		StripeID:        redemption.PaymentIntentID,
+		PaymentIntentID: redemption.PaymentIntentID,
+		PatientID:       redemption.PatientID,
+	}); err != nil {
+		log.Printf("Erro ao registrar desconto do cupom %s no livro-razão: %v", redemption.Code, err)
+	}
+	return nil
+}
+
+func applyDto(coupon *model.Coupon, couponDto dto.CouponDto) {
+	coupon.Code = Normalize(couponDto.Code)
+	coupon.Description = strings.TrimSpace(couponDto.Description)
+	coupon.DiscountType = strings.ToUpper(strings.TrimSpace(couponDto.DiscountType))
+	coupon.DiscountValue = couponDto.DiscountValue
+	coupon.StartsAt = couponDto.StartsAt
+	coupon.EndsAt = couponDto.EndsAt
+	coupon.MaxRedemptions = couponDto.MaxRedemptions
+	coupon.MaxRedemptionsPerPatient = couponDto.MaxRedemptionsPerPatient
+	coupon.Specializations = trimAll(couponDto.Specializations)
+	coupon.Cities = trimAll(couponDto.Cities)
+	if couponDto.Active != nil {
+		coupon.Active = *couponDto.Active
+	}
+}
+
+func trimAll(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
+
+func repositoryError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCouponNotFound):
+		return ErrCouponNotFound
+	case errors.Is(err, repository.ErrCouponCodeTaken):
+		return invalidCoupon("Já existe um cupom com este código.")
+	}
+	return fmt.Errorf("Erro ao salvar cupom: %w", err)
+}
@@ -0,0 +1,149 @@
+package coupon
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/coupon/dto"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCouponService_Apply(t *testing.T) {
+	now := time.Now()
+	coupon := model.Coupon{ID: primitive.NewObjectID(), Code: "BEMVINDO", DiscountType: model.CouponDiscountPercent, DiscountValue: 20, MaxRedemptionsPerPatient: 1, Active: true}
+
+	t.Run("Sucesso_Normaliza_Codigo_E_Calcula_Desconto", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewCouponService(repo, nil)
+
+		repo.EXPECT().FindCouponByCode("BEMVINDO").Return(coupon, nil)
+		repo.EXPECT().CountPatientRedemptions(coupon.ID.Hex(), "patient-1").Return(0, nil)
+
+		applied, discount, err := s.Apply(" bemvindo ", "patient-1", Purchase{AmountInCents: 15000, At: now})
+
+		assert.NoError(t, err)
+		assert.Equal(t, coupon.ID, applied.ID)
+		assert.Equal(t, int64(3000), discount)
+	})
+
+	t.Run("Erro_Paciente_Ja_Usou", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewCouponService(repo, nil)
+
+		repo.EXPECT().FindCouponByCode("BEMVINDO").Return(coupon, nil)
+		repo.EXPECT().CountPatientRedemptions(coupon.ID.Hex(), "patient-1").Return(1, nil)
+
+		_, _, err := s.Apply("BEMVINDO", "patient-1", Purchase{AmountInCents: 15000, At: now})
+
+		assert.ErrorIs(t, err, ErrCouponNotApplicable)
+	})
+
+	t.Run("Erro_Cupom_Inexistente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewCouponService(repo, nil)
+
+		repo.EXPECT().FindCouponByCode("NAOEXISTE").Return(model.Coupon{}, repository.ErrCouponNotFound)
+
+		_, _, err := s.Apply("naoexiste", "patient-1", Purchase{AmountInCents: 15000, At: now})
+
+		assert.ErrorIs(t, err, ErrCouponNotApplicable)
+	})
+}
+
+func TestCouponService_Redeem(t *testing.T) {
+	redemption := model.CouponRedemption{CouponID: "coupon-1", Code: "BEMVINDO", PatientID: "patient-1", PaymentIntentID: "pi_123", DiscountInCents: 3000}
+
+	t.Run("Sucesso_Registra_Desconto_No_Livro_Razao", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		s := NewCouponService(repo, paymentRepo)
+
+		repo.EXPECT().RecordRedemption(redemption).Return(true, nil)
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Equal(t, model.PaymentEntryDiscount, entry.Type)
+			assert.Equal(t, int64(3000), entry.AmountInCents)
+			assert.Equal(t, "pi_123", entry.PaymentIntentID)
+			return entry, nil
+		})
+
+		assert.NoError(t, s.Redeem(redemption))
+	})
+
+	t.Run("Sucesso_Resgate_Repetido_Nao_Conta_De_Novo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewCouponService(repo, repmocks.NewMockPaymentRepository(ctrl))
+
+		repo.EXPECT().RecordRedemption(redemption).Return(false, nil)
+
+		assert.NoError(t, s.Redeem(redemption))
+	})
+}
+
+func TestCouponService_Coupons(t *testing.T) {
+	t.Run("Erro_Cupom_Invalido_Nao_E_Salvo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewCouponService(repmocks.NewMockCouponRepository(ctrl), nil)
+
+		_, err := s.CreateCoupon("admin-1", dto.CouponDto{Code: "BEMVINDO", DiscountType: "percent", DiscountValue: 150})
+
+		assert.ErrorIs(t, err, ErrInvalidCoupon)
+	})
+
+	t.Run("Erro_Codigo_Repetido", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewCouponService(repo, nil)
+
+		repo.EXPECT().CreateCoupon(gomock.Any()).Return(model.Coupon{}, repository.ErrCouponCodeTaken)
+
+		_, err := s.CreateCoupon("admin-1", dto.CouponDto{Code: "BEMVINDO", DiscountType: "FIXED", DiscountValue: 20})
+
+		assert.ErrorIs(t, err, ErrInvalidCoupon)
+	})
+
+	t.Run("Sucesso_Cria_Cupom_Ativo_Em_Maiusculas", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewCouponService(repo, nil)
+
+		repo.EXPECT().CreateCoupon(gomock.Any()).DoAndReturn(func(coupon model.Coupon) (model.Coupon, error) {
+			return coupon, nil
+		})
+
+		created, err := s.CreateCoupon("admin-1", dto.CouponDto{Code: "bemvindo", DiscountType: "fixed", DiscountValue: 20, Cities: []string{" Recife ", ""}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "BEMVINDO", created.Code)
+		assert.Equal(t, model.CouponDiscountFixed, created.DiscountType)
+		assert.Equal(t, []string{"Recife"}, created.Cities)
+		assert.True(t, created.Active)
+		assert.Equal(t, "admin-1", created.CreatedBy)
+	})
+}
@@ -0,0 +1,118 @@
+package coupon
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	purchase := Purchase{Specialization: "Pediatria", City: "Recife", AmountInCents: 20000, At: now}
+
+	t.Run("Sucesso_Cupom_Sem_Restricoes", func(t *testing.T) {
+		assert.NoError(t, Check(model.Coupon{Active: true}, purchase))
+	})
+
+	t.Run("Sucesso_Especializacao_E_Cidade_Sem_Diferenciar_Maiusculas", func(t *testing.T) {
+		coupon := model.Coupon{Active: true, Specializations: []string{"pediatria"}, Cities: []string{"RECIFE", "Olinda"}}
+
+		assert.NoError(t, Check(coupon, purchase))
+	})
+
+	cases := map[string]model.Coupon{
+		"Erro_Inativo":              {Active: false},
+		"Erro_Ainda_Nao_Vale":       {Active: true, StartsAt: ptr(now.Add(time.Hour))},
+		"Erro_Expirado":             {Active: true, EndsAt: &yesterday},
+		"Erro_Limite_Global":        {Active: true, MaxRedemptions: 10, TimesRedeemed: 10},
+		"Erro_Outra_Especializacao": {Active: true, Specializations: []string{"Geriatria"}},
+		"Erro_Outra_Cidade":         {Active: true, Cities: []string{"Olinda"}},
+	}
+	for name, coupon := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, Check(coupon, purchase), ErrCouponNotApplicable)
+		})
+	}
+
+	t.Run("Erro_Limite_Por_Paciente", func(t *testing.T) {
+		coupon := model.Coupon{Active: true, MaxRedemptionsPerPatient: 1}
+
+		assert.ErrorIs(t, Check(coupon, Purchase{PatientRedemptions: 1, AmountInCents: 20000, At: now}), ErrCouponNotApplicable)
+	})
+
+	t.Run("Erro_Especializacao_Sem_Enfermeiro_Definido", func(t *testing.T) {
+		coupon := model.Coupon{Active: true, Specializations: []string{"Pediatria"}}
+
+		assert.ErrorIs(t, Check(coupon, Purchase{City: "Recife", AmountInCents: 20000, At: now}), ErrCouponNotApplicable)
+	})
+}
+
+func TestDiscount(t *testing.T) {
+	t.Run("Sucesso_Percentual", func(t *testing.T) {
+		coupon := model.Coupon{DiscountType: model.CouponDiscountPercent, DiscountValue: 15}
+
+		assert.Equal(t, int64(3000), Discount(coupon, 20000))
+	})
+
+	t.Run("Sucesso_Fixo", func(t *testing.T) {
+		coupon := model.Coupon{DiscountType: model.CouponDiscountFixed, DiscountValue: 25.5}
+
+		assert.Equal(t, int64(2550), Discount(coupon, 20000))
+	})
+
+	t.Run("Sucesso_Nunca_Abaixo_Da_Cobranca_Minima", func(t *testing.T) {
+		full := model.Coupon{DiscountType: model.CouponDiscountPercent, DiscountValue: 100}
+		fixed := model.Coupon{DiscountType: model.CouponDiscountFixed, DiscountValue: 500}
+
+		assert.Equal(t, int64(20000-MinimumChargeInCents), Discount(full, 20000))
+		assert.Equal(t, int64(20000-MinimumChargeInCents), Discount(fixed, 20000))
+	})
+}
+
+func TestSplit(t *testing.T) {
+	t.Run("Sucesso_Proporcional_Ao_Valor", func(t *testing.T) {
+		assert.Equal(t, []int64{1000, 2000}, Split(3000, []int64{10000, 20000}))
+	})
+
+	t.Run("Sucesso_Ultima_Recebe_O_Arredondamento", func(t *testing.T) {
+		shares := Split(1000, []int64{12000, 12000, 12000})
+
+		assert.Equal(t, []int64{333, 333, 334}, shares)
+	})
+
+	t.Run("Sucesso_Sem_Desconto", func(t *testing.T) {
+		assert.Equal(t, []int64{0, 0}, Split(0, []int64{12000, 12000}))
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Sucesso_Cupom_Valido", func(t *testing.T) {
+		coupon := model.Coupon{Code: "BEMVINDO", DiscountType: model.CouponDiscountPercent, DiscountValue: 20}
+
+		assert.NoError(t, Validate(coupon))
+	})
+
+	now := time.Now()
+	cases := map[string]model.Coupon{
+		"Erro_Sem_Codigo":           {DiscountType: model.CouponDiscountFixed, DiscountValue: 10},
+		"Erro_Codigo_Com_Espaco":    {Code: "BEM VINDO", DiscountType: model.CouponDiscountFixed, DiscountValue: 10},
+		"Erro_Percentual_Acima_100": {Code: "X", DiscountType: model.CouponDiscountPercent, DiscountValue: 120},
+		"Erro_Fixo_Zerado":          {Code: "X", DiscountType: model.CouponDiscountFixed},
+		"Erro_Tipo_Desconhecido":    {Code: "X", DiscountType: "FREE", DiscountValue: 10},
+		"Erro_Fim_Antes_Do_Inicio":  {Code: "X", DiscountType: model.CouponDiscountFixed, DiscountValue: 10, StartsAt: &now, EndsAt: ptr(now.Add(-time.Hour))},
+		"Erro_Limite_Negativo":      {Code: "X", DiscountType: model.CouponDiscountFixed, DiscountValue: 10, MaxRedemptions: -1},
+	}
+	for name, coupon := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, Validate(coupon), ErrInvalidCoupon)
+		})
+	}
+}
+
+func ptr(value time.Time) *time.Time {
+	return &value
+}
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// CouponDto é o corpo usado para criar ou alterar um cupom. discount_type aceita PERCENT ou
+// FIXED (valor em reais); limites zerados e listas vazias não restringem o uso.
+type CouponDto struct {
+	Code                     string     `json:"code" binding:"required"`
+	Description              string     `json:"description"`
+	DiscountType             string     `json:"discount_type" binding:"required"`
+	DiscountValue            float64    `json:"discount_value" binding:"required"`
+	StartsAt                 *time.Time `json:"starts_at"`
+	EndsAt                   *time.Time `json:"ends_at"`
+	MaxRedemptions           int        `json:"max_redemptions"`
+	MaxRedemptionsPerPatient int        `json:"max_redemptions_per_patient"`
+	Specializations          []string   `json:"specializations"`
+	Cities                   []string   `json:"cities"`
+	Active                   *bool      `json:"active"`
+}
@@ -5,8 +5,14 @@ import (
 	"medassist/internal/admin"
 	"medassist/internal/auth"
 	"medassist/internal/chat"
+	"medassist/internal/commission"
+	"medassist/internal/coupon"
+	"medassist/internal/dispatch"
+	"medassist/internal/dispute"
+	"medassist/internal/expiry"
 	"medassist/internal/nurse"
 	"medassist/internal/payment"
+	"medassist/internal/receipt"
 	"medassist/internal/repository"
 	"medassist/internal/user"
 )
@@ -17,8 +23,14 @@ type Container struct {
 	NurseHandler   *nurse.NurseHandler
 	AdminHandler   *admin.AdminHandler
 	ChatHub        *chat.Hub
+	ExpirySweeper  expiry.Sweeper
+	CaptureSweeper payment.AuthorizationSweeper
 	ChatHandler    *chat.ChatHandler
 	PaymentHandler *payment.PaymentHandler
+
+	CommissionHandler *commission.CommissionHandler
+	CouponHandler     *coupon.CouponHandler
+	DisputeHandler    *dispute.DisputeHandler
 }
 
 func NewContainer() *Container {
@@ -30,22 +42,39 @@ func NewContainer() *Container {
 	visitRepository := repository.NewVisitRepository(db)
 	messageRepository := repository.NewMessageRepository(db)
 	reviewRepository := repository.NewReviewRepository(db)
+	visitSeriesRepository := repository.NewVisitSeriesRepository(db)
 	paymentRepository := repository.NewPaymentRepository(db)
-	stripeRepository := repository.NewStripeRepository()
-	hub := chat.NewHub(messageRepository)
+	paymentGateway := repository.NewStripePaymentGateway()
+	stripeEventRepository := repository.NewStripeEventRepository(db)
+	commissionRuleRepository := repository.NewCommissionRuleRepository(db)
+	couponRepository := repository.NewCouponRepository(db)
+	disputeRepository := repository.NewDisputeRepository(db)
+	refunder := payment.NewRefunder(visitRepository, paymentGateway, paymentRepository)
+	hub := chat.NewHub(messageRepository, visitRepository, userRepository, nurseRepository)
+	dispatcher := dispatch.NewDispatcher(nurseRepository, visitRepository, refunder, hub, dispatch.LoadConfig())
+	expirySweeper := expiry.NewSweeper(visitRepository, refunder, hub, expiry.LoadConfig())
+	captureSweeper := payment.NewAuthorizationSweeper(visitRepository, paymentGateway, payment.LoadAuthorizationConfig())
+	receiptIssuer := receipt.NewIssuer(userRepository, nurseRepository, visitRepository)
 
+	commissionService := commission.NewCommissionService(commissionRuleRepository)
+	couponService := coupon.NewCouponService(couponRepository, paymentRepository)
+	disputeService := dispute.NewDisputeService(disputeRepository, visitRepository, messageRepository, paymentRepository, paymentGateway)
 	authService := auth.NewAuthService(userRepository, nurseRepository)
-	adminService := admin.NewAdminService(userRepository, nurseRepository, visitRepository)
-	userService := user.NewUserService(userRepository, nurseRepository, visitRepository, reviewRepository, hub)
-	nurseService := nurse.NewNurseService(userRepository, nurseRepository, visitRepository, reviewRepository, stripeRepository)
-	paymentService := payment.NewPaymentService(paymentRepository, userRepository, visitRepository)
+	adminService := admin.NewAdminService(userRepository, nurseRepository, visitRepository, refunder)
+	userService := user.NewUserService(userRepository, nurseRepository, visitRepository, reviewRepository, visitSeriesRepository, paymentGateway, couponService, refunder, hub, dispatcher, receiptIssuer)
+	nurseService := nurse.NewNurseService(userRepository, nurseRepository, visitRepository, reviewRepository, visitSeriesRepository, paymentGateway, paymentRepository, commissionService, refunder, hub, dispatcher, receiptIssuer)
+	paymentService := payment.NewPaymentService(paymentRepository, userRepository, nurseRepository, visitRepository, paymentGateway, couponService)
+	webhookService := payment.NewWebhookService(visitRepository, nurseRepository, paymentRepository, stripeEventRepository, disputeService)
 
 	authHandler := auth.NewAuthHandler(authService)
 	adminHandler := admin.NewAdminHandler(adminService)
 	userHandler := user.NewUserHandler(userService)
 	nurseHandler := nurse.NewNurseHandler(nurseService)
 	chatHandler := chat.NewChatHandler(messageRepository)
-	paymentHandler := payment.NewPaymentHandler(paymentService)
+	paymentHandler := payment.NewPaymentHandler(paymentService, webhookService)
+	commissionHandler := commission.NewCommissionHandler(commissionService)
+	couponHandler := coupon.NewCouponHandler(couponService)
+	disputeHandler := dispute.NewDisputeHandler(disputeService)
 
 	return &Container{
 		AuthHandler:    authHandler,
@@ -53,7 +82,13 @@ func NewContainer() *Container {
 		UserHandler:    userHandler,
 		NurseHandler:   nurseHandler,
 		ChatHub:        hub,
+		ExpirySweeper:  expirySweeper,
+		CaptureSweeper: captureSweeper,
 		ChatHandler:    chatHandler,
 		PaymentHandler: paymentHandler,
+
+		CommissionHandler: commissionHandler,
+		CouponHandler:     couponHandler,
+		DisputeHandler:    disputeHandler,
 	}
 }
@@ -0,0 +1,343 @@
+package dispatch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/chat"
+	chatDTO "medassist/internal/chat/dto"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/scheduling"
+	userDTO "medassist/internal/user/dto"
+	"medassist/utils"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Eventos enviados pelo WebSocket durante o despacho.
+const (
+	EventBroadcast   = "IMMEDIATE_VISIT_BROADCAST"   // nova oferta para o enfermeiro
+	EventTaken       = "IMMEDIATE_VISIT_TAKEN"       // outro enfermeiro aceitou antes
+	EventAccepted    = "IMMEDIATE_VISIT_ACCEPTED"    // avisa o paciente quem aceitou
+	EventUnavailable = "IMMEDIATE_VISIT_UNAVAILABLE" // ninguém aceitou dentro do prazo
+)
+
+// ErrNoNurseAvailable indica que não há enfermeiros online no raio máximo de busca.
+var ErrNoNurseAvailable = errors.New("Nenhum enfermeiro disponível no momento.")
+
+// Config controla o despacho: quantos enfermeiros recebem a oferta por rodada, o raio inicial
+// (dobrado a cada rodada), quanto tempo cada rodada espera por um aceite e o número de rodadas.
+type Config struct {
+	NurseCount      int
+	InitialRadiusKm float64
+	RoundTimeout    time.Duration
+	MaxRounds       int
+}
+
+// LoadConfig lê DISPATCH_NURSE_COUNT, DISPATCH_RADIUS_KM, DISPATCH_TIMEOUT_SECONDS e
+// DISPATCH_MAX_ROUNDS, com valores padrão para as que não estiverem definidas.
+func LoadConfig() Config {
+	config := Config{
+		NurseCount:      5,
+		InitialRadiusKm: 5,
+		RoundTimeout:    60 * time.Second,
+		MaxRounds:       3,
+	}
+
+	if count, err := strconv.Atoi(os.Getenv("DISPATCH_NURSE_COUNT")); err == nil && count > 0 {
+		config.NurseCount = count
+	}
+	if radius, err := strconv.ParseFloat(os.Getenv("DISPATCH_RADIUS_KM"), 64); err == nil && radius > 0 {
+		config.InitialRadiusKm = radius
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("DISPATCH_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		config.RoundTimeout = time.Duration(seconds) * time.Second
+	}
+	if rounds, err := strconv.Atoi(os.Getenv("DISPATCH_MAX_ROUNDS")); err == nil && rounds > 0 {
+		config.MaxRounds = rounds
+	}
+
+	return config
+}
+
+// Dispatcher oferece uma visita imediata aos enfermeiros online mais próximos do paciente
+// e entrega a visita ao primeiro que aceitar.
+type Dispatcher interface {
+	Start(visit model.Visit, patient model.User) (model.Visit, error)
+	Accept(visitId, nurseId string) (model.Visit, error)
+}
+
+type dispatcher struct {
+	nurseRepository   repository.NurseRepository
+	visitRepository   repository.VisitRepository
+	visitStateMachine lifecycle.VisitStateMachine
+	visitHub          *chat.Hub
+	config            Config
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func NewDispatcher(nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository, refunder lifecycle.Refunder, visitHub *chat.Hub, config Config) Dispatcher {
+	return &dispatcher{
+		nurseRepository:   nurseRepository,
+		visitRepository:   visitRepository,
+		visitStateMachine: lifecycle.NewVisitStateMachine(visitRepository, refunder),
+		visitHub:          visitHub,
+		config:            config,
+		timers:            make(map[string]*time.Timer),
+	}
+}
+
+type candidate struct {
+	NurseId    string
+	DistanceKm float64
+}
+
+// nearestNurses retorna os enfermeiros online dentro do raio, do mais próximo para o mais distante.
+func (d *dispatcher) nearestNurses(dispatch model.VisitDispatch) ([]candidate, error) {
+	page, err := d.nurseRepository.GetAllOnlineNurses(userDTO.NurseSearchDto{
+		City:      dispatch.City,
+		Latitude:  dispatch.Latitude,
+		Longitude: dispatch.Longitude,
+		RadiusKm:  dispatch.RadiusKm,
+		SortField: repository.NurseSortDistance,
+		Limit:     d.config.NurseCount * dispatch.Round,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]candidate, 0, len(page.Nurses))
+	for _, nurse := range page.Nurses {
+		if nurse.Latitude == 0 && nurse.Longitude == 0 {
+			continue
+		}
+		distance := utils.DistanceKm(dispatch.Latitude, dispatch.Longitude, nurse.Latitude, nurse.Longitude)
+		if distance <= dispatch.RadiusKm {
+			candidates = append(candidates, candidate{NurseId: nurse.ID, DistanceKm: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].DistanceKm < candidates[j].DistanceKm })
+	if len(candidates) > d.config.NurseCount*dispatch.Round {
+		candidates = candidates[:d.config.NurseCount*dispatch.Round]
+	}
+
+	return candidates, nil
+}
+
+// Start grava a visita sem enfermeiro e a oferece aos mais próximos, ampliando o raio
+// imediatamente enquanto ninguém for encontrado.
+func (d *dispatcher) Start(visit model.Visit, patient model.User) (model.Visit, error) {
+	dispatch := model.VisitDispatch{
+		Round:     1,
+		RadiusKm:  d.config.InitialRadiusKm,
+		Latitude:  patient.Latitude,
+		Longitude: patient.Longitude,
+		City:      patient.City,
+	}
+
+	var candidates []candidate
+	for ; dispatch.Round <= d.config.MaxRounds; dispatch.Round++ {
+		found, err := d.nearestNurses(dispatch)
+		if err != nil {
+			return model.Visit{}, fmt.Errorf("Erro ao buscar enfermeiros próximos: %w", err)
+		}
+		if len(found) > 0 {
+			candidates = found
+			break
+		}
+		dispatch.RadiusKm *= 2
+	}
+	if len(candidates) == 0 {
+		return model.Visit{}, ErrNoNurseAvailable
+	}
+
+	for _, c := range candidates {
+		dispatch.Candidates = append(dispatch.Candidates, c.NurseId)
+	}
+	dispatch.ExpiresAt = time.Now().Add(d.config.RoundTimeout)
+
+	visit.NurseId = ""
+	visit.NurseName = ""
+	visit.Status = model.VisitStatusPending
+	visit.Dispatch = &dispatch
+
+	if err := d.visitRepository.CreateVisit(visit); err != nil {
+		return model.Visit{}, fmt.Errorf("Erro ao criar visita: %w", err)
+	}
+
+	d.offer(visit, candidates)
+	d.schedule(visit.ID.Hex())
+
+	return visit, nil
+}
+
+func (d *dispatcher) offer(visit model.Visit, candidates []candidate) {
+	address := fmt.Sprintf("%s, %s - %s", visit.Street, visit.Number, visit.Neighborhood)
+	for _, c := range candidates {
+		d.visitHub.NotifyVisitEvent(c.NurseId, chatDTO.VisitEventDTO{
+			Type:    EventBroadcast,
+			VisitID: visit.ID.Hex(),
+			Message: fmt.Sprintf("Nova visita imediata a %.1f km: %s", c.DistanceKm, visit.Reason),
+			Data: map[string]interface{}{
+				"patient_id":   visit.PatientId,
+				"patient_name": visit.PatientName,
+				"reason":       visit.Reason,
+				"address":      address,
+				"distance_km":  c.DistanceKm,
+				"expires_at":   visit.Dispatch.ExpiresAt,
+			},
+		})
+	}
+}
+
+func (d *dispatcher) schedule(visitId string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if timer, ok := d.timers[visitId]; ok {
+		timer.Stop()
+	}
+	d.timers[visitId] = time.AfterFunc(d.config.RoundTimeout, func() { d.nextRound(visitId) })
+}
+
+func (d *dispatcher) stop(visitId string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if timer, ok := d.timers[visitId]; ok {
+		timer.Stop()
+		delete(d.timers, visitId)
+	}
+}
+
+// nextRound é chamado quando uma rodada termina sem aceite: amplia o raio e oferece a visita
+// aos novos enfermeiros encontrados, ou desiste e avisa o paciente após a última rodada.
+func (d *dispatcher) nextRound(visitId string) {
+	visit, err := d.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		log.Printf("[Dispatch] Erro ao buscar visita %s: %v", visitId, err)
+		d.stop(visitId)
+		return
+	}
+	if visit.NurseId != "" || visit.Status != model.VisitStatusPending || visit.Dispatch == nil {
+		d.stop(visitId)
+		return
+	}
+
+	dispatch := *visit.Dispatch
+	if dispatch.Round >= d.config.MaxRounds {
+		d.giveUp(visit)
+		return
+	}
+
+	dispatch.Round++
+	dispatch.RadiusKm *= 2
+	dispatch.ExpiresAt = time.Now().Add(d.config.RoundTimeout)
+
+	found, err := d.nearestNurses(dispatch)
+	if err != nil {
+		log.Printf("[Dispatch] Erro ao buscar enfermeiros para a visita %s: %v", visitId, err)
+	}
+
+	alreadyOffered := make(map[string]bool, len(dispatch.Candidates))
+	for _, nurseId := range dispatch.Candidates {
+		alreadyOffered[nurseId] = true
+	}
+	newCandidates := make([]candidate, 0, len(found))
+	for _, c := range found {
+		if !alreadyOffered[c.NurseId] {
+			newCandidates = append(newCandidates, c)
+			dispatch.Candidates = append(dispatch.Candidates, c.NurseId)
+		}
+	}
+
+	if err := d.visitRepository.UpdateDispatch(visitId, dispatch); err != nil {
+		// a visita foi aceita enquanto a nova rodada era montada
+		d.stop(visitId)
+		return
+	}
+
+	visit.Dispatch = &dispatch
+	d.offer(visit, newCandidates)
+	d.schedule(visitId)
+}
+
+func (d *dispatcher) giveUp(visit model.Visit) {
+	visitId := visit.ID.Hex()
+	d.stop(visitId)
+
+	reason := "Nenhum enfermeiro aceitou a visita imediata a tempo."
+	actor := lifecycle.Actor{ID: lifecycle.RoleSystem, Role: lifecycle.RoleSystem}
+	if _, err := d.visitStateMachine.Transition(visit, model.VisitStatusCanceled, actor, reason, map[string]interface{}{"cancel_reason": reason}); err != nil {
+		log.Printf("[Dispatch] Erro ao encerrar visita %s sem aceite: %v", visitId, err)
+		return
+	}
+
+	d.visitHub.NotifyVisitEvent(visit.PatientId, chatDTO.VisitEventDTO{
+		Type:    EventUnavailable,
+		VisitID: visitId,
+		Message: ErrNoNurseAvailable.Error(),
+	})
+	d.notifyCandidates(visit, "", "A visita imediata não está mais disponível.")
+}
+
+func (d *dispatcher) notifyCandidates(visit model.Visit, exceptNurseId, message string) {
+	if visit.Dispatch == nil {
+		return
+	}
+	for _, nurseId := range visit.Dispatch.Candidates {
+		if nurseId == exceptNurseId {
+			continue
+		}
+		d.visitHub.NotifyVisitEvent(nurseId, chatDTO.VisitEventDTO{
+			Type:    EventTaken,
+			VisitID: visit.ID.Hex(),
+			Message: message,
+		})
+	}
+}
+
+// Accept entrega a visita ao enfermeiro se ninguém tiver aceitado antes dele. Quem garante
+// que a visita ainda está PENDING e foi oferecida a ele é o filtro de ClaimDispatchedVisit.
+func (d *dispatcher) Accept(visitId, nurseId string) (model.Visit, error) {
+	nurse, err := d.nurseRepository.FindNurseById(nurseId)
+	if err != nil {
+		return model.Visit{}, err
+	}
+
+	change := model.VisitStatusChange{
+		From:      model.VisitStatusPending,
+		To:        model.VisitStatusConfirmed,
+		ActorID:   nurseId,
+		ActorRole: lifecycle.RoleNurse,
+		ChangedAt: time.Now(),
+	}
+	// o valor já foi pago pelo paciente ao pedir a visita e não muda com quem aceitar
+	updates := map[string]interface{}{
+		"nurse_name": nurse.Name,
+	}
+
+	visit, err := d.visitRepository.ClaimDispatchedVisit(visitId, change, scheduling.ReservedWindow(scheduling.DefaultVisitDuration), updates)
+	if err != nil {
+		return model.Visit{}, err
+	}
+
+	d.stop(visitId)
+	d.notifyCandidates(visit, nurseId, "Outro enfermeiro aceitou esta visita.")
+	d.visitHub.NotifyVisitEvent(visit.PatientId, chatDTO.VisitEventDTO{
+		Type:    EventAccepted,
+		VisitID: visitId,
+		Message: fmt.Sprintf("%s aceitou sua visita e está a caminho.", nurse.Name),
+		Data:    map[string]interface{}{"nurse_id": nurseId, "nurse_name": nurse.Name},
+	})
+
+	return visit, nil
+}
@@ -0,0 +1,199 @@
+package dispatch
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	repmocks "medassist/internal/repository/mocks"
+	userDTO "medassist/internal/user/dto"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+var testConfig = Config{NurseCount: 2, InitialRadiusKm: 5, RoundTimeout: time.Hour, MaxRounds: 2}
+
+// Coordenadas em São Paulo: Sé e pontos a ~1 km, ~3 km e ~8 km de distância.
+var (
+	patient = model.User{ID: primitive.NewObjectID(), City: "São Paulo", Latitude: -23.5505, Longitude: -46.6333}
+	nurses  = []userDTO.AllNursesListDto{
+		{ID: "nurse-far", Latitude: -23.6225, Longitude: -46.6333},
+		{ID: "nurse-near", Latitude: -23.5595, Longitude: -46.6333},
+		{ID: "nurse-mid", Latitude: -23.5775, Longitude: -46.6333},
+		{ID: "nurse-no-location"},
+	}
+)
+
+func TestDispatcher_Start(t *testing.T) {
+	t.Run("Sucesso_Oferece_Aos_Mais_Proximos", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig)
+
+		nurseRepo.EXPECT().GetAllOnlineNurses(userDTO.NurseSearchDto{City: patient.City, Latitude: patient.Latitude, Longitude: patient.Longitude, RadiusKm: 5, SortField: "distance", Limit: 2}).Return(userDTO.NurseListPageDto{Nurses: nurses}, nil)
+		visitRepo.EXPECT().CreateVisit(gomock.Any()).Return(nil)
+
+		visit, err := d.Start(model.Visit{ID: primitive.NewObjectID(), PatientId: patient.ID.Hex()}, patient)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", visit.NurseId)
+		assert.Equal(t, []string{"nurse-near", "nurse-mid"}, visit.Dispatch.Candidates)
+		d.(*dispatcher).stop(visit.ID.Hex())
+	})
+
+	t.Run("Sucesso_Amplia_Raio_Quando_Ninguem_Esta_Perto", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig)
+
+		nurseRepo.EXPECT().GetAllOnlineNurses(gomock.Any()).Return(userDTO.NurseListPageDto{Nurses: nurses[:1]}, nil).Times(2)
+		visitRepo.EXPECT().CreateVisit(gomock.Any()).Return(nil)
+
+		visit, err := d.Start(model.Visit{ID: primitive.NewObjectID()}, patient)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, visit.Dispatch.Round)
+		assert.Equal(t, 10.0, visit.Dispatch.RadiusKm)
+		assert.Equal(t, []string{"nurse-far"}, visit.Dispatch.Candidates)
+		d.(*dispatcher).stop(visit.ID.Hex())
+	})
+
+	t.Run("Erro_Nenhum_Enfermeiro_Disponivel", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig)
+
+		nurseRepo.EXPECT().GetAllOnlineNurses(gomock.Any()).Return(userDTO.NurseListPageDto{}, nil).Times(2)
+
+		_, err := d.Start(model.Visit{ID: primitive.NewObjectID()}, patient)
+
+		assert.ErrorIs(t, err, ErrNoNurseAvailable)
+	})
+}
+
+func TestDispatcher_Accept(t *testing.T) {
+	t.Run("Erro_409_Outro_Enfermeiro_Aceitou_Antes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-mid").Return(model.Nurse{Name: "Ana", Price: 120}, nil)
+		visitRepo.EXPECT().ClaimDispatchedVisit("visit-1", gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Visit{}, repository.ErrVisitAlreadyClaimed)
+
+		_, err := d.Accept("visit-1", "nurse-mid")
+
+		assert.ErrorIs(t, err, repository.ErrVisitAlreadyClaimed)
+	})
+
+	t.Run("Erro_403_Enfermeiro_Nao_Convidado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-far").Return(model.Nurse{Name: "Bia", Price: 120}, nil)
+		visitRepo.EXPECT().ClaimDispatchedVisit("visit-1", gomock.Any(), gomock.Any(), gomock.Any()).Return(model.Visit{}, repository.ErrNotDispatchCandidate)
+
+		_, err := d.Accept("visit-1", "nurse-far")
+
+		assert.ErrorIs(t, err, repository.ErrNotDispatchCandidate)
+		assert.NotErrorIs(t, err, repository.ErrVisitAlreadyClaimed)
+	})
+
+	t.Run("Sucesso_Primeiro_Aceite", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-near").Return(model.Nurse{Name: "Ana", Price: 120}, nil)
+		visitRepo.EXPECT().ClaimDispatchedVisit("visit-1", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(visitId string, change model.VisitStatusChange, window time.Duration, updates map[string]interface{}) (model.Visit, error) {
+				assert.Equal(t, "nurse-near", change.ActorID)
+				assert.Equal(t, model.VisitStatusConfirmed, change.To)
+				assert.NotContains(t, updates, "value")
+				return model.Visit{NurseId: "nurse-near", Status: model.VisitStatusConfirmed}, nil
+			})
+
+		visit, err := d.Accept("visit-1", "nurse-near")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "nurse-near", visit.NurseId)
+	})
+}
+
+func TestDispatcher_NextRound(t *testing.T) {
+	t.Run("Sucesso_Desiste_Apos_Ultima_Rodada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig).(*dispatcher)
+
+		visit := model.Visit{
+			ID:       primitive.NewObjectID(),
+			Status:   model.VisitStatusPending,
+			Dispatch: &model.VisitDispatch{Round: testConfig.MaxRounds, Candidates: []string{"nurse-near"}},
+		}
+
+		visitRepo.EXPECT().FindVisitById(visit.ID.Hex()).Return(visit, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+				assert.Equal(t, model.VisitStatusCanceled, change.To)
+				return model.Visit{Status: change.To}, nil
+			})
+		visitRepo.EXPECT().ReleaseVisitSlot(visit.ID.Hex()).Return(nil)
+
+		d.nextRound(visit.ID.Hex())
+	})
+
+	t.Run("Sucesso_Oferece_Apenas_Aos_Novos_Enfermeiros", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		d := NewDispatcher(nurseRepo, visitRepo, nil, nil, testConfig).(*dispatcher)
+
+		visit := model.Visit{
+			ID:     primitive.NewObjectID(),
+			Status: model.VisitStatusPending,
+			Dispatch: &model.VisitDispatch{
+				Round: 1, RadiusKm: 5, Candidates: []string{"nurse-near"},
+				City: patient.City, Latitude: patient.Latitude, Longitude: patient.Longitude,
+			},
+		}
+
+		visitRepo.EXPECT().FindVisitById(visit.ID.Hex()).Return(visit, nil)
+		nurseRepo.EXPECT().GetAllOnlineNurses(gomock.Any()).Return(userDTO.NurseListPageDto{Nurses: nurses}, nil)
+		visitRepo.EXPECT().UpdateDispatch(visit.ID.Hex(), gomock.Any()).DoAndReturn(
+			func(visitId string, dispatch model.VisitDispatch) error {
+				assert.Equal(t, 2, dispatch.Round)
+				assert.Equal(t, []string{"nurse-near", "nurse-mid", "nurse-far"}, dispatch.Candidates)
+				return nil
+			})
+
+		d.nextRound(visit.ID.Hex())
+		d.stop(visit.ID.Hex())
+	})
+}
@@ -0,0 +1,124 @@
+package dispute
+
+import (
+	"errors"
+	"medassist/internal/dispute/dto"
+	"medassist/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DisputeHandler struct {
+	disputeService DisputeService
+}
+
+func NewDisputeHandler(disputeService DisputeService) *DisputeHandler {
+	return &DisputeHandler{disputeService: disputeService}
+}
+
+// @Summary Lista as contestações
+// @Description Lista as contestações (chargebacks) de pagamentos de visitas, das mais recentes para as mais antigas. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Situação no Stripe (warning_needs_response, needs_response, under_review, won, lost...)"
+// @Success 200 {object} utils.SuccessResponseNoData "Contestações encontradas"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao buscar contestações"
+// @Router /admin/disputes [get]
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	disputes, err := h.disputeService.ListDisputes(c.Query("status"))
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Contestações encontradas.", disputes)
+}
+
+// @Summary Detalha uma contestação
+// @Description Retorna a contestação com as visitas ligadas a ela, as evidências montadas automaticamente (hora do código de confirmação, conversa e prescrições) e os repasses já revertidos. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da contestação"
+// @Success 200 {object} utils.SuccessResponseNoData "Contestação encontrada"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Contestação não encontrada"
+// @Router /admin/disputes/{id} [get]
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	dispute, err := h.disputeService.GetDispute(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Contestação encontrada.", dispute)
+}
+
+// @Summary Envia as evidências da contestação
+// @Description Envia ao banco, pelo Stripe, as evidências montadas para a contestação, com as observações do administrador. Só é aceito um envio, enquanto a contestação aguarda resposta. Requer autenticação de Administrador.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da contestação"
+// @Param payload body dto.DisputeEvidenceDto true "Observações do administrador"
+// @Success 200 {object} utils.SuccessResponseNoData "Evidências enviadas"
+// @Failure 400 {object} utils.ErrorResponse "A contestação não aguarda evidências"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Contestação não encontrada"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao enviar evidências"
+// @Router /admin/disputes/{id}/evidence [post]
+func (h *DisputeHandler) SubmitEvidence(c *gin.Context) {
+	adminId := utils.GetUserId(c)
+
+	var evidenceDto dto.DisputeEvidenceDto
+	if err := c.ShouldBindJSON(&evidenceDto); err != nil {
+		utils.SendErrorResponse(c, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	dispute, err := h.disputeService.SubmitEvidence(adminId, c.Param("id"), evidenceDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Evidências enviadas.", dispute)
+}
+
+// @Summary Reverte os repasses de uma contestação perdida
+// @Description Devolve, da conta do enfermeiro, o repasse de cada visita do pagamento cuja contestação foi perdida. Repasses já revertidos são ignorados. Requer autenticação de Administrador.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da contestação"
+// @Success 200 {object} utils.SuccessResponseNoData "Repasses revertidos"
+// @Failure 400 {object} utils.ErrorResponse "Contestação não perdida ou sem repasses a reverter"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 404 {object} utils.ErrorResponse "Contestação não encontrada"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao reverter repasses"
+// @Router /admin/disputes/{id}/reverse-transfers [post]
+func (h *DisputeHandler) ReverseTransfers(c *gin.Context) {
+	adminId := utils.GetUserId(c)
+
+	dispute, err := h.disputeService.ReverseTransfers(adminId, c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), statusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Repasses revertidos.", dispute)
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidDisputeAction):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrDisputeNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,315 @@
+package dispute
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/dispute/dto"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+// ErrDisputeNotFound indica que a contestação não existe.
+var ErrDisputeNotFound = errors.New("Contestação não encontrada.")
+
+// ErrInvalidDisputeAction indica uma operação que a contestação não aceita na situação atual.
+var ErrInvalidDisputeAction = errors.New("Operação inválida para a contestação.")
+
+// DisputeService acompanha as contestações (chargebacks) dos pagamentos de visitas: registra o
+// que chega pelos webhooks, monta as evidências e executa as decisões dos administradores.
+type DisputeService interface {
+	HandleDispute(stripeDispute stripe.Dispute) error
+	ListDisputes(status string) ([]model.Dispute, error)
+	GetDispute(disputeId string) (model.Dispute, error)
+	SubmitEvidence(adminId, disputeId string, evidenceDto dto.DisputeEvidenceDto) (model.Dispute, error)
+	ReverseTransfers(adminId, disputeId string) (model.Dispute, error)
+}
+
+type disputeService struct {
+	disputeRepository repository.DisputeRepository
+	visitRepository   repository.VisitRepository
+	messageRepository repository.MessageRepository
+	paymentRepository repository.PaymentRepository
+	paymentGateway    repository.PaymentGateway
+}
+
+func NewDisputeService(disputeRepository repository.DisputeRepository, visitRepository repository.VisitRepository, messageRepository repository.MessageRepository, paymentRepository repository.PaymentRepository, paymentGateway repository.PaymentGateway) DisputeService {
+	return &disputeService{
+		disputeRepository: disputeRepository,
+		visitRepository:   visitRepository,
+		messageRepository: messageRepository,
+		paymentRepository: paymentRepository,
+		paymentGateway:    paymentGateway,
+	}
+}
+
+// HandleDispute aplica um evento charge.dispute.*. A primeira vez que a contestação aparece ela
+// é ligada às visitas do PaymentIntent e as evidências são montadas; nas seguintes só a
+// situação muda. As visitas e o livro-razão acompanham a situação da contestação.
+func (s *disputeService) HandleDispute(stripeDispute stripe.Dispute) error {
+	dispute, err := s.disputeRepository.FindDisputeByStripeId(stripeDispute.ID)
+	switch {
+	case errors.Is(err, repository.ErrDisputeNotFound):
+		dispute, err = s.createDispute(stripeDispute)
+	case err == nil:
+		dispute, err = s.updateDispute(dispute, stripeDispute)
+	}
+	if err != nil {
+		return fmt.Errorf("Erro ao registrar contestação %s: %w", stripeDispute.ID, err)
+	}
+	if dispute.PaymentIntentID == "" {
+		log.Printf("Contestação %s sem PaymentIntent", dispute.StripeDisputeID)
+		return nil
+	}
+
+	if _, err := s.visitRepository.UpdateVisitsByPaymentIntentId(dispute.PaymentIntentID, map[string]interface{}{
+		"dispute_id": dispute.ID.Hex(),
+	}); err != nil {
+		return fmt.Errorf("Erro ao marcar visitas do pagamento %s como contestadas: %w", dispute.PaymentIntentID, err)
+	}
+	paymentStatus, from := visitPaymentStatus(dispute.Status)
+	if _, err := s.visitRepository.UpdateVisitsPaymentStatus(dispute.PaymentIntentID, from, paymentStatus); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s das visitas contestadas: %w", dispute.PaymentIntentID, err)
+	}
+
+	entry := model.PaymentEntry{
+		Type:            model.PaymentEntryDispute,
+		Status:          entryStatus(dispute.Status),
+		AmountInCents:   dispute.AmountInCents,
+		Currency:        dispute.Currency,
+		StripeID:        dispute.StripeDisputeID,
+		PaymentIntentID: dispute.PaymentIntentID,
+		PatientID:       dispute.PatientID,
+		NurseID:         dispute.NurseID,
+	}
+	if len(dispute.VisitIDs) == 1 {
+		entry.VisitID = dispute.VisitIDs[0]
+	}
+	if _, err := s.paymentRepository.RecordEntry(entry); err != nil {
+		return fmt.Errorf("Erro ao registrar contestação %s no livro-razão: %w", dispute.StripeDisputeID, err)
+	}
+	return nil
+}
+
+func (s *disputeService) createDispute(stripeDispute stripe.Dispute) (model.Dispute, error) {
+	dispute := model.Dispute{StripeDisputeID: stripeDispute.ID, VisitIDs: []string{}}
+	if stripeDispute.Charge != nil {
+		dispute.ChargeID = stripeDispute.Charge.ID
+	}
+	if stripeDispute.PaymentIntent != nil {
+		dispute.PaymentIntentID = stripeDispute.PaymentIntent.ID
+	}
+	applyStripe(&dispute, stripeDispute)
+
+	if dispute.PaymentIntentID != "" {
+		visits, err := s.visitRepository.FindVisitsByPaymentIntentId(dispute.PaymentIntentID)
+		if err != nil {
+			return model.Dispute{}, fmt.Errorf("Erro ao buscar visitas do pagamento %s: %w", dispute.PaymentIntentID, err)
+		}
+		if len(visits) == 0 {
+			log.Printf("Contestação %s: nenhuma visita com o pagamento %s", stripeDispute.ID, dispute.PaymentIntentID)
+		} else {
+			dispute.PatientID = visits[0].PatientId
+			dispute.NurseID = visits[0].NurseId
+		}
+		for _, visit := range visits {
+			dispute.VisitIDs = append(dispute.VisitIDs, visit.ID.Hex())
+		}
+		dispute.Evidence = s.collectEvidence(visits)
+	}
+
+	created, err := s.disputeRepository.CreateDispute(dispute)
+	if errors.Is(err, repository.ErrDisputeExists) {
+		// outro evento da mesma contestação chegou primeiro
+		existing, err := s.disputeRepository.FindDisputeByStripeId(stripeDispute.ID)
+		if err != nil {
+			return model.Dispute{}, err
+		}
+		return s.updateDispute(existing, stripeDispute)
+	}
+	return created, err
+}
+
+func (s *disputeService) updateDispute(dispute model.Dispute, stripeDispute stripe.Dispute) (model.Dispute, error) {
+	applyStripe(&dispute, stripeDispute)
+	return s.disputeRepository.UpdateDisputeFields(dispute.ID.Hex(), map[string]interface{}{
+		"status":          dispute.Status,
+		"reason":          dispute.Reason,
+		"amount_in_cents": dispute.AmountInCents,
+		"currency":        dispute.Currency,
+		"evidence_due_by": dispute.EvidenceDueBy,
+		"closed_at":       dispute.ClosedAt,
+	})
+}
+
+// applyStripe copia para a contestação os dados que o Stripe pode alterar a cada evento.
+func applyStripe(dispute *model.Dispute, stripeDispute stripe.Dispute) {
+	dispute.Status = string(stripeDispute.Status)
+	dispute.Reason = string(stripeDispute.Reason)
+	dispute.AmountInCents = stripeDispute.Amount
+	dispute.Currency = string(stripeDispute.Currency)
+	if stripeDispute.EvidenceDetails != nil && stripeDispute.EvidenceDetails.DueBy > 0 {
+		dueBy := time.Unix(stripeDispute.EvidenceDetails.DueBy, 0)
+		dispute.EvidenceDueBy = &dueBy
+	}
+	if !dispute.Open() && dispute.ClosedAt == nil {
+		now := time.Now()
+		dispute.ClosedAt = &now
+	}
+}
+
+// visitPaymentStatus é a situação do pagamento das visitas enquanto a contestação corre e
+// depois que o banco decide, junto com os status de que elas podem chegar nela. Consultas
+// encerradas sem chargeback voltam a pagamento confirmado só se a visita estava contestada:
+// um estorno feito no meio tempo não é desfeito. Visitas com estorno parcial não passam a
+// contestadas, para não perderem o estorno quando a contestação é ganha.
+func visitPaymentStatus(status string) (string, []string) {
+	switch status {
+	case model.DisputeStatusWon, model.DisputeStatusWarningClosed:
+		return model.PaymentStatusSucceeded, []string{model.PaymentStatusDisputed}
+	case model.DisputeStatusLost:
+		return model.PaymentStatusDisputeLost, []string{"", model.PaymentStatusSucceeded, model.PaymentStatusPartiallyRefunded, model.PaymentStatusDisputed}
+	}
+	return model.PaymentStatusDisputed, []string{"", model.PaymentStatusSucceeded}
+}
+
+// entryStatus é a situação do lançamento da contestação: pendente enquanto o banco decide,
+// efetivado quando o valor fica com o paciente e revertido quando volta para a plataforma.
+func entryStatus(status string) string {
+	switch status {
+	case model.DisputeStatusWon, model.DisputeStatusWarningClosed:
+		return model.PaymentEntryStatusReversed
+	case model.DisputeStatusLost:
+		return model.PaymentEntryStatusSucceeded
+	}
+	return model.PaymentEntryStatusPending
+}
+
+// ListDisputes lista as contestações, das mais recentes para as mais antigas. O status, se
+// informado, segue os valores do Stripe (ex: needs_response).
+func (s *disputeService) ListDisputes(status string) ([]model.Dispute, error) {
+	disputes, err := s.disputeRepository.FindDisputes(strings.ToLower(strings.TrimSpace(status)))
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar contestações: %w", err)
+	}
+	return disputes, nil
+}
+
+func (s *disputeService) GetDispute(disputeId string) (model.Dispute, error) {
+	dispute, err := s.disputeRepository.FindDisputeById(disputeId)
+	if err != nil {
+		return model.Dispute{}, repositoryError(err)
+	}
+	return dispute, nil
+}
+
+// SubmitEvidence envia ao banco as evidências montadas, com as observações do administrador.
+// O Stripe aceita um único envio por contestação.
+func (s *disputeService) SubmitEvidence(adminId, disputeId string, evidenceDto dto.DisputeEvidenceDto) (model.Dispute, error) {
+	dispute, err := s.disputeRepository.FindDisputeById(disputeId)
+	if err != nil {
+		return model.Dispute{}, repositoryError(err)
+	}
+	if dispute.Status != model.DisputeStatusNeedsResponse && dispute.Status != model.DisputeStatusWarningNeedsResponse {
+		return model.Dispute{}, invalidAction("A contestação não está aguardando evidências.")
+	}
+
+	evidence := dispute.Evidence
+	evidence.Notes = strings.TrimSpace(evidenceDto.Notes)
+
+	submitted, err := s.paymentGateway.SubmitDisputeEvidence(dispute.StripeDisputeID, evidence)
+	if err != nil {
+		return model.Dispute{}, fmt.Errorf("Erro ao enviar evidências da contestação: %w", err)
+	}
+
+	now := time.Now()
+	updated, err := s.disputeRepository.UpdateDisputeFields(disputeId, map[string]interface{}{
+		"evidence":              evidence,
+		"status":                string(submitted.Status),
+		"evidence_submitted_at": now,
+		"evidence_submitted_by": adminId,
+	})
+	if err != nil {
+		return model.Dispute{}, fmt.Errorf("Evidências enviadas, mas houve erro ao registrar o envio: %w", err)
+	}
+	return updated, nil
+}
+
+// ReverseTransfers devolve, das contas dos enfermeiros, os repasses das visitas de uma
+// contestação perdida. Repasses já devolvidos são ignorados, então a operação pode ser
+// repetida depois de uma falha parcial.
+func (s *disputeService) ReverseTransfers(adminId, disputeId string) (model.Dispute, error) {
+	dispute, err := s.disputeRepository.FindDisputeById(disputeId)
+	if err != nil {
+		return model.Dispute{}, repositoryError(err)
+	}
+	if dispute.Status != model.DisputeStatusLost {
+		return model.Dispute{}, invalidAction("Só é possível reverter o repasse de contestações perdidas.")
+	}
+
+	reversed := map[string]bool{}
+	for _, reversal := range dispute.TransferReversals {
+		reversed[reversal.TransferID] = true
+	}
+
+	pending := 0
+	for _, visitId := range dispute.VisitIDs {
+		visit, err := s.visitRepository.FindVisitById(visitId)
+		if err != nil {
+			return model.Dispute{}, fmt.Errorf("Erro ao buscar visita %s: %w", visitId, err)
+		}
+		if visit.TransferID == "" || reversed[visit.TransferID] || visit.TransferStatus == model.TransferStatusReversed {
+			continue
+		}
+		pending++
+
+		var amountInCents int64 // sem comissão registrada, o repasse inteiro
+		if visit.Commission != nil {
+			amountInCents = visit.Commission.NurseAmountInCents
+		}
+		reversal, err := s.paymentGateway.ReverseTransfer(visit.TransferID, amountInCents, dispute.ID.Hex())
+		if err != nil {
+			return model.Dispute{}, fmt.Errorf("Erro ao reverter repasse %s da visita %s: %w", visit.TransferID, visitId, err)
+		}
+
+		dispute, err = s.disputeRepository.AddTransferReversal(disputeId, model.DisputeTransferReversal{
+			VisitID:       visitId,
+			TransferID:    visit.TransferID,
+			ReversalID:    reversal.ID,
+			AmountInCents: reversal.Amount,
+			ReversedBy:    adminId,
+			ReversedAt:    time.Now(),
+		})
+		if err != nil {
+			return model.Dispute{}, fmt.Errorf("Repasse %s revertido, mas houve erro ao registrar a reversão: %w", visit.TransferID, err)
+		}
+
+		// o webhook transfer.reversed confirma depois; o dinheiro já voltou, então falhas aqui são apenas logadas
+		if _, err := s.visitRepository.UpdateVisitFields(visitId, map[string]interface{}{"transfer_status": model.TransferStatusReversed}); err != nil {
+			log.Printf("Erro ao marcar repasse %s da visita %s como revertido: %v", visit.TransferID, visitId, err)
+		}
+		if err := s.paymentRepository.UpdateEntryStatus(model.PaymentEntryTransfer, visit.TransferID, model.PaymentEntryStatusReversed); err != nil {
+			log.Printf("Erro ao atualizar repasse %s no livro-razão: %v", visit.TransferID, err)
+		}
+	}
+
+	if pending == 0 {
+		return model.Dispute{}, invalidAction("Não há repasses a reverter nesta contestação.")
+	}
+	return dispute, nil
+}
+
+func repositoryError(err error) error {
+	if errors.Is(err, repository.ErrDisputeNotFound) {
+		return ErrDisputeNotFound
+	}
+	return fmt.Errorf("Erro ao buscar contestação: %w", err)
+}
+
+func invalidAction(message string) error {
+	return fmt.Errorf("%w %s", ErrInvalidDisputeAction, message)
+}
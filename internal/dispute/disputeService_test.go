@@ -0,0 +1,302 @@
+package dispute
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/dispute/dto"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/repository/fakes"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+type disputeFixture struct {
+	service     *disputeService
+	gateway     *fakes.PaymentGateway
+	disputeRepo *repmocks.MockDisputeRepository
+	visitRepo   *repmocks.MockVisitRepository
+	messageRepo *repmocks.MockMessageRepository
+	paymentRepo *repmocks.MockPaymentRepository
+}
+
+func newDisputeFixture(t *testing.T) disputeFixture {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	f := disputeFixture{
+		gateway:     fakes.NewPaymentGateway(),
+		disputeRepo: repmocks.NewMockDisputeRepository(ctrl),
+		visitRepo:   repmocks.NewMockVisitRepository(ctrl),
+		messageRepo: repmocks.NewMockMessageRepository(ctrl),
+		paymentRepo: repmocks.NewMockPaymentRepository(ctrl),
+	}
+	f.service = NewDisputeService(f.disputeRepo, f.visitRepo, f.messageRepo, f.paymentRepo, f.gateway).(*disputeService)
+	return f
+}
+
+func TestDisputeService_HandleDispute(t *testing.T) {
+	patientId := primitive.NewObjectID()
+	nurseId := primitive.NewObjectID()
+	bookedAt := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	confirmedAt := time.Date(2025, 3, 12, 17, 30, 0, 0, time.UTC)
+
+	visit := model.Visit{
+		ID: primitive.NewObjectID(), Status: model.VisitStatusCompleted, PaymentIntentID: "pi_123",
+		PatientId: patientId.Hex(), PatientName: "Maria", PatientEmail: "maria@medassist.com",
+		NurseId: nurseId.Hex(), NurseName: "Ana", VisitType: "Curativo", Description: "Troca de curativo",
+		VisitDate: time.Date(2025, 3, 12, 17, 0, 0, 0, time.UTC), CreatedAt: bookedAt,
+		Prescriptions: []string{"Dipirona 500mg de 6 em 6 horas"},
+		StatusHistory: []model.VisitStatusChange{
+			{From: model.VisitStatusPending, To: model.VisitStatusConfirmed, ActorRole: lifecycle.RoleNurse, ChangedAt: bookedAt.Add(time.Hour)},
+			{From: model.VisitStatusConfirmed, To: model.VisitStatusCompleted, ActorRole: lifecycle.RoleNurse, ChangedAt: confirmedAt},
+		},
+	}
+
+	stripeDispute := stripe.Dispute{
+		ID: "dp_123", Amount: 15000, Currency: stripe.CurrencyBRL, Reason: stripe.DisputeReasonProductNotReceived,
+		Status: stripe.DisputeStatusNeedsResponse, Charge: &stripe.Charge{ID: "ch_123"}, PaymentIntent: &stripe.PaymentIntent{ID: "pi_123"},
+		EvidenceDetails: &stripe.DisputeEvidenceDetails{DueBy: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC).Unix()},
+	}
+
+	t.Run("Sucesso_Nova_Contestacao_Liga_Visita_E_Monta_Evidencias", func(t *testing.T) {
+		f := newDisputeFixture(t)
+
+		f.disputeRepo.EXPECT().FindDisputeByStripeId("dp_123").Return(model.Dispute{}, repository.ErrDisputeNotFound)
+		f.visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return([]model.Visit{visit}, nil)
+		f.messageRepo.EXPECT().FindMessagesBetween(patientId, nurseId).Return([]model.Message{
+			{SenderID: patientId, Content: "Conversa de uma visita antiga", Timestamp: bookedAt.Add(-48 * time.Hour)},
+			{SenderID: patientId, Content: "Olá, o portão é azul", Timestamp: bookedAt.Add(time.Hour)},
+			{SenderID: nurseId, Content: "Chegando em 10 minutos", Timestamp: confirmedAt.Add(-40 * time.Minute)},
+		}, nil)
+
+		var created model.Dispute
+		f.disputeRepo.EXPECT().CreateDispute(gomock.Any()).DoAndReturn(func(d model.Dispute) (model.Dispute, error) {
+			d.ID = primitive.NewObjectID()
+			created = d
+			return d, nil
+		})
+		f.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", gomock.Any()).DoAndReturn(func(pi string, updates map[string]interface{}) (int64, error) {
+			assert.Equal(t, map[string]interface{}{"dispute_id": created.ID.Hex()}, updates)
+			return 1, nil
+		})
+		f.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", []string{"", model.PaymentStatusSucceeded}, model.PaymentStatusDisputed).Return(int64(1), nil)
+		f.paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Equal(t, model.PaymentEntryDispute, entry.Type)
+			assert.Equal(t, model.PaymentEntryStatusPending, entry.Status)
+			assert.Equal(t, int64(15000), entry.AmountInCents)
+			assert.Equal(t, "dp_123", entry.StripeID)
+			assert.Equal(t, visit.ID.Hex(), entry.VisitID)
+			return entry, nil
+		})
+
+		err := f.service.HandleDispute(stripeDispute)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{visit.ID.Hex()}, created.VisitIDs)
+		assert.Equal(t, model.DisputeStatusNeedsResponse, created.Status)
+		assert.Equal(t, "ch_123", created.ChargeID)
+		assert.Equal(t, patientId.Hex(), created.PatientID)
+		assert.NotNil(t, created.EvidenceDueBy)
+		assert.Nil(t, created.ClosedAt)
+
+		evidence := created.Evidence
+		assert.Equal(t, "Maria", evidence.CustomerName)
+		assert.Equal(t, "maria@medassist.com", evidence.CustomerEmail)
+		assert.Equal(t, "12/03/2025", evidence.ServiceDate)
+		assert.Contains(t, evidence.ConfirmationLog, "informado pelo enfermeiro em 12/03/2025 14:30")
+		assert.Equal(t, []string{"Dipirona 500mg de 6 em 6 horas"}, evidence.Prescriptions)
+		assert.Equal(t, "[10/03/2025 10:00] Paciente: Olá, o portão é azul\n[12/03/2025 13:50] Enfermeiro(a): Chegando em 10 minutos", evidence.ChatTranscript)
+	})
+
+	t.Run("Sucesso_Contestacao_Perdida_Encerra_E_Efetiva_Lancamento", func(t *testing.T) {
+		f := newDisputeFixture(t)
+		existing := model.Dispute{ID: primitive.NewObjectID(), StripeDisputeID: "dp_123", PaymentIntentID: "pi_123", VisitIDs: []string{visit.ID.Hex()}, Status: model.DisputeStatusUnderReview}
+		lost := stripeDispute
+		lost.Status = stripe.DisputeStatusLost
+
+		f.disputeRepo.EXPECT().FindDisputeByStripeId("dp_123").Return(existing, nil)
+		f.disputeRepo.EXPECT().UpdateDisputeFields(existing.ID.Hex(), gomock.Any()).DoAndReturn(func(id string, updates map[string]interface{}) (model.Dispute, error) {
+			assert.Equal(t, model.DisputeStatusLost, updates["status"])
+			assert.NotNil(t, updates["closed_at"])
+			existing.Status = model.DisputeStatusLost
+			existing.AmountInCents = 15000
+			return existing, nil
+		})
+		f.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", map[string]interface{}{
+			"dispute_id": existing.ID.Hex(),
+		}).Return(int64(1), nil)
+		f.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", gomock.Any(), model.PaymentStatusDisputeLost).Return(int64(1), nil)
+		f.paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Equal(t, model.PaymentEntryStatusSucceeded, entry.Status)
+			return entry, nil
+		})
+
+		assert.NoError(t, f.service.HandleDispute(lost))
+	})
+
+	t.Run("Sucesso_Evento_Simultaneo_Atualiza_A_Ja_Criada", func(t *testing.T) {
+		f := newDisputeFixture(t)
+		existing := model.Dispute{ID: primitive.NewObjectID(), StripeDisputeID: "dp_123", PaymentIntentID: "pi_123"}
+
+		f.disputeRepo.EXPECT().FindDisputeByStripeId("dp_123").Return(model.Dispute{}, repository.ErrDisputeNotFound)
+		f.visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		f.disputeRepo.EXPECT().CreateDispute(gomock.Any()).Return(model.Dispute{}, repository.ErrDisputeExists)
+		f.disputeRepo.EXPECT().FindDisputeByStripeId("dp_123").Return(existing, nil)
+		f.disputeRepo.EXPECT().UpdateDisputeFields(existing.ID.Hex(), gomock.Any()).Return(existing, nil)
+		f.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", gomock.Any()).Return(int64(0), nil)
+		f.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", gomock.Any(), model.PaymentStatusDisputed).Return(int64(0), nil)
+		f.paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil)
+
+		assert.NoError(t, f.service.HandleDispute(stripeDispute))
+	})
+
+	t.Run("Sucesso_Contestacao_Ganha_Nao_Desfaz_Estorno", func(t *testing.T) {
+		f := newDisputeFixture(t)
+		existing := model.Dispute{ID: primitive.NewObjectID(), StripeDisputeID: "dp_123", PaymentIntentID: "pi_123", VisitIDs: []string{visit.ID.Hex()}, Status: model.DisputeStatusUnderReview}
+		won := stripeDispute
+		won.Status = stripe.DisputeStatusWon
+
+		f.disputeRepo.EXPECT().FindDisputeByStripeId("dp_123").Return(existing, nil)
+		f.disputeRepo.EXPECT().UpdateDisputeFields(existing.ID.Hex(), gomock.Any()).DoAndReturn(func(id string, updates map[string]interface{}) (model.Dispute, error) {
+			existing.Status = model.DisputeStatusWon
+			return existing, nil
+		})
+		f.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", gomock.Any()).Return(int64(1), nil)
+		// só visitas ainda contestadas voltam a pagas; a estornada fica como está
+		f.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", []string{model.PaymentStatusDisputed}, model.PaymentStatusSucceeded).Return(int64(0), nil)
+		f.paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil)
+
+		assert.NoError(t, f.service.HandleDispute(won))
+	})
+}
+
+func TestDisputeService_SubmitEvidence(t *testing.T) {
+	// pagamento de cartão capturado e contestado pelo paciente no fake
+	setup := func(t *testing.T) (disputeFixture, model.Dispute) {
+		f := newDisputeFixture(t)
+		customerId, _ := f.gateway.CreateCustomer("patient-1", model.User{})
+		pi, _ := f.gateway.CreatePaymentIntent(customerId, 15000, false, nil)
+		_, _ = f.gateway.Pay(pi.ID)
+		opened, _ := f.gateway.OpenDispute(pi.ID, stripe.DisputeReasonFraudulent)
+
+		return f, model.Dispute{
+			ID: primitive.NewObjectID(), StripeDisputeID: opened.ID, PaymentIntentID: pi.ID, Status: string(opened.Status),
+			Evidence: model.DisputeEvidence{CustomerName: "Maria", ConfirmationLog: "Visita de 12/03/2025 17:00: código informado."},
+		}
+	}
+
+	t.Run("Sucesso_Envia_Com_Observacoes", func(t *testing.T) {
+		f, dispute := setup(t)
+		disputeId := dispute.ID.Hex()
+
+		f.disputeRepo.EXPECT().FindDisputeById(disputeId).Return(dispute, nil)
+		f.disputeRepo.EXPECT().UpdateDisputeFields(disputeId, gomock.Any()).DoAndReturn(func(id string, updates map[string]interface{}) (model.Dispute, error) {
+			evidence := updates["evidence"].(model.DisputeEvidence)
+			assert.Equal(t, "Paciente reconheceu a visita por telefone.", evidence.Notes)
+			assert.Equal(t, "Maria", evidence.CustomerName)
+			assert.Equal(t, model.DisputeStatusUnderReview, updates["status"])
+			assert.Equal(t, "admin-1", updates["evidence_submitted_by"])
+			dispute.Status = model.DisputeStatusUnderReview
+			return dispute, nil
+		})
+
+		updated, err := f.service.SubmitEvidence("admin-1", disputeId, dto.DisputeEvidenceDto{Notes: " Paciente reconheceu a visita por telefone. "})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.DisputeStatusUnderReview, updated.Status)
+	})
+
+	t.Run("Erro_Evidencias_Ja_Enviadas", func(t *testing.T) {
+		f, dispute := setup(t)
+		dispute.Status = model.DisputeStatusUnderReview
+		f.disputeRepo.EXPECT().FindDisputeById(dispute.ID.Hex()).Return(dispute, nil)
+
+		_, err := f.service.SubmitEvidence("admin-1", dispute.ID.Hex(), dto.DisputeEvidenceDto{})
+
+		assert.ErrorIs(t, err, ErrInvalidDisputeAction)
+	})
+
+	t.Run("Erro_Contestacao_Inexistente", func(t *testing.T) {
+		f := newDisputeFixture(t)
+		f.disputeRepo.EXPECT().FindDisputeById("404").Return(model.Dispute{}, repository.ErrDisputeNotFound)
+
+		_, err := f.service.SubmitEvidence("admin-1", "404", dto.DisputeEvidenceDto{})
+
+		assert.ErrorIs(t, err, ErrDisputeNotFound)
+	})
+}
+
+func TestDisputeService_ReverseTransfers(t *testing.T) {
+	// visita concluída: pagamento capturado e repasse de R$ 120,00 feito ao enfermeiro
+	setup := func(t *testing.T) (disputeFixture, model.Dispute, model.Visit) {
+		f := newDisputeFixture(t)
+		customerId, _ := f.gateway.CreateCustomer("patient-1", model.User{})
+		pi, _ := f.gateway.CreatePaymentIntent(customerId, 15000, false, nil)
+		_, _ = f.gateway.Pay(pi.ID)
+		accountId, _ := f.gateway.CreateExpressAccount("ana@medassist.com")
+		transfer, _ := f.gateway.CreateTransfer(12000, accountId, pi.ID, "")
+
+		visit := model.Visit{
+			ID: primitive.NewObjectID(), Status: model.VisitStatusCompleted, PaymentIntentID: pi.ID, TransferID: transfer.ID,
+			TransferStatus: model.TransferStatusCreated, Commission: &model.VisitCommission{NurseAmountInCents: 12000},
+		}
+		dispute := model.Dispute{ID: primitive.NewObjectID(), PaymentIntentID: pi.ID, VisitIDs: []string{visit.ID.Hex()}, Status: model.DisputeStatusLost}
+		return f, dispute, visit
+	}
+
+	t.Run("Sucesso_Devolve_Repasse_Da_Visita", func(t *testing.T) {
+		f, dispute, visit := setup(t)
+		disputeId, visitId := dispute.ID.Hex(), visit.ID.Hex()
+
+		f.disputeRepo.EXPECT().FindDisputeById(disputeId).Return(dispute, nil)
+		f.visitRepo.EXPECT().FindVisitById(visitId).Return(visit, nil)
+		f.disputeRepo.EXPECT().AddTransferReversal(disputeId, gomock.Any()).DoAndReturn(func(id string, reversal model.DisputeTransferReversal) (model.Dispute, error) {
+			assert.Equal(t, visit.TransferID, reversal.TransferID)
+			assert.Equal(t, int64(12000), reversal.AmountInCents)
+			assert.Equal(t, "admin-1", reversal.ReversedBy)
+			dispute.TransferReversals = append(dispute.TransferReversals, reversal)
+			return dispute, nil
+		})
+		f.visitRepo.EXPECT().UpdateVisitFields(visitId, map[string]interface{}{"transfer_status": model.TransferStatusReversed}).Return(visit, nil)
+		f.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryTransfer, visit.TransferID, model.PaymentEntryStatusReversed).Return(nil)
+
+		updated, err := f.service.ReverseTransfers("admin-1", disputeId)
+
+		assert.NoError(t, err)
+		assert.Len(t, updated.TransferReversals, 1)
+		transfers := f.gateway.Transfers()
+		assert.True(t, transfers[0].Reversed)
+		assert.Equal(t, int64(12000), transfers[0].AmountReversed)
+	})
+
+	t.Run("Erro_Contestacao_Nao_Perdida", func(t *testing.T) {
+		f, dispute, _ := setup(t)
+		dispute.Status = model.DisputeStatusUnderReview
+		f.disputeRepo.EXPECT().FindDisputeById(dispute.ID.Hex()).Return(dispute, nil)
+
+		_, err := f.service.ReverseTransfers("admin-1", dispute.ID.Hex())
+
+		assert.ErrorIs(t, err, ErrInvalidDisputeAction)
+		assert.False(t, f.gateway.Transfers()[0].Reversed)
+	})
+
+	t.Run("Erro_Repasse_Ja_Revertido", func(t *testing.T) {
+		f, dispute, visit := setup(t)
+		dispute.TransferReversals = []model.DisputeTransferReversal{{VisitID: visit.ID.Hex(), TransferID: visit.TransferID}}
+		f.disputeRepo.EXPECT().FindDisputeById(dispute.ID.Hex()).Return(dispute, nil)
+		f.visitRepo.EXPECT().FindVisitById(visit.ID.Hex()).Return(visit, nil)
+
+		_, err := f.service.ReverseTransfers("admin-1", dispute.ID.Hex())
+
+		assert.ErrorIs(t, err, ErrInvalidDisputeAction)
+		assert.Contains(t, err.Error(), "Não há repasses")
+		assert.False(t, f.gateway.Transfers()[0].Reversed)
+	})
+}
@@ -0,0 +1,7 @@
+package dto
+
+// DisputeEvidenceDto é o corpo usado pelo administrador para enviar as evidências da
+// contestação. As provas das visitas já vêm montadas; notes acrescenta as observações dele.
+type DisputeEvidenceDto struct {
+	Notes string `json:"notes"`
+}
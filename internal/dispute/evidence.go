@@ -0,0 +1,113 @@
+package dispute
+
+import (
+	"fmt"
+	"log"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
+	"medassist/internal/scheduling"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxTranscriptLength limita a conversa enviada como evidência; o Stripe aceita no máximo
+// 150.000 caracteres somando todos os campos.
+const maxTranscriptLength = 20000
+
+const evidenceTimeLayout = "02/01/2006 15:04"
+
+// collectEvidence monta as provas de que as visitas do pagamento aconteceram: a hora em que o
+// enfermeiro informou o código de confirmação que só o paciente tem, as prescrições registradas
+// e a conversa entre os dois pelo aplicativo.
+func (s *disputeService) collectEvidence(visits []model.Visit) model.DisputeEvidence {
+	if len(visits) == 0 {
+		return model.DisputeEvidence{}
+	}
+	location := scheduling.Location()
+	first := visits[0]
+
+	evidence := model.DisputeEvidence{
+		CustomerName:  first.PatientName,
+		CustomerEmail: first.PatientEmail,
+		ServiceDate:   first.VisitDate.In(location).Format("02/01/2006"),
+	}
+
+	var descriptions, confirmations []string
+	for _, visit := range visits {
+		visitDate := visit.VisitDate.In(location).Format(evidenceTimeLayout)
+		descriptions = append(descriptions, fmt.Sprintf("Visita domiciliar de enfermagem (%s) em %s, %s, nº %s, com %s: %s",
+			visit.VisitType, visitDate, visit.Street, visit.Number, visit.NurseName, visit.Description))
+
+		if confirmedAt, ok := confirmationTime(visit); ok {
+			confirmations = append(confirmations, fmt.Sprintf("Visita de %s: código de confirmação do paciente informado pelo enfermeiro em %s (horário de Brasília).",
+				visitDate, confirmedAt.In(location).Format(evidenceTimeLayout)))
+		} else {
+			confirmations = append(confirmations, fmt.Sprintf("Visita de %s: código de confirmação não informado (situação %s).", visitDate, visit.Status))
+		}
+		evidence.Prescriptions = append(evidence.Prescriptions, visit.Prescriptions...)
+	}
+	evidence.ServiceDescription = strings.Join(descriptions, "\n")
+	evidence.ConfirmationLog = strings.Join(confirmations, "\n")
+	evidence.ChatTranscript = s.chatTranscript(visits)
+
+	return evidence
+}
+
+// confirmationTime é quando o enfermeiro concluiu a visita, o que só acontece depois de ele
+// informar o código de confirmação do paciente.
+func confirmationTime(visit model.Visit) (time.Time, bool) {
+	for _, change := range visit.StatusHistory {
+		if change.To == model.VisitStatusCompleted && change.ActorRole == lifecycle.RoleNurse {
+			return change.ChangedAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// chatTranscript retorna as mensagens trocadas entre paciente e enfermeiro desde que a primeira
+// visita foi marcada. Sem conversa, ou se ela não puder ser lida, a evidência fica sem ela.
+func (s *disputeService) chatTranscript(visits []model.Visit) string {
+	first := visits[0]
+	patientId, err := primitive.ObjectIDFromHex(first.PatientId)
+	if err != nil {
+		return ""
+	}
+	nurseId, err := primitive.ObjectIDFromHex(first.NurseId)
+	if err != nil {
+		return ""
+	}
+
+	messages, err := s.messageRepository.FindMessagesBetween(patientId, nurseId)
+	if err != nil {
+		log.Printf("Erro ao buscar conversa entre paciente %s e enfermeiro %s: %v", first.PatientId, first.NurseId, err)
+		return ""
+	}
+
+	since := first.CreatedAt
+	for _, visit := range visits {
+		if visit.CreatedAt.Before(since) {
+			since = visit.CreatedAt
+		}
+	}
+
+	location := scheduling.Location()
+	var transcript strings.Builder
+	for _, message := range messages {
+		if message.Timestamp.Before(since) {
+			continue
+		}
+		sender := "Enfermeiro(a)"
+		if message.SenderID == patientId {
+			sender = "Paciente"
+		}
+		line := fmt.Sprintf("[%s] %s: %s\n", message.Timestamp.In(location).Format(evidenceTimeLayout), sender, message.Content)
+		if transcript.Len()+len(line) > maxTranscriptLength {
+			transcript.WriteString("[conversa truncada]\n")
+			break
+		}
+		transcript.WriteString(line)
+	}
+	return strings.TrimSuffix(transcript.String(), "\n")
+}
@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/dispute/disputeService.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/dispute/disputeService.go -destination=internal/dispute/mocks/mock_disputeService.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	dto "medassist/internal/dispute/dto"
+	model "medassist/internal/model"
+	reflect "reflect"
+
+	stripe "github.com/stripe/stripe-go/v76"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDisputeService is a mock of DisputeService interface.
+type MockDisputeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisputeServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDisputeServiceMockRecorder is the mock recorder for MockDisputeService.
+type MockDisputeServiceMockRecorder struct {
+	mock *MockDisputeService
+}
+
+// NewMockDisputeService creates a new mock instance.
+func NewMockDisputeService(ctrl *gomock.Controller) *MockDisputeService {
+	mock := &MockDisputeService{ctrl: ctrl}
+	mock.recorder = &MockDisputeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisputeService) EXPECT() *MockDisputeServiceMockRecorder {
+	return m.recorder
+}
+
+// GetDispute mocks base method.
+func (m *MockDisputeService) GetDispute(disputeId string) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDispute", disputeId)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDispute indicates an expected call of GetDispute.
+func (mr *MockDisputeServiceMockRecorder) GetDispute(disputeId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDispute", reflect.TypeOf((*MockDisputeService)(nil).GetDispute), disputeId)
+}
+
+// HandleDispute mocks base method.
+func (m *MockDisputeService) HandleDispute(stripeDispute stripe.Dispute) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleDispute", stripeDispute)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleDispute indicates an expected call of HandleDispute.
+func (mr *MockDisputeServiceMockRecorder) HandleDispute(stripeDispute any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDispute", reflect.TypeOf((*MockDisputeService)(nil).HandleDispute), stripeDispute)
+}
+
+// ListDisputes mocks base method.
+func (m *MockDisputeService) ListDisputes(status string) ([]model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisputes", status)
+	ret0, _ := ret[0].([]model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisputes indicates an expected call of ListDisputes.
+func (mr *MockDisputeServiceMockRecorder) ListDisputes(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisputes", reflect.TypeOf((*MockDisputeService)(nil).ListDisputes), status)
+}
+
+// ReverseTransfers mocks base method.
+func (m *MockDisputeService) ReverseTransfers(adminId, disputeId string) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransfers", adminId, disputeId)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransfers indicates an expected call of ReverseTransfers.
+func (mr *MockDisputeServiceMockRecorder) ReverseTransfers(adminId, disputeId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransfers", reflect.TypeOf((*MockDisputeService)(nil).ReverseTransfers), adminId, disputeId)
+}
+
+// SubmitEvidence mocks base method.
+func (m *MockDisputeService) SubmitEvidence(adminId, disputeId string, evidenceDto dto.DisputeEvidenceDto) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitEvidence", adminId, disputeId, evidenceDto)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitEvidence indicates an expected call of SubmitEvidence.
+func (mr *MockDisputeServiceMockRecorder) SubmitEvidence(adminId, disputeId, evidenceDto any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitEvidence", reflect.TypeOf((*MockDisputeService)(nil).SubmitEvidence), adminId, disputeId, evidenceDto)
+}
@@ -0,0 +1,225 @@
+package earnings
+
+import (
+	"errors"
+	"math"
+	"medassist/internal/model"
+	"sort"
+	"time"
+)
+
+// Agrupamentos aceitos no extrato de ganhos.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Situação do valor líquido de cada visita no extrato.
+const (
+	StatusTransferred = "TRANSFERRED" // repasse feito ao enfermeiro
+	StatusPending     = "PENDING"     // visita confirmada ou concluída ainda sem repasse
+	StatusReversed    = "REVERSED"    // repasse estornado (ex: reembolso ao paciente)
+)
+
+// ErrInvalidPeriod indica um agrupamento de extrato desconhecido.
+var ErrInvalidPeriod = errors.New("Período inválido. Use day, week ou month.")
+
+// VisitEarning detalha o valor de uma visita: quanto o paciente pagou, a comissão da plataforma
+// e o que cabe ao enfermeiro.
+type VisitEarning struct {
+	VisitID        string    `json:"visit_id"`
+	VisitDate      time.Time `json:"visit_date"`
+	PatientName    string    `json:"patient_name"`
+	VisitType      string    `json:"visit_type"`
+	VisitStatus    string    `json:"visit_status"`
+	GrossAmount    float64   `json:"gross_amount"`
+	CommissionRate float64   `json:"commission_rate_percent"`
+	PlatformFee    float64   `json:"platform_fee"`
+	NetAmount      float64   `json:"net_amount"`
+	Status         string    `json:"status"`
+	TransferID     string    `json:"transfer_id,omitempty"`
+	// Tip é a gorjeta do paciente, repassada inteira ao enfermeiro e fora dos demais valores.
+	Tip float64 `json:"tip"`
+	// Estimated indica que a comissão ainda não foi aplicada em um repasse e foi calculada com as regras atuais.
+	Estimated bool `json:"estimated"`
+}
+
+// Totals soma os valores de um conjunto de visitas.
+type Totals struct {
+	VisitCount     int     `json:"visit_count"`
+	GrossAmount    float64 `json:"gross_amount"`
+	PlatformFee    float64 `json:"platform_fee"`
+	NetTransferred float64 `json:"net_transferred"`
+	Pending        float64 `json:"pending"`
+	Reversed       float64 `json:"reversed"`
+	Tips           float64 `json:"tips"`
+}
+
+// PeriodEarnings reúne as visitas de um dia, semana (começando na segunda-feira) ou mês.
+type PeriodEarnings struct {
+	Label  string         `json:"label"`
+	Start  time.Time      `json:"start"`
+	Totals Totals         `json:"totals"`
+	Visits []VisitEarning `json:"visits"`
+}
+
+// Statement é o extrato de ganhos do enfermeiro entre From (inclusive) e To (exclusive).
+type Statement struct {
+	NurseID   string           `json:"nurse_id"`
+	NurseName string           `json:"nurse_name"`
+	Coren     string           `json:"coren"`
+	Period    string           `json:"period"`
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Totals    Totals           `json:"totals"`
+	Periods   []PeriodEarnings `json:"periods"`
+}
+
+// Estimator calcula a comissão de uma visita que ainda não teve repasse.
+type Estimator func(visit model.Visit) model.VisitCommission
+
+// ValidPeriod indica se period é um agrupamento aceito.
+func ValidPeriod(period string) bool {
+	return period == PeriodDay || period == PeriodWeek || period == PeriodMonth
+}
+
+// Build monta o extrato a partir das visitas CONFIRMED e COMPLETED do enfermeiro, agrupadas por
+// period no fuso location. Visitas já repassadas usam a comissão gravada no repasse; as demais
+// usam estimate.
+func Build(nurse model.Nurse, visits []model.Visit, period string, from, to time.Time, location *time.Location, estimate Estimator) (Statement, error) {
+	if !ValidPeriod(period) {
+		return Statement{}, ErrInvalidPeriod
+	}
+
+	statement := Statement{
+		NurseID:   nurse.ID.Hex(),
+		NurseName: nurse.Name,
+		Coren:     nurse.Coren,
+		Period:    period,
+		From:      from,
+		To:        to,
+		Periods:   []PeriodEarnings{},
+	}
+
+	sorted := append([]model.Visit(nil), visits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].VisitDate.Before(sorted[j].VisitDate) })
+
+	byStart := map[time.Time]int{}
+	for _, visit := range sorted {
+		earning, ok := visitEarning(visit, estimate)
+		if !ok {
+			continue
+		}
+
+		start := periodStart(visit.VisitDate.In(location), period)
+		index, exists := byStart[start]
+		if !exists {
+			index = len(statement.Periods)
+			byStart[start] = index
+			statement.Periods = append(statement.Periods, PeriodEarnings{
+				Label:  periodLabel(start, period),
+				Start:  start,
+				Visits: []VisitEarning{},
+			})
+		}
+
+		group := &statement.Periods[index]
+		group.Visits = append(group.Visits, earning)
+		group.Totals.add(earning)
+		statement.Totals.add(earning)
+	}
+
+	return statement, nil
+}
+
+// visitEarning calcula os valores da visita. Visitas fora de CONFIRMED e COMPLETED não entram no extrato.
+func visitEarning(visit model.Visit, estimate Estimator) (VisitEarning, bool) {
+	if visit.Status != model.VisitStatusConfirmed && visit.Status != model.VisitStatusCompleted {
+		return VisitEarning{}, false
+	}
+
+	earning := VisitEarning{
+		VisitID:     visit.ID.Hex(),
+		VisitDate:   visit.VisitDate,
+		PatientName: visit.PatientName,
+		VisitType:   visit.VisitType,
+		VisitStatus: string(visit.Status),
+		TransferID:  visit.TransferID,
+	}
+
+	var applied model.VisitCommission
+	if visit.Commission != nil {
+		applied = *visit.Commission
+	} else {
+		applied = estimate(visit)
+		earning.Estimated = true
+	}
+
+	earning.GrossAmount = fromCents(applied.AmountInCents + applied.NurseAmountInCents)
+	earning.CommissionRate = applied.RatePercent
+	earning.PlatformFee = fromCents(applied.AmountInCents)
+	earning.NetAmount = fromCents(applied.NurseAmountInCents)
+
+	if visit.Tip != nil && visit.Tip.Status == model.TipStatusTransferred {
+		earning.Tip = fromCents(visit.Tip.AmountInCents)
+	}
+
+	switch {
+	case visit.TransferID == "":
+		earning.Status = StatusPending
+	case visit.TransferStatus == model.TransferStatusReversed:
+		earning.Status = StatusReversed
+	default:
+		earning.Status = StatusTransferred
+	}
+
+	return earning, true
+}
+
+func (t *Totals) add(earning VisitEarning) {
+	t.VisitCount++
+	t.GrossAmount = round(t.GrossAmount + earning.GrossAmount)
+	t.PlatformFee = round(t.PlatformFee + earning.PlatformFee)
+	t.Tips = round(t.Tips + earning.Tip)
+
+	switch earning.Status {
+	case StatusTransferred:
+		t.NetTransferred = round(t.NetTransferred + earning.NetAmount)
+	case StatusPending:
+		t.Pending = round(t.Pending + earning.NetAmount)
+	case StatusReversed:
+		t.Reversed = round(t.Reversed + earning.NetAmount)
+	}
+}
+
+// periodStart retorna o início do dia, da semana (segunda-feira) ou do mês de day.
+func periodStart(day time.Time, period string) time.Time {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	switch period {
+	case PeriodWeek:
+		offset := (int(start.Weekday()) + 6) % 7 // dias desde a segunda-feira
+		return start.AddDate(0, 0, -offset)
+	case PeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	}
+	return start
+}
+
+func periodLabel(start time.Time, period string) string {
+	switch period {
+	case PeriodWeek:
+		return "Semana de " + start.Format("02/01/2006")
+	case PeriodMonth:
+		return start.Format("01/2006")
+	}
+	return start.Format("02/01/2006")
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
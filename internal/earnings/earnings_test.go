@@ -0,0 +1,110 @@
+package earnings
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	"medassist/internal/scheduling"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func estimateTenPercent(visit model.Visit) model.VisitCommission {
+	cents := int64(visit.VisitValue * 100)
+	return model.VisitCommission{RatePercent: 10, AmountInCents: cents / 10, NurseAmountInCents: cents - cents/10}
+}
+
+func TestBuild(t *testing.T) {
+	location := scheduling.Location()
+	nurse := model.Nurse{ID: primitive.NewObjectID(), Name: "Ana", Coren: "123"}
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, location)
+	to := from.AddDate(0, 1, 0)
+
+	transferred := model.Visit{
+		ID: primitive.NewObjectID(), Status: model.VisitStatusCompleted, VisitValue: 200,
+		VisitDate:  time.Date(2025, 3, 10, 9, 0, 0, 0, location), // segunda-feira
+		TransferID: "tr_1", TransferStatus: model.TransferStatusCreated,
+		Commission: &model.VisitCommission{RatePercent: 15, AmountInCents: 3000, NurseAmountInCents: 17000},
+	}
+	pending := model.Visit{
+		ID: primitive.NewObjectID(), Status: model.VisitStatusConfirmed, VisitValue: 100,
+		VisitDate: time.Date(2025, 3, 16, 20, 0, 0, 0, location), // domingo da mesma semana
+	}
+	reversed := model.Visit{
+		ID: primitive.NewObjectID(), Status: model.VisitStatusCompleted, VisitValue: 50,
+		VisitDate:  time.Date(2025, 3, 17, 9, 0, 0, 0, location),
+		TransferID: "tr_2", TransferStatus: model.TransferStatusReversed,
+		Commission: &model.VisitCommission{RatePercent: 10, AmountInCents: 500, NurseAmountInCents: 4500},
+	}
+	canceled := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusCanceled, VisitValue: 80, VisitDate: transferred.VisitDate}
+
+	t.Run("Erro_PeriodoInvalido", func(t *testing.T) {
+		_, err := Build(nurse, nil, "year", from, to, location, estimateTenPercent)
+
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+	})
+
+	t.Run("Sucesso_AgrupaPorSemana", func(t *testing.T) {
+		statement, err := Build(nurse, []model.Visit{reversed, canceled, pending, transferred}, PeriodWeek, from, to, location, estimateTenPercent)
+
+		assert.NoError(t, err)
+		assert.Len(t, statement.Periods, 2)
+		assert.Equal(t, "Semana de 10/03/2025", statement.Periods[0].Label)
+		assert.Equal(t, 2, statement.Periods[0].Totals.VisitCount)
+		assert.Equal(t, "Semana de 17/03/2025", statement.Periods[1].Label)
+
+		assert.Equal(t, Totals{VisitCount: 3, GrossAmount: 350, PlatformFee: 45, NetTransferred: 170, Pending: 90, Reversed: 45}, statement.Totals)
+	})
+
+	t.Run("Sucesso_VisitaSemRepasseUsaEstimativa", func(t *testing.T) {
+		statement, err := Build(nurse, []model.Visit{pending}, PeriodDay, from, to, location, estimateTenPercent)
+
+		assert.NoError(t, err)
+		visit := statement.Periods[0].Visits[0]
+		assert.Equal(t, StatusPending, visit.Status)
+		assert.True(t, visit.Estimated)
+		assert.Equal(t, 10.0, visit.PlatformFee)
+		assert.Equal(t, "16/03/2025", statement.Periods[0].Label)
+	})
+
+	t.Run("Sucesso_GorjetaSeparadaDosValoresDaVisita", func(t *testing.T) {
+		tipped := transferred
+		tipped.Tip = &model.VisitTip{AmountInCents: 2500, Status: model.TipStatusTransferred, TransferID: "tr_tip"}
+		processing := pending
+		processing.Tip = &model.VisitTip{AmountInCents: 1000, Status: model.TipStatusProcessing}
+
+		statement, err := Build(nurse, []model.Visit{tipped, processing}, PeriodMonth, from, to, location, estimateTenPercent)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 25.0, statement.Periods[0].Visits[0].Tip)
+		assert.Equal(t, 0.0, statement.Periods[0].Visits[1].Tip)
+		assert.Equal(t, Totals{VisitCount: 2, GrossAmount: 300, PlatformFee: 40, NetTransferred: 170, Pending: 90, Tips: 25}, statement.Totals)
+	})
+
+	t.Run("Sucesso_AgrupaPorMes", func(t *testing.T) {
+		statement, err := Build(nurse, []model.Visit{transferred, pending}, PeriodMonth, from, to, location, estimateTenPercent)
+
+		assert.NoError(t, err)
+		assert.Len(t, statement.Periods, 1)
+		assert.Equal(t, "03/2025", statement.Periods[0].Label)
+	})
+
+	t.Run("Sucesso_ExportaCsvEPdf", func(t *testing.T) {
+		statement, _ := Build(nurse, []model.Visit{transferred, pending}, PeriodMonth, from, to, location, estimateTenPercent)
+
+		var csv bytes.Buffer
+		assert.NoError(t, WriteCSV(&csv, statement))
+		lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+		assert.Len(t, lines, 4)
+		assert.Contains(t, lines[1], "200,00;15,00;30,00;170,00;Repassado;tr_1")
+		assert.Contains(t, lines[3], "Total")
+
+		out := RenderPDF(statement)
+		assert.True(t, bytes.HasPrefix(out, []byte("%PDF-")))
+		assert.Contains(t, string(out), "(tr_1) Tj")
+	})
+}
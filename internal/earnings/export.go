@@ -0,0 +1,129 @@
+package earnings
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"medassist/internal/pdf"
+	"strconv"
+	"strings"
+)
+
+var statusLabels = map[string]string{
+	StatusTransferred: "Repassado",
+	StatusPending:     "Pendente",
+	StatusReversed:    "Estornado",
+}
+
+// WriteCSV escreve o extrato em CSV, uma linha por visita, com ";" como separador e vírgula
+// decimal para abrir direto em planilhas configuradas em português.
+func WriteCSV(w io.Writer, statement Statement) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+
+	rows := [][]string{{
+		"Período", "Data", "Visita", "Paciente", "Tipo", "Status da visita", "Valor bruto",
+		"Comissão (%)", "Taxa da plataforma", "Valor líquido", "Situação do repasse", "Repasse", "Gorjeta",
+	}}
+	for _, period := range statement.Periods {
+		for _, visit := range period.Visits {
+			rows = append(rows, []string{
+				period.Label,
+				visit.VisitDate.In(period.Start.Location()).Format("02/01/2006 15:04"),
+				visit.VisitID,
+				visit.PatientName,
+				visit.VisitType,
+				visit.VisitStatus,
+				decimal(visit.GrossAmount),
+				decimal(visit.CommissionRate),
+				decimal(visit.PlatformFee),
+				decimal(visit.NetAmount),
+				statusLabels[visit.Status],
+				visit.TransferID,
+				decimal(visit.Tip),
+			})
+		}
+	}
+	rows = append(rows, []string{
+		"Total", "", "", "", "", "",
+		decimal(statement.Totals.GrossAmount), "",
+		decimal(statement.Totals.PlatformFee),
+		decimal(statement.Totals.NetTransferred + statement.Totals.Pending), "", "",
+		decimal(statement.Totals.Tips),
+	})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("erro ao gerar o CSV do extrato: %w", err)
+	}
+	return nil
+}
+
+// RenderPDF gera o extrato em PDF, com o resumo do intervalo e uma tabela por período.
+func RenderPDF(statement Statement) []byte {
+	doc := pdf.New()
+	doc.Title("Extrato de ganhos")
+	doc.Text(fmt.Sprintf("%s - COREN %s", statement.NurseName, statement.Coren))
+	doc.Text(fmt.Sprintf("De %s a %s", statement.From.Format("02/01/2006"), statement.To.AddDate(0, 0, -1).Format("02/01/2006")))
+
+	doc.Space()
+	doc.Heading("Resumo")
+	doc.Text("Visitas: " + strconv.Itoa(statement.Totals.VisitCount))
+	doc.Text("Valor bruto: " + money(statement.Totals.GrossAmount))
+	doc.Text("Taxa da plataforma: " + money(statement.Totals.PlatformFee))
+	doc.Text("Líquido repassado: " + money(statement.Totals.NetTransferred))
+	doc.Text("A receber: " + money(statement.Totals.Pending))
+	if statement.Totals.Reversed > 0 {
+		doc.Text("Repasses estornados: " + money(statement.Totals.Reversed))
+	}
+	doc.Text("Gorjetas recebidas: " + money(statement.Totals.Tips))
+
+	widths := []float64{62, 98, 55, 45, 55, 55, 75, 50}
+	for _, period := range statement.Periods {
+		doc.Space()
+		doc.Heading(period.Label)
+		doc.Row([]string{"Data", "Paciente", "Bruto", "Taxa", "Líquido", "Situação", "Repasse", "Gorjeta"}, widths, true)
+		for _, visit := range period.Visits {
+			doc.Row([]string{
+				visit.VisitDate.In(period.Start.Location()).Format("02/01 15:04"),
+				truncate(visit.PatientName, 19),
+				money(visit.GrossAmount),
+				money(visit.PlatformFee),
+				money(visit.NetAmount),
+				statusLabels[visit.Status],
+				visit.TransferID,
+				money(visit.Tip),
+			}, widths, false)
+		}
+		doc.Row([]string{
+			"Total", "",
+			money(period.Totals.GrossAmount),
+			money(period.Totals.PlatformFee),
+			money(period.Totals.NetTransferred + period.Totals.Pending),
+			"", "",
+			money(period.Totals.Tips),
+		}, widths, true)
+	}
+
+	if len(statement.Periods) == 0 {
+		doc.Space()
+		doc.Text("Nenhuma visita no período.")
+	}
+
+	return doc.Bytes()
+}
+
+func decimal(value float64) string {
+	return strings.Replace(strconv.FormatFloat(value, 'f', 2, 64), ".", ",", 1)
+}
+
+func money(value float64) string {
+	return "R$ " + decimal(value)
+}
+
+func truncate(text string, size int) string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return text
+	}
+	return string(runes[:size-1]) + "…"
+}
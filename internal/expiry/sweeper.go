@@ -0,0 +1,156 @@
+package expiry
+
+import (
+	"fmt"
+	"log"
+	"medassist/internal/chat"
+	chatDTO "medassist/internal/chat/dto"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/scheduling"
+	"medassist/utils"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EventExpired é enviado pelo WebSocket ao paciente (e ao enfermeiro, se houver) quando a visita expira.
+const EventExpired = "VISIT_EXPIRED"
+
+// Config controla por quanto tempo uma visita pode ficar PENDING sem resposta e de quanto em
+// quanto tempo a varredura roda. Visitas IMMEDIATE usam uma janela de resposta mais curta.
+type Config struct {
+	ResponseWindow          time.Duration
+	ImmediateResponseWindow time.Duration
+	Interval                time.Duration
+}
+
+// LoadConfig lê EXPIRY_RESPONSE_WINDOW_HOURS, EXPIRY_IMMEDIATE_WINDOW_MINUTES e
+// EXPIRY_SWEEP_INTERVAL_SECONDS, com valores padrão para as que não estiverem definidas.
+func LoadConfig() Config {
+	config := Config{
+		ResponseWindow:          24 * time.Hour,
+		ImmediateResponseWindow: 15 * time.Minute,
+		Interval:                time.Minute,
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("EXPIRY_RESPONSE_WINDOW_HOURS")); err == nil && hours > 0 {
+		config.ResponseWindow = time.Duration(hours) * time.Hour
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("EXPIRY_IMMEDIATE_WINDOW_MINUTES")); err == nil && minutes > 0 {
+		config.ImmediateResponseWindow = time.Duration(minutes) * time.Minute
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("EXPIRY_SWEEP_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		config.Interval = time.Duration(seconds) * time.Second
+	}
+
+	return config
+}
+
+// Stale informa se a visita PENDING já passou do prazo de resposta em now. Visitas IMMEDIATE
+// são criadas com a data do pedido e só expiram pela janela curta, contada da criação; as
+// agendadas expiram pela janela normal ou quando a data da visita chega.
+func (c Config) Stale(visit model.Visit, now time.Time) bool {
+	if visit.VisitRequestType == "IMMEDIATE" {
+		return !visit.CreatedAt.After(now.Add(-c.ImmediateResponseWindow))
+	}
+	return !visit.CreatedAt.After(now.Add(-c.ResponseWindow)) || !visit.VisitDate.After(now)
+}
+
+// Sweeper expira periodicamente as visitas PENDING que não foram respondidas a tempo,
+// estornando o pagamento e avisando o paciente.
+type Sweeper interface {
+	Run()
+	Sweep(now time.Time) int
+}
+
+type sweeper struct {
+	visitRepository   repository.VisitRepository
+	visitStateMachine lifecycle.VisitStateMachine
+	visitHub          *chat.Hub
+	config            Config
+
+	sendEmail func(patientEmail, patientName, visitDate string, refundAmount float64) error
+}
+
+// NewSweeper cria a varredura. O estorno das visitas expiradas é feito pelo refunder, na
+// transição para EXPIRED.
+func NewSweeper(visitRepository repository.VisitRepository, refunder lifecycle.Refunder, visitHub *chat.Hub, config Config) Sweeper {
+	return &sweeper{
+		visitRepository:   visitRepository,
+		visitStateMachine: lifecycle.NewVisitStateMachine(visitRepository, refunder),
+		visitHub:          visitHub,
+		config:            config,
+		sendEmail:         utils.SendEmailVisitExpired,
+	}
+}
+
+// Run executa a varredura a cada Config.Interval. Deve ser iniciado em uma goroutine própria.
+func (s *sweeper) Run() {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if expired := s.Sweep(now); expired > 0 {
+			log.Printf("[Expiry] %d visita(s) pendente(s) expirada(s)", expired)
+		}
+	}
+}
+
+// Sweep expira as visitas pendentes vencidas em now e retorna quantas foram expiradas.
+func (s *sweeper) Sweep(now time.Time) int {
+	visits, err := s.visitRepository.FindStalePendingVisits(now, now.Add(-s.config.ResponseWindow), now.Add(-s.config.ImmediateResponseWindow))
+	if err != nil {
+		log.Printf("[Expiry] Erro ao buscar visitas pendentes: %v", err)
+		return 0
+	}
+
+	expired := 0
+	for _, visit := range visits {
+		if !s.config.Stale(visit, now) {
+			continue
+		}
+		if err := s.expire(visit, now); err != nil {
+			log.Printf("[Expiry] Erro ao expirar visita %s: %v", visit.ID.Hex(), err)
+			continue
+		}
+		expired++
+	}
+
+	return expired
+}
+
+func (s *sweeper) expire(visit model.Visit, now time.Time) error {
+	visitId := visit.ID.Hex()
+
+	reason := "A visita não foi respondida dentro do prazo."
+	if visit.VisitRequestType != "IMMEDIATE" && !visit.VisitDate.After(now) {
+		reason = "A data da visita passou sem resposta do enfermeiro."
+	}
+
+	actor := lifecycle.Actor{ID: lifecycle.RoleSystem, Role: lifecycle.RoleSystem}
+	expired, err := s.visitStateMachine.Transition(visit, model.VisitStatusExpired, actor, reason, map[string]interface{}{"cancel_reason": reason})
+	if err != nil {
+		return err
+	}
+	refundAmount := expired.RefundAmount
+
+	visitDate := visit.VisitDate.In(scheduling.Location()).Format("02/01/2006 15:04")
+	if err := s.sendEmail(visit.PatientEmail, visit.PatientName, visitDate, refundAmount); err != nil {
+		log.Printf("[Expiry] Erro ao enviar e-mail de expiração da visita %s: %v", visitId, err)
+	}
+
+	event := chatDTO.VisitEventDTO{
+		Type:    EventExpired,
+		VisitID: visitId,
+		Message: fmt.Sprintf("A visita de %s expirou sem resposta. %s", visitDate, reason),
+		Data:    map[string]interface{}{"refund_amount": refundAmount},
+	}
+	s.visitHub.NotifyVisitEvent(visit.PatientId, event)
+	if visit.NurseId != "" {
+		s.visitHub.NotifyVisitEvent(visit.NurseId, event)
+	}
+
+	return nil
+}
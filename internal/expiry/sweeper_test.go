@@ -0,0 +1,150 @@
+package expiry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+var testConfig = Config{ResponseWindow: 24 * time.Hour, ImmediateResponseWindow: 15 * time.Minute, Interval: time.Minute}
+
+// fakeRefunder registra os estornos pedidos pela máquina de estados.
+type fakeRefunder struct {
+	refunded []float64
+	err      error
+}
+
+func (f *fakeRefunder) RefundVisit(visit model.Visit, amount float64) (model.Visit, error) {
+	if f.err != nil {
+		return visit, f.err
+	}
+	f.refunded = append(f.refunded, amount)
+	visit.RefundAmount = amount
+	return visit, nil
+}
+
+func newTestSweeper(visitRepo *repmocks.MockVisitRepository, refunder *fakeRefunder, sent *[]float64) *sweeper {
+	s := NewSweeper(visitRepo, refunder, nil, testConfig).(*sweeper)
+	s.sendEmail = func(patientEmail, patientName, visitDate string, refundAmount float64) error {
+		*sent = append(*sent, refundAmount)
+		return nil
+	}
+	return s
+}
+
+func TestSweeper_Sweep(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Sucesso_Expira_E_Estorna", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		refunder := &fakeRefunder{}
+		var sent []float64
+		s := newTestSweeper(visitRepo, refunder, &sent)
+
+		visit := model.Visit{
+			ID: primitive.NewObjectID(), Status: model.VisitStatusPending, PatientId: "patient-1",
+			VisitValue: 150, PaymentIntentID: "pi_123", VisitRequestType: "IMMEDIATE",
+			VisitDate: now.Add(time.Hour), CreatedAt: now.Add(-time.Hour),
+		}
+
+		visitRepo.EXPECT().FindStalePendingVisits(now, now.Add(-24*time.Hour), now.Add(-15*time.Minute)).Return([]model.Visit{visit}, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+				assert.Equal(t, model.VisitStatusPending, change.From)
+				assert.Equal(t, model.VisitStatusExpired, change.To)
+				assert.Equal(t, "SYSTEM", change.ActorRole)
+				updated := visit
+				updated.Status = change.To
+				return updated, nil
+			})
+		visitRepo.EXPECT().ReleaseVisitSlot(visit.ID.Hex()).Return(nil)
+
+		assert.Equal(t, 1, s.Sweep(now))
+		assert.Equal(t, []float64{150}, refunder.refunded)
+		assert.Equal(t, []float64{150}, sent)
+	})
+
+	t.Run("Sucesso_Nao_Expira_Imediata_Dentro_Da_Janela", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		refunder := &fakeRefunder{}
+		var sent []float64
+		s := newTestSweeper(visitRepo, refunder, &sent)
+
+		// visitas imediatas são criadas com visit_date no horário do pedido, que já passou
+		visit := model.Visit{
+			ID: primitive.NewObjectID(), Status: model.VisitStatusPending, PatientId: "patient-1",
+			VisitValue: 150, PaymentIntentID: "pi_123", VisitRequestType: "IMMEDIATE",
+			VisitDate: now, CreatedAt: now.Add(-3 * time.Minute),
+		}
+
+		visitRepo.EXPECT().FindStalePendingVisits(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Visit{visit}, nil)
+
+		assert.Equal(t, 0, s.Sweep(now))
+		assert.Empty(t, refunder.refunded)
+		assert.Empty(t, sent)
+	})
+
+	t.Run("Sucesso_Ignora_Visita_Respondida_Durante_Varredura", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		refunder := &fakeRefunder{}
+		var sent []float64
+		s := newTestSweeper(visitRepo, refunder, &sent)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, PaymentIntentID: "pi_123", VisitDate: now.Add(-time.Hour)}
+
+		visitRepo.EXPECT().FindStalePendingVisits(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Visit{visit}, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), gomock.Any()).Return(model.Visit{}, repository.ErrVisitStatusChanged)
+
+		assert.Equal(t, 0, s.Sweep(now))
+		assert.Empty(t, refunder.refunded)
+		assert.Empty(t, sent)
+	})
+
+	t.Run("Sucesso_Expira_Mesmo_Se_Estorno_Falhar", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		var sent []float64
+		s := newTestSweeper(visitRepo, &fakeRefunder{err: errors.New("stripe indisponível")}, &sent)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, PaymentIntentID: "pi_123", VisitDate: now.Add(-time.Hour)}
+
+		visitRepo.EXPECT().FindStalePendingVisits(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Visit{visit}, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), gomock.Any()).Return(model.Visit{Status: model.VisitStatusExpired}, nil)
+		visitRepo.EXPECT().ReleaseVisitSlot(visit.ID.Hex()).Return(nil)
+
+		assert.Equal(t, 1, s.Sweep(now))
+		assert.Equal(t, []float64{0}, sent)
+	})
+
+	t.Run("Erro_Busca_Visitas", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		var sent []float64
+		s := newTestSweeper(visitRepo, &fakeRefunder{}, &sent)
+
+		visitRepo.EXPECT().FindStalePendingVisits(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("mongo indisponível"))
+
+		assert.Equal(t, 0, s.Sweep(now))
+	})
+}
@@ -0,0 +1,64 @@
+package lifecycle
+
+import (
+	"fmt"
+	"math"
+	"medassist/internal/model"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CancellationPolicy define quanto o paciente recebe de volta ao cancelar uma visita:
+// reembolso integral até FreeUntil antes do horário marcado e, depois disso, desconto
+// de LateFeePercent do valor da visita.
+type CancellationPolicy struct {
+	FreeUntil      time.Duration
+	LateFeePercent float64
+}
+
+// CancellationQuote é o resultado da aplicação da política a uma visita.
+type CancellationQuote struct {
+	RefundAmount float64
+	Fee          float64
+}
+
+const (
+	defaultCancellationFreeHours      = 24
+	defaultCancellationLateFeePercent = 50
+)
+
+// LoadCancellationPolicy lê a política de CANCELLATION_FREE_HOURS e CANCELLATION_LATE_FEE_PERCENT,
+// usando 24 horas e 50% quando as variáveis não estão definidas ou são inválidas.
+func LoadCancellationPolicy() CancellationPolicy {
+	policy := CancellationPolicy{
+		FreeUntil:      defaultCancellationFreeHours * time.Hour,
+		LateFeePercent: defaultCancellationLateFeePercent,
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("CANCELLATION_FREE_HOURS")); err == nil && hours >= 0 {
+		policy.FreeUntil = time.Duration(hours) * time.Hour
+	}
+	if percent, err := strconv.ParseFloat(os.Getenv("CANCELLATION_LATE_FEE_PERCENT"), 64); err == nil && percent >= 0 && percent <= 100 {
+		policy.LateFeePercent = percent
+	}
+
+	return policy
+}
+
+// Quote calcula o reembolso do cancelamento feito pelo paciente em now. Visitas ainda não
+// aceitas pelo enfermeiro são sempre reembolsadas por completo. A taxa é calculada sobre o
+// valor da visita, mas o reembolso nunca passa do que o paciente pagou (visitas com cupom).
+func (p CancellationPolicy) Quote(visit model.Visit, now time.Time) (CancellationQuote, error) {
+	if !now.Before(visit.VisitDate) {
+		return CancellationQuote{}, fmt.Errorf("Não é possível cancelar uma visita cujo horário já passou.")
+	}
+
+	paid := float64(visit.PaidInCents()) / 100
+	if visit.Status == model.VisitStatusPending || visit.VisitDate.Sub(now) >= p.FreeUntil {
+		return CancellationQuote{RefundAmount: paid}, nil
+	}
+
+	fee := math.Round(visit.VisitValue*p.LateFeePercent) / 100
+	return CancellationQuote{RefundAmount: math.Max(0, math.Min(visit.VisitValue-fee, paid)), Fee: fee}, nil
+}
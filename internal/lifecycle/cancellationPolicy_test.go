@@ -0,0 +1,77 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancellationPolicy_Quote(t *testing.T) {
+	policy := CancellationPolicy{FreeUntil: 24 * time.Hour, LateFeePercent: 30}
+	now := time.Now()
+
+	t.Run("Sucesso_Reembolso_Integral_Antes_Do_Prazo", func(t *testing.T) {
+		visit := model.Visit{Status: model.VisitStatusConfirmed, VisitValue: 200, VisitDate: now.Add(48 * time.Hour)}
+
+		quote, err := policy.Quote(visit, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200.0, quote.RefundAmount)
+		assert.Equal(t, 0.0, quote.Fee)
+	})
+
+	t.Run("Sucesso_Taxa_Apos_O_Prazo", func(t *testing.T) {
+		visit := model.Visit{Status: model.VisitStatusConfirmed, VisitValue: 200, VisitDate: now.Add(2 * time.Hour)}
+
+		quote, err := policy.Quote(visit, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 140.0, quote.RefundAmount)
+		assert.Equal(t, 60.0, quote.Fee)
+	})
+
+	t.Run("Sucesso_Pendente_Sempre_Reembolsa_Tudo", func(t *testing.T) {
+		visit := model.Visit{Status: model.VisitStatusPending, VisitValue: 200, VisitDate: now.Add(time.Hour)}
+
+		quote, err := policy.Quote(visit, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200.0, quote.RefundAmount)
+	})
+
+	t.Run("Sucesso_Reembolso_Limitado_Ao_Valor_Pago_Com_Cupom", func(t *testing.T) {
+		discount := &model.VisitDiscount{Code: "BEMVINDO", AmountInCents: 8000}
+		early := model.Visit{Status: model.VisitStatusConfirmed, VisitValue: 200, VisitDate: now.Add(48 * time.Hour), Discount: discount}
+		late := model.Visit{Status: model.VisitStatusConfirmed, VisitValue: 200, VisitDate: now.Add(2 * time.Hour), Discount: discount}
+
+		earlyQuote, err := policy.Quote(early, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 120.0, earlyQuote.RefundAmount)
+
+		lateQuote, err := policy.Quote(late, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 60.0, lateQuote.Fee)
+		assert.Equal(t, 120.0, lateQuote.RefundAmount)
+	})
+
+	t.Run("Erro_Visita_Ja_Passou", func(t *testing.T) {
+		visit := model.Visit{Status: model.VisitStatusConfirmed, VisitValue: 200, VisitDate: now.Add(-time.Hour)}
+
+		_, err := policy.Quote(visit, now)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadCancellationPolicy(t *testing.T) {
+	t.Setenv("CANCELLATION_FREE_HOURS", "12")
+	t.Setenv("CANCELLATION_LATE_FEE_PERCENT", "invalido")
+
+	policy := LoadCancellationPolicy()
+
+	assert.Equal(t, 12*time.Hour, policy.FreeUntil)
+	assert.Equal(t, float64(defaultCancellationLateFeePercent), policy.LateFeePercent)
+}
@@ -0,0 +1,164 @@
+package lifecycle
+
+import (
+	"fmt"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/scheduling"
+	"time"
+)
+
+// Escopos de cancelamento de uma série.
+const (
+	SeriesScopeOccurrence = "OCCURRENCE" // apenas a ocorrência informada
+	SeriesScopeFollowing  = "FOLLOWING"  // a ocorrência informada e todas as seguintes
+)
+
+// VisitSeriesManager aplica as transições da máquina de estados às ocorrências de uma série.
+type VisitSeriesManager interface {
+	ConfirmFollowing(visit model.Visit, actor Actor) ([]model.Visit, error)
+	Cancel(seriesId, visitId, scope string, actor Actor, reason string, visitUpdates VisitUpdates) ([]model.Visit, error)
+}
+
+type visitSeriesManager struct {
+	visitRepository       repository.VisitRepository
+	visitSeriesRepository repository.VisitSeriesRepository
+	visitStateMachine     VisitStateMachine
+}
+
+func NewVisitSeriesManager(visitRepository repository.VisitRepository, visitSeriesRepository repository.VisitSeriesRepository, visitStateMachine VisitStateMachine) VisitSeriesManager {
+	return &visitSeriesManager{
+		visitRepository:       visitRepository,
+		visitSeriesRepository: visitSeriesRepository,
+		visitStateMachine:     visitStateMachine,
+	}
+}
+
+// ConfirmFollowing confirma a ocorrência informada e todas as ocorrências pendentes seguintes da série.
+func (m *visitSeriesManager) ConfirmFollowing(visit model.Visit, actor Actor) ([]model.Visit, error) {
+	if visit.SeriesId == "" {
+		return nil, fmt.Errorf("Essa visita não faz parte de uma série.")
+	}
+
+	visits, err := m.visitRepository.FindVisitsBySeriesId(visit.SeriesId)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar as visitas da série: %w", err)
+	}
+
+	pending := make([]model.Visit, 0, len(visits))
+	for _, occurrence := range visits {
+		if occurrence.Status == model.VisitStatusPending && !occurrence.VisitDate.Before(visit.VisitDate) {
+			pending = append(pending, occurrence)
+		}
+	}
+
+	return m.visitStateMachine.TransitionMany(pending, model.VisitStatusConfirmed, actor, "", map[string]interface{}{"cancel_reason": ""})
+}
+
+// VisitUpdates calcula os campos gravados junto com o cancelamento de cada ocorrência
+// (ex: taxa e reembolso da política de cancelamento do paciente).
+type VisitUpdates func(visit model.Visit) (map[string]interface{}, error)
+
+// Cancel cancela uma ocorrência ou a ocorrência e as seguintes. Sem visitId, o escopo
+// FOLLOWING cancela tudo o que ainda não aconteceu. Com visitUpdates nil as ocorrências são
+// reembolsadas por completo. A série é encerrada quando não sobra nenhuma ocorrência ativa.
+func (m *visitSeriesManager) Cancel(seriesId, visitId, scope string, actor Actor, reason string, visitUpdates VisitUpdates) ([]model.Visit, error) {
+	series, err := m.visitSeriesRepository.FindSeriesById(seriesId)
+	if err != nil {
+		return nil, err
+	}
+	if (actor.Role == RolePatient && series.PatientId != actor.ID) || (actor.Role == RoleNurse && series.NurseId != actor.ID) {
+		return nil, fmt.Errorf("Essa série de visitas não pertence a você.")
+	}
+
+	visits, err := m.visitRepository.FindVisitsBySeriesId(seriesId)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar as visitas da série: %w", err)
+	}
+
+	from := time.Now()
+	var pivot *model.Visit
+	if visitId != "" {
+		for i := range visits {
+			if visits[i].ID.Hex() == visitId {
+				pivot = &visits[i]
+				break
+			}
+		}
+		if pivot == nil {
+			return nil, fmt.Errorf("A visita informada não faz parte desta série.")
+		}
+		from = pivot.VisitDate
+	}
+
+	var targets []model.Visit
+	switch scope {
+	case SeriesScopeOccurrence:
+		if pivot == nil {
+			return nil, fmt.Errorf("Informe a visita a ser cancelada.")
+		}
+		if err := Validate(*pivot, model.VisitStatusCanceled, actor); err != nil {
+			return nil, err
+		}
+		targets = []model.Visit{*pivot}
+	case SeriesScopeFollowing:
+		for _, occurrence := range visits {
+			if !occurrence.VisitDate.Before(from) && CanTransition(occurrence.Status, model.VisitStatusCanceled, actor.Role) {
+				targets = append(targets, occurrence)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Escopo de cancelamento inválido: %s", scope)
+	}
+
+	// os campos de todas as ocorrências são calculados antes de gravar, para que um erro
+	// não deixe a série cancelada pela metade
+	updates := make([]map[string]interface{}, len(targets))
+	for i, occurrence := range targets {
+		updates[i] = map[string]interface{}{"cancel_reason": reason}
+		if visitUpdates == nil {
+			continue
+		}
+		extra, err := visitUpdates(occurrence)
+		if err != nil {
+			return nil, fmt.Errorf("Visita de %s: %w", occurrence.VisitDate.In(scheduling.Location()).Format("02/01/2006 15:04"), err)
+		}
+		for field, value := range extra {
+			updates[i][field] = value
+		}
+	}
+
+	canceled := make([]model.Visit, 0, len(targets))
+	for i, occurrence := range targets {
+		updated, err := m.visitStateMachine.Transition(occurrence, model.VisitStatusCanceled, actor, reason, updates[i])
+		if err != nil {
+			return canceled, err
+		}
+		canceled = append(canceled, updated)
+	}
+
+	if !hasActiveOccurrence(visits, canceled) {
+		if err := m.visitSeriesRepository.UpdateSeriesStatus(seriesId, model.VisitSeriesStatusCanceled); err != nil {
+			return canceled, fmt.Errorf("Erro ao encerrar a série: %w", err)
+		}
+	}
+
+	return canceled, nil
+}
+
+func hasActiveOccurrence(visits, canceled []model.Visit) bool {
+	canceledIds := make(map[string]bool, len(canceled))
+	for _, visit := range canceled {
+		canceledIds[visit.ID.Hex()] = true
+	}
+
+	for _, visit := range visits {
+		if canceledIds[visit.ID.Hex()] {
+			continue
+		}
+		if visit.Status == model.VisitStatusPending || visit.Status == model.VisitStatusConfirmed {
+			return true
+		}
+	}
+	return false
+}
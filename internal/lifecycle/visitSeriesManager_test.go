@@ -0,0 +1,197 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func newSeriesVisits(seriesId string, statuses ...model.VisitStatus) []model.Visit {
+	first := time.Now().Add(24 * time.Hour)
+	visits := make([]model.Visit, 0, len(statuses))
+	for i, status := range statuses {
+		visits = append(visits, model.Visit{
+			ID:        primitive.NewObjectID(),
+			Status:    status,
+			NurseId:   "nurse-1",
+			PatientId: "patient-1",
+			SeriesId:  seriesId,
+			VisitDate: first.AddDate(0, 0, 7*i),
+		})
+	}
+	return visits
+}
+
+func TestVisitSeriesManager_ConfirmFollowing(t *testing.T) {
+	t.Run("Sucesso_Confirma_Ocorrencias_Pendentes_Seguintes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		seriesRepo := repmocks.NewMockVisitSeriesRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, seriesRepo, NewVisitStateMachine(visitRepo, nil))
+
+		visits := newSeriesVisits("series-1", model.VisitStatusPending, model.VisitStatusPending, model.VisitStatusRejected, model.VisitStatusPending)
+
+		visitRepo.EXPECT().FindVisitsBySeriesId("series-1").Return(visits, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+			func(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+				assert.NotEqual(t, visits[0].ID.Hex(), id)
+				return model.Visit{Status: change.To}, nil
+			})
+
+		confirmed, err := manager.ConfirmFollowing(visits[1], Actor{ID: "nurse-1", Role: RoleNurse})
+
+		assert.NoError(t, err)
+		assert.Len(t, confirmed, 2)
+	})
+
+	t.Run("Erro_Visita_Sem_Serie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, repmocks.NewMockVisitSeriesRepository(ctrl), NewVisitStateMachine(visitRepo, nil))
+
+		_, err := manager.ConfirmFollowing(model.Visit{Status: model.VisitStatusPending}, Actor{ID: "nurse-1", Role: RoleNurse})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestVisitSeriesManager_Cancel(t *testing.T) {
+	t.Run("Erro_Serie_De_Outro_Paciente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		seriesRepo := repmocks.NewMockVisitSeriesRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, seriesRepo, NewVisitStateMachine(visitRepo, nil))
+
+		seriesRepo.EXPECT().FindSeriesById("series-1").Return(model.VisitSeries{PatientId: "patient-1", NurseId: "nurse-1"}, nil)
+
+		_, err := manager.Cancel("series-1", "", SeriesScopeFollowing, Actor{ID: "patient-2", Role: RolePatient}, "", nil)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Sucesso_Cancela_Uma_Ocorrencia_Mantem_Serie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		seriesRepo := repmocks.NewMockVisitSeriesRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, seriesRepo, NewVisitStateMachine(visitRepo, nil))
+
+		visits := newSeriesVisits("series-1", model.VisitStatusConfirmed, model.VisitStatusConfirmed)
+
+		seriesRepo.EXPECT().FindSeriesById("series-1").Return(model.VisitSeries{PatientId: "patient-1", NurseId: "nurse-1"}, nil)
+		visitRepo.EXPECT().FindVisitsBySeriesId("series-1").Return(visits, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visits[0].ID.Hex(), gomock.Any(), gomock.Any()).Return(visits[0], nil)
+		visitRepo.EXPECT().ReleaseVisitSlot(visits[0].ID.Hex()).Return(nil)
+
+		canceled, err := manager.Cancel("series-1", visits[0].ID.Hex(), SeriesScopeOccurrence, Actor{ID: "patient-1", Role: RolePatient}, "Viagem", nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, canceled, 1)
+	})
+
+	t.Run("Sucesso_Cancela_Seguintes_Encerra_Serie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		seriesRepo := repmocks.NewMockVisitSeriesRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, seriesRepo, NewVisitStateMachine(visitRepo, nil))
+
+		visits := newSeriesVisits("series-1", model.VisitStatusCompleted, model.VisitStatusConfirmed, model.VisitStatusPending)
+
+		seriesRepo.EXPECT().FindSeriesById("series-1").Return(model.VisitSeries{PatientId: "patient-1", NurseId: "nurse-1"}, nil)
+		visitRepo.EXPECT().FindVisitsBySeriesId("series-1").Return(visits, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+			func(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+				for _, visit := range visits {
+					if visit.ID.Hex() == id {
+						visit.Status = change.To
+						return visit, nil
+					}
+				}
+				return model.Visit{}, nil
+			})
+		visitRepo.EXPECT().ReleaseVisitSlot(gomock.Any()).Times(2).Return(nil)
+		seriesRepo.EXPECT().UpdateSeriesStatus("series-1", model.VisitSeriesStatusCanceled).Return(nil)
+
+		canceled, err := manager.Cancel("series-1", visits[1].ID.Hex(), SeriesScopeFollowing, Actor{ID: "nurse-1", Role: RoleNurse}, "Mudança de cidade", nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, canceled, 2)
+	})
+
+	t.Run("Sucesso_Aplica_Politica_Por_Ocorrencia", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		seriesRepo := repmocks.NewMockVisitSeriesRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, seriesRepo, NewVisitStateMachine(visitRepo, nil))
+		policy := CancellationPolicy{FreeUntil: 48 * time.Hour, LateFeePercent: 50}
+
+		// a primeira ocorrência é amanhã (com taxa), a segunda daqui a 8 dias (sem taxa)
+		visits := newSeriesVisits("series-1", model.VisitStatusConfirmed, model.VisitStatusConfirmed)
+		for i := range visits {
+			visits[i].VisitValue = 200
+		}
+
+		seriesRepo.EXPECT().FindSeriesById("series-1").Return(model.VisitSeries{PatientId: "patient-1", NurseId: "nurse-1"}, nil)
+		visitRepo.EXPECT().FindVisitsBySeriesId("series-1").Return(visits, nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visits[0].ID.Hex(), gomock.Any(), map[string]interface{}{
+			"cancel_reason": "Viagem", "cancellation_fee": 100.0, "refund_amount": 100.0,
+		}).Return(visits[0], nil)
+		visitRepo.EXPECT().UpdateVisitStatus(visits[1].ID.Hex(), gomock.Any(), map[string]interface{}{
+			"cancel_reason": "Viagem", "cancellation_fee": 0.0, "refund_amount": 200.0,
+		}).Return(visits[1], nil)
+		visitRepo.EXPECT().ReleaseVisitSlot(gomock.Any()).Times(2).Return(nil)
+		seriesRepo.EXPECT().UpdateSeriesStatus("series-1", model.VisitSeriesStatusCanceled).Return(nil)
+
+		canceled, err := manager.Cancel("series-1", "", SeriesScopeFollowing, Actor{ID: "patient-1", Role: RolePatient}, "Viagem",
+			func(visit model.Visit) (map[string]interface{}, error) {
+				quote, err := policy.Quote(visit, time.Now())
+				return map[string]interface{}{"cancellation_fee": quote.Fee, "refund_amount": quote.RefundAmount}, err
+			})
+
+		assert.NoError(t, err)
+		assert.Len(t, canceled, 2)
+	})
+
+	t.Run("Erro_Politica_Nao_Cancela_Nenhuma_Ocorrencia", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		seriesRepo := repmocks.NewMockVisitSeriesRepository(ctrl)
+		manager := NewVisitSeriesManager(visitRepo, seriesRepo, NewVisitStateMachine(visitRepo, nil))
+
+		visits := newSeriesVisits("series-1", model.VisitStatusConfirmed, model.VisitStatusConfirmed)
+
+		seriesRepo.EXPECT().FindSeriesById("series-1").Return(model.VisitSeries{PatientId: "patient-1", NurseId: "nurse-1"}, nil)
+		visitRepo.EXPECT().FindVisitsBySeriesId("series-1").Return(visits, nil)
+
+		canceled, err := manager.Cancel("series-1", "", SeriesScopeFollowing, Actor{ID: "patient-1", Role: RolePatient}, "",
+			func(visit model.Visit) (map[string]interface{}, error) {
+				if visit.ID == visits[1].ID {
+					return nil, errors.New("horário já passou")
+				}
+				return nil, nil
+			})
+
+		assert.Error(t, err)
+		assert.Empty(t, canceled)
+	})
+}
@@ -0,0 +1,231 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/scheduling"
+	"net/http"
+	"time"
+)
+
+const (
+	RolePatient = "PATIENT"
+	RoleNurse   = "NURSE"
+	RoleAdmin   = "ADMIN"
+	// RoleSystem identifica mudanças feitas pelo próprio backend (ex: rotinas em segundo plano).
+	RoleSystem = "SYSTEM"
+)
+
+// ErrInvalidTransition é o erro base de toda transição de status proibida.
+// Use errors.Is(err, ErrInvalidTransition) para identificá-lo.
+var ErrInvalidTransition = errors.New("transição de status de visita inválida")
+
+// TransitionError descreve uma transição recusada pela máquina de estados.
+type TransitionError struct {
+	From model.VisitStatus
+	To   model.VisitStatus
+	Role string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("Não é possível alterar a visita de %s para %s.", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Actor identifica quem está solicitando a mudança de status.
+type Actor struct {
+	ID   string
+	Role string
+}
+
+// guard é uma condição extra que precisa ser satisfeita para a transição acontecer.
+type guard func(visit model.Visit, actor Actor) error
+
+type transition struct {
+	roles []string
+	guard guard
+}
+
+// transitions define, para cada status de origem, os destinos possíveis e quais papéis podem executá-los.
+var transitions = map[model.VisitStatus]map[model.VisitStatus]transition{
+	model.VisitStatusPending: {
+		model.VisitStatusConfirmed: {roles: []string{RoleNurse, RoleAdmin}, guard: ownedByActor},
+		model.VisitStatusRejected:  {roles: []string{RoleNurse, RoleAdmin}, guard: ownedByActor},
+		model.VisitStatusCanceled:  {roles: []string{RolePatient, RoleNurse, RoleAdmin, RoleSystem}, guard: ownedByActor},
+		model.VisitStatusExpired:   {roles: []string{RoleSystem}},
+	},
+	model.VisitStatusConfirmed: {
+		model.VisitStatusCanceled: {roles: []string{RolePatient, RoleNurse, RoleAdmin}, guard: ownedByActor},
+		// só o enfermeiro conclui: a conclusão captura o pagamento retido e faz o repasse
+		model.VisitStatusCompleted: {roles: []string{RoleNurse}, guard: ownedByActor},
+	},
+}
+
+func ownedByActor(visit model.Visit, actor Actor) error {
+	switch actor.Role {
+	case RoleNurse:
+		if visit.NurseId != actor.ID {
+			return fmt.Errorf("Essa visita é pertencente à outro enfermeiro.")
+		}
+	case RolePatient:
+		if visit.PatientId != actor.ID {
+			return fmt.Errorf("Essa visita é pertencente à outro paciente.")
+		}
+	}
+	return nil
+}
+
+// CanTransition informa se o papel pode levar uma visita do status from para o status to.
+func CanTransition(from, to model.VisitStatus, role string) bool {
+	t, ok := transitions[from][to]
+	if !ok {
+		return false
+	}
+	for _, allowed := range t.roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate verifica a transição e suas condições sem gravar nada.
+func Validate(visit model.Visit, to model.VisitStatus, actor Actor) error {
+	if !CanTransition(visit.Status, to, actor.Role) {
+		return &TransitionError{From: visit.Status, To: to, Role: actor.Role}
+	}
+	if g := transitions[visit.Status][to].guard; g != nil {
+		return g(visit, actor)
+	}
+	return nil
+}
+
+// ErrorStatusCode retorna 409 para transições inválidas e conflitos de agenda e fallback para os demais erros.
+func ErrorStatusCode(err error, fallback int) int {
+	if errors.Is(err, ErrInvalidTransition) ||
+		errors.Is(err, repository.ErrSlotAlreadyBooked) ||
+		errors.Is(err, repository.ErrVisitAlreadyClaimed) ||
+		errors.Is(err, repository.ErrRescheduleNotPending) ||
+		errors.Is(err, scheduling.ErrSlotUnavailable) {
+		return http.StatusConflict
+	}
+	return fallback
+}
+
+type VisitStateMachine interface {
+	Transition(visit model.Visit, to model.VisitStatus, actor Actor, reason string, updates map[string]interface{}) (model.Visit, error)
+	TransitionMany(visits []model.Visit, to model.VisitStatus, actor Actor, reason string, updates map[string]interface{}) ([]model.Visit, error)
+}
+
+// Refunder devolve ao paciente o valor pago por uma visita encerrada sem atendimento e
+// retorna a visita com o estorno registrado.
+type Refunder interface {
+	RefundVisit(visit model.Visit, amount float64) (model.Visit, error)
+}
+
+type visitStateMachine struct {
+	visitRepository repository.VisitRepository
+	refunder        Refunder
+}
+
+// NewVisitStateMachine cria a máquina de estados. Com refunder nil nenhuma transição gera estorno.
+func NewVisitStateMachine(visitRepository repository.VisitRepository, refunder Refunder) VisitStateMachine {
+	return &visitStateMachine{visitRepository: visitRepository, refunder: refunder}
+}
+
+// Transition valida e grava a mudança de status junto com os campos extras em updates,
+// registrando a alteração no status_history da visita.
+func (m *visitStateMachine) Transition(visit model.Visit, to model.VisitStatus, actor Actor, reason string, updates map[string]interface{}) (model.Visit, error) {
+	if err := Validate(visit, to, actor); err != nil {
+		return model.Visit{}, err
+	}
+
+	change := model.VisitStatusChange{
+		From:      visit.Status,
+		To:        to,
+		ActorID:   actor.ID,
+		ActorRole: actor.Role,
+		Reason:    reason,
+		ChangedAt: time.Now(),
+	}
+
+	updated, err := m.visitRepository.UpdateVisitStatus(visit.ID.Hex(), change, updates)
+	if err != nil {
+		if errors.Is(err, repository.ErrVisitStatusChanged) {
+			return model.Visit{}, &TransitionError{From: visit.Status, To: to, Role: actor.Role}
+		}
+		return model.Visit{}, fmt.Errorf("Erro ao atualizar status da visita: %w", err)
+	}
+
+	if !OccupiesSchedule(to) {
+		if err := m.visitRepository.ReleaseVisitSlot(visit.ID.Hex()); err != nil {
+			log.Printf("Erro ao liberar horário da visita %s: %v", visit.ID.Hex(), err)
+		}
+	}
+
+	if RefundsPayment(to) && m.refunder != nil {
+		refunded, err := m.refunder.RefundVisit(updated, refundAmount(updated, updates))
+		if err != nil {
+			// a transição já foi gravada; a visita fica sem refund_id e o estorno pode ser refeito
+			log.Printf("Erro ao estornar pagamento da visita %s: %v", visit.ID.Hex(), err)
+		} else {
+			updated = refunded
+		}
+	}
+
+	return updated, nil
+}
+
+// RefundsPayment informa se a visita que chega ao status informado deve ter o pagamento
+// devolvido ao paciente: todos os encerramentos sem atendimento.
+func RefundsPayment(status model.VisitStatus) bool {
+	switch status {
+	case model.VisitStatusRejected, model.VisitStatusCanceled, model.VisitStatusExpired:
+		return true
+	}
+	return false
+}
+
+// refundAmount usa o refund_amount calculado por quem pediu a transição (ex: política de
+// cancelamento do paciente) ou, sem ele, o valor integral da visita.
+func refundAmount(visit model.Visit, updates map[string]interface{}) float64 {
+	if amount, ok := updates["refund_amount"].(float64); ok {
+		return amount
+	}
+	return visit.VisitValue
+}
+
+// OccupiesSchedule informa se uma visita no status informado ainda ocupa a agenda do enfermeiro.
+func OccupiesSchedule(status model.VisitStatus) bool {
+	switch status {
+	case model.VisitStatusPending, model.VisitStatusConfirmed, model.VisitStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// TransitionMany aplica a mesma transição a várias visitas (ex: ocorrências de uma série),
+// ignorando as que já não admitem a transição. Para no primeiro erro de gravação e
+// retorna as visitas alteradas até ali.
+func (m *visitStateMachine) TransitionMany(visits []model.Visit, to model.VisitStatus, actor Actor, reason string, updates map[string]interface{}) ([]model.Visit, error) {
+	changed := make([]model.Visit, 0, len(visits))
+	for _, visit := range visits {
+		if !CanTransition(visit.Status, to, actor.Role) {
+			continue
+		}
+
+		updated, err := m.Transition(visit, to, actor, reason, updates)
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, updated)
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,154 @@
+package lifecycle
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(model.VisitStatusPending, model.VisitStatusConfirmed, RoleNurse))
+	assert.True(t, CanTransition(model.VisitStatusConfirmed, model.VisitStatusCompleted, RoleNurse))
+	assert.False(t, CanTransition(model.VisitStatusConfirmed, model.VisitStatusCompleted, RolePatient))
+	assert.False(t, CanTransition(model.VisitStatusConfirmed, model.VisitStatusCompleted, RoleAdmin))
+	assert.False(t, CanTransition(model.VisitStatusPending, model.VisitStatusConfirmed, RolePatient))
+	assert.False(t, CanTransition(model.VisitStatusRejected, model.VisitStatusConfirmed, RoleNurse))
+	assert.False(t, CanTransition(model.VisitStatusCompleted, model.VisitStatusPending, RoleAdmin))
+}
+
+func TestVisitStateMachine_Transition(t *testing.T) {
+	t.Run("Erro_Rejeitada_Nao_Volta_Para_Confirmada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		machine := NewVisitStateMachine(visitRepo, nil)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusRejected, NurseId: "nurse-1"}
+
+		_, err := machine.Transition(visit, model.VisitStatusConfirmed, Actor{ID: "nurse-1", Role: RoleNurse}, "", nil)
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.Equal(t, http.StatusConflict, ErrorStatusCode(err, http.StatusBadRequest))
+	})
+
+	t.Run("Erro_Visita_De_Outro_Enfermeiro", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		machine := NewVisitStateMachine(visitRepo, nil)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, NurseId: "nurse-1"}
+
+		_, err := machine.Transition(visit, model.VisitStatusRejected, Actor{ID: "nurse-2", Role: RoleNurse}, "", nil)
+
+		assert.EqualError(t, err, "Essa visita é pertencente à outro enfermeiro.")
+		assert.Equal(t, http.StatusBadRequest, ErrorStatusCode(err, http.StatusBadRequest))
+	})
+
+	t.Run("Erro_Status_Alterado_Concorrentemente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		machine := NewVisitStateMachine(visitRepo, nil)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, NurseId: "nurse-1"}
+
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), gomock.Any()).Return(model.Visit{}, repository.ErrVisitStatusChanged)
+
+		_, err := machine.Transition(visit, model.VisitStatusConfirmed, Actor{ID: "nurse-1", Role: RoleNurse}, "", nil)
+
+		var transitionErr *TransitionError
+		assert.True(t, errors.As(err, &transitionErr))
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+	})
+
+	t.Run("Sucesso_Registra_Historico", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		machine := NewVisitStateMachine(visitRepo, nil)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusConfirmed, NurseId: "nurse-1"}
+		updates := map[string]interface{}{"cancel_reason": "Imprevisto"}
+
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), updates).DoAndReturn(
+			func(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+				assert.Equal(t, model.VisitStatusConfirmed, change.From)
+				assert.Equal(t, model.VisitStatusCanceled, change.To)
+				assert.Equal(t, "nurse-1", change.ActorID)
+				assert.Equal(t, RoleNurse, change.ActorRole)
+				assert.Equal(t, "Imprevisto", change.Reason)
+				return model.Visit{ID: visit.ID, Status: change.To, StatusHistory: []model.VisitStatusChange{change}}, nil
+			})
+		// Visita cancelada deixa de ocupar a agenda do enfermeiro
+		visitRepo.EXPECT().ReleaseVisitSlot(visit.ID.Hex()).Return(nil)
+
+		updated, err := machine.Transition(visit, model.VisitStatusCanceled, Actor{ID: "nurse-1", Role: RoleNurse}, "Imprevisto", updates)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.VisitStatusCanceled, updated.Status)
+		assert.Len(t, updated.StatusHistory, 1)
+	})
+	t.Run("Sucesso_Estorna_Visita_Rejeitada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		refunder := &fakeRefunder{}
+		machine := NewVisitStateMachine(visitRepo, refunder)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, NurseId: "nurse-1", VisitValue: 150, PaymentIntentID: "pi_123"}
+
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), gomock.Any()).Return(model.Visit{ID: visit.ID, Status: model.VisitStatusRejected, VisitValue: 150}, nil)
+		visitRepo.EXPECT().ReleaseVisitSlot(visit.ID.Hex()).Return(nil)
+
+		updated, err := machine.Transition(visit, model.VisitStatusRejected, Actor{ID: "nurse-1", Role: RoleNurse}, "", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{150}, refunder.amounts)
+		assert.Equal(t, 150.0, updated.RefundAmount)
+	})
+
+	t.Run("Sucesso_Estorno_Usa_Valor_Informado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		refunder := &fakeRefunder{}
+		machine := NewVisitStateMachine(visitRepo, refunder)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusConfirmed, PatientId: "patient-1", VisitValue: 150}
+		updates := map[string]interface{}{"refund_amount": 75.0}
+
+		visitRepo.EXPECT().UpdateVisitStatus(visit.ID.Hex(), gomock.Any(), updates).Return(model.Visit{ID: visit.ID, Status: model.VisitStatusCanceled, VisitValue: 150}, nil)
+		visitRepo.EXPECT().ReleaseVisitSlot(visit.ID.Hex()).Return(nil)
+
+		_, err := machine.Transition(visit, model.VisitStatusCanceled, Actor{ID: "patient-1", Role: RolePatient}, "", updates)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{75}, refunder.amounts)
+	})
+}
+
+// fakeRefunder registra os valores estornados.
+type fakeRefunder struct {
+	amounts []float64
+}
+
+func (f *fakeRefunder) RefundVisit(visit model.Visit, amount float64) (model.Visit, error) {
+	f.amounts = append(f.amounts, amount)
+	visit.RefundAmount = amount
+	return visit, nil
+}
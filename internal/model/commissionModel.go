@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Escopos das regras de comissão, da menos para a mais específica. Quando mais de uma regra
+// vale para a visita, a de escopo mais específico é aplicada.
+const (
+	CommissionScopeDefault        = "DEFAULT"        // taxa padrão da plataforma
+	CommissionScopeSpecialization = "SPECIALIZATION" // enfermeiros de uma especialização
+	CommissionScopeVisitType      = "VISIT_TYPE"     // um tipo de visita
+	CommissionScopeCampaign       = "CAMPAIGN"       // campanha com início e fim definidos
+	CommissionScopeNurse          = "NURSE"          // um enfermeiro (ex: 0% no primeiro mês)
+)
+
+// CommissionRule é uma regra de comissão cadastrada pelos administradores (coleção
+// "commission_rules"). Os critérios vazios valem para qualquer visita; StartsAt e EndsAt
+// limitam o período em que a regra vale.
+type CommissionRule struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name           string             `bson:"name" json:"name"`
+	Scope          string             `bson:"scope" json:"scope"`
+	RatePercent    float64            `bson:"rate_percent" json:"rate_percent"`
+	Specialization string             `bson:"specialization,omitempty" json:"specialization,omitempty"`
+	VisitType      string             `bson:"visit_type,omitempty" json:"visit_type,omitempty"`
+	NurseID        string             `bson:"nurse_id,omitempty" json:"nurse_id,omitempty"`
+	StartsAt       *time.Time         `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt         *time.Time         `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	Active         bool               `bson:"active" json:"active"`
+	CreatedBy      string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// VisitCommission é a comissão aplicada no repasse da visita, gravada nela para que o valor
+// pago ao enfermeiro continue explicável mesmo depois de a regra mudar ou ser removida.
+type VisitCommission struct {
+	RuleID             string    `bson:"rule_id,omitempty" json:"rule_id,omitempty"`
+	RuleName           string    `bson:"rule_name" json:"rule_name"`
+	Scope              string    `bson:"scope" json:"scope"`
+	RatePercent        float64   `bson:"rate_percent" json:"rate_percent"`
+	AmountInCents      int64     `bson:"amount_in_cents" json:"amount_in_cents"`
+	NurseAmountInCents int64     `bson:"nurse_amount_in_cents" json:"nurse_amount_in_cents"`
+	AppliedAt          time.Time `bson:"applied_at" json:"applied_at"`
+}
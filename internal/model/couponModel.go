@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de desconto dos cupons.
+const (
+	CouponDiscountPercent = "PERCENT" // DiscountValue é uma porcentagem do valor da visita
+	CouponDiscountFixed   = "FIXED"   // DiscountValue é um valor em reais
+)
+
+// Coupon é um código promocional cadastrado pelos administradores (coleção "coupons"). O
+// desconto é absorvido pela plataforma: o paciente paga menos e o repasse ao enfermeiro
+// continua calculado sobre o valor cheio da visita. Limites zerados e listas vazias não restringem.
+type Coupon struct {
+	ID                       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code                     string             `bson:"code" json:"code"` // sempre em maiúsculas
+	Description              string             `bson:"description,omitempty" json:"description,omitempty"`
+	DiscountType             string             `bson:"discount_type" json:"discount_type"`
+	DiscountValue            float64            `bson:"discount_value" json:"discount_value"`
+	StartsAt                 *time.Time         `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt                   *time.Time         `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	MaxRedemptions           int                `bson:"max_redemptions" json:"max_redemptions"`
+	MaxRedemptionsPerPatient int                `bson:"max_redemptions_per_patient" json:"max_redemptions_per_patient"`
+	Specializations          []string           `bson:"specializations,omitempty" json:"specializations,omitempty"`
+	Cities                   []string           `bson:"cities,omitempty" json:"cities,omitempty"`
+	Active                   bool               `bson:"active" json:"active"`
+	TimesRedeemed            int                `bson:"times_redeemed" json:"times_redeemed"`
+	CreatedBy                string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt                time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt                time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CouponRedemption registra o uso de um cupom em um pagamento (coleção "coupon_redemptions").
+// Cada PaymentIntent resgata no máximo um cupom, uma única vez.
+type CouponRedemption struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CouponID        string             `bson:"coupon_id" json:"coupon_id"`
+	Code            string             `bson:"code" json:"code"`
+	PatientID       string             `bson:"patient_id" json:"patient_id"`
+	PaymentIntentID string             `bson:"payment_intent_id" json:"payment_intent_id"`
+	DiscountInCents int64              `bson:"discount_in_cents" json:"discount_in_cents"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// VisitDiscount é a parte do desconto de um cupom que coube à visita. Em séries, o desconto do
+// pagamento é dividido entre as ocorrências proporcionalmente ao valor de cada uma.
+type VisitDiscount struct {
+	CouponID      string `bson:"coupon_id" json:"coupon_id"`
+	Code          string `bson:"code" json:"code"`
+	AmountInCents int64  `bson:"amount_in_cents" json:"amount_in_cents"`
+}
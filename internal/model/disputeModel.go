@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Situação da contestação, com os mesmos valores usados pelo Stripe.
+const (
+	DisputeStatusWarningNeedsResponse = "warning_needs_response" // consulta prévia do banco, ainda sem retenção do valor
+	DisputeStatusWarningUnderReview   = "warning_under_review"
+	DisputeStatusWarningClosed        = "warning_closed"
+	DisputeStatusNeedsResponse        = "needs_response" // aguardando as evidências da plataforma
+	DisputeStatusUnderReview          = "under_review"   // evidências enviadas, aguardando o banco
+	DisputeStatusWon                  = "won"
+	DisputeStatusLost                 = "lost"
+)
+
+// Dispute é a contestação (chargeback) de um pagamento de visitas feita pelo paciente junto ao
+// banco (coleção "disputes"). Ela é criada pelos webhooks charge.dispute.* e ligada às visitas
+// pelo PaymentIntent; as evidências são montadas automaticamente e revisadas pelos administradores.
+type Dispute struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StripeDisputeID string             `bson:"stripe_dispute_id" json:"stripe_dispute_id"`
+	ChargeID        string             `bson:"charge_id" json:"charge_id"`
+	PaymentIntentID string             `bson:"payment_intent_id" json:"payment_intent_id"`
+	VisitIDs        []string           `bson:"visit_ids" json:"visit_ids"`
+	PatientID       string             `bson:"patient_id,omitempty" json:"patient_id,omitempty"`
+	NurseID         string             `bson:"nurse_id,omitempty" json:"nurse_id,omitempty"`
+	AmountInCents   int64              `bson:"amount_in_cents" json:"amount_in_cents"`
+	Currency        string             `bson:"currency" json:"currency"`
+	Reason          string             `bson:"reason" json:"reason"`
+	Status          string             `bson:"status" json:"status"`
+	EvidenceDueBy   *time.Time         `bson:"evidence_due_by,omitempty" json:"evidence_due_by,omitempty"`
+
+	Evidence            DisputeEvidence `bson:"evidence" json:"evidence"`
+	EvidenceSubmittedAt *time.Time      `bson:"evidence_submitted_at,omitempty" json:"evidence_submitted_at,omitempty"`
+	EvidenceSubmittedBy string          `bson:"evidence_submitted_by,omitempty" json:"evidence_submitted_by,omitempty"`
+
+	TransferReversals []DisputeTransferReversal `bson:"transfer_reversals,omitempty" json:"transfer_reversals"`
+
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `bson:"updated_at" json:"updated_at"`
+	ClosedAt  *time.Time `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+}
+
+// Open informa se a contestação ainda não foi decidida pelo banco.
+func (d Dispute) Open() bool {
+	switch d.Status {
+	case DisputeStatusWon, DisputeStatusLost, DisputeStatusWarningClosed:
+		return false
+	}
+	return true
+}
+
+// DisputeEvidence são as provas de que a visita aconteceu, montadas a partir das visitas do
+// pagamento quando a contestação chega. Os textos já estão no formato enviado ao banco.
+type DisputeEvidence struct {
+	CustomerName       string   `bson:"customer_name" json:"customer_name"`
+	CustomerEmail      string   `bson:"customer_email" json:"customer_email"`
+	ServiceDate        string   `bson:"service_date" json:"service_date"`
+	ServiceDescription string   `bson:"service_description" json:"service_description"`
+	ConfirmationLog    string   `bson:"confirmation_log" json:"confirmation_log"` // quando o enfermeiro informou o código de confirmação de cada visita
+	ChatTranscript     string   `bson:"chat_transcript" json:"chat_transcript"`
+	Prescriptions      []string `bson:"prescriptions,omitempty" json:"prescriptions"`
+	Notes              string   `bson:"notes,omitempty" json:"notes,omitempty"` // observações do administrador
+}
+
+// DisputeTransferReversal é a devolução, pelo enfermeiro, do repasse de uma visita cuja
+// contestação foi perdida.
+type DisputeTransferReversal struct {
+	VisitID       string    `bson:"visit_id" json:"visit_id"`
+	TransferID    string    `bson:"transfer_id" json:"transfer_id"`
+	ReversalID    string    `bson:"reversal_id" json:"reversal_id"`
+	AmountInCents int64     `bson:"amount_in_cents" json:"amount_in_cents"`
+	ReversedBy    string    `bson:"reversed_by" json:"reversed_by"`
+	ReversedAt    time.Time `bson:"reversed_at" json:"reversed_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// GeoPoint é um ponto GeoJSON usado nos índices 2dsphere. As coordenadas ficam
+// na ordem do GeoJSON: [longitude, latitude].
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint monta o ponto a partir de latitude e longitude. Retorna nil para
+// coordenadas zeradas, que indicam endereço não geocodificado.
+func NewGeoPoint(latitude, longitude float64) *GeoPoint {
+	if latitude == 0 && longitude == 0 {
+		return nil
+	}
+	return &GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
@@ -22,17 +22,20 @@ type Nurse struct {
 	AvailableNeighborhoods []string           `bson:"available_neighborhoods" json:"available_neighborhoods"`
 	Qualifications         []string           `bson:"qualifications" json:"qualifications"`
 	StripeAccountId        string             `bson:"stripe_account_id" json:"stripe_account_id"`
+	// StripeOnboardingComplete indica que a conta Express pode receber repasses (via webhook account.updated)
+	StripeOnboardingComplete bool `bson:"stripe_onboarding_complete" json:"stripe_onboarding_complete"`
 
-	Address      string  `bson:"address" json:"address" binding:"required,address"`
-	CEP          string  `json:"cep"`
-	Street       string  `bson:"street" json:"street" binding:"required"`
-	Number       string  `bson:"number" json:"number" binding:"required"`
-	Complement   string  `bson:"complement" json:"complement"`
-	Neighborhood string  `bson:"neighborhood" json:"neighborhood" binding:"required"`
-	City         string  `bson:"city" json:"city" binding:"required"`
-	UF           string  `bson:"uf" json:"uf" binding:"required"`
-	Latitude     float64 `bson:"latitude" json:"latitude"`
-	Longitude    float64 `bson:"longitude" json:"longitude"`
+	Address      string    `bson:"address" json:"address" binding:"required,address"`
+	CEP          string    `json:"cep"`
+	Street       string    `bson:"street" json:"street" binding:"required"`
+	Number       string    `bson:"number" json:"number" binding:"required"`
+	Complement   string    `bson:"complement" json:"complement"`
+	Neighborhood string    `bson:"neighborhood" json:"neighborhood" binding:"required"`
+	City         string    `bson:"city" json:"city" binding:"required"`
+	UF           string    `bson:"uf" json:"uf" binding:"required"`
+	Latitude     float64   `bson:"latitude" json:"latitude"`
+	Longitude    float64   `bson:"longitude" json:"longitude"`
+	GeoLocation  *GeoPoint `bson:"geo_location,omitempty" json:"geo_location,omitempty"`
 
 	Coren           string `bson:"coren" json:"coren" binding:"required"` // registro profissional
 	Specialization  string `bson:"specialization" json:"specialization"`  // área (ex: pediatrics, geriatrics, ER)
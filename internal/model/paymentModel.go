@@ -1,13 +1,132 @@
 package model
 
-// PaymentIntentRequest é o que o frontend (Next.js) nos envia.
-// (O frontend lê o valor do sessionStorage)
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentIntentRequest descreve a visita que o paciente vai pagar. O valor não vem do
+// frontend: ele é calculado no servidor a partir do preço do enfermeiro e dos adicionais.
 type PaymentIntentRequest struct {
-    Value float64 `json:"value"`
+	NurseId         string          `json:"nurse_id"`
+	RequestType     string          `json:"request_type" binding:"required"` // SCHEDULED, IMMEDIATE ou BROADCAST
+	VisitDate       time.Time       `json:"date"`                            // obrigatória em visitas agendadas
+	Recurrence      *RecurrenceRule `json:"recurrence,omitempty"`            // para séries de visitas
+	PaymentMethod   string          `json:"payment_method"`                  // card (padrão) ou pix
+	PaymentMethodId string          `json:"payment_method_id"`               // cartão salvo a usar, sem digitar os dados de novo
+	CouponCode      string          `json:"coupon_code"`                     // cupom promocional opcional
 }
 
+// Formas de pagamento aceitas para as visitas.
+const (
+	PaymentMethodCard = "card"
+	PaymentMethodPix  = "pix"
+)
+
 // PaymentIntentResponse é o que nosso backend retorna para o frontend.
 // (O frontend usa isso para inicializar o Stripe Elements)
 type PaymentIntentResponse struct {
-    ClientSecret string `json:"client_secret"`
-}
\ No newline at end of file
+	ClientSecret    string           `json:"client_secret"`
+	PaymentIntentID string           `json:"payment_intent_id"`
+	Amount          float64          `json:"amount"` // valor cobrado, já com o desconto
+	Subtotal        float64          `json:"subtotal"`
+	Discount        float64          `json:"discount"`
+	CouponCode      string           `json:"coupon_code,omitempty"`
+	Visits          int              `json:"visits"`
+	PaymentMethod   string           `json:"payment_method"`
+	RequiresAction  bool             `json:"requires_action"` // pago com cartão salvo: o banco pediu autenticação, concluída pelo frontend com o client secret
+	Pix             *PixInstructions `json:"pix,omitempty"`
+}
+
+// PixInstructions é o que o paciente precisa para pagar com PIX. Depois de ExpiresAt o
+// pagamento é cancelado e um novo precisa ser criado.
+type PixInstructions struct {
+	CopyPasteCode         string    `json:"copy_paste_code"` // código "copia e cola", também usado para gerar o QR code
+	QRCodeImageURL        string    `json:"qr_code_image_url"`
+	HostedInstructionsURL string    `json:"hosted_instructions_url"`
+	ExpiresAt             time.Time `json:"expires_at"`
+}
+
+// SavedPaymentMethod é um cartão salvo no cliente do paciente no Stripe, que pode ser usado
+// para pagar visitas sem digitar os dados de novo.
+type SavedPaymentMethod struct {
+	ID       string `json:"id"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int64  `json:"exp_month"`
+	ExpYear  int64  `json:"exp_year"`
+	Default  bool   `json:"default"`
+}
+
+// TipRequest é o corpo usado pelo paciente para dar gorjeta ao enfermeiro, em reais.
+type TipRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+// Situação da gorjeta de uma visita.
+const (
+	TipStatusProcessing  = "PROCESSING"  // cobrança ou repasse em andamento
+	TipStatusTransferred = "TRANSFERRED" // cobrada do paciente e repassada ao enfermeiro
+)
+
+// VisitTip é a gorjeta dada pelo paciente depois da visita concluída. Ela é cobrada à parte,
+// no cartão salvo do paciente, e repassada sem comissão ao enfermeiro.
+type VisitTip struct {
+	AmountInCents   int64     `bson:"amount_in_cents" json:"amount_in_cents"`
+	Status          string    `bson:"status" json:"status"`
+	PaymentIntentID string    `bson:"payment_intent_id,omitempty" json:"payment_intent_id,omitempty"`
+	TransferID      string    `bson:"transfer_id,omitempty" json:"transfer_id,omitempty"`
+	CreatedAt       time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Tipos de lançamento do livro-razão de pagamentos.
+const (
+	PaymentEntryIntent     = "INTENT"     // PaymentIntent criado para o paciente
+	PaymentEntryCapture    = "CAPTURE"    // valor efetivamente cobrado do paciente
+	PaymentEntryTransfer   = "TRANSFER"   // repasse para a conta Express do enfermeiro
+	PaymentEntryCommission = "COMMISSION" // parte do pagamento retida pela plataforma
+	PaymentEntryRefund     = "REFUND"     // estorno ao paciente
+	PaymentEntryDiscount   = "DISCOUNT"   // desconto de cupom absorvido pela plataforma
+	PaymentEntryTip        = "TIP"        // gorjeta cobrada do paciente, repassada inteira ao enfermeiro
+	PaymentEntryDispute    = "DISPUTE"    // valor contestado pelo paciente junto ao banco
+)
+
+// Situação de um lançamento do livro-razão.
+const (
+	PaymentEntryStatusPending    = "PENDING"
+	PaymentEntryStatusAuthorized = "AUTHORIZED"
+	PaymentEntryStatusSucceeded  = "SUCCEEDED"
+	PaymentEntryStatusFailed     = "FAILED"
+	PaymentEntryStatusReversed   = "REVERSED"
+	PaymentEntryStatusCanceled   = "CANCELED"
+)
+
+// PaymentEntry é um lançamento do livro-razão: cada movimentação de dinheiro de uma visita,
+// com valores em centavos. Tipo e StripeID identificam o lançamento, então registrar o mesmo
+// objeto do Stripe duas vezes apenas atualiza o lançamento existente.
+type PaymentEntry struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type            string             `bson:"type" json:"type"`
+	Status          string             `bson:"status" json:"status"`
+	AmountInCents   int64              `bson:"amount_in_cents" json:"amount_in_cents"`
+	Currency        string             `bson:"currency" json:"currency"`
+	StripeID        string             `bson:"stripe_id" json:"stripe_id"`
+	PaymentIntentID string             `bson:"payment_intent_id" json:"payment_intent_id"`
+	VisitID         string             `bson:"visit_id,omitempty" json:"visit_id,omitempty"`
+	PatientID       string             `bson:"patient_id,omitempty" json:"patient_id,omitempty"`
+	NurseID         string             `bson:"nurse_id,omitempty" json:"nurse_id,omitempty"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PaymentEntryFilter restringe a listagem do livro-razão. Campos vazios não filtram.
+type PaymentEntryFilter struct {
+	Type            string
+	Status          string
+	VisitID         string
+	PaymentIntentID string
+	PatientID       string
+	NurseID         string
+	Limit           int64
+}
@@ -1,14 +1,95 @@
 package model
 
 import (
+	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// VisitStatus representa o estado de uma visita dentro do seu ciclo de vida.
+// As transições permitidas entre os estados ficam centralizadas em internal/lifecycle.
+type VisitStatus string
+
+const (
+	VisitStatusPending   VisitStatus = "PENDING"
+	VisitStatusConfirmed VisitStatus = "CONFIRMED"
+	VisitStatusRejected  VisitStatus = "REJECTED"
+	VisitStatusCanceled  VisitStatus = "CANCELED"
+	VisitStatusCompleted VisitStatus = "COMPLETED"
+	// VisitStatusExpired marca visitas pendentes que ninguém respondeu a tempo.
+	VisitStatusExpired VisitStatus = "EXPIRED"
+)
+
+// VisitStatusChange registra quem mudou o status da visita, quando e por quê.
+type VisitStatusChange struct {
+	From      VisitStatus `bson:"from" json:"from"`
+	To        VisitStatus `bson:"to" json:"to"`
+	ActorID   string      `bson:"actor_id" json:"actor_id"`
+	ActorRole string      `bson:"actor_role" json:"actor_role"`
+	Reason    string      `bson:"reason,omitempty" json:"reason,omitempty"`
+	ChangedAt time.Time   `bson:"changed_at" json:"changed_at"`
+}
+
+type RescheduleStatus string
+
+const (
+	RescheduleStatusPending  RescheduleStatus = "PENDING"
+	RescheduleStatusAccepted RescheduleStatus = "ACCEPTED"
+	RescheduleStatusDeclined RescheduleStatus = "DECLINED"
+)
+
+// RescheduleProposal é o pedido do paciente para mover a visita para outra data,
+// que só vale depois de aceito pelo enfermeiro.
+type RescheduleProposal struct {
+	ProposedDate time.Time        `bson:"proposed_date" json:"proposed_date"`
+	PreviousDate time.Time        `bson:"previous_date" json:"previous_date"`
+	Reason       string           `bson:"reason,omitempty" json:"reason,omitempty"`
+	Status       RescheduleStatus `bson:"status" json:"status"`
+	ProposedAt   time.Time        `bson:"proposed_at" json:"proposed_at"`
+	AnsweredAt   *time.Time       `bson:"answered_at,omitempty" json:"answered_at,omitempty"`
+}
+
+// VisitDispatch acompanha uma visita imediata oferecida a vários enfermeiros ao mesmo tempo,
+// que fica sem NurseId até o primeiro aceite.
+type VisitDispatch struct {
+	Candidates []string  `bson:"candidates" json:"candidates"`
+	Round      int       `bson:"round" json:"round"`
+	RadiusKm   float64   `bson:"radius_km" json:"radius_km"`
+	Latitude   float64   `bson:"latitude" json:"latitude"`
+	Longitude  float64   `bson:"longitude" json:"longitude"`
+	City       string    `bson:"city" json:"city"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// Situação do pagamento da visita no Stripe, atualizada pelos webhooks.
+const (
+	PaymentStatusAuthorized        = "AUTHORIZED" // valor retido no cartão, ainda não capturado
+	PaymentStatusSucceeded         = "SUCCEEDED"
+	PaymentStatusFailed            = "FAILED"
+	PaymentStatusRefunded          = "REFUNDED"
+	PaymentStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	PaymentStatusReleased          = "RELEASED"     // autorização cancelada sem cobrança
+	PaymentStatusDisputed          = "DISPUTED"     // contestado pelo paciente junto ao banco
+	PaymentStatusDisputeLost       = "DISPUTE_LOST" // contestação perdida: o valor voltou ao paciente
+)
+
+// Situação do repasse ao enfermeiro no Stripe, atualizada pelos webhooks.
+const (
+	TransferStatusCreated  = "CREATED"
+	TransferStatusReversed = "REVERSED"
+)
+
+// VisitReceipt é o recibo emitido quando a visita é concluída. O PDF fica no GridFS.
+type VisitReceipt struct {
+	Number   string             `bson:"number" json:"number"`
+	FileID   primitive.ObjectID `bson:"file_id" json:"file_id"`
+	IssuedAt time.Time          `bson:"issued_at" json:"issued_at"`
+}
+
 type Visit struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Status           string             `bson:"status" json:"status" binding:"required"`
+	Status           VisitStatus        `bson:"status" json:"status" binding:"required"`
 	ConfirmationCode string             `bson:"confirmation_code" json:"confirmation_code"`
 
 	PatientId    string `bson:"patient_id" json:"patient_id" binding:"required"`
@@ -37,7 +118,41 @@ type Visit struct {
 
 	PaymentIntentID string `bson:"payment_intent_id" json:"payment_intent_id" binding:"required"`
 	TransferID      string `bson:"transfer_id" json:"transfer_id" binding:"required"`
+	PaymentStatus   string `bson:"payment_status,omitempty" json:"payment_status,omitempty"`
+	PaymentMethod   string `bson:"payment_method,omitempty" json:"payment_method,omitempty"`
+	TransferStatus  string `bson:"transfer_status,omitempty" json:"transfer_status,omitempty"`
+	DisputeID       string `bson:"dispute_id,omitempty" json:"dispute_id,omitempty"`
+
+	Commission *VisitCommission `bson:"commission,omitempty" json:"commission,omitempty"`
+	Discount   *VisitDiscount   `bson:"discount,omitempty" json:"discount,omitempty"`
+	Tip        *VisitTip        `bson:"tip,omitempty" json:"tip,omitempty"`
+	Receipt    *VisitReceipt    `bson:"receipt,omitempty" json:"receipt,omitempty"`
+
+	StatusHistory []VisitStatusChange `bson:"status_history,omitempty" json:"status_history"`
+
+	CancellationFee float64             `bson:"cancellation_fee,omitempty" json:"cancellation_fee,omitempty"`
+	RefundAmount    float64             `bson:"refund_amount,omitempty" json:"refund_amount,omitempty"`
+	RefundID        string              `bson:"refund_id,omitempty" json:"refund_id,omitempty"`
+	Reschedule      *RescheduleProposal `bson:"reschedule,omitempty" json:"reschedule,omitempty"`
+
+	Dispatch *VisitDispatch `bson:"dispatch,omitempty" json:"dispatch,omitempty"`
+
+	SeriesId         string `bson:"series_id,omitempty" json:"series_id,omitempty"`
+	SeriesOccurrence int    `bson:"series_occurrence,omitempty" json:"series_occurrence,omitempty"`
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
+
+// PaidInCents é o que o paciente pagou pela visita: o valor dela menos o desconto de cupom,
+// que fica por conta da plataforma. Estornos nunca devolvem mais do que isso.
+func (v Visit) PaidInCents() int64 {
+	paid := int64(math.Round(v.VisitValue * 100))
+	if v.Discount != nil {
+		paid -= v.Discount.AmountInCents
+	}
+	if paid < 0 {
+		return 0
+	}
+	return paid
+}
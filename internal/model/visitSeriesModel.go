@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type VisitSeriesStatus string
+
+const (
+	VisitSeriesStatusActive   VisitSeriesStatus = "ACTIVE"
+	VisitSeriesStatusCanceled VisitSeriesStatus = "CANCELED"
+)
+
+// RecurrenceRule descreve como as ocorrências de uma série são geradas: nos dias da semana
+// informados, a cada Interval semanas, até EndDate ou até completar Count ocorrências.
+type RecurrenceRule struct {
+	Weekdays []string   `bson:"weekdays" json:"weekdays"`
+	Interval int        `bson:"interval" json:"interval"`
+	EndDate  *time.Time `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	Count    int        `bson:"count,omitempty" json:"count,omitempty"`
+}
+
+type VisitSeries struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+
+	PatientId string `bson:"patient_id" json:"patient_id"`
+	NurseId   string `bson:"nurse_id" json:"nurse_id"`
+
+	Recurrence RecurrenceRule    `bson:"recurrence" json:"recurrence"`
+	Status     VisitSeriesStatus `bson:"status" json:"status"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
@@ -15,4 +15,24 @@ type DeleteAccountPasswordDto struct {
 
 type PrescriptionList struct{
 	PrescriptionList []string `json:"prescription_list" binding:"required"`
-}
\ No newline at end of file
+}
+// CancelVisitSeriesDto cancela uma ocorrência (OCCURRENCE) ou a ocorrência e as seguintes
+// (FOLLOWING) de uma série atendida pelo enfermeiro.
+type CancelVisitSeriesDto struct {
+	VisitId string `json:"visit_id"`
+	Scope   string `json:"scope" binding:"required,oneof=OCCURRENCE FOLLOWING"`
+	Reason  string `json:"reason"`
+}
+
+type RescheduleAnswerDto struct {
+	Accept *bool `json:"accept" binding:"required"`
+}
+
+// EarningsQueryDto filtra o extrato de ganhos. period aceita day, week ou month (padrão);
+// from e to são datas AAAA-MM-DD, inclusivas, e por padrão cobrem o mês atual.
+type EarningsQueryDto struct {
+	Period string `form:"period"`
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Format string `form:"format"`
+}
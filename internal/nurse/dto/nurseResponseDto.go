@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"medassist/internal/model"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,6 +12,8 @@ type NurseVisitsListsDto struct {
 	Confirmed   []VisitDto `json:"confirmed"`
 	Completed   []VisitDto `json:"completed"`
 	Rejected    []VisitDto `json:"rejected"`
+	Canceled    []VisitDto `json:"canceled"`
+	Expired     []VisitDto `json:"expired"`
 	VisitsToday []VisitDto `json:"visits_today"`
 }
 
@@ -28,6 +31,9 @@ type VisitDto struct {
 	PatientId      string  `json:"patient_id"`
 	NurseName      string  `json:"nurse_name"`
 	PatientImageID string  `json:"patient_image_id"`
+
+	SeriesId   string                    `json:"series_id,omitempty"`
+	Reschedule *model.RescheduleProposal `json:"reschedule,omitempty"`
 }
 
 type PatientProfileResponseDTO struct {
@@ -1,8 +1,13 @@
 package nurse
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"medassist/internal/earnings"
+	"medassist/internal/lifecycle"
 	"medassist/internal/nurse/dto"
+	"medassist/internal/repository"
 	"medassist/utils"
 	"net/http"
 	"strings"
@@ -129,17 +134,19 @@ func (h *NurseHandler) GetAllVisits(c *gin.Context) {
 }
 
 // @Summary Confirma ou cancela uma visita (Enfermeiro)
-// @Description Permite ao enfermeiro confirmar uma visita PENDENTE/REJEITADA, ou cancelar uma visita CONFIRMADA (com motivo). Requer autenticação de Enfermeiro.
+// @Description Permite ao enfermeiro confirmar uma visita PENDENTE, ou cancelar uma visita CONFIRMADA (com motivo). Requer autenticação de Enfermeiro.
 // @Tags Nurse
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "ID da Visita a ser modificada"
 // @Param payload body dto.CancelReason false "Motivo do cancelamento. Obrigatório apenas ao cancelar uma visita 'CONFIRMED'. Pode ser um JSON vazio {} para confirmar."
+// @Param series query bool false "Em visitas de uma série, aplica a ação a esta ocorrência e a todas as seguintes"
 // @Success 200 {object} utils.SuccessResponseString "Status da visita alterado com sucesso"
 // @Failure 400 {object} utils.ErrorResponse "ID inválido, JSON inválido ou erro na lógica de status"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Enfermeiro)"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
 // @Router /nurse/visit/{id} [patch]
 func (h *NurseHandler) ConfirmOrCancelVisit(c *gin.Context) {
 	nurseId := utils.GetUserId(c) // pega o id do user pela req
@@ -156,9 +163,12 @@ func (h *NurseHandler) ConfirmOrCancelVisit(c *gin.Context) {
 		}
 	}
 
-	response, err := h.nurseService.ConfirmOrCancelVisit(nurseId, visitId, reason.Reason)
+	wholeSeries := c.Query("series") == "true"
+
+	response, err := h.nurseService.ConfirmOrCancelVisit(nurseId, visitId, reason.Reason, wholeSeries)
 	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
 	}
 	fmt.Println("response", response)
 
@@ -326,6 +336,7 @@ func (h *NurseHandler) GetNurseVisitInfo(c *gin.Context) {
 // @Failure 400 {object} utils.ErrorResponse "JSON inválido, código obrigatório ou código incorreto"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Enfermeiro)"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
 // @Router /nurse/service-confirmation/{id} [patch]
 func (h *NurseHandler) VisitServiceConfirmation(c *gin.Context) {
 	nurseId := utils.GetUserId(c)
@@ -353,7 +364,7 @@ func (h *NurseHandler) VisitServiceConfirmation(c *gin.Context) {
 
 	err := h.nurseService.VisitServiceConfirmation(nurseId, visitId, codeStr)
 	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
 		return
 	}
 
@@ -383,6 +394,100 @@ func (h *NurseHandler) TurnOfflineOnLogout(c *gin.Context) {
 	utils.SendSuccessResponse(c, "Logout realizado com sucesso.", http.StatusOK)
 }
 
+// @Summary Cancela ocorrências de uma série (Enfermeiro)
+// @Description Cancela uma ocorrência (scope OCCURRENCE) ou a ocorrência informada e todas as seguintes (scope FOLLOWING) de uma série de visitas. Sem visit_id, FOLLOWING cancela todas as visitas futuras. Requer autenticação de Enfermeiro.
+// @Tags Nurse
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da série"
+// @Param payload body dto.CancelVisitSeriesDto true "Escopo e motivo do cancelamento"
+// @Success 200 {object} utils.SuccessResponseString "Visitas da série canceladas com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "JSON inválido ou série inválida"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
+// @Router /nurse/visit-series/{id}/cancel [patch]
+func (h *NurseHandler) CancelVisitSeries(c *gin.Context) {
+	nurseId := utils.GetUserId(c)
+	seriesId := c.Param("id")
+
+	var cancelDto dto.CancelVisitSeriesDto
+	if err := c.ShouldBindJSON(&cancelDto); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	canceled, err := h.nurseService.CancelVisitSeries(nurseId, seriesId, cancelDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Visitas da série canceladas com sucesso.", fmt.Sprintf("%d visitas canceladas.", canceled))
+}
+
+// @Summary Responde a um pedido de remarcação (Enfermeiro)
+// @Description Aceita ou recusa a nova data proposta pelo paciente. Ao aceitar, a visita é movida na agenda. Requer autenticação de Enfermeiro.
+// @Tags Nurse
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da Visita"
+// @Param payload body dto.RescheduleAnswerDto true "Aceitar (true) ou recusar (false)"
+// @Success 200 {object} utils.SuccessResponseString "Pedido de remarcação respondido com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "JSON inválido ou não há pedido pendente"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 409 {object} utils.ErrorResponse "Nova data já reservada por outra visita ou pedido já respondido"
+// @Router /nurse/visit/{id}/reschedule [patch]
+func (h *NurseHandler) AnswerReschedule(c *gin.Context) {
+	nurseId := utils.GetUserId(c)
+	visitId := c.Param("id")
+
+	var answerDto dto.RescheduleAnswerDto
+	if err := c.ShouldBindJSON(&answerDto); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.nurseService.AnswerReschedule(nurseId, visitId, *answerDto.Accept)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Pedido de remarcação respondido com sucesso.", response)
+}
+
+// @Summary Aceita uma visita imediata oferecida a vários enfermeiros
+// @Description O primeiro enfermeiro a aceitar fica com a visita; os demais recebem 409 e o evento IMMEDIATE_VISIT_TAKEN pelo WebSocket. Enfermeiros aos quais a visita não foi oferecida recebem 403. Requer autenticação de Enfermeiro.
+// @Tags Nurse
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da Visita"
+// @Success 200 {object} dto.VisitDto "Visita aceita com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "ID inválido"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 403 {object} utils.ErrorResponse "A visita não foi oferecida a este enfermeiro"
+// @Failure 409 {object} utils.ErrorResponse "Visita já aceita por outro enfermeiro ou agenda ocupada"
+// @Router /nurse/dispatch/{id}/accept [patch]
+func (h *NurseHandler) AcceptBroadcastVisit(c *gin.Context) {
+	nurseId := utils.GetUserId(c)
+	visitId := c.Param("id")
+
+	visit, err := h.nurseService.AcceptBroadcastVisit(nurseId, visitId)
+	if errors.Is(err, repository.ErrNotDispatchCandidate) {
+		utils.SendErrorResponse(c, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Visita imediata aceita com sucesso.", visit)
+}
+
 // @Summary Rejeita uma visita pendente
 // @Description Permite ao enfermeiro rejeitar uma visita que estava 'PENDING'. Requer autenticação de Enfermeiro.
 // @Tags Nurse
@@ -394,6 +499,7 @@ func (h *NurseHandler) TurnOfflineOnLogout(c *gin.Context) {
 // @Failure 400 {object} utils.ErrorResponse "ID inválido ou erro"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Enfermeiro)"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
 // @Router /nurse/reject-visit/{id} [patch]
 func (h *NurseHandler) RejectVisit(c *gin.Context) {
 	nurseId := utils.GetUserId(c)
@@ -402,7 +508,7 @@ func (h *NurseHandler) RejectVisit(c *gin.Context) {
 
 	err := h.nurseService.RejectVisit(nurseId, visitId)
 	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
 		return
 	}
 
@@ -522,3 +628,84 @@ func (h *NurseHandler) AddPrescription(c *gin.Context) {
 
 	utils.SendSuccessResponse(c, "Prescrição adicionada com sucesso.", visit)
 }
+
+// @Summary Extrato de ganhos do Enfermeiro
+// @Description Retorna os ganhos do enfermeiro logado agrupados por dia, semana ou mês: valor bruto, taxa da plataforma, líquido repassado, valores a receber e o ID do repasse no Stripe de cada visita. Requer autenticação de Enfermeiro.
+// @Tags Nurse
+// @Produce json
+// @Security ApiKeyAuth
+// @Param period query string false "Agrupamento: day, week ou month (padrão)"
+// @Param from query string false "Data inicial (AAAA-MM-DD). Padrão: início do mês atual"
+// @Param to query string false "Data final, inclusiva (AAAA-MM-DD). Padrão: um mês a partir da data inicial"
+// @Success 200 {object} utils.SuccessResponseNoData "Extrato de ganhos carregado com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "Período ou datas inválidos"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Enfermeiro)"
+// @Router /nurse/earnings [get]
+func (h *NurseHandler) GetEarnings(c *gin.Context) {
+	nurseId := utils.GetUserId(c)
+
+	var query dto.EarningsQueryDto
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.SendErrorResponse(c, "Filtros do extrato inválidos.", http.StatusBadRequest)
+		return
+	}
+
+	statement, err := h.nurseService.GetEarnings(nurseId, query)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Extrato de ganhos carregado com sucesso.", statement)
+}
+
+// @Summary Baixa o extrato de ganhos do Enfermeiro
+// @Description Gera o extrato de ganhos do enfermeiro logado em CSV ou PDF, para declaração de impostos. Aceita os mesmos filtros de /nurse/earnings. Requer autenticação de Enfermeiro.
+// @Tags Nurse
+// @Produce text/csv
+// @Produce application/pdf
+// @Security ApiKeyAuth
+// @Param format query string true "Formato do arquivo: csv ou pdf"
+// @Param period query string false "Agrupamento: day, week ou month (padrão)"
+// @Param from query string false "Data inicial (AAAA-MM-DD). Padrão: início do mês atual"
+// @Param to query string false "Data final, inclusiva (AAAA-MM-DD). Padrão: um mês a partir da data inicial"
+// @Success 200 {file} file "Arquivo do extrato"
+// @Failure 400 {object} utils.ErrorResponse "Formato, período ou datas inválidos"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Enfermeiro)"
+// @Router /nurse/earnings/statement [get]
+func (h *NurseHandler) DownloadEarningsStatement(c *gin.Context) {
+	nurseId := utils.GetUserId(c)
+
+	var query dto.EarningsQueryDto
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.SendErrorResponse(c, "Filtros do extrato inválidos.", http.StatusBadRequest)
+		return
+	}
+	if query.Format != "csv" && query.Format != "pdf" {
+		utils.SendErrorResponse(c, "Formato inválido. Use csv ou pdf.", http.StatusBadRequest)
+		return
+	}
+
+	statement, err := h.nurseService.GetEarnings(nurseId, query)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fileName := fmt.Sprintf("extrato-%s-%s.%s", statement.From.Format("2006-01-02"), statement.To.AddDate(0, 0, -1).Format("2006-01-02"), query.Format)
+	c.Header("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+
+	if query.Format == "pdf" {
+		c.Data(http.StatusOK, "application/pdf", earnings.RenderPDF(statement))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := earnings.WriteCSV(&buf, statement); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -2,16 +2,25 @@ package nurse
 
 import (
 	"fmt"
-	"math"
+	"log"
+	"medassist/internal/chat"
+	chatDTO "medassist/internal/chat/dto"
+	"medassist/internal/commission"
+	"medassist/internal/dispatch"
+	"medassist/internal/earnings"
+	"medassist/internal/lifecycle"
 	"medassist/internal/model"
 	"medassist/internal/nurse/dto"
+	"medassist/internal/receipt"
 	"medassist/internal/repository"
+	"medassist/internal/scheduling"
 	userDTO "medassist/internal/user/dto"
 	"medassist/utils"
 	"time"
 
 	"strings"
 
+	"github.com/stripe/stripe-go/v76"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,7 +29,10 @@ import (
 type NurseService interface {
 	UpdateAvailablityNursingService(userId string) (model.Nurse, error)
 	GetAllVisits(nurseId string) (dto.NurseVisitsListsDto, error)
-	ConfirmOrCancelVisit(nurseId, visitId, reason string) (string, error)
+	ConfirmOrCancelVisit(nurseId, visitId, reason string, wholeSeries bool) (string, error)
+	CancelVisitSeries(nurseId, seriesId string, cancelDto dto.CancelVisitSeriesDto) (int, error)
+	AnswerReschedule(nurseId, visitId string, accept bool) (string, error)
+	AcceptBroadcastVisit(nurseId, visitId string) (dto.VisitDto, error)
 	GetPatientProfile(patientId string) (dto.PatientProfileResponseDTO, error)
 	NurseDashboardData(nurseId string) (dto.NurseDashboardDataResponseDTO, error)
 	UpdateNurseFields(id string, updates map[string]interface{}) (dto.NurseUpdateResponseDTO, error)
@@ -35,18 +47,27 @@ type NurseService interface {
 	GetMyNurseProfile(nurseId string) (userDTO.NurseProfileResponseDTO, error)
 	CreateStripeOnboardingLink(nurseId string) (dto.StripeOnboardingResponseDTO, error)
 	AddPrescription(nurseId string, visitId string, prescriptions []string) (dto.NurseVisitInfo, error)
+	GetEarnings(nurseId string, query dto.EarningsQueryDto) (earnings.Statement, error)
 }
 
 type nurseService struct {
-	userRepository   repository.UserRepository
-	nurseRepository  repository.NurseRepository
-	visitRepository  repository.VisitRepository
-	reviewRepository repository.ReviewRepository
-	stripeRepository repository.StripeRepository
+	userRepository     repository.UserRepository
+	nurseRepository    repository.NurseRepository
+	visitRepository    repository.VisitRepository
+	reviewRepository   repository.ReviewRepository
+	paymentGateway     repository.PaymentGateway
+	paymentRepository  repository.PaymentRepository
+	commissionService  commission.CommissionService
+	visitStateMachine  lifecycle.VisitStateMachine
+	visitSeriesManager lifecycle.VisitSeriesManager
+	visitHub           *chat.Hub
+	dispatcher         dispatch.Dispatcher
+	receiptIssuer      receipt.Issuer
 }
 
-func NewNurseService(userRepository repository.UserRepository, nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository, reviewRepository repository.ReviewRepository, stripeRepository repository.StripeRepository) NurseService {
-	return &nurseService{userRepository: userRepository, nurseRepository: nurseRepository, visitRepository: visitRepository, reviewRepository: reviewRepository, stripeRepository: stripeRepository}
+func NewNurseService(userRepository repository.UserRepository, nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository, reviewRepository repository.ReviewRepository, visitSeriesRepository repository.VisitSeriesRepository, paymentGateway repository.PaymentGateway, paymentRepository repository.PaymentRepository, commissionService commission.CommissionService, refunder lifecycle.Refunder, visitHub *chat.Hub, dispatcher dispatch.Dispatcher, receiptIssuer receipt.Issuer) NurseService {
+	visitStateMachine := lifecycle.NewVisitStateMachine(visitRepository, refunder)
+	return &nurseService{userRepository: userRepository, nurseRepository: nurseRepository, visitRepository: visitRepository, reviewRepository: reviewRepository, paymentGateway: paymentGateway, paymentRepository: paymentRepository, commissionService: commissionService, visitStateMachine: visitStateMachine, visitSeriesManager: lifecycle.NewVisitSeriesManager(visitRepository, visitSeriesRepository, visitStateMachine), visitHub: visitHub, dispatcher: dispatcher, receiptIssuer: receiptIssuer}
 }
 
 func (s *nurseService) UpdateAvailablityNursingService(nurseId string) (model.Nurse, error) {
@@ -103,6 +124,8 @@ func (s *nurseService) GetAllVisits(nurseId string) (dto.NurseVisitsListsDto, er
 	confirmedVisits := make([]dto.VisitDto, 0)
 	completedVisits := make([]dto.VisitDto, 0)
 	rejectedVisits := make([]dto.VisitDto, 0)
+	canceledVisits := make([]dto.VisitDto, 0)
+	expiredVisits := make([]dto.VisitDto, 0)
 
 	visitsToday := make([]dto.VisitDto, 0)
 
@@ -140,28 +163,33 @@ func (s *nurseService) GetAllVisits(nurseId string) (dto.NurseVisitsListsDto, er
 			VisitValue:     visit.VisitValue,
 			CreatedAt:      visit.CreatedAt.Format("02/01/2006 15:04"),
 			Date:           visit.VisitDate.Format("02/01/2006 15:04"),
-			Status:         visit.Status,
+			Status:         string(visit.Status),
 			Rating:         visitReview.Rating,
 			PatientName:    visit.PatientName,
 			PatientImageID: patient.ProfileImageID.Hex(),
 			PatientId:      visit.PatientId,
 			NurseName:      visit.NurseName,
+			SeriesId:       visit.SeriesId,
+			Reschedule:     visit.Reschedule,
 		}
 
 		switch visit.Status {
-		case "PENDING":
+		case model.VisitStatusPending:
 			pendingVisits = append(pendingVisits, visitDto)
-		case "CONFIRMED":
+		case model.VisitStatusConfirmed:
 			confirmedVisits = append(confirmedVisits, visitDto)
-		case "COMPLETED":
+		case model.VisitStatusCompleted:
 			completedVisits = append(completedVisits, visitDto)
-		case "REJECTED":
-			fmt.Print("oi", visitDto)
+		case model.VisitStatusRejected:
 			rejectedVisits = append(rejectedVisits, visitDto)
+		case model.VisitStatusCanceled:
+			canceledVisits = append(canceledVisits, visitDto)
+		case model.VisitStatusExpired:
+			expiredVisits = append(expiredVisits, visitDto)
 		}
 
 		visitDate := visit.VisitDate.In(location)
-		isValidStatus := visit.Status == "CONFIRMED"
+		isValidStatus := visit.Status == model.VisitStatusConfirmed
 		isToday := (visitDate.Equal(todayStart) || visitDate.After(todayStart)) && visitDate.Before(tomorrowStart)
 
 		if isValidStatus && isToday {
@@ -174,50 +202,174 @@ func (s *nurseService) GetAllVisits(nurseId string) (dto.NurseVisitsListsDto, er
 		Confirmed:   confirmedVisits,
 		Completed:   completedVisits,
 		Rejected:    rejectedVisits,
+		Canceled:    canceledVisits,
+		Expired:     expiredVisits,
 		VisitsToday: visitsToday,
 	}
 
 	return allVisitsDto, nil
 }
 
-func (s *nurseService) ConfirmOrCancelVisit(nurseId, visitId, reason string) (string, error) {
+func (s *nurseService) CancelVisitSeries(nurseId, seriesId string, cancelDto dto.CancelVisitSeriesDto) (int, error) {
+	actor := lifecycle.Actor{ID: nurseId, Role: lifecycle.RoleNurse}
+
+	canceled, err := s.visitSeriesManager.Cancel(seriesId, cancelDto.VisitId, cancelDto.Scope, actor, cancelDto.Reason, nil)
+	for _, visit := range canceled {
+		s.visitHub.StopLocationSharing(visit)
+	}
+	if err != nil {
+		return len(canceled), err
+	}
+
+	if len(canceled) > 0 {
+		utils.SendEmailVisitCanceledWithReason(canceled[0].PatientEmail, canceled[0].NurseName, canceled[0].VisitDate.Format("02/01/2006 15:04"), cancelDto.Reason)
+	}
+
+	return len(canceled), nil
+}
+
+// AnswerReschedule aceita ou recusa o pedido de remarcação feito pelo paciente. Ao aceitar,
+// a reserva na agenda é movida para a nova data atomicamente.
+func (s *nurseService) AnswerReschedule(nurseId, visitId string, accept bool) (string, error) {
 	visit, err := s.visitRepository.FindVisitById(visitId)
 	if err != nil {
 		return "", err
 	}
 
-	var response string
-	if visit.Status == "CONFIRMED" {
-		visit.CancelReason = reason
-		visit.Status = "REJECTED"
-		response = "Visita que estava confirmada foi cancelada com sucesso."
-		utils.SendEmailVisitCanceledWithReason("komatsuhenry@gmail.com", visit.NurseName, visit.VisitDate.Format("02/01/2006 15:04"), reason)
-	} else if visit.Status == "PENDING" {
-		visit.CancelReason = ""
-		visit.Status = "CONFIRMED"
-		response = "Visita que estava pendente foi confirmada com sucesso."
+	if visit.NurseId != nurseId {
+		return "", fmt.Errorf("Essa visita é pertencente à outro enfermeiro.")
+	}
+	if visit.Reschedule == nil || visit.Reschedule.Status != model.RescheduleStatusPending {
+		return "", fmt.Errorf("Não há pedido de remarcação pendente para esta visita.")
+	}
+	if visit.Status != model.VisitStatusPending && visit.Status != model.VisitStatusConfirmed {
+		return "", fmt.Errorf("Apenas visitas pendentes ou confirmadas podem ser remarcadas.")
+	}
+
+	proposal := *visit.Reschedule
+	answeredAt := time.Now()
+	proposal.AnsweredAt = &answeredAt
+
+	var message string
+	if accept {
+		proposal.Status = model.RescheduleStatusAccepted
+		visit, err = s.visitRepository.RescheduleVisit(visit, proposal.ProposedDate, scheduling.ReservedWindow(scheduling.DefaultVisitDuration), map[string]interface{}{"reschedule": proposal})
+		if err != nil {
+			return "", err
+		}
+		message = "Remarcação aceita com sucesso."
+	} else {
+		proposal.Status = model.RescheduleStatusDeclined
+		visit, err = s.visitRepository.UpdateVisitFields(visitId, map[string]interface{}{"reschedule": proposal})
+		if err != nil {
+			return "", err
+		}
+		message = "Remarcação recusada com sucesso."
+	}
+
+	proposedDate := proposal.ProposedDate.In(scheduling.Location()).Format("02/01/2006 15:04")
+	utils.SendEmailRescheduleAnswered(visit.PatientEmail, visit.NurseName, proposedDate, accept)
+
+	eventType := "VISIT_RESCHEDULE_DECLINED"
+	if accept {
+		eventType = "VISIT_RESCHEDULE_ACCEPTED"
+	}
+	s.visitHub.NotifyVisitEvent(visit.PatientId, chatDTO.VisitEventDTO{
+		Type:    eventType,
+		VisitID: visitId,
+		Message: message,
+		Data:    proposal,
+	})
+
+	return message, nil
+}
+
+// AcceptBroadcastVisit aceita uma visita imediata oferecida a vários enfermeiros.
+// Apenas o primeiro aceite vale; os demais recebem erro de conflito.
+func (s *nurseService) AcceptBroadcastVisit(nurseId, visitId string) (dto.VisitDto, error) {
+	visit, err := s.dispatcher.Accept(visitId, nurseId)
+	if err != nil {
+		return dto.VisitDto{}, err
+	}
+
+	return dto.VisitDto{
+		ID:          visit.ID.Hex(),
+		Description: visit.Description,
+		Reason:      visit.Reason,
+		VisitType:   visit.VisitType,
+		VisitValue:  visit.VisitValue,
+		CreatedAt:   visit.CreatedAt.Format("02/01/2006 15:04"),
+		Date:        visit.VisitDate.Format("02/01/2006 15:04"),
+		Status:      string(visit.Status),
+		PatientName: visit.PatientName,
+		PatientId:   visit.PatientId,
+		NurseName:   visit.NurseName,
+	}, nil
+}
+
+func (s *nurseService) ConfirmOrCancelVisit(nurseId, visitId, reason string, wholeSeries bool) (string, error) {
+	visit, err := s.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		return "", err
+	}
+
+	actor := lifecycle.Actor{ID: nurseId, Role: lifecycle.RoleNurse}
+
+	// em séries, o enfermeiro pode aceitar esta ocorrência e todas as seguintes de uma vez,
+	// ou cancelar esta e as seguintes
+	if wholeSeries {
+		if err := lifecycle.Validate(visit, model.VisitStatusConfirmed, actor); err == nil {
+			confirmed, err := s.visitSeriesManager.ConfirmFollowing(visit, actor)
+			if err != nil {
+				return "", err
+			}
+
+			utils.SendEmailVisitApproved(visit.PatientEmail, visit.NurseName, fmt.Sprintf("%s (%d visitas da série)", visit.VisitDate.Format("02/01/2006 15:04"), len(confirmed)), visit.VisitValue)
+			return fmt.Sprintf("%d visitas da série foram confirmadas com sucesso.", len(confirmed)), nil
+		}
 
-		utils.SendEmailVisitApproved("komatsuhenry@gmail.com", visit.NurseName, visit.VisitDate.Format("02/01/2006 15:04"), visit.VisitValue)
-	} else if visit.Status == "REJECTED" {
-		visit.CancelReason = ""
-		visit.Status = "CONFIRMED"
-		response = "Visita que estava rejeitada foi confirmada com sucesso."
+		if visit.Status == model.VisitStatusConfirmed && visit.SeriesId != "" {
+			canceled, err := s.visitSeriesManager.Cancel(visit.SeriesId, visitId, lifecycle.SeriesScopeFollowing, actor, reason, nil)
+			for _, occurrence := range canceled {
+				s.visitHub.StopLocationSharing(occurrence)
+			}
+			if err != nil {
+				return "", err
+			}
 
-		utils.SendEmailVisitApproved("komatsuhenry@gmail.com", visit.NurseName, visit.VisitDate.Format("02/01/2006 15:04"), visit.VisitValue)
+			utils.SendEmailVisitCanceledWithReason(visit.PatientEmail, visit.NurseName, visit.VisitDate.Format("02/01/2006 15:04"), reason)
+			return fmt.Sprintf("%d visitas da série foram canceladas com sucesso.", len(canceled)), nil
+		}
+	}
+
+	// visitas confirmadas são canceladas; qualquer outro status tenta a confirmação
+	// e a máquina de estados recusa o que não for permitido (ex: REJECTED -> CONFIRMED)
+	if visit.Status == model.VisitStatusConfirmed {
+		visitUpdates := bson.M{
+			"cancel_reason": reason,
+		}
+
+		visit, err = s.visitStateMachine.Transition(visit, model.VisitStatusCanceled, actor, reason, visitUpdates)
+		if err != nil {
+			return "", err
+		}
+		s.visitHub.StopLocationSharing(visit)
+
+		utils.SendEmailVisitCanceledWithReason("komatsuhenry@gmail.com", visit.NurseName, visit.VisitDate.Format("02/01/2006 15:04"), reason)
+		return "Visita que estava confirmada foi cancelada com sucesso.", nil
 	}
 
 	visitUpdates := bson.M{
-		"status":        visit.Status,
-		"cancel_reason": visit.CancelReason,
-		"updated_at":    time.Now(),
+		"cancel_reason": "",
 	}
 
-	_, err = s.visitRepository.UpdateVisitFields(visitId, visitUpdates)
+	visit, err = s.visitStateMachine.Transition(visit, model.VisitStatusConfirmed, actor, "", visitUpdates)
 	if err != nil {
 		return "", err
 	}
 
-	return response, nil
+	utils.SendEmailVisitApproved("komatsuhenry@gmail.com", visit.NurseName, visit.VisitDate.Format("02/01/2006 15:04"), visit.VisitValue)
+	return "Visita que estava pendente foi confirmada com sucesso.", nil
 }
 
 func (s *nurseService) GetPatientProfile(patientId string) (dto.PatientProfileResponseDTO, error) {
@@ -308,7 +460,7 @@ func (s *nurseService) NurseDashboardData(nurseId string) (dto.NurseDashboardDat
 			VisitValue:  visit.VisitValue,
 			CreatedAt:   visit.CreatedAt.Format("02/01/2006 15:04"),
 			Date:        visit.VisitDate.Format("02/01/2006 15:04"),
-			Status:      visit.Status,
+			Status:      string(visit.Status),
 			PatientName: visit.PatientName,
 			PatientId:   visit.PatientId,
 			NurseName:   visit.NurseName,
@@ -362,9 +514,14 @@ func (h *nurseService) GetNurseProfile(nurseId string) (userDTO.NurseProfileResp
 	var totalPatients int = 0
 
 	for _, visit := range schedule {
-		if visit.Status == "COMPLETED" {
+		if visit.Status == model.VisitStatusCompleted {
 			totalPatients += 1
-			totalEarnings += visit.VisitValue
+			// com a comissão gravada no repasse, conta só o que foi repassado ao enfermeiro
+			if visit.Commission != nil {
+				totalEarnings += float64(visit.Commission.NurseAmountInCents) / 100
+			} else {
+				totalEarnings += visit.VisitValue
+			}
 		}
 	}
 	// =======================================================
@@ -547,7 +704,7 @@ func (s nurseService) GetNurseVisitInfo(nurseId, visitId string) (dto.NurseVisit
 
 	visitDto := dto.VisitInfoDto{
 		ID:            visit.ID.Hex(),
-		Status:        visit.Status,
+		Status:        string(visit.Status),
 		PatientId:     visit.PatientId,
 		PatientName:   visit.PatientName,
 		Description:   visit.Description,
@@ -576,12 +733,11 @@ func (s *nurseService) VisitServiceConfirmation(nurseId, visitId, confirmationCo
 		return fmt.Errorf("Erro ao buscar id da visita.")
 	}
 
-	if visit.Status != "CONFIRMED" {
-		return fmt.Errorf("O atendimento aindão não foi confirmado pelo enfermeiro(a).")
-	}
+	actor := lifecycle.Actor{ID: nurseId, Role: lifecycle.RoleNurse}
 
-	if visit.NurseId != nurseId {
-		return fmt.Errorf("Essa visita é pertencente à outro enfermeiro.")
+	// valida antes de buscar o enfermeiro e a comissão; a conclusão em si é gravada adiante
+	if err := lifecycle.Validate(visit, model.VisitStatusCompleted, actor); err != nil {
+		return err
 	}
 
 	//validacao de so conseguir confirmar servico no dia da visita
@@ -609,30 +765,43 @@ func (s *nurseService) VisitServiceConfirmation(nurseId, visitId, confirmationCo
 		return fmt.Errorf("Pagamento original não encontrado para esta visita.")
 	}
 
-	// 3. Calcular valor do repasse (Ex: comissão da plataforma de 10%)
-	commissionRate := 0.10 // 10%
-	amountToTransfer := visit.VisitValue * (1.0 - commissionRate)
-	amountInCents := int64(math.Round(amountToTransfer * 100)) // valor em cents
-
-	transfer, err := s.stripeRepository.CreateTransfer(
-		amountInCents,
-		nurse.StripeAccountId, // Destino (conta do enfermeiro)
-		visit.PaymentIntentID, // Origem (pagamento do paciente)
-	)
+	// 3. Calcular valor do repasse com a regra de comissão vigente
+	visitCommission, err := s.commissionService.ForVisit(visit, nurse, time.Now())
 	if err != nil {
-		// Se o repasse falhar, NÃO confirme a visita.
-		return fmt.Errorf("Erro ao processar repasse para o enfermeiro: %w", err)
+		return fmt.Errorf("Erro ao calcular comissão da visita: %w", err)
 	}
 
-	visitUpdates := bson.M{
-		"status":      "COMPLETED",
-		"updated_at":  time.Now(),
-		"transfer_id": transfer.ID,
+	// A conclusão é gravada antes de mover dinheiro: a troca de status é condicional, então
+	// só uma confirmação repetida ou um cancelamento simultâneo chega à cobrança e ao repasse.
+	completedVisit, err := s.visitStateMachine.Transition(visit, model.VisitStatusCompleted, actor, "", bson.M{
+		"commission": visitCommission, // a taxa aplicada fica na visita mesmo que a regra mude depois
+	})
+	if err != nil {
+		return err
 	}
 
-	_, err = s.visitRepository.UpdateVisitFields(visitId, visitUpdates)
+	transferId, err := s.payNurse(completedVisit, nurse, visitCommission)
 	if err != nil {
-		return fmt.Errorf("Erro ao atualizar status da visita para completar serviço.")
+		// Se a cobrança ou o repasse falhar, a visita volta a confirmada para nova tentativa.
+		s.reopenVisit(completedVisit, err)
+		return err
+	}
+	s.visitHub.StopLocationSharing(visit)
+	s.recordTransfer(visit, transferId, visitCommission)
+
+	if updated, err := s.visitRepository.UpdateVisitFields(visitId, bson.M{
+		"transfer_id":    transferId,
+		"payment_status": model.PaymentStatusSucceeded,
+	}); err != nil {
+		// o repasse já foi feito; ele continua registrado no livro-razão
+		log.Printf("Erro ao registrar repasse %s na visita %s: %v", transferId, visitId, err)
+	} else {
+		completedVisit = updated
+	}
+
+	// a visita já foi concluída e paga; sem recibo agora, ele é emitido quando o paciente baixar
+	if _, err := s.receiptIssuer.Issue(completedVisit); err != nil {
+		log.Printf("Erro ao emitir recibo da visita %s: %v", visit.ID.Hex(), err)
 	}
 
 	//logica de liberar dinheiro retido para enfermerio
@@ -640,6 +809,95 @@ func (s *nurseService) VisitServiceConfirmation(nurseId, visitId, confirmationCo
 	return nil
 }
 
+// payNurse cobra o pagamento da visita concluída e repassa ao enfermeiro o valor da comissão.
+// A chave de idempotência do repasse vale para esta conclusão: repetir a chamada não paga o
+// enfermeiro duas vezes, e uma nova conclusão depois de uma falha não reaproveita a recusa
+// guardada pelo Stripe.
+func (s *nurseService) payNurse(visit model.Visit, nurse model.Nurse, visitCommission model.VisitCommission) (string, error) {
+	// visitas avulsas só têm o valor retido no cartão; a cobrança acontece aqui, antes do repasse
+	if err := s.capturePayment(visit); err != nil {
+		return "", err
+	}
+
+	idempotencyKey := fmt.Sprintf("transfer-visit-%s-%d", visit.ID.Hex(), visit.UpdatedAt.UnixMilli())
+	transfer, err := s.paymentGateway.CreateTransfer(
+		visitCommission.NurseAmountInCents, // valor em cents
+		nurse.StripeAccountId,              // Destino (conta do enfermeiro)
+		visit.PaymentIntentID,              // Origem (pagamento do paciente)
+		idempotencyKey,
+	)
+	if err != nil {
+		return "", fmt.Errorf("Erro ao processar repasse para o enfermeiro: %w", err)
+	}
+	return transfer.ID, nil
+}
+
+// reopenVisit devolve a visita concluída a confirmada quando a cobrança ou o repasse falham,
+// registrando o motivo no histórico. A volta não passa pela máquina de estados, que não
+// permite sair de uma visita concluída.
+func (s *nurseService) reopenVisit(visit model.Visit, cause error) {
+	change := model.VisitStatusChange{
+		From:      model.VisitStatusCompleted,
+		To:        model.VisitStatusConfirmed,
+		ActorRole: lifecycle.RoleSystem,
+		Reason:    "Falha no pagamento da conclusão: " + cause.Error(),
+		ChangedAt: time.Now(),
+	}
+	if _, err := s.visitRepository.UpdateVisitStatus(visit.ID.Hex(), change, nil); err != nil {
+		log.Printf("Erro ao reabrir visita %s após falha no pagamento: %v", visit.ID.Hex(), err)
+	}
+}
+
+// capturePayment captura o valor autorizado no PaymentIntent da visita. Pagamentos já
+// capturados (séries e autorizações capturadas antes de expirar) seguem direto para o repasse.
+func (s *nurseService) capturePayment(visit model.Visit) error {
+	pi, err := s.paymentGateway.GetPaymentIntent(visit.PaymentIntentID)
+	if err != nil {
+		return fmt.Errorf("Erro ao consultar pagamento da visita: %w", err)
+	}
+
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return nil
+	case stripe.PaymentIntentStatusRequiresCapture:
+		if _, err := s.paymentGateway.CapturePaymentIntent(pi.ID, 0); err != nil {
+			return fmt.Errorf("Erro ao cobrar pagamento da visita: %w", err)
+		}
+		return nil
+	case stripe.PaymentIntentStatusCanceled:
+		return fmt.Errorf("A autorização do pagamento desta visita expirou.")
+	}
+	return fmt.Errorf("O pagamento desta visita ainda não foi confirmado.")
+}
+
+// recordTransfer registra no livro-razão o repasse ao enfermeiro e a comissão retida pela
+// plataforma. O dinheiro já foi movimentado, então falhas aqui são apenas logadas.
+func (s *nurseService) recordTransfer(visit model.Visit, transferId string, visitCommission model.VisitCommission) {
+	visitId := visit.ID.Hex()
+	base := model.PaymentEntry{
+		Status:          model.PaymentEntryStatusSucceeded,
+		StripeID:        transferId,
+		PaymentIntentID: visit.PaymentIntentID,
+		VisitID:         visitId,
+		PatientID:       visit.PatientId,
+		NurseID:         visit.NurseId,
+	}
+
+	transferEntry := base
+	transferEntry.Type = model.PaymentEntryTransfer
+	transferEntry.AmountInCents = visitCommission.NurseAmountInCents
+	if _, err := s.paymentRepository.RecordEntry(transferEntry); err != nil {
+		log.Printf("Erro ao registrar repasse %s da visita %s: %v", transferId, visitId, err)
+	}
+
+	commissionEntry := base
+	commissionEntry.Type = model.PaymentEntryCommission
+	commissionEntry.AmountInCents = visitCommission.AmountInCents
+	if _, err := s.paymentRepository.RecordEntry(commissionEntry); err != nil {
+		log.Printf("Erro ao registrar comissão da visita %s: %v", visitId, err)
+	}
+}
+
 func (s *nurseService) TurnOfflineOnLogout(nurseId string) error {
 
 	nurseUpdates := bson.M{
@@ -659,22 +917,11 @@ func (s *nurseService) RejectVisit(nurseId, visitId string) error {
 		return fmt.Errorf("Erro ao buscar id da visita.")
 	}
 
-	if visit.Status != "PENDING" {
-		return fmt.Errorf("A visita não está pendente para ser rejeitada.")
-	}
-
-	if visit.NurseId != nurseId {
-		fmt.Errorf("Essa visita é pertencente à outro enfermeiro.")
-	}
-
-	visitUpdates := bson.M{
-		"status":     "REJECTED",
-		"updated_at": time.Now(),
-	}
+	actor := lifecycle.Actor{ID: nurseId, Role: lifecycle.RoleNurse}
 
-	_, err = s.visitRepository.UpdateVisitFields(visitId, visitUpdates)
+	_, err = s.visitStateMachine.Transition(visit, model.VisitStatusRejected, actor, "", nil)
 	if err != nil {
-		fmt.Errorf("Erro ao atualizar status da visita para rejeitada.")
+		return err
 	}
 
 	return nil
@@ -689,7 +936,7 @@ func (s *nurseService) AddReview(nurseId, visitId string, reviewDto dto.ReviewDT
 
 	//valida se a visita ja foi avaliada.
 
-	if visit.Status != "COMPLETED" {
+	if visit.Status != model.VisitStatusCompleted {
 		return fmt.Errorf("A visita ainda não foi completada. Portanto não é possível deixar uma avaliação.")
 	}
 
@@ -782,7 +1029,7 @@ func (s *nurseService) CreateStripeOnboardingLink(nurseId string) (dto.StripeOnb
 	stripeAccountId := nurse.StripeAccountId
 
 	if stripeAccountId == "" {
-		newAccountId, err := s.stripeRepository.CreateExpressAccount(nurse.Email)
+		newAccountId, err := s.paymentGateway.CreateExpressAccount(nurse.Email)
 		if err != nil {
 			return response, fmt.Errorf("Erro ao criar conta Stripe: %w", err)
 		}
@@ -797,7 +1044,7 @@ func (s *nurseService) CreateStripeOnboardingLink(nurseId string) (dto.StripeOnb
 
 	// 3. Crie o link de onboarding (onetime link)
 	// O 'stripeAccountId' aqui será o antigo (se já existia) ou o novo (se acabamos de criar)
-	linkUrl, err := s.stripeRepository.CreateAccountLink(stripeAccountId)
+	linkUrl, err := s.paymentGateway.CreateAccountLink(stripeAccountId)
 	if err != nil {
 		return response, fmt.Errorf("Erro ao criar link de onboarding Stripe: %w", err)
 	}
@@ -816,7 +1063,7 @@ func (s *nurseService) AddPrescription(nurseId string, visitId string, prescript
 	fmt.Println("====")
 	fmt.Println(visit.Status)
 	fmt.Println("====")
-	if visit.Status != "CONFIRMED" && visit.Status != "COMPLETED" {
+	if visit.Status != model.VisitStatusConfirmed && visit.Status != model.VisitStatusCompleted {
 		return dto.NurseVisitInfo{}, fmt.Errorf("A visita precisar estar confirmada ou completada para adicionar uma prescrição.")
 	}
 
@@ -859,7 +1106,7 @@ func (s *nurseService) AddPrescription(nurseId string, visitId string, prescript
 
 	visitDto := dto.VisitInfoDto{
 		ID:            visit.ID.Hex(),
-		Status:        visit.Status,
+		Status:        string(visit.Status),
 		PatientId:     visit.PatientId,
 		PatientName:   visit.PatientName,
 		Description:   visit.Description,
@@ -881,3 +1128,78 @@ func (s *nurseService) AddPrescription(nurseId string, visitId string, prescript
 	return visitInfo, nil
 
 }
+
+// maxEarningsRange limita o intervalo de um extrato de ganhos.
+const maxEarningsRange = 366 * 24 * time.Hour
+
+// GetEarnings monta o extrato de ganhos do enfermeiro no intervalo e agrupamento pedidos.
+// Visitas ainda sem repasse têm a comissão estimada com as regras vigentes.
+func (s *nurseService) GetEarnings(nurseId string, query dto.EarningsQueryDto) (earnings.Statement, error) {
+	period := query.Period
+	if period == "" {
+		period = earnings.PeriodMonth
+	}
+	if !earnings.ValidPeriod(period) {
+		return earnings.Statement{}, earnings.ErrInvalidPeriod
+	}
+
+	from, to, err := earningsRange(query.From, query.To, time.Now())
+	if err != nil {
+		return earnings.Statement{}, err
+	}
+
+	nurse, err := s.nurseRepository.FindNurseById(nurseId)
+	if err != nil {
+		return earnings.Statement{}, fmt.Errorf("Enfermeiro não encontrado.")
+	}
+
+	visits, err := s.visitRepository.FindEarningVisitsForNurse(nurseId, from, to)
+	if err != nil {
+		return earnings.Statement{}, fmt.Errorf("Erro ao buscar visitas do extrato.")
+	}
+
+	now := time.Now()
+	estimate := func(visit model.Visit) model.VisitCommission {
+		visitCommission, err := s.commissionService.ForVisit(visit, nurse, now)
+		if err != nil {
+			log.Printf("[Earnings] Erro ao estimar comissão da visita %s, usando a taxa padrão: %v", visit.ID.Hex(), err)
+			return commission.Apply(commission.Resolve(nil, visit, nurse, now), visit.VisitValue, now)
+		}
+		return visitCommission
+	}
+
+	return earnings.Build(nurse, visits, period, from, to, scheduling.Location(), estimate)
+}
+
+// earningsRange converte as datas do extrato (AAAA-MM-DD, inclusivas) em [from, to) no fuso da
+// plataforma. Sem datas, o extrato cobre o mês de now; só com from, o mês a partir de from.
+func earningsRange(fromText, toText string, now time.Time) (time.Time, time.Time, error) {
+	location := scheduling.Location()
+	today := now.In(location)
+
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, location)
+	if fromText != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromText, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Data inicial inválida. Use o formato AAAA-MM-DD.")
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(0, 1, 0)
+	if toText != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toText, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Data final inválida. Use o formato AAAA-MM-DD.")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("A data final deve ser igual ou posterior à inicial.")
+	}
+	if to.Sub(from) > maxEarningsRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("O extrato pode cobrir no máximo um ano.")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,97 @@
+package payment
+
+import (
+	"log"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"os"
+	"strconv"
+	"time"
+)
+
+// AuthorizationConfig controla quanto tempo o valor de uma visita pode ficar só retido no
+// cartão antes de ser capturado e de quanto em quanto tempo a varredura roda. O Stripe cancela
+// autorizações de cartão após 7 dias, então CaptureAfter deve ficar abaixo disso.
+type AuthorizationConfig struct {
+	CaptureAfter time.Duration
+	Interval     time.Duration
+}
+
+// LoadAuthorizationConfig lê PAYMENT_AUTHORIZATION_CAPTURE_HOURS e
+// PAYMENT_AUTHORIZATION_SWEEP_INTERVAL_MINUTES, com valores padrão para as que não estiverem definidas.
+func LoadAuthorizationConfig() AuthorizationConfig {
+	config := AuthorizationConfig{
+		CaptureAfter: 6 * 24 * time.Hour,
+		Interval:     time.Hour,
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("PAYMENT_AUTHORIZATION_CAPTURE_HOURS")); err == nil && hours > 0 {
+		config.CaptureAfter = time.Duration(hours) * time.Hour
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("PAYMENT_AUTHORIZATION_SWEEP_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		config.Interval = time.Duration(minutes) * time.Minute
+	}
+
+	return config
+}
+
+// AuthorizationSweeper captura periodicamente os pagamentos de visitas marcadas para muito
+// depois da reserva, antes que a autorização no cartão expire. A partir daí a visita segue
+// como um pagamento já cobrado: a conclusão só faz o repasse e o cancelamento vira estorno.
+type AuthorizationSweeper interface {
+	Run()
+	Sweep(now time.Time) int
+}
+
+type authorizationSweeper struct {
+	visitRepository repository.VisitRepository
+	paymentGateway  repository.PaymentGateway
+	config          AuthorizationConfig
+}
+
+func NewAuthorizationSweeper(visitRepository repository.VisitRepository, paymentGateway repository.PaymentGateway, config AuthorizationConfig) AuthorizationSweeper {
+	return &authorizationSweeper{
+		visitRepository: visitRepository,
+		paymentGateway:  paymentGateway,
+		config:          config,
+	}
+}
+
+// Run executa a varredura a cada AuthorizationConfig.Interval. Deve ser iniciado em uma goroutine própria.
+func (s *authorizationSweeper) Run() {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if captured := s.Sweep(now); captured > 0 {
+			log.Printf("[Payment] %d pagamento(s) autorizado(s) capturado(s) antes de expirar", captured)
+		}
+	}
+}
+
+// Sweep captura os pagamentos autorizados há mais de CaptureAfter e retorna quantos foram capturados.
+func (s *authorizationSweeper) Sweep(now time.Time) int {
+	visits, err := s.visitRepository.FindAuthorizedVisitsCreatedBefore(now.Add(-s.config.CaptureAfter))
+	if err != nil {
+		log.Printf("[Payment] Erro ao buscar visitas com pagamento autorizado: %v", err)
+		return 0
+	}
+
+	captured := 0
+	for _, visit := range visits {
+		if _, err := s.paymentGateway.CapturePaymentIntent(visit.PaymentIntentID, 0); err != nil {
+			log.Printf("[Payment] Erro ao capturar pagamento %s da visita %s: %v", visit.PaymentIntentID, visit.ID.Hex(), err)
+			continue
+		}
+
+		if _, err := s.visitRepository.UpdateVisitsByPaymentIntentId(visit.PaymentIntentID, map[string]interface{}{
+			"payment_status": model.PaymentStatusSucceeded,
+		}); err != nil {
+			// o webhook payment_intent.succeeded também atualiza a visita
+			log.Printf("[Payment] Erro ao registrar captura do pagamento %s: %v", visit.PaymentIntentID, err)
+		}
+		captured++
+	}
+
+	return captured
+}
@@ -0,0 +1,53 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthorizationSweeper_Sweep(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	config := AuthorizationConfig{CaptureAfter: 6 * 24 * time.Hour, Interval: time.Hour}
+
+	t.Run("Sucesso_Captura_Antes_De_Expirar", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		gateway := repmocks.NewMockPaymentGateway(ctrl)
+		s := NewAuthorizationSweeper(visitRepo, gateway, config)
+
+		ok := model.Visit{ID: primitive.NewObjectID(), PaymentIntentID: "pi_1"}
+		failing := model.Visit{ID: primitive.NewObjectID(), PaymentIntentID: "pi_2"}
+
+		visitRepo.EXPECT().FindAuthorizedVisitsCreatedBefore(now.Add(-6*24*time.Hour)).Return([]model.Visit{ok, failing}, nil)
+		gateway.EXPECT().CapturePaymentIntent("pi_1", int64(0)).Return(&stripe.PaymentIntent{ID: "pi_1"}, nil)
+		visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_1", map[string]interface{}{
+			"payment_status": model.PaymentStatusSucceeded,
+		}).Return(int64(1), nil)
+		gateway.EXPECT().CapturePaymentIntent("pi_2", int64(0)).Return(nil, errors.New("autorização expirada"))
+
+		assert.Equal(t, 1, s.Sweep(now))
+	})
+
+	t.Run("Erro_Busca_Visitas", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		s := NewAuthorizationSweeper(visitRepo, repmocks.NewMockPaymentGateway(ctrl), config)
+
+		visitRepo.EXPECT().FindAuthorizedVisitsCreatedBefore(gomock.Any()).Return(nil, errors.New("mongo indisponível"))
+
+		assert.Equal(t, 0, s.Sweep(now))
+	})
+}
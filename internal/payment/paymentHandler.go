@@ -1,31 +1,36 @@
 package payment
 
 import (
+	"errors"
+	"io"
 	"medassist/internal/model"
 	"medassist/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PaymentHandler struct {
 	paymentService PaymentService
+	webhookService WebhookService
 }
 
-func NewPaymentHandler(paymentService PaymentService) *PaymentHandler {
-	return &PaymentHandler{paymentService: paymentService}
+func NewPaymentHandler(paymentService PaymentService, webhookService WebhookService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService, webhookService: webhookService}
 }
 
 // @Summary Cria uma Intenção de Pagamento (Stripe)
-// @Description Gera um 'client_secret' do Stripe para o paciente logado poder realizar um pagamento (ex: adicionar fundos à carteira). Requer autenticação de Paciente.
+// @Description Gera um 'client_secret' do Stripe para o paciente logado pagar a visita descrita no corpo. O valor é calculado no servidor a partir do preço do enfermeiro e dos adicionais (visita imediata, horário noturno). Com payment_method "pix" a resposta traz o QR code e o código "copia e cola", válidos até pix.expires_at; a visita só pode ser criada depois que o PIX for pago. Com payment_method_id o pagamento é confirmado na hora com um cartão salvo do paciente; se requires_action vier true, o banco pediu autenticação e o frontend a conclui com o client secret antes de criar a visita. Requer autenticação de Paciente.
 // @Tags Payment
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param payload body model.PaymentIntentRequest true "Valor do pagamento a ser criado"
+// @Param payload body model.PaymentIntentRequest true "Visita a ser paga"
 // @Success 200 {object} utils.SuccessPaymentIntentResponse "Intenção de pagamento criada com sucesso"
-// @Failure 400 {object} utils.ErrorResponse "Requisição inválida"
+// @Failure 400 {object} utils.ErrorResponse "Requisição inválida, cartão salvo não encontrado ou visita que não pode ser cobrada"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 402 {object} utils.ErrorResponse "Cartão salvo recusado"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Paciente)"
 // @Failure 500 {object} utils.ErrorResponse "Não foi possível criar a intenção de pagamento"
 // @Router /payment/create-intent [post]
@@ -42,15 +47,230 @@ func (h *PaymentHandler) CreatePaymentIntent(c *gin.Context) {
     //    Isso é FUNDAMENTAL para vincular o pagamento ao paciente correto.
     patientID := utils.GetUserId(c)
 
-    // 3. Chamar o serviço de pagamento
-    clientSecret, err := h.paymentService.CreatePaymentIntent(patientID, req.Value)
+    // 3. Chamar o serviço de pagamento (o valor é calculado no servidor)
+    response, err := h.paymentService.CreatePaymentIntent(patientID, req)
     if err != nil {
+        if errors.Is(err, ErrInvalidPaymentRequest) || errors.Is(err, ErrPaymentMethodNotFound) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        if errors.Is(err, ErrCardDeclined) {
+            c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Não foi possível criar a intenção de pagamento"})
         return
     }
 
-    // 4. Retornar o Client Secret para o frontend
-    c.JSON(http.StatusOK, model.PaymentIntentResponse{
-        ClientSecret: clientSecret,
-    })
-}
\ No newline at end of file
+    // 4. Retornar o Client Secret e o valor calculado para o frontend
+    c.JSON(http.StatusOK, response)
+}
+
+// @Summary Webhook do Stripe
+// @Description Recebe os eventos do Stripe (pagamentos, estornos, repasses e contas Express). O corpo precisa estar assinado no header Stripe-Signature com o segredo do endpoint. Eventos repetidos são ignorados.
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param Stripe-Signature header string true "Assinatura do evento gerada pelo Stripe"
+// @Success 200 {object} utils.SuccessResponseNoData "Evento processado"
+// @Failure 400 {object} utils.ErrorResponse "Payload ou assinatura inválidos"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao processar o evento (o Stripe tentará novamente)"
+// @Router /payment/webhook [post]
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.SendErrorResponse(c, "Não foi possível ler o corpo da requisição.", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookService.HandleEvent(payload, c.GetHeader("Stripe-Signature")); err != nil {
+		if errors.Is(err, ErrInvalidWebhookSignature) {
+			utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Evento processado.", nil)
+}
+
+// @Summary Lista o livro-razão de pagamentos
+// @Description Lista as movimentações registradas (intenções, cobranças, repasses, comissões e estornos), das mais recentes para as mais antigas. Requer autenticação de Administrador.
+// @Tags Payment
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "Tipo do lançamento (INTENT, CAPTURE, TRANSFER, COMMISSION, REFUND, DISCOUNT, TIP, DISPUTE)"
+// @Param status query string false "Situação do lançamento (PENDING, SUCCEEDED, FAILED, REVERSED)"
+// @Param visit_id query string false "ID da visita"
+// @Param payment_intent_id query string false "ID do PaymentIntent no Stripe"
+// @Param patient_id query string false "ID do paciente"
+// @Param nurse_id query string false "ID do enfermeiro"
+// @Param limit query int false "Quantidade máxima de lançamentos (padrão 100, máximo 500)"
+// @Success 200 {object} utils.SuccessResponseNoData "Lançamentos encontrados"
+// @Failure 400 {object} utils.ErrorResponse "Parâmetro inválido"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao buscar pagamentos"
+// @Router /payment/ledger [get]
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	filter := model.PaymentEntryFilter{
+		Type:            c.Query("type"),
+		Status:          c.Query("status"),
+		VisitID:         c.Query("visit_id"),
+		PaymentIntentID: c.Query("payment_intent_id"),
+		PatientID:       c.Query("patient_id"),
+		NurseID:         c.Query("nurse_id"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || value < 0 {
+			utils.SendErrorResponse(c, "limit inválido.", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = value
+	}
+
+	entries, err := h.paymentService.ListPayments(filter)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Lançamentos encontrados.", entries)
+}
+
+// @Summary Pagamentos de uma visita
+// @Description Retorna as movimentações de dinheiro da visita. Disponível apenas para o paciente e o enfermeiro da visita.
+// @Tags Payment
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da visita"
+// @Success 200 {object} utils.SuccessResponseNoData "Lançamentos da visita"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado"
+// @Failure 403 {object} utils.ErrorResponse "O usuário não participa da visita"
+// @Failure 404 {object} utils.ErrorResponse "Visita não encontrada"
+// @Router /payment/visit/{id} [get]
+func (h *PaymentHandler) GetVisitPayments(c *gin.Context) {
+	userId := utils.GetUserId(c)
+	visitId := c.Param("id")
+
+	entries, err := h.paymentService.GetVisitPayments(userId, visitId)
+	if err != nil {
+		if errors.Is(err, ErrNotVisitParty) {
+			utils.SendErrorResponse(c, err.Error(), http.StatusForbidden)
+			return
+		}
+		utils.SendErrorResponse(c, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Lançamentos da visita.", entries)
+}
+
+// @Summary Dá gorjeta ao enfermeiro
+// @Description Cobra a gorjeta no cartão usado para pagar a visita, sem nova confirmação do paciente, e repassa o valor inteiro ao enfermeiro. Disponível uma vez por visita, depois que ela for concluída. Requer autenticação de Paciente.
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da visita"
+// @Param payload body model.TipRequest true "Valor da gorjeta em reais"
+// @Success 200 {object} utils.SuccessResponseNoData "Gorjeta enviada"
+// @Failure 400 {object} utils.ErrorResponse "Valor inválido ou visita que não aceita gorjeta"
+// @Failure 402 {object} utils.ErrorResponse "Cartão recusado"
+// @Failure 403 {object} utils.ErrorResponse "A visita é de outro paciente"
+// @Failure 404 {object} utils.ErrorResponse "Visita não encontrada"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao processar a gorjeta"
+// @Router /payment/visit/{id}/tip [post]
+func (h *PaymentHandler) TipVisit(c *gin.Context) {
+	var req model.TipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, "Informe o valor da gorjeta.", http.StatusBadRequest)
+		return
+	}
+
+	tip, err := h.paymentService.TipVisit(utils.GetUserId(c), c.Param("id"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTip):
+			utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrTipDeclined):
+			utils.SendErrorResponse(c, err.Error(), http.StatusPaymentRequired)
+		case errors.Is(err, ErrNotVisitParty):
+			utils.SendErrorResponse(c, err.Error(), http.StatusForbidden)
+		case errors.Is(err, ErrVisitNotFound):
+			utils.SendErrorResponse(c, err.Error(), http.StatusNotFound)
+		default:
+			utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Gorjeta enviada.", tip)
+}
+
+// @Summary Lista os cartões salvos
+// @Description Lista os cartões salvos nos pagamentos anteriores do paciente, indicando o padrão. O id do cartão pode ser enviado em payment_method_id ao criar a intenção de pagamento. Requer autenticação de Paciente.
+// @Tags Payment
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.SuccessResponseNoData "Cartões encontrados"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao buscar cartões"
+// @Router /payment/methods [get]
+func (h *PaymentHandler) ListPaymentMethods(c *gin.Context) {
+	methods, err := h.paymentService.ListPaymentMethods(utils.GetUserId(c))
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cartões encontrados.", methods)
+}
+
+// @Summary Define o cartão padrão
+// @Description Marca um cartão salvo do paciente como o padrão. Requer autenticação de Paciente.
+// @Tags Payment
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID do cartão (pm_...)"
+// @Success 200 {object} utils.SuccessResponseNoData "Cartão padrão definido"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 404 {object} utils.ErrorResponse "Cartão não encontrado"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao definir cartão padrão"
+// @Router /payment/methods/{id}/default [put]
+func (h *PaymentHandler) SetDefaultPaymentMethod(c *gin.Context) {
+	if err := h.paymentService.SetDefaultPaymentMethod(utils.GetUserId(c), c.Param("id")); err != nil {
+		utils.SendErrorResponse(c, err.Error(), paymentMethodStatusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cartão padrão definido.", nil)
+}
+
+// @Summary Remove um cartão salvo
+// @Description Remove o cartão do cliente do paciente no Stripe. Pagamentos já autorizados no cartão não são afetados. Requer autenticação de Paciente.
+// @Tags Payment
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID do cartão (pm_...)"
+// @Success 200 {object} utils.SuccessResponseNoData "Cartão removido"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Failure 404 {object} utils.ErrorResponse "Cartão não encontrado"
+// @Failure 500 {object} utils.ErrorResponse "Erro ao remover cartão"
+// @Router /payment/methods/{id} [delete]
+func (h *PaymentHandler) DetachPaymentMethod(c *gin.Context) {
+	if err := h.paymentService.DetachPaymentMethod(utils.GetUserId(c), c.Param("id")); err != nil {
+		utils.SendErrorResponse(c, err.Error(), paymentMethodStatusCode(err))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Cartão removido.", nil)
+}
+
+func paymentMethodStatusCode(err error) int {
+	if errors.Is(err, ErrPaymentMethodNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
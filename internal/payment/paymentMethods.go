@@ -0,0 +1,129 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"medassist/internal/model"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+// ErrPaymentMethodNotFound indica que o cartão não existe ou não está salvo no cliente do paciente.
+var ErrPaymentMethodNotFound = errors.New("Cartão não encontrado.")
+
+// ErrCardDeclined indica que o cartão salvo escolhido pelo paciente recusou o pagamento da visita.
+var ErrCardDeclined = errors.New("O cartão salvo recusou o pagamento. Escolha outro cartão ou informe um novo.")
+
+// ListPaymentMethods lista os cartões salvos nos pagamentos anteriores do paciente
+// (SetupFutureUsage off_session), marcando o padrão. Sem cliente no Stripe não há cartões.
+func (s *paymentService) ListPaymentMethods(patientId string) ([]model.SavedPaymentMethod, error) {
+	patient, err := s.userRepository.FindUserById(patientId)
+	if err != nil {
+		return nil, fmt.Errorf("paciente não encontrado: %w", err)
+	}
+	methods := []model.SavedPaymentMethod{}
+	if patient.GatewayCustomerID == "" {
+		return methods, nil
+	}
+
+	cards, err := s.paymentGateway.ListPaymentMethods(patient.GatewayCustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar cartões salvos: %w", err)
+	}
+	defaultId, err := s.paymentGateway.GetDefaultPaymentMethod(patient.GatewayCustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar cartão padrão: %w", err)
+	}
+
+	for _, card := range cards {
+		if card.Card == nil {
+			continue
+		}
+		methods = append(methods, model.SavedPaymentMethod{
+			ID:       card.ID,
+			Brand:    string(card.Card.Brand),
+			Last4:    card.Card.Last4,
+			ExpMonth: card.Card.ExpMonth,
+			ExpYear:  card.Card.ExpYear,
+			Default:  card.ID == defaultId,
+		})
+	}
+	return methods, nil
+}
+
+// SetDefaultPaymentMethod marca o cartão salvo como o padrão do paciente.
+func (s *paymentService) SetDefaultPaymentMethod(patientId, paymentMethodId string) error {
+	customerId, err := s.paymentMethodCustomer(patientId, paymentMethodId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.paymentGateway.SetDefaultPaymentMethod(customerId, paymentMethodId); err != nil {
+		return fmt.Errorf("Erro ao definir cartão padrão: %w", err)
+	}
+	return nil
+}
+
+// DetachPaymentMethod remove o cartão salvo do paciente. Pagamentos já autorizados nele não
+// são afetados, mas ele deixa de poder ser usado em novas visitas e gorjetas.
+func (s *paymentService) DetachPaymentMethod(patientId, paymentMethodId string) error {
+	if _, err := s.paymentMethodCustomer(patientId, paymentMethodId); err != nil {
+		return err
+	}
+
+	if err := s.paymentGateway.DetachPaymentMethod(paymentMethodId); err != nil {
+		return fmt.Errorf("Erro ao remover cartão: %w", err)
+	}
+	return nil
+}
+
+// paymentMethodCustomer retorna o cliente do paciente no Stripe se o cartão estiver salvo nele.
+// Cartões de outros pacientes são tratados como inexistentes.
+func (s *paymentService) paymentMethodCustomer(patientId, paymentMethodId string) (string, error) {
+	patient, err := s.userRepository.FindUserById(patientId)
+	if err != nil {
+		return "", fmt.Errorf("paciente não encontrado: %w", err)
+	}
+	if err := s.checkPaymentMethod(patient.GatewayCustomerID, paymentMethodId); err != nil {
+		return "", err
+	}
+	return patient.GatewayCustomerID, nil
+}
+
+func (s *paymentService) checkPaymentMethod(customerId, paymentMethodId string) error {
+	if customerId == "" || paymentMethodId == "" {
+		return ErrPaymentMethodNotFound
+	}
+
+	card, err := s.paymentGateway.GetPaymentMethod(paymentMethodId)
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+		return ErrPaymentMethodNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("Erro ao buscar cartão: %w", err)
+	}
+	if card.Customer == nil || card.Customer.ID != customerId {
+		return ErrPaymentMethodNotFound
+	}
+	return nil
+}
+
+// createSavedCardPaymentIntent cria e confirma o PaymentIntent com um cartão salvo. Se o banco
+// pedir autenticação, o frontend conclui a confirmação com o client secret antes de criar a
+// visita; caso contrário o pagamento já está autorizado.
+func (s *paymentService) createSavedCardPaymentIntent(customerID, paymentMethodId string, amountInCents int64, singleVisit bool, metadata map[string]string, response *model.PaymentIntentResponse) error {
+	pi, err := s.paymentGateway.CreateSavedCardPaymentIntent(customerID, paymentMethodId, amountInCents, singleVisit, metadata)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard {
+			return ErrCardDeclined
+		}
+		return err
+	}
+
+	response.ClientSecret = pi.ClientSecret
+	response.PaymentIntentID = pi.ID
+	response.RequiresAction = pi.Status == stripe.PaymentIntentStatusRequiresAction
+	return nil
+}
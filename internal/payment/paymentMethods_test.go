@@ -0,0 +1,176 @@
+package payment
+
+import (
+	"testing"
+
+	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"medassist/internal/repository/fakes"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentService_PaymentMethods(t *testing.T) {
+	type fixture struct {
+		service  *paymentService
+		gateway  *fakes.PaymentGateway
+		userRepo *repmocks.MockUserRepository
+		patient  model.User
+		cards    []string
+	}
+
+	// paciente com dois cartões salvos em pagamentos anteriores
+	setup := func(t *testing.T) fixture {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		f := fixture{gateway: fakes.NewPaymentGateway(), userRepo: repmocks.NewMockUserRepository(ctrl)}
+		f.service = NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), f.userRepo, repmocks.NewMockNurseRepository(ctrl), repmocks.NewMockVisitRepository(ctrl), f.gateway, nil).(*paymentService)
+
+		customerId, _ := f.gateway.CreateCustomer("patient-1", model.User{})
+		f.patient = model.User{GatewayCustomerID: customerId}
+		for i := 0; i < 2; i++ {
+			pi, _ := f.gateway.CreatePaymentIntent(customerId, 15000, true, nil)
+			paid, _ := f.gateway.Pay(pi.ID)
+			f.cards = append(f.cards, paid.PaymentMethod.ID)
+		}
+		f.userRepo.EXPECT().FindUserById("patient-1").Return(f.patient, nil).AnyTimes()
+		return f
+	}
+
+	t.Run("Sucesso_ListaSemClienteNoStripe", func(t *testing.T) {
+		f := setup(t)
+		f.userRepo.EXPECT().FindUserById("patient-2").Return(model.User{}, nil)
+
+		methods, err := f.service.ListPaymentMethods("patient-2")
+
+		assert.NoError(t, err)
+		assert.Empty(t, methods)
+	})
+
+	t.Run("Sucesso_ListaMarcandoPadrao", func(t *testing.T) {
+		f := setup(t)
+
+		assert.NoError(t, f.service.SetDefaultPaymentMethod("patient-1", f.cards[1]))
+		methods, err := f.service.ListPaymentMethods("patient-1")
+
+		assert.NoError(t, err)
+		assert.Len(t, methods, 2)
+		assert.Equal(t, model.SavedPaymentMethod{ID: f.cards[0], Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2030}, methods[0])
+		assert.True(t, methods[1].Default)
+	})
+
+	t.Run("Erro_CartaoDeOutroPaciente", func(t *testing.T) {
+		f := setup(t)
+		otherCustomer, _ := f.gateway.CreateCustomer("patient-2", model.User{})
+		f.userRepo.EXPECT().FindUserById("patient-2").Return(model.User{GatewayCustomerID: otherCustomer}, nil).Times(2)
+
+		assert.ErrorIs(t, f.service.SetDefaultPaymentMethod("patient-2", f.cards[0]), ErrPaymentMethodNotFound)
+		assert.ErrorIs(t, f.service.DetachPaymentMethod("patient-2", f.cards[0]), ErrPaymentMethodNotFound)
+		assert.ErrorIs(t, f.service.DetachPaymentMethod("patient-1", "pm_404"), ErrPaymentMethodNotFound)
+
+		methods, _ := f.service.ListPaymentMethods("patient-1")
+		assert.Len(t, methods, 2)
+	})
+
+	t.Run("Sucesso_RemoveCartaoPadrao", func(t *testing.T) {
+		f := setup(t)
+		assert.NoError(t, f.service.SetDefaultPaymentMethod("patient-1", f.cards[0]))
+
+		err := f.service.DetachPaymentMethod("patient-1", f.cards[0])
+
+		assert.NoError(t, err)
+		methods, _ := f.service.ListPaymentMethods("patient-1")
+		assert.Len(t, methods, 1)
+		assert.Equal(t, f.cards[1], methods[0].ID)
+		assert.False(t, methods[0].Default)
+	})
+}
+
+func TestPaymentService_CreatePaymentIntent_SavedCard(t *testing.T) {
+	type fixture struct {
+		service *paymentService
+		gateway *fakes.PaymentGateway
+		card    string
+	}
+
+	setup := func(t *testing.T) fixture {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		f := fixture{gateway: fakes.NewPaymentGateway()}
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		f.service = NewPaymentService(paymentRepo, userRepo, nurseRepo, repmocks.NewMockVisitRepository(ctrl), f.gateway, nil).(*paymentService)
+		f.service.pricingPolicy = pricing.Policy{}
+
+		customerId, _ := f.gateway.CreateCustomer("patient-1", model.User{})
+		pi, _ := f.gateway.CreatePaymentIntent(customerId, 15000, true, nil)
+		paid, _ := f.gateway.Pay(pi.ID)
+		f.card = paid.PaymentMethod.ID
+
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil).AnyTimes()
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 200, Online: true}, nil).AnyTimes()
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{GatewayCustomerID: customerId}, nil).AnyTimes()
+		return f
+	}
+
+	request := func(paymentMethodId string) model.PaymentIntentRequest {
+		return model.PaymentIntentRequest{NurseId: "nurse-1", RequestType: "IMMEDIATE", PaymentMethodId: paymentMethodId}
+	}
+
+	t.Run("Erro_CartaoSalvoComPix", func(t *testing.T) {
+		f := setup(t)
+		pixRequest := request(f.card)
+		pixRequest.PaymentMethod = model.PaymentMethodPix
+
+		_, err := f.service.CreatePaymentIntent("patient-1", pixRequest)
+
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+
+	t.Run("Erro_CartaoNaoEncontrado", func(t *testing.T) {
+		f := setup(t)
+
+		_, err := f.service.CreatePaymentIntent("patient-1", request("pm_404"))
+
+		assert.ErrorIs(t, err, ErrPaymentMethodNotFound)
+	})
+
+	t.Run("Erro_CartaoRecusado", func(t *testing.T) {
+		f := setup(t)
+		f.gateway.Decline(f.card, stripe.ErrorCodeCardDeclined)
+
+		_, err := f.service.CreatePaymentIntent("patient-1", request(f.card))
+
+		assert.ErrorIs(t, err, ErrCardDeclined)
+	})
+
+	t.Run("Sucesso_RetemValorSemNovaConfirmacao", func(t *testing.T) {
+		f := setup(t)
+
+		response, err := f.service.CreatePaymentIntent("patient-1", request(f.card))
+
+		assert.NoError(t, err)
+		assert.False(t, response.RequiresAction)
+		pi, _ := f.gateway.GetPaymentIntent(response.PaymentIntentID)
+		assert.Equal(t, stripe.PaymentIntentStatusRequiresCapture, pi.Status)
+		assert.Equal(t, f.card, pi.PaymentMethod.ID)
+		assert.Equal(t, int64(20000), pi.AmountCapturable)
+	})
+
+	t.Run("Sucesso_BancoPedeAutenticacao", func(t *testing.T) {
+		f := setup(t)
+		f.gateway.Decline(f.card, stripe.ErrorCodeAuthenticationRequired)
+
+		response, err := f.service.CreatePaymentIntent("patient-1", request(f.card))
+
+		assert.NoError(t, err)
+		assert.True(t, response.RequiresAction)
+		assert.NotEmpty(t, response.ClientSecret)
+	})
+}
@@ -1,40 +1,89 @@
 package payment
 
 import (
+	"errors"
 	"fmt"
-	"math"
+	"log"
+	"medassist/internal/coupon"
+	"medassist/internal/model"
+	"medassist/internal/pricing"
 	"medassist/internal/repository"
+	"medassist/internal/scheduling"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/stripe/stripe-go/v72"
-	"github.com/stripe/stripe-go/v72/customer"
-	"github.com/stripe/stripe-go/v72/paymentintent"
+	"github.com/stripe/stripe-go/v76"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// ErrNotVisitParty indica que o usuário não é o paciente nem o enfermeiro da visita.
+var ErrNotVisitParty = errors.New("Você não tem acesso aos pagamentos desta visita.")
+
+// ErrInvalidPaymentRequest indica que a visita descrita no pedido de pagamento não pode ser cobrada.
+var ErrInvalidPaymentRequest = errors.New("Pedido de pagamento inválido.")
+
 // Interface para o serviço
 type PaymentService interface {
-	CreatePaymentIntent(patientID string, value float64) (string, error)
+	CreatePaymentIntent(patientID string, request model.PaymentIntentRequest) (model.PaymentIntentResponse, error)
+	ListPayments(filter model.PaymentEntryFilter) ([]model.PaymentEntry, error)
+	GetVisitPayments(userId string, visitId string) ([]model.PaymentEntry, error)
+	TipVisit(patientId, visitId string, request model.TipRequest) (model.VisitTip, error)
+	ListPaymentMethods(patientId string) ([]model.SavedPaymentMethod, error)
+	SetDefaultPaymentMethod(patientId, paymentMethodId string) error
+	DetachPaymentMethod(patientId, paymentMethodId string) error
 }
 
 type paymentService struct {
 	paymentRepository repository.PaymentRepository
 	userRepository    repository.UserRepository
+	nurseRepository   repository.NurseRepository
 	visitRepository   repository.VisitRepository
+	paymentGateway    repository.PaymentGateway
+	couponService     coupon.CouponService
+	pricingPolicy     pricing.Policy
+	pixExpiresAfter   time.Duration
+}
+
+func NewPaymentService(paymentRepository repository.PaymentRepository, userRepository repository.UserRepository, nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository, paymentGateway repository.PaymentGateway, couponService coupon.CouponService) PaymentService {
+	return &paymentService{paymentRepository: paymentRepository, userRepository: userRepository, nurseRepository: nurseRepository, visitRepository: visitRepository, paymentGateway: paymentGateway, couponService: couponService, pricingPolicy: pricing.LoadPolicy(), pixExpiresAfter: loadPixExpiresAfter()}
 }
 
-func NewPaymentService(paymentRepository repository.PaymentRepository, userRepository repository.UserRepository, visitRepository repository.VisitRepository) PaymentService {
-	// Configura a chave secreta do Stripe (NUNCA exponha no código)
-	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
-	return &paymentService{paymentRepository: paymentRepository, userRepository: userRepository, visitRepository: visitRepository}
+// defaultPixExpiresAfter é o prazo para o paciente pagar o PIX antes de o QR code expirar.
+const defaultPixExpiresAfter = 30 * time.Minute
+
+// loadPixExpiresAfter lê PIX_EXPIRES_AFTER_MINUTES (entre 1 minuto e 14 dias, limite do Stripe).
+func loadPixExpiresAfter() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("PIX_EXPIRES_AFTER_MINUTES"))
+	if err != nil || minutes <= 0 || minutes > 14*24*60 {
+		return defaultPixExpiresAfter
+	}
+	return time.Duration(minutes) * time.Minute
 }
 
-func (s *paymentService) CreatePaymentIntent(patientID string, value float64) (string, error) {
+func (s *paymentService) CreatePaymentIntent(patientID string, request model.PaymentIntentRequest) (model.PaymentIntentResponse, error) {
+
+	paymentMethod := request.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = model.PaymentMethodCard
+	}
+	if paymentMethod != model.PaymentMethodCard && paymentMethod != model.PaymentMethodPix {
+		return model.PaymentIntentResponse{}, invalidPaymentRequest("Forma de pagamento inválida.")
+	}
+	if request.PaymentMethodId != "" && paymentMethod != model.PaymentMethodCard {
+		return model.PaymentIntentResponse{}, invalidPaymentRequest("Cartão salvo só pode ser usado no pagamento com cartão.")
+	}
+
+	// o valor é sempre calculado aqui, nunca recebido do frontend
+	quote, nurse, err := s.quote(request, time.Now())
+	if err != nil {
+		return model.PaymentIntentResponse{}, err
+	}
 
 	// obter o cliente no stripe
 	patient, err := s.userRepository.FindUserById(patientID)
 	if err != nil {
-		return "", fmt.Errorf("paciente não encontrado: %w", err)
+		return model.PaymentIntentResponse{}, fmt.Errorf("paciente não encontrado: %w", err)
 	}
 
 	stripeCustomerID := patient.GatewayCustomerID
@@ -42,60 +91,221 @@ func (s *paymentService) CreatePaymentIntent(patientID string, value float64) (s
 	//cria o cliente no stripe
 
 	if stripeCustomerID == "" {
-		customerParams := &stripe.CustomerParams{
-			Name:  stripe.String(patient.Name),
-			Email: stripe.String(patient.Email),
-			Phone: stripe.String(patient.Phone),
-			Address: &stripe.AddressParams{
-				Line1:      stripe.String(patient.Address), 
-				City:       stripe.String(patient.City),	
-				State:      stripe.String(patient.UF),
-				PostalCode: stripe.String(patient.CEP),
-				Country:    stripe.String("Brasil"),
-			}}
-
-		customerParams.AddMetadata("app_patient_id", patientID)
-
-		newCustomer, err := customer.New(customerParams)
+		stripeCustomerID, err = s.paymentGateway.CreateCustomer(patientID, patient)
 		if err != nil {
-			return "", fmt.Errorf("erro ao criar cliente no Stripe: %w", err)
+			return model.PaymentIntentResponse{}, err
 		}
 
 		//salva  o costumer id no meu banco
 
-		stripeCustomerID = newCustomer.ID
-
 		updates := bson.M{
 			"gateway_customer_id": stripeCustomerID,
 		}
 
 		if _, err := s.userRepository.UpdateUserFields(patientID, updates); err != nil {
-			return "", fmt.Errorf("erro ao salvar o ID do cliente Stripe no banco: %w", err)
+			return model.PaymentIntentResponse{}, fmt.Errorf("erro ao salvar o ID do cliente Stripe no banco: %w", err)
+		}
+	}
+
+	if request.PaymentMethodId != "" {
+		if err := s.checkPaymentMethod(stripeCustomerID, request.PaymentMethodId); err != nil {
+			return model.PaymentIntentResponse{}, err
+		}
+	}
+
+	amountInCents := quote.AmountInCents()
+	// a criação da visita confere esses dados antes de aceitar o pagamento
+	metadata := map[string]string{
+		"app_patient_id": patientID,
+		"request_type":   request.RequestType,
+		"payment_method": paymentMethod,
+	}
+	if request.NurseId != "" {
+		metadata["nurse_id"] = request.NurseId
+	}
+
+	response := model.PaymentIntentResponse{
+		Amount:        quote.Total,
+		Subtotal:      quote.Total,
+		Visits:        len(quote.Visits),
+		PaymentMethod: paymentMethod,
+	}
+
+	// o desconto do cupom sai da parte da plataforma: o repasse continua calculado sobre o valor cheio
+	if request.CouponCode != "" {
+		appliedCoupon, discountInCents, err := s.couponService.Apply(request.CouponCode, patientID, coupon.Purchase{
+			Specialization: nurse.Specialization,
+			City:           patient.City,
+			AmountInCents:  amountInCents,
+			At:             time.Now(),
+		})
+		if errors.Is(err, coupon.ErrCouponNotApplicable) {
+			return model.PaymentIntentResponse{}, fmt.Errorf("%w %w", ErrInvalidPaymentRequest, err)
+		}
+		if err != nil {
+			return model.PaymentIntentResponse{}, err
+		}
+
+		amountInCents -= discountInCents
+		metadata["coupon_id"] = appliedCoupon.ID.Hex()
+		metadata["coupon_code"] = appliedCoupon.Code
+		metadata["discount_in_cents"] = strconv.FormatInt(discountInCents, 10)
+
+		response.Amount = float64(amountInCents) / 100
+		response.Discount = float64(discountInCents) / 100
+		response.CouponCode = appliedCoupon.Code
+	}
+	switch {
+	case paymentMethod == model.PaymentMethodPix:
+		err = s.createPixPaymentIntent(stripeCustomerID, amountInCents, metadata, &response)
+	case request.PaymentMethodId != "":
+		err = s.createSavedCardPaymentIntent(stripeCustomerID, request.PaymentMethodId, amountInCents, len(quote.Visits) == 1, metadata, &response)
+	default:
+		err = s.createCardPaymentIntent(stripeCustomerID, amountInCents, len(quote.Visits) == 1, metadata, &response)
+	}
+	if err != nil {
+		return model.PaymentIntentResponse{}, err
+	}
+
+	if _, err := s.paymentRepository.RecordEntry(model.PaymentEntry{
+		Type:            model.PaymentEntryIntent,
+		Status:          model.PaymentEntryStatusPending,
+		AmountInCents:   amountInCents,
+		Currency:        string(stripe.CurrencyBRL),
+		StripeID:        response.PaymentIntentID,
+		PaymentIntentID: response.PaymentIntentID,
+		PatientID:       patientID,
+		NurseID:         request.NurseId,
+	}); err != nil {
+		log.Printf("Erro ao registrar PaymentIntent %s no livro-razão: %v", response.PaymentIntentID, err)
+	}
+
+	return response, nil
+}
+
+// createCardPaymentIntent cria o PaymentIntent de cartão. O frontend usa o client secret para
+// confirmar o pagamento no Stripe Elements.
+func (s *paymentService) createCardPaymentIntent(customerID string, amountInCents int64, singleVisit bool, metadata map[string]string, response *model.PaymentIntentResponse) error {
+	// Visitas avulsas só retêm o valor no cartão; a cobrança acontece quando o enfermeiro
+	// informa o código de confirmação. Uma série é cobrada de uma vez, pois o Stripe só permite
+	// uma captura por PaymentIntent.
+	pi, err := s.paymentGateway.CreatePaymentIntent(customerID, amountInCents, singleVisit, metadata)
+	if err != nil {
+		return err
+	}
+
+	// RETORNAR O "CLIENT SECRET"
+	response.ClientSecret = pi.ClientSecret
+	response.PaymentIntentID = pi.ID
+	return nil
+}
+
+// createPixPaymentIntent cria o pagamento PIX e devolve o QR code e o código "copia e cola".
+// O PIX não tem captura manual: o valor é cobrado assim que o paciente paga e a visita segue
+// o mesmo fluxo de um cartão já cobrado (repasse na conclusão, estorno no cancelamento).
+func (s *paymentService) createPixPaymentIntent(customerID string, amountInCents int64, metadata map[string]string, response *model.PaymentIntentResponse) error {
+	pi, err := s.paymentGateway.CreatePixPaymentIntent(customerID, amountInCents, s.pixExpiresAfter, metadata)
+	if err != nil {
+		return err
+	}
+	if pi.NextAction == nil || pi.NextAction.PixDisplayQRCode == nil {
+		return fmt.Errorf("o Stripe não retornou o QR code do pagamento PIX %s", pi.ID)
+	}
+
+	qrCode := pi.NextAction.PixDisplayQRCode
+	response.ClientSecret = pi.ClientSecret
+	response.PaymentIntentID = pi.ID
+	response.Pix = &model.PixInstructions{
+		CopyPasteCode:         qrCode.Data,
+		QRCodeImageURL:        qrCode.ImageURLPNG,
+		HostedInstructionsURL: qrCode.HostedInstructionsURL,
+		ExpiresAt:             time.Unix(qrCode.ExpiresAt, 0),
+	}
+	return nil
+}
+
+// quote calcula o valor da visita descrita em request, com as mesmas regras usadas na
+// criação da visita para conferir o pagamento, e retorna o enfermeiro escolhido (vazio em
+// visitas para qualquer enfermeiro).
+func (s *paymentService) quote(request model.PaymentIntentRequest, now time.Time) (pricing.Quote, model.Nurse, error) {
+	if request.RequestType == pricing.RequestBroadcast {
+		quote, err := s.pricingPolicy.Quote(0, request.RequestType, []time.Time{now})
+		return quote, model.Nurse{}, err
+	}
+
+	if request.NurseId == "" {
+		return pricing.Quote{}, model.Nurse{}, invalidPaymentRequest("Informe o enfermeiro da visita.")
+	}
+	nurse, err := s.nurseRepository.FindNurseById(request.NurseId)
+	if err != nil {
+		return pricing.Quote{}, model.Nurse{}, invalidPaymentRequest("Enfermeiro não encontrado.")
+	}
+
+	dates := []time.Time{now}
+	switch request.RequestType {
+	case pricing.RequestImmediate:
+		if !nurse.Online {
+			return pricing.Quote{}, model.Nurse{}, invalidPaymentRequest(fmt.Sprintf("O(A) enfermeiro(a) %s não está online no momento.", nurse.Name))
+		}
+	case pricing.RequestScheduled:
+		if !request.VisitDate.After(now) {
+			return pricing.Quote{}, model.Nurse{}, invalidPaymentRequest("Informe uma data futura para a visita.")
+		}
+		dates = []time.Time{request.VisitDate}
+		if request.Recurrence != nil {
+			dates, err = scheduling.ExpandRecurrence(*request.Recurrence, request.VisitDate)
+			if err != nil {
+				return pricing.Quote{}, model.Nurse{}, fmt.Errorf("%w %v", ErrInvalidPaymentRequest, err)
+			}
 		}
 	}
 
-	amountInCents := int64(math.Round(value * 100))
-
-    // CRIAR A INTENÇÃO DE PAGAMENTO (PAYMENT INTENT)
-    params := &stripe.PaymentIntentParams{
-        Amount:           stripe.Int64(amountInCents), // quantia
-        Currency:         stripe.String(string(stripe.CurrencyBRL)), // moeda
-        Customer:         stripe.String(stripeCustomerID), // cliente
-        SetupFutureUsage: stripe.String(string(stripe.PaymentIntentSetupFutureUsageOffSession)), // serve para indicar que o método de pagamento poderá ser reutilizado no futuro, sem precisar da interação do usuário (ou seja, "off-session"
-
-        PaymentMethodTypes: []*string{
-            stripe.String("card"),
-        },
-    }
-    
-    pi, err := paymentintent.New(params)
-    if err != nil {
-        return "", fmt.Errorf("erro ao criar PaymentIntent no Stripe: %w", err)
-    }
-
-	// b, _ := json.MarshalIndent(pi, "", "  ")
-	// fmt.Println(string(b))
-	
-    // 4. RETORNAR O "CLIENT SECRET"
-    return pi.ClientSecret, nil
-}
\ No newline at end of file
+	quote, err := s.pricingPolicy.Quote(nurse.Price, request.RequestType, dates)
+	if err != nil {
+		return pricing.Quote{}, model.Nurse{}, fmt.Errorf("%w %v", ErrInvalidPaymentRequest, err)
+	}
+	return quote, nurse, nil
+}
+
+func invalidPaymentRequest(message string) error {
+	return fmt.Errorf("%w %s", ErrInvalidPaymentRequest, message)
+}
+
+// Limites da listagem do livro-razão para administradores.
+const (
+	DefaultPaymentsLimit = 100
+	MaxPaymentsLimit     = 500
+)
+
+func (s *paymentService) ListPayments(filter model.PaymentEntryFilter) ([]model.PaymentEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPaymentsLimit
+	}
+	if filter.Limit > MaxPaymentsLimit {
+		filter.Limit = MaxPaymentsLimit
+	}
+
+	entries, err := s.paymentRepository.FindEntries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar pagamentos: %w", err)
+	}
+	return entries, nil
+}
+
+// GetVisitPayments retorna as movimentações da visita para o paciente ou o enfermeiro dela.
+func (s *paymentService) GetVisitPayments(userId string, visitId string) ([]model.PaymentEntry, error) {
+	visit, err := s.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		return nil, fmt.Errorf("Visita não encontrada.")
+	}
+
+	if visit.PatientId != userId && visit.NurseId != userId {
+		return nil, ErrNotVisitParty
+	}
+
+	entries, err := s.paymentRepository.FindVisitEntries(visitId, visit.PaymentIntentID)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar pagamentos da visita: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,339 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"medassist/internal/coupon"
+	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"medassist/internal/repository/fakes"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentService_GetVisitPayments(t *testing.T) {
+	visit := model.Visit{PatientId: "patient-1", NurseId: "nurse-1", PaymentIntentID: "pi_123"}
+
+	t.Run("Erro_UsuarioNaoParticipaDaVisita", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		s := NewPaymentService(paymentRepo, repmocks.NewMockUserRepository(ctrl), repmocks.NewMockNurseRepository(ctrl), visitRepo, nil, nil)
+
+		visitRepo.EXPECT().FindVisitById("visit-1").Return(visit, nil)
+
+		entries, err := s.GetVisitPayments("outro-paciente", "visit-1")
+
+		assert.ErrorIs(t, err, ErrNotVisitParty)
+		assert.Nil(t, entries)
+	})
+
+	t.Run("Erro_VisitaNaoEncontrada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		s := NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), repmocks.NewMockUserRepository(ctrl), repmocks.NewMockNurseRepository(ctrl), visitRepo, nil, nil)
+
+		visitRepo.EXPECT().FindVisitById("visit-1").Return(model.Visit{}, errors.New("not found"))
+
+		_, err := s.GetVisitPayments("patient-1", "visit-1")
+
+		assert.EqualError(t, err, "Visita não encontrada.")
+	})
+
+	t.Run("Sucesso_EnfermeiroDaVisita", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		s := NewPaymentService(paymentRepo, repmocks.NewMockUserRepository(ctrl), repmocks.NewMockNurseRepository(ctrl), visitRepo, nil, nil)
+
+		expected := []model.PaymentEntry{
+			{Type: model.PaymentEntryCapture, AmountInCents: 15000},
+			{Type: model.PaymentEntryTransfer, AmountInCents: 13500},
+			{Type: model.PaymentEntryCommission, AmountInCents: 1500},
+		}
+		visitRepo.EXPECT().FindVisitById("visit-1").Return(visit, nil)
+		paymentRepo.EXPECT().FindVisitEntries("visit-1", "pi_123").Return(expected, nil)
+
+		entries, err := s.GetVisitPayments("nurse-1", "visit-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, entries)
+	})
+}
+
+func TestPaymentService_ListPayments(t *testing.T) {
+	t.Run("Sucesso_LimitePadraoEMaximo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		s := NewPaymentService(paymentRepo, repmocks.NewMockUserRepository(ctrl), repmocks.NewMockNurseRepository(ctrl), repmocks.NewMockVisitRepository(ctrl), nil, nil)
+
+		paymentRepo.EXPECT().FindEntries(model.PaymentEntryFilter{Type: model.PaymentEntryRefund, Limit: DefaultPaymentsLimit}).Return([]model.PaymentEntry{}, nil)
+		paymentRepo.EXPECT().FindEntries(model.PaymentEntryFilter{Limit: MaxPaymentsLimit}).Return([]model.PaymentEntry{}, nil)
+
+		_, err := s.ListPayments(model.PaymentEntryFilter{Type: model.PaymentEntryRefund})
+		assert.NoError(t, err)
+		_, err = s.ListPayments(model.PaymentEntryFilter{Limit: 10000})
+		assert.NoError(t, err)
+	})
+}
+
+func TestPaymentService_CreatePaymentIntent(t *testing.T) {
+	t.Run("Erro_Enfermeiro_Nao_Informado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), repmocks.NewMockUserRepository(ctrl), repmocks.NewMockNurseRepository(ctrl), repmocks.NewMockVisitRepository(ctrl), nil, nil)
+
+		_, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{RequestType: "SCHEDULED", VisitDate: time.Now().Add(24 * time.Hour)})
+
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+
+	t.Run("Erro_Data_No_Passado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		s := NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), repmocks.NewMockUserRepository(ctrl), nurseRepo, repmocks.NewMockVisitRepository(ctrl), nil, nil)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 200}, nil)
+
+		_, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{NurseId: "nurse-1", RequestType: "SCHEDULED", VisitDate: time.Now().Add(-time.Hour)})
+
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+
+	t.Run("Erro_Imediata_Com_Enfermeiro_Offline", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		s := NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), repmocks.NewMockUserRepository(ctrl), nurseRepo, repmocks.NewMockVisitRepository(ctrl), nil, nil)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Name: "Ana", Price: 200, Online: false}, nil)
+
+		_, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{NurseId: "nurse-1", RequestType: "IMMEDIATE"})
+
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+}
+
+func TestPaymentService_CreatePaymentIntent_Card(t *testing.T) {
+	setup := func(t *testing.T) (*paymentService, *fakes.PaymentGateway, *repmocks.MockUserRepository, *repmocks.MockNurseRepository) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		gateway := fakes.NewPaymentGateway()
+		s := NewPaymentService(paymentRepo, userRepo, nurseRepo, repmocks.NewMockVisitRepository(ctrl), gateway, nil).(*paymentService)
+		s.pricingPolicy = pricing.Policy{}
+
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil).AnyTimes()
+		return s, gateway, userRepo, nurseRepo
+	}
+
+	t.Run("Sucesso_Cria_Cliente_E_Retem_Valor_Da_Visita_Avulsa", func(t *testing.T) {
+		s, gateway, userRepo, nurseRepo := setup(t)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 200, Online: true}, nil)
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{Name: "Maria", City: "Recife"}, nil)
+		userRepo.EXPECT().UpdateUserFields("patient-1", bson.M{"gateway_customer_id": "cus_1"}).Return(model.User{}, nil)
+
+		response, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{NurseId: "nurse-1", RequestType: "IMMEDIATE"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "pi_1", response.PaymentIntentID)
+		assert.Equal(t, "pi_1_secret_fake", response.ClientSecret)
+
+		customer, ok := gateway.Customer("cus_1")
+		assert.True(t, ok)
+		assert.Equal(t, "Maria", customer.Name)
+
+		pi, err := gateway.GetPaymentIntent("pi_1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20000), pi.Amount)
+		assert.Equal(t, stripe.PaymentIntentCaptureMethodManual, pi.CaptureMethod)
+		assert.Equal(t, "nurse-1", pi.Metadata["nurse_id"])
+		assert.Equal(t, "patient-1", pi.Metadata["app_patient_id"])
+	})
+
+	t.Run("Sucesso_Serie_E_Cobrada_De_Uma_Vez", func(t *testing.T) {
+		s, gateway, userRepo, nurseRepo := setup(t)
+		customerId, _ := gateway.CreateCustomer("patient-1", model.User{})
+		start := time.Now().Add(48 * time.Hour)
+
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 100, Online: true}, nil)
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{GatewayCustomerID: customerId}, nil)
+
+		response, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{
+			NurseId: "nurse-1", RequestType: "SCHEDULED", VisitDate: start,
+			Recurrence: &model.RecurrenceRule{Weekdays: []string{start.Weekday().String()}, Interval: 1, Count: 3},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, response.Visits)
+		pi, err := gateway.GetPaymentIntent(response.PaymentIntentID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(30000), pi.Amount)
+		assert.Equal(t, stripe.PaymentIntentCaptureMethodAutomatic, pi.CaptureMethod)
+	})
+}
+
+func TestPaymentService_CreatePaymentIntent_Pix(t *testing.T) {
+	t.Run("Erro_Forma_De_Pagamento_Invalida", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		s := NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), repmocks.NewMockUserRepository(ctrl), repmocks.NewMockNurseRepository(ctrl), repmocks.NewMockVisitRepository(ctrl), nil, nil)
+
+		_, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{RequestType: "BROADCAST", PaymentMethod: "boleto"})
+
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+	})
+
+	t.Run("Sucesso_Retorna_QR_Code", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		gateway := repmocks.NewMockPaymentGateway(ctrl)
+		s := NewPaymentService(paymentRepo, userRepo, nurseRepo, repmocks.NewMockVisitRepository(ctrl), gateway, nil).(*paymentService)
+		s.pricingPolicy = pricing.Policy{}
+
+		expiresAt := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 200, Online: true}, nil)
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{GatewayCustomerID: "cus_123"}, nil)
+		gateway.EXPECT().CreatePixPaymentIntent("cus_123", int64(20000), 30*time.Minute, gomock.Any()).DoAndReturn(
+			func(customerId string, amountInCents int64, expiresAfter time.Duration, metadata map[string]string) (*stripe.PaymentIntent, error) {
+				assert.Equal(t, "patient-1", metadata["app_patient_id"])
+				assert.Equal(t, model.PaymentMethodPix, metadata["payment_method"])
+				return &stripe.PaymentIntent{
+					ID:           "pi_pix",
+					ClientSecret: "secret",
+					NextAction: &stripe.PaymentIntentNextAction{PixDisplayQRCode: &stripe.PaymentIntentNextActionPixDisplayQRCode{
+						Data:        "00020126580014br.gov.bcb.pix",
+						ImageURLPNG: "https://qr.stripe.com/pix.png",
+						ExpiresAt:   expiresAt.Unix(),
+					}},
+				}, nil
+			})
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Equal(t, "pi_pix", entry.StripeID)
+			assert.Equal(t, int64(20000), entry.AmountInCents)
+			return entry, nil
+		})
+
+		response, err := s.CreatePaymentIntent("patient-1", model.PaymentIntentRequest{NurseId: "nurse-1", RequestType: "IMMEDIATE", PaymentMethod: "pix"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "pi_pix", response.PaymentIntentID)
+		assert.Equal(t, model.PaymentMethodPix, response.PaymentMethod)
+		assert.Equal(t, "00020126580014br.gov.bcb.pix", response.Pix.CopyPasteCode)
+		assert.True(t, expiresAt.Equal(response.Pix.ExpiresAt))
+	})
+}
+
+func TestPaymentService_CreatePaymentIntent_Coupon(t *testing.T) {
+	bemVindo := model.Coupon{ID: primitive.NewObjectID(), Code: "BEMVINDO", DiscountType: model.CouponDiscountPercent, DiscountValue: 25, Cities: []string{"Recife"}, Active: true}
+
+	setup := func(t *testing.T, patient model.User) (*paymentService, *repmocks.MockPaymentRepository, *repmocks.MockPaymentGateway, *repmocks.MockCouponRepository) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		gateway := repmocks.NewMockPaymentGateway(ctrl)
+		couponRepo := repmocks.NewMockCouponRepository(ctrl)
+		s := NewPaymentService(paymentRepo, userRepo, nurseRepo, repmocks.NewMockVisitRepository(ctrl), gateway, coupon.NewCouponService(couponRepo, paymentRepo)).(*paymentService)
+		s.pricingPolicy = pricing.Policy{}
+
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 200, Online: true}, nil)
+		userRepo.EXPECT().FindUserById("patient-1").Return(patient, nil)
+		couponRepo.EXPECT().FindCouponByCode("BEMVINDO").Return(bemVindo, nil)
+		couponRepo.EXPECT().CountPatientRedemptions(bemVindo.ID.Hex(), "patient-1").Return(0, nil)
+		return s, paymentRepo, gateway, couponRepo
+	}
+	request := model.PaymentIntentRequest{NurseId: "nurse-1", RequestType: "IMMEDIATE", PaymentMethod: "pix", CouponCode: "bemvindo"}
+
+	t.Run("Sucesso_Cobra_Valor_Com_Desconto", func(t *testing.T) {
+		s, paymentRepo, gateway, _ := setup(t, model.User{GatewayCustomerID: "cus_123", City: "Recife"})
+
+		gateway.EXPECT().CreatePixPaymentIntent("cus_123", int64(15000), 30*time.Minute, gomock.Any()).DoAndReturn(
+			func(customerId string, amountInCents int64, expiresAfter time.Duration, metadata map[string]string) (*stripe.PaymentIntent, error) {
+				assert.Equal(t, bemVindo.ID.Hex(), metadata["coupon_id"])
+				assert.Equal(t, "BEMVINDO", metadata["coupon_code"])
+				assert.Equal(t, "5000", metadata["discount_in_cents"])
+				return &stripe.PaymentIntent{
+					ID:         "pi_pix",
+					NextAction: &stripe.PaymentIntentNextAction{PixDisplayQRCode: &stripe.PaymentIntentNextActionPixDisplayQRCode{Data: "pix"}},
+				}, nil
+			})
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Equal(t, int64(15000), entry.AmountInCents)
+			return entry, nil
+		})
+
+		response, err := s.CreatePaymentIntent("patient-1", request)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 150.0, response.Amount)
+		assert.Equal(t, 200.0, response.Subtotal)
+		assert.Equal(t, 50.0, response.Discount)
+		assert.Equal(t, "BEMVINDO", response.CouponCode)
+	})
+
+	t.Run("Erro_Cupom_Nao_Vale_Na_Cidade", func(t *testing.T) {
+		s, _, _, _ := setup(t, model.User{GatewayCustomerID: "cus_123", City: "Olinda"})
+
+		_, err := s.CreatePaymentIntent("patient-1", request)
+
+		assert.ErrorIs(t, err, ErrInvalidPaymentRequest)
+		assert.ErrorIs(t, err, coupon.ErrCouponNotApplicable)
+	})
+}
+
+func TestPaymentService_Quote(t *testing.T) {
+	t.Run("Sucesso_Serie_Cobra_Todas_As_Ocorrencias", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		s := NewPaymentService(repmocks.NewMockPaymentRepository(ctrl), repmocks.NewMockUserRepository(ctrl), nurseRepo, repmocks.NewMockVisitRepository(ctrl), nil, nil).(*paymentService)
+		s.pricingPolicy = pricing.Policy{}
+
+		now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+		first := time.Date(2025, 3, 11, 14, 0, 0, 0, time.UTC)
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(model.Nurse{Price: 120}, nil)
+
+		quote, _, err := s.quote(model.PaymentIntentRequest{
+			NurseId:     "nurse-1",
+			RequestType: "SCHEDULED",
+			VisitDate:   first,
+			Recurrence:  &model.RecurrenceRule{Weekdays: []string{first.Weekday().String()}, Interval: 1, Count: 4},
+		}, now)
+
+		assert.NoError(t, err)
+		assert.Len(t, quote.Visits, 4)
+		assert.Equal(t, 480.0, quote.Total)
+	})
+}
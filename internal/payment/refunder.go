@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"fmt"
+	"log"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"medassist/internal/repository"
+	"medassist/internal/scheduling"
+	"medassist/utils"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+type refunder struct {
+	visitRepository   repository.VisitRepository
+	paymentGateway    repository.PaymentGateway
+	paymentRepository repository.PaymentRepository
+
+	sendEmail func(patientEmail, patientName, visitDate string, refundAmount float64) error
+}
+
+// NewRefunder cria o responsável por estornar as visitas encerradas sem atendimento. É usado
+// pela máquina de estados das visitas em toda rejeição, cancelamento e expiração.
+func NewRefunder(visitRepository repository.VisitRepository, paymentGateway repository.PaymentGateway, paymentRepository repository.PaymentRepository) lifecycle.Refunder {
+	return &refunder{
+		visitRepository:   visitRepository,
+		paymentGateway:    paymentGateway,
+		paymentRepository: paymentRepository,
+		sendEmail:         utils.SendEmailRefundConfirmation,
+	}
+}
+
+// RefundVisit devolve amount (limitado ao que o paciente pagou pela visita) ao paciente, registra a devolução na
+// visita e no livro-razão e avisa o paciente por e-mail. Se o valor ainda está só retido no
+// cartão, a autorização é cancelada (ou apenas a parte não devolvida é capturada); se já foi
+// cobrado, é estornado. Visitas sem pagamento, com pagamento recusado ou já devolvido são ignoradas.
+func (r *refunder) RefundVisit(visit model.Visit, amount float64) (model.Visit, error) {
+	if visit.PaymentIntentID == "" || visit.RefundID != "" || visit.PaymentStatus == model.PaymentStatusFailed || visit.PaymentStatus == model.PaymentStatusReleased {
+		return visit, nil
+	}
+
+	// com cupom, o paciente pagou menos que o valor da visita: o desconto não é devolvido em dinheiro
+	amountInCents := pricing.ToCents(amount)
+	paidInCents := visit.PaidInCents()
+	if amountInCents > paidInCents {
+		amountInCents = paidInCents
+	}
+	if amountInCents <= 0 {
+		return visit, nil
+	}
+
+	pi, err := r.paymentGateway.GetPaymentIntent(visit.PaymentIntentID)
+	if err != nil {
+		return visit, fmt.Errorf("Erro ao consultar pagamento %s: %w", visit.PaymentIntentID, err)
+	}
+
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return r.refund(visit, amountInCents, paidInCents)
+	case stripe.PaymentIntentStatusRequiresCapture:
+		return r.release(visit, amountInCents, paidInCents)
+	case stripe.PaymentIntentStatusCanceled:
+		// a autorização já foi cancelada ou expirou: nada foi cobrado do paciente
+		return r.updateVisit(visit, map[string]interface{}{"payment_status": model.PaymentStatusReleased}), nil
+	}
+	return visit, fmt.Errorf("O pagamento %s ainda não pode ser devolvido (status %s).", pi.ID, pi.Status)
+}
+
+// refund estorna um pagamento já cobrado.
+func (r *refunder) refund(visit model.Visit, amountInCents, paidInCents int64) (model.Visit, error) {
+	visitId := visit.ID.Hex()
+
+	refund, err := r.paymentGateway.RefundPaymentIntent(visit.PaymentIntentID, amountInCents, visitId)
+	if err != nil {
+		return visit, fmt.Errorf("Erro ao estornar pagamento %s: %w", visit.PaymentIntentID, err)
+	}
+
+	refundAmount := float64(amountInCents) / 100
+	paymentStatus := model.PaymentStatusRefunded
+	if amountInCents < paidInCents {
+		paymentStatus = model.PaymentStatusPartiallyRefunded
+	}
+
+	updated := r.updateVisit(visit, map[string]interface{}{
+		"refund_amount":  refundAmount,
+		"refund_id":      refund.ID,
+		"payment_status": paymentStatus,
+	})
+
+	if _, err := r.paymentRepository.RecordEntry(model.PaymentEntry{
+		Type:            model.PaymentEntryRefund,
+		Status:          model.PaymentEntryStatusSucceeded,
+		AmountInCents:   amountInCents,
+		StripeID:        refund.ID,
+		PaymentIntentID: visit.PaymentIntentID,
+		VisitID:         visitId,
+		PatientID:       visit.PatientId,
+		NurseID:         visit.NurseId,
+	}); err != nil {
+		log.Printf("Erro ao registrar estorno %s no livro-razão: %v", refund.ID, err)
+	}
+
+	r.notify(visit, refundAmount)
+	return updated, nil
+}
+
+// release libera o valor retido no cartão. Quando só parte dele é devolvida (ex: taxa de
+// cancelamento), a diferença é capturada e o restante da autorização é liberado pelo Stripe.
+func (r *refunder) release(visit model.Visit, amountInCents, paidInCents int64) (model.Visit, error) {
+	refundAmount := float64(amountInCents) / 100
+	updates := map[string]interface{}{"refund_amount": refundAmount}
+
+	if amountInCents < paidInCents {
+		if _, err := r.paymentGateway.CapturePaymentIntent(visit.PaymentIntentID, paidInCents-amountInCents); err != nil {
+			return visit, fmt.Errorf("Erro ao cobrar taxa do pagamento %s: %w", visit.PaymentIntentID, err)
+		}
+		updates["payment_status"] = model.PaymentStatusSucceeded
+	} else {
+		if _, err := r.paymentGateway.CancelPaymentIntent(visit.PaymentIntentID); err != nil {
+			return visit, fmt.Errorf("Erro ao liberar pagamento %s: %w", visit.PaymentIntentID, err)
+		}
+		updates["payment_status"] = model.PaymentStatusReleased
+
+		if err := r.paymentRepository.UpdateEntryStatus(model.PaymentEntryIntent, visit.PaymentIntentID, model.PaymentEntryStatusCanceled); err != nil {
+			log.Printf("Erro ao registrar liberação do pagamento %s no livro-razão: %v", visit.PaymentIntentID, err)
+		}
+	}
+
+	updated := r.updateVisit(visit, updates)
+	r.notify(visit, refundAmount)
+	return updated, nil
+}
+
+// updateVisit grava a devolução na visita. O dinheiro já foi movimentado, então uma falha
+// aqui é apenas logada e a visita retornada reflete a devolução.
+func (r *refunder) updateVisit(visit model.Visit, updates map[string]interface{}) model.Visit {
+	visitId := visit.ID.Hex()
+
+	updated, err := r.visitRepository.UpdateVisitFields(visitId, updates)
+	if err != nil {
+		log.Printf("Erro ao registrar devolução do pagamento da visita %s: %v", visitId, err)
+		updated = visit
+		if amount, ok := updates["refund_amount"].(float64); ok {
+			updated.RefundAmount = amount
+		}
+		if refundId, ok := updates["refund_id"].(string); ok {
+			updated.RefundID = refundId
+		}
+		if status, ok := updates["payment_status"].(string); ok {
+			updated.PaymentStatus = status
+		}
+	}
+	return updated
+}
+
+func (r *refunder) notify(visit model.Visit, refundAmount float64) {
+	visitDate := visit.VisitDate.In(scheduling.Location()).Format("02/01/2006 15:04")
+	if err := r.sendEmail(visit.PatientEmail, visit.PatientName, visitDate, refundAmount); err != nil {
+		log.Printf("Erro ao enviar e-mail de estorno da visita %s: %v", visit.ID.Hex(), err)
+	}
+}
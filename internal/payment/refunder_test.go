@@ -0,0 +1,273 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+
+	"medassist/internal/model"
+	"medassist/internal/repository/fakes"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestRefunder(ctrl *gomock.Controller, sent *[]float64) (*refunder, *repmocks.MockVisitRepository, *repmocks.MockPaymentGateway, *repmocks.MockPaymentRepository) {
+	visitRepo := repmocks.NewMockVisitRepository(ctrl)
+	gateway := repmocks.NewMockPaymentGateway(ctrl)
+	paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+
+	r := NewRefunder(visitRepo, gateway, paymentRepo).(*refunder)
+	r.sendEmail = func(patientEmail, patientName, visitDate string, refundAmount float64) error {
+		*sent = append(*sent, refundAmount)
+		return nil
+	}
+	return r, visitRepo, gateway, paymentRepo
+}
+
+func TestRefunder_RefundVisit(t *testing.T) {
+	visit := model.Visit{
+		ID: primitive.NewObjectID(), PatientId: "patient-1", NurseId: "nurse-1",
+		PaymentIntentID: "pi_123", VisitValue: 150,
+	}
+
+	t.Run("Sucesso_Ignora_Visitas_Sem_Pagamento_Estornadas_Ou_Recusadas", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, _, _, _ := newTestRefunder(ctrl, &sent)
+
+		withoutPayment := visit
+		withoutPayment.PaymentIntentID = ""
+		refunded := visit
+		refunded.RefundID = "re_antigo"
+		failed := visit
+		failed.PaymentStatus = model.PaymentStatusFailed
+
+		for _, v := range []model.Visit{withoutPayment, refunded, failed} {
+			result, err := r.RefundVisit(v, 150)
+			assert.NoError(t, err)
+			assert.Equal(t, v, result)
+		}
+		assert.Empty(t, sent)
+	})
+
+	t.Run("Sucesso_Estorno_Integral", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, visitRepo, gateway, paymentRepo := newTestRefunder(ctrl, &sent)
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusSucceeded}, nil)
+		gateway.EXPECT().RefundPaymentIntent("pi_123", int64(15000), visit.ID.Hex()).Return(&stripe.Refund{ID: "re_1", Amount: 15000}, nil)
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), map[string]interface{}{
+			"refund_amount":  150.0,
+			"refund_id":      "re_1",
+			"payment_status": model.PaymentStatusRefunded,
+		}).DoAndReturn(func(id string, updates map[string]interface{}) (model.Visit, error) {
+			updated := visit
+			updated.RefundAmount = 150
+			updated.RefundID = "re_1"
+			updated.PaymentStatus = model.PaymentStatusRefunded
+			return updated, nil
+		})
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Equal(t, model.PaymentEntryRefund, entry.Type)
+			assert.Equal(t, int64(15000), entry.AmountInCents)
+			assert.Equal(t, "re_1", entry.StripeID)
+			assert.Equal(t, visit.ID.Hex(), entry.VisitID)
+			return entry, nil
+		})
+
+		// valores acima do valor da visita são limitados a ele
+		result, err := r.RefundVisit(visit, 200)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 150.0, result.RefundAmount)
+		assert.Equal(t, model.PaymentStatusRefunded, result.PaymentStatus)
+		assert.Equal(t, []float64{150}, sent)
+	})
+
+	t.Run("Sucesso_Estorno_Parcial", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, visitRepo, gateway, paymentRepo := newTestRefunder(ctrl, &sent)
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusSucceeded}, nil)
+		gateway.EXPECT().RefundPaymentIntent("pi_123", int64(7500), visit.ID.Hex()).Return(&stripe.Refund{ID: "re_2", Amount: 7500}, nil)
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), gomock.Any()).DoAndReturn(func(id string, updates map[string]interface{}) (model.Visit, error) {
+			assert.Equal(t, model.PaymentStatusPartiallyRefunded, updates["payment_status"])
+			return visit, nil
+		})
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil)
+
+		_, err := r.RefundVisit(visit, 75)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{75}, sent)
+	})
+
+	t.Run("Erro_Stripe", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, _, gateway, _ := newTestRefunder(ctrl, &sent)
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusSucceeded}, nil)
+		gateway.EXPECT().RefundPaymentIntent("pi_123", int64(15000), visit.ID.Hex()).Return(nil, errors.New("stripe indisponível"))
+
+		result, err := r.RefundVisit(visit, 150)
+
+		assert.Error(t, err)
+		assert.Equal(t, visit, result)
+		assert.Empty(t, sent)
+	})
+	t.Run("Sucesso_Libera_Valor_Retido", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, visitRepo, gateway, paymentRepo := newTestRefunder(ctrl, &sent)
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusRequiresCapture}, nil)
+		gateway.EXPECT().CancelPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusCanceled}, nil)
+		paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryIntent, "pi_123", model.PaymentEntryStatusCanceled).Return(nil)
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), map[string]interface{}{
+			"refund_amount":  150.0,
+			"payment_status": model.PaymentStatusReleased,
+		}).Return(model.Visit{}, errors.New("mongo indisponível"))
+
+		result, err := r.RefundVisit(visit, 150)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PaymentStatusReleased, result.PaymentStatus)
+		assert.Equal(t, 150.0, result.RefundAmount)
+		assert.Equal(t, []float64{150}, sent)
+	})
+
+	t.Run("Sucesso_Captura_Apenas_Taxa_Retida", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, visitRepo, gateway, _ := newTestRefunder(ctrl, &sent)
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusRequiresCapture}, nil)
+		// devolve R$100 de R$150: só a taxa de R$50 é cobrada
+		gateway.EXPECT().CapturePaymentIntent("pi_123", int64(5000)).Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusSucceeded}, nil)
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), map[string]interface{}{
+			"refund_amount":  100.0,
+			"payment_status": model.PaymentStatusSucceeded,
+		}).Return(visit, nil)
+
+		_, err := r.RefundVisit(visit, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{100}, sent)
+	})
+
+	t.Run("Sucesso_Cupom_Limita_Devolucao_Ao_Valor_Pago", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, visitRepo, gateway, paymentRepo := newTestRefunder(ctrl, &sent)
+
+		// R$150 de visita com R$30 de desconto: o paciente pagou R$120
+		discounted := visit
+		discounted.Discount = &model.VisitDiscount{Code: "BEMVINDO", AmountInCents: 3000}
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusSucceeded}, nil)
+		gateway.EXPECT().RefundPaymentIntent("pi_123", int64(12000), visit.ID.Hex()).Return(&stripe.Refund{ID: "re_123"}, nil)
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), map[string]interface{}{
+			"refund_amount":  120.0,
+			"refund_id":      "re_123",
+			"payment_status": model.PaymentStatusRefunded,
+		}).Return(discounted, nil)
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil)
+
+		_, err := r.RefundVisit(discounted, 150)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{120}, sent)
+	})
+
+	t.Run("Sucesso_Autorizacao_Ja_Cancelada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var sent []float64
+		r, visitRepo, gateway, _ := newTestRefunder(ctrl, &sent)
+
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(&stripe.PaymentIntent{ID: "pi_123", Status: stripe.PaymentIntentStatusCanceled}, nil)
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), map[string]interface{}{
+			"payment_status": model.PaymentStatusReleased,
+		}).Return(visit, nil)
+
+		_, err := r.RefundVisit(visit, 150)
+
+		assert.NoError(t, err)
+		assert.Empty(t, sent)
+	})
+}
+
+func TestRefunder_RefundVisit_InMemoryGateway(t *testing.T) {
+	// fluxo completo contra o gateway em memória: o valor retido é capturado só na parte da taxa
+	// e o estorno seguinte da mesma visita não devolve o dinheiro duas vezes
+	setup := func(t *testing.T, manualCapture bool) (*refunder, *fakes.PaymentGateway, model.Visit) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		gateway := fakes.NewPaymentGateway()
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		paymentRepo := repmocks.NewMockPaymentRepository(ctrl)
+		r := NewRefunder(visitRepo, gateway, paymentRepo).(*refunder)
+		r.sendEmail = func(patientEmail, patientName, visitDate string, refundAmount float64) error { return nil }
+
+		customerId, _ := gateway.CreateCustomer("patient-1", model.User{})
+		pi, _ := gateway.CreatePaymentIntent(customerId, 15000, manualCapture, nil)
+		_, _ = gateway.Pay(pi.ID)
+
+		visit := model.Visit{ID: primitive.NewObjectID(), PatientId: "patient-1", NurseId: "nurse-1", PaymentIntentID: pi.ID, VisitValue: 150}
+		visitRepo.EXPECT().UpdateVisitFields(visit.ID.Hex(), gomock.Any()).Return(model.Visit{}, errors.New("Erro db")).AnyTimes()
+		paymentRepo.EXPECT().RecordEntry(gomock.Any()).Return(model.PaymentEntry{}, nil).AnyTimes()
+		return r, gateway, visit
+	}
+
+	t.Run("Sucesso_Captura_Taxa_Da_Autorizacao", func(t *testing.T) {
+		r, gateway, visit := setup(t, true)
+
+		updated, err := r.RefundVisit(visit, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PaymentStatusSucceeded, updated.PaymentStatus)
+		pi, _ := gateway.GetPaymentIntent(visit.PaymentIntentID)
+		assert.Equal(t, stripe.PaymentIntentStatusSucceeded, pi.Status)
+		assert.Equal(t, int64(5000), pi.AmountReceived)
+	})
+
+	t.Run("Sucesso_Estorno_Parcial_Do_Pagamento_Cobrado", func(t *testing.T) {
+		r, gateway, visit := setup(t, false)
+
+		updated, err := r.RefundVisit(visit, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PaymentStatusPartiallyRefunded, updated.PaymentStatus)
+		assert.Equal(t, "re_1", updated.RefundID)
+
+		// repetir o estorno (ex: a gravação na visita falhou) devolve o mesmo estorno
+		_, err = r.RefundVisit(visit, 100)
+
+		assert.NoError(t, err)
+		refunds := gateway.Refunds(visit.PaymentIntentID)
+		assert.Len(t, refunds, 1)
+		assert.Equal(t, int64(10000), refunds[0].Amount)
+	})
+}
@@ -0,0 +1,173 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"medassist/internal/repository"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+// Limites da gorjeta em centavos. O máximo evita cobranças altas digitadas por engano.
+const (
+	MinTipInCents = 200   // R$ 2,00
+	MaxTipInCents = 50000 // R$ 500,00
+)
+
+// ErrInvalidTip indica uma gorjeta que não pode ser dada para a visita.
+var ErrInvalidTip = errors.New("Gorjeta inválida.")
+
+// ErrTipDeclined indica que o cartão salvo do paciente recusou a cobrança da gorjeta.
+var ErrTipDeclined = errors.New("O cartão salvo recusou a cobrança da gorjeta. Tente novamente mais tarde.")
+
+// ErrVisitNotFound indica que a visita informada não existe.
+var ErrVisitNotFound = errors.New("Visita não encontrada.")
+
+// TipVisit cobra a gorjeta no cartão salvo do paciente, sem que ele precise confirmar o
+// pagamento de novo, e repassa o valor inteiro ao enfermeiro. Só é aceita uma gorjeta por
+// visita concluída; se a cobrança falhar, o paciente pode tentar outra vez.
+func (s *paymentService) TipVisit(patientId, visitId string, request model.TipRequest) (model.VisitTip, error) {
+	amountInCents := pricing.ToCents(request.Amount)
+	if amountInCents < MinTipInCents || amountInCents > MaxTipInCents {
+		return model.VisitTip{}, invalidTip(fmt.Sprintf("A gorjeta deve ser de R$ %.2f a R$ %.2f.", float64(MinTipInCents)/100, float64(MaxTipInCents)/100))
+	}
+
+	visit, err := s.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		return model.VisitTip{}, ErrVisitNotFound
+	}
+	if visit.PatientId != patientId {
+		return model.VisitTip{}, ErrNotVisitParty
+	}
+	if visit.Status != model.VisitStatusCompleted {
+		return model.VisitTip{}, invalidTip("A gorjeta só pode ser dada depois que a visita for concluída.")
+	}
+	if visit.Tip != nil {
+		return model.VisitTip{}, invalidTip("Você já deu gorjeta nesta visita.")
+	}
+	if visit.PaymentIntentID == "" {
+		return model.VisitTip{}, invalidTip("Pagamento original não encontrado para esta visita.")
+	}
+
+	nurse, err := s.nurseRepository.FindNurseById(visit.NurseId)
+	if err != nil {
+		return model.VisitTip{}, fmt.Errorf("Erro ao localizar dados do enfermeiro: %w", err)
+	}
+	if nurse.StripeAccountId == "" {
+		return model.VisitTip{}, invalidTip("Este enfermeiro(a) ainda não pode receber gorjetas.")
+	}
+
+	// o cartão salvo é o usado no pagamento da visita (SetupFutureUsage off_session)
+	original, err := s.paymentGateway.GetPaymentIntent(visit.PaymentIntentID)
+	if err != nil {
+		return model.VisitTip{}, fmt.Errorf("Erro ao consultar pagamento %s: %w", visit.PaymentIntentID, err)
+	}
+	if original.Customer == nil || original.PaymentMethod == nil {
+		return model.VisitTip{}, invalidTip("A gorjeta só pode ser paga em visitas pagas com cartão.")
+	}
+	// o PaymentIntent traz só o ID da forma de pagamento, então o tipo vem dela
+	paymentMethod, err := s.paymentGateway.GetPaymentMethod(original.PaymentMethod.ID)
+	if err != nil {
+		return model.VisitTip{}, fmt.Errorf("Erro ao consultar forma de pagamento da visita: %w", err)
+	}
+	if paymentMethod.Type != stripe.PaymentMethodTypeCard {
+		return model.VisitTip{}, invalidTip("A gorjeta só pode ser paga em visitas pagas com cartão.")
+	}
+
+	tip := model.VisitTip{AmountInCents: amountInCents, Status: model.TipStatusProcessing, CreatedAt: time.Now()}
+	if _, err := s.visitRepository.ClaimVisitTip(visitId, tip); err != nil {
+		if errors.Is(err, repository.ErrVisitTipTaken) {
+			return model.VisitTip{}, invalidTip("Você já deu gorjeta nesta visita.")
+		}
+		return model.VisitTip{}, fmt.Errorf("Erro ao registrar gorjeta: %w", err)
+	}
+
+	// a chave muda a cada tentativa: o Stripe guarda também as recusas de uma chave
+	idempotencyKey := fmt.Sprintf("tip-%s-%d", visitId, tip.CreatedAt.UnixNano())
+	pi, err := s.paymentGateway.ChargeSavedCard(original.Customer.ID, original.PaymentMethod.ID, amountInCents, idempotencyKey, map[string]string{
+		"app_patient_id": patientId,
+		"nurse_id":       visit.NurseId,
+		"visit_id":       visitId,
+		"type":           "tip",
+	})
+	if err == nil && pi.Status != stripe.PaymentIntentStatusSucceeded {
+		err = fmt.Errorf("cobrança %s com status %s", pi.ID, pi.Status)
+	}
+	if err != nil {
+		s.releaseTip(visitId)
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard {
+			return model.VisitTip{}, ErrTipDeclined
+		}
+		return model.VisitTip{}, fmt.Errorf("Erro ao cobrar gorjeta: %w", err)
+	}
+
+	// a gorjeta sai da própria cobrança, então o repasse é o valor inteiro, sem comissão
+	transfer, err := s.paymentGateway.CreateTransfer(amountInCents, nurse.StripeAccountId, pi.ID, "transfer-tip-"+pi.ID)
+	if err != nil {
+		if _, refundErr := s.paymentGateway.RefundPaymentIntent(pi.ID, 0, ""); refundErr != nil {
+			// o dinheiro ficou com a plataforma: a gorjeta continua em andamento para acerto manual
+			log.Printf("Erro ao devolver gorjeta %s da visita %s após falha no repasse: %v", pi.ID, visitId, refundErr)
+			if _, updateErr := s.visitRepository.UpdateVisitFields(visitId, map[string]interface{}{"tip.payment_intent_id": pi.ID}); updateErr != nil {
+				log.Printf("Erro ao registrar cobrança da gorjeta %s na visita %s: %v", pi.ID, visitId, updateErr)
+			}
+		} else {
+			s.releaseTip(visitId)
+		}
+		return model.VisitTip{}, fmt.Errorf("Erro ao repassar gorjeta ao enfermeiro: %w", err)
+	}
+
+	tip.Status = model.TipStatusTransferred
+	tip.PaymentIntentID = pi.ID
+	tip.TransferID = transfer.ID
+	// o dinheiro já foi movimentado, então uma falha aqui é apenas logada
+	if _, err := s.visitRepository.UpdateVisitFields(visitId, map[string]interface{}{"tip": tip}); err != nil {
+		log.Printf("Erro ao registrar gorjeta %s na visita %s: %v", pi.ID, visitId, err)
+	}
+	s.recordTip(visit, tip)
+
+	return tip, nil
+}
+
+// releaseTip libera a visita para uma nova tentativa de gorjeta.
+func (s *paymentService) releaseTip(visitId string) {
+	if err := s.visitRepository.ReleaseVisitTip(visitId); err != nil {
+		log.Printf("Erro ao liberar gorjeta da visita %s: %v", visitId, err)
+	}
+}
+
+// recordTip registra no livro-razão a cobrança da gorjeta e o repasse ao enfermeiro.
+func (s *paymentService) recordTip(visit model.Visit, tip model.VisitTip) {
+	visitId := visit.ID.Hex()
+	base := model.PaymentEntry{
+		Status:          model.PaymentEntryStatusSucceeded,
+		AmountInCents:   tip.AmountInCents,
+		Currency:        string(stripe.CurrencyBRL),
+		PaymentIntentID: tip.PaymentIntentID,
+		VisitID:         visitId,
+		PatientID:       visit.PatientId,
+		NurseID:         visit.NurseId,
+	}
+
+	tipEntry := base
+	tipEntry.Type = model.PaymentEntryTip
+	tipEntry.StripeID = tip.PaymentIntentID
+	if _, err := s.paymentRepository.RecordEntry(tipEntry); err != nil {
+		log.Printf("Erro ao registrar gorjeta %s da visita %s: %v", tip.PaymentIntentID, visitId, err)
+	}
+
+	transferEntry := base
+	transferEntry.Type = model.PaymentEntryTransfer
+	transferEntry.StripeID = tip.TransferID
+	if _, err := s.paymentRepository.RecordEntry(transferEntry); err != nil {
+		log.Printf("Erro ao registrar repasse da gorjeta %s da visita %s: %v", tip.TransferID, visitId, err)
+	}
+}
+
+func invalidTip(message string) error {
+	return fmt.Errorf("%w %s", ErrInvalidTip, message)
+}
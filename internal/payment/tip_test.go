@@ -0,0 +1,170 @@
+package payment
+
+import (
+	"testing"
+
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/repository/fakes"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentService_TipVisit(t *testing.T) {
+	type fixture struct {
+		service     *paymentService
+		gateway     *fakes.PaymentGateway
+		visitRepo   *repmocks.MockVisitRepository
+		nurseRepo   *repmocks.MockNurseRepository
+		paymentRepo *repmocks.MockPaymentRepository
+		visit       model.Visit
+		nurse       model.Nurse
+	}
+
+	// visita concluída paga com cartão: o valor retido já foi capturado e o cartão ficou salvo
+	setup := func(t *testing.T, paymentMethod string) fixture {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		f := fixture{
+			gateway:     fakes.NewPaymentGateway(),
+			visitRepo:   repmocks.NewMockVisitRepository(ctrl),
+			nurseRepo:   repmocks.NewMockNurseRepository(ctrl),
+			paymentRepo: repmocks.NewMockPaymentRepository(ctrl),
+		}
+		f.service = NewPaymentService(f.paymentRepo, repmocks.NewMockUserRepository(ctrl), f.nurseRepo, f.visitRepo, f.gateway, nil).(*paymentService)
+
+		customerId, _ := f.gateway.CreateCustomer("patient-1", model.User{})
+		var pi *stripe.PaymentIntent
+		if paymentMethod == model.PaymentMethodPix {
+			pi, _ = f.gateway.CreatePixPaymentIntent(customerId, 15000, 0, nil)
+			_, _ = f.gateway.Pay(pi.ID)
+		} else {
+			pi, _ = f.gateway.CreatePaymentIntent(customerId, 15000, true, nil)
+			_, _ = f.gateway.Pay(pi.ID)
+			_, _ = f.gateway.CapturePaymentIntent(pi.ID, 0)
+		}
+		accountId, _ := f.gateway.CreateExpressAccount("ana@medassist.com")
+
+		f.nurse = model.Nurse{StripeAccountId: accountId}
+		f.visit = model.Visit{
+			ID: primitive.NewObjectID(), Status: model.VisitStatusCompleted, PatientId: "patient-1", NurseId: "nurse-1",
+			PaymentIntentID: pi.ID, VisitValue: 150,
+		}
+		return f
+	}
+
+	t.Run("Sucesso_Cobra_Cartao_Salvo_E_Repassa_Tudo", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodCard)
+		visitId := f.visit.ID.Hex()
+
+		f.visitRepo.EXPECT().FindVisitById(visitId).Return(f.visit, nil)
+		f.nurseRepo.EXPECT().FindNurseById("nurse-1").Return(f.nurse, nil)
+		f.visitRepo.EXPECT().ClaimVisitTip(visitId, gomock.Any()).DoAndReturn(func(id string, tip model.VisitTip) (model.Visit, error) {
+			assert.Equal(t, int64(2000), tip.AmountInCents)
+			assert.Equal(t, model.TipStatusProcessing, tip.Status)
+			return f.visit, nil
+		})
+		f.visitRepo.EXPECT().UpdateVisitFields(visitId, gomock.Any()).Return(f.visit, nil)
+		var entries []model.PaymentEntry
+		f.paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			entries = append(entries, entry)
+			return entry, nil
+		}).Times(2)
+
+		tip, err := f.service.TipVisit("patient-1", visitId, model.TipRequest{Amount: 20})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.TipStatusTransferred, tip.Status)
+		assert.Equal(t, "pi_2", tip.PaymentIntentID)
+
+		original, _ := f.gateway.GetPaymentIntent(f.visit.PaymentIntentID)
+		charged, _ := f.gateway.GetPaymentIntent(tip.PaymentIntentID)
+		assert.Equal(t, original.PaymentMethod.ID, charged.PaymentMethod.ID)
+		assert.Equal(t, int64(2000), charged.AmountReceived)
+		assert.Equal(t, visitId, charged.Metadata["visit_id"])
+
+		transfers := f.gateway.Transfers()
+		assert.Len(t, transfers, 1)
+		assert.Equal(t, int64(2000), transfers[0].Amount)
+		assert.Equal(t, f.nurse.StripeAccountId, transfers[0].Destination.ID)
+
+		assert.Equal(t, model.PaymentEntryTip, entries[0].Type)
+		assert.Equal(t, model.PaymentEntryTransfer, entries[1].Type)
+		assert.Equal(t, transfers[0].ID, entries[1].StripeID)
+	})
+
+	t.Run("Erro_Valor_Fora_Dos_Limites", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodCard)
+
+		_, err := f.service.TipVisit("patient-1", f.visit.ID.Hex(), model.TipRequest{Amount: 1})
+		assert.ErrorIs(t, err, ErrInvalidTip)
+
+		_, err = f.service.TipVisit("patient-1", f.visit.ID.Hex(), model.TipRequest{Amount: 501})
+		assert.ErrorIs(t, err, ErrInvalidTip)
+	})
+
+	t.Run("Erro_Visita_De_Outro_Paciente", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodCard)
+		f.visitRepo.EXPECT().FindVisitById(f.visit.ID.Hex()).Return(f.visit, nil)
+
+		_, err := f.service.TipVisit("patient-2", f.visit.ID.Hex(), model.TipRequest{Amount: 20})
+
+		assert.ErrorIs(t, err, ErrNotVisitParty)
+	})
+
+	t.Run("Erro_Visita_Nao_Concluida", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodCard)
+		f.visit.Status = model.VisitStatusConfirmed
+		f.visitRepo.EXPECT().FindVisitById(f.visit.ID.Hex()).Return(f.visit, nil)
+
+		_, err := f.service.TipVisit("patient-1", f.visit.ID.Hex(), model.TipRequest{Amount: 20})
+
+		assert.ErrorIs(t, err, ErrInvalidTip)
+	})
+
+	t.Run("Erro_Visita_Paga_Com_Pix", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodPix)
+		f.visitRepo.EXPECT().FindVisitById(f.visit.ID.Hex()).Return(f.visit, nil)
+		f.nurseRepo.EXPECT().FindNurseById("nurse-1").Return(f.nurse, nil)
+
+		_, err := f.service.TipVisit("patient-1", f.visit.ID.Hex(), model.TipRequest{Amount: 20})
+
+		assert.ErrorIs(t, err, ErrInvalidTip)
+		assert.Empty(t, f.gateway.Transfers())
+	})
+
+	t.Run("Erro_Gorjeta_Ja_Dada_Em_Outra_Requisicao", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodCard)
+		visitId := f.visit.ID.Hex()
+		f.visitRepo.EXPECT().FindVisitById(visitId).Return(f.visit, nil)
+		f.nurseRepo.EXPECT().FindNurseById("nurse-1").Return(f.nurse, nil)
+		f.visitRepo.EXPECT().ClaimVisitTip(visitId, gomock.Any()).Return(model.Visit{}, repository.ErrVisitTipTaken)
+
+		_, err := f.service.TipVisit("patient-1", visitId, model.TipRequest{Amount: 20})
+
+		assert.ErrorIs(t, err, ErrInvalidTip)
+		assert.Empty(t, f.gateway.Transfers())
+	})
+
+	t.Run("Erro_Cartao_Recusado_Libera_Nova_Tentativa", func(t *testing.T) {
+		f := setup(t, model.PaymentMethodCard)
+		visitId := f.visit.ID.Hex()
+		original, _ := f.gateway.GetPaymentIntent(f.visit.PaymentIntentID)
+		f.gateway.Decline(original.PaymentMethod.ID, stripe.ErrorCodeAuthenticationRequired)
+
+		f.visitRepo.EXPECT().FindVisitById(visitId).Return(f.visit, nil)
+		f.nurseRepo.EXPECT().FindNurseById("nurse-1").Return(f.nurse, nil)
+		f.visitRepo.EXPECT().ClaimVisitTip(visitId, gomock.Any()).Return(f.visit, nil)
+		f.visitRepo.EXPECT().ReleaseVisitTip(visitId).Return(nil)
+
+		_, err := f.service.TipVisit("patient-1", visitId, model.TipRequest{Amount: 20})
+
+		assert.ErrorIs(t, err, ErrTipDeclined)
+		assert.Empty(t, f.gateway.Transfers())
+	})
+}
@@ -0,0 +1,355 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/dispute"
+	"medassist/internal/lifecycle"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"os"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// ErrInvalidWebhookSignature indica que o payload não foi assinado com o segredo do endpoint.
+var ErrInvalidWebhookSignature = errors.New("Assinatura do webhook inválida.")
+
+// WebhookService processa os eventos enviados pelo Stripe, mantendo as visitas e os
+// enfermeiros sincronizados com o que de fato aconteceu nos pagamentos e repasses.
+type WebhookService interface {
+	HandleEvent(payload []byte, signature string) error
+}
+
+type webhookService struct {
+	visitRepository       repository.VisitRepository
+	nurseRepository       repository.NurseRepository
+	paymentRepository     repository.PaymentRepository
+	stripeEventRepository repository.StripeEventRepository
+	disputeService        dispute.DisputeService
+	visitStateMachine     lifecycle.VisitStateMachine
+	secret                string
+}
+
+// NewWebhookService cria o serviço usando o segredo do endpoint em STRIPE_WEBHOOK_SECRET.
+func NewWebhookService(visitRepository repository.VisitRepository, nurseRepository repository.NurseRepository, paymentRepository repository.PaymentRepository, stripeEventRepository repository.StripeEventRepository, disputeService dispute.DisputeService) WebhookService {
+	return newWebhookService(visitRepository, nurseRepository, paymentRepository, stripeEventRepository, disputeService, os.Getenv("STRIPE_WEBHOOK_SECRET"))
+}
+
+func newWebhookService(visitRepository repository.VisitRepository, nurseRepository repository.NurseRepository, paymentRepository repository.PaymentRepository, stripeEventRepository repository.StripeEventRepository, disputeService dispute.DisputeService, secret string) *webhookService {
+	return &webhookService{
+		visitRepository:       visitRepository,
+		nurseRepository:       nurseRepository,
+		paymentRepository:     paymentRepository,
+		stripeEventRepository: stripeEventRepository,
+		disputeService:        disputeService,
+		// sem estorno: as visitas canceladas por aqui tiveram o pagamento recusado ou liberado
+		visitStateMachine: lifecycle.NewVisitStateMachine(visitRepository, nil),
+		secret:            secret,
+	}
+}
+
+// HandleEvent verifica a assinatura e aplica o evento. Eventos repetidos são ignorados; se o
+// processamento falhar, o evento é liberado para que o reenvio do Stripe tente de novo.
+func (s *webhookService) HandleEvent(payload []byte, signature string) error {
+	event, err := webhook.ConstructEventWithOptions(payload, signature, s.secret, webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w %v", ErrInvalidWebhookSignature, err)
+	}
+
+	claimed, err := s.stripeEventRepository.Claim(event.ID, string(event.Type))
+	if err != nil {
+		return fmt.Errorf("Erro ao registrar evento %s: %w", event.ID, err)
+	}
+	if !claimed {
+		log.Printf("[Stripe Webhook] Evento %s (%s) já processado", event.ID, event.Type)
+		return nil
+	}
+
+	if err := s.dispatch(event); err != nil {
+		if releaseErr := s.stripeEventRepository.Release(event.ID); releaseErr != nil {
+			log.Printf("[Stripe Webhook] Erro ao liberar evento %s: %v", event.ID, releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *webhookService) dispatch(event stripe.Event) error {
+	switch event.Type {
+	case "payment_intent.succeeded":
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return fmt.Errorf("Erro ao ler PaymentIntent do evento %s: %w", event.ID, err)
+		}
+		return s.paymentSucceeded(pi)
+
+	case "payment_intent.amount_capturable_updated":
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return fmt.Errorf("Erro ao ler PaymentIntent do evento %s: %w", event.ID, err)
+		}
+		return s.paymentAuthorized(pi)
+
+	case "payment_intent.canceled":
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return fmt.Errorf("Erro ao ler PaymentIntent do evento %s: %w", event.ID, err)
+		}
+		return s.paymentCanceled(pi)
+
+	case "payment_intent.payment_failed":
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return fmt.Errorf("Erro ao ler PaymentIntent do evento %s: %w", event.ID, err)
+		}
+		return s.paymentFailed(pi)
+
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return fmt.Errorf("Erro ao ler cobrança do evento %s: %w", event.ID, err)
+		}
+		return s.chargeRefunded(charge)
+
+	case "transfer.created", "transfer.updated", "transfer.reversed":
+		var transfer stripe.Transfer
+		if err := json.Unmarshal(event.Data.Raw, &transfer); err != nil {
+			return fmt.Errorf("Erro ao ler repasse do evento %s: %w", event.ID, err)
+		}
+		return s.transferChanged(transfer)
+
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		var stripeDispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &stripeDispute); err != nil {
+			return fmt.Errorf("Erro ao ler contestação do evento %s: %w", event.ID, err)
+		}
+		return s.disputeService.HandleDispute(stripeDispute)
+
+	case "account.updated":
+		var account stripe.Account
+		if err := json.Unmarshal(event.Data.Raw, &account); err != nil {
+			return fmt.Errorf("Erro ao ler conta do evento %s: %w", event.ID, err)
+		}
+		return s.accountUpdated(account)
+	}
+
+	log.Printf("[Stripe Webhook] Evento %s ignorado (%s)", event.ID, event.Type)
+	return nil
+}
+
+// paymentStatusPredecessors lista, para cada status gravado pelos eventos de pagamento, de quais
+// status a visita pode chegar nele. O Stripe não garante a ordem dos eventos e um evento
+// atrasado não pode trazer de volta um pagamento já estornado, liberado ou contestado.
+var paymentStatusPredecessors = map[string][]string{
+	model.PaymentStatusAuthorized: {"", model.PaymentStatusFailed},
+	model.PaymentStatusSucceeded:  {"", model.PaymentStatusFailed, model.PaymentStatusAuthorized},
+}
+
+func (s *webhookService) paymentSucceeded(pi stripe.PaymentIntent) error {
+	matched, err := s.visitRepository.UpdateVisitsPaymentStatus(pi.ID, paymentStatusPredecessors[model.PaymentStatusSucceeded], model.PaymentStatusSucceeded)
+	if err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s: %w", pi.ID, err)
+	}
+	if matched == 0 {
+		// O pagamento pode ser confirmado antes de a visita ser criada com o PaymentIntent,
+		// ou o evento chegou depois de um estorno ou contestação.
+		log.Printf("[Stripe Webhook] Nenhuma visita a marcar como paga com o pagamento %s", pi.ID)
+	}
+
+	captureId := pi.ID
+	if pi.LatestCharge != nil && pi.LatestCharge.ID != "" {
+		captureId = pi.LatestCharge.ID
+	}
+	patientId := ""
+	if pi.Metadata != nil {
+		patientId = pi.Metadata["app_patient_id"]
+	}
+	if _, err := s.paymentRepository.RecordEntry(model.PaymentEntry{
+		Type:            model.PaymentEntryCapture,
+		Status:          model.PaymentEntryStatusSucceeded,
+		AmountInCents:   pi.AmountReceived,
+		Currency:        string(pi.Currency),
+		StripeID:        captureId,
+		PaymentIntentID: pi.ID,
+		PatientID:       patientId,
+	}); err != nil {
+		return fmt.Errorf("Erro ao registrar cobrança do pagamento %s: %w", pi.ID, err)
+	}
+	if err := s.paymentRepository.UpdateEntryStatus(model.PaymentEntryIntent, pi.ID, model.PaymentEntryStatusSucceeded); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s no livro-razão: %w", pi.ID, err)
+	}
+	return nil
+}
+
+// paymentAuthorized marca o valor como retido no cartão do paciente. Ele só é cobrado quando
+// o enfermeiro conclui a visita.
+func (s *webhookService) paymentAuthorized(pi stripe.PaymentIntent) error {
+	if _, err := s.visitRepository.UpdateVisitsPaymentStatus(pi.ID, paymentStatusPredecessors[model.PaymentStatusAuthorized], model.PaymentStatusAuthorized); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s: %w", pi.ID, err)
+	}
+	if err := s.paymentRepository.UpdateEntryStatus(model.PaymentEntryIntent, pi.ID, model.PaymentEntryStatusAuthorized); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s no livro-razão: %w", pi.ID, err)
+	}
+	return nil
+}
+
+// paymentCanceled registra a liberação do valor retido. Se a autorização expirou antes de a
+// visita ser atendida, as visitas que ainda aguardavam o enfermeiro são canceladas; as já
+// confirmadas ficam sem pagamento e são apenas logadas.
+func (s *webhookService) paymentCanceled(pi stripe.PaymentIntent) error {
+	if _, err := s.visitRepository.UpdateVisitsByPaymentIntentId(pi.ID, map[string]interface{}{
+		"payment_status": model.PaymentStatusReleased,
+	}); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s: %w", pi.ID, err)
+	}
+	if err := s.paymentRepository.UpdateEntryStatus(model.PaymentEntryIntent, pi.ID, model.PaymentEntryStatusCanceled); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s no livro-razão: %w", pi.ID, err)
+	}
+
+	visits, err := s.visitRepository.FindVisitsByPaymentIntentId(pi.ID)
+	if err != nil {
+		return fmt.Errorf("Erro ao buscar visitas do pagamento %s: %w", pi.ID, err)
+	}
+
+	reason := "A autorização do pagamento da visita expirou."
+	actor := lifecycle.Actor{ID: lifecycle.RoleSystem, Role: lifecycle.RoleSystem}
+	if _, err := s.visitStateMachine.TransitionMany(visits, model.VisitStatusCanceled, actor, reason, map[string]interface{}{"cancel_reason": reason}); err != nil {
+		return fmt.Errorf("Erro ao cancelar visitas do pagamento %s: %w", pi.ID, err)
+	}
+	for _, visit := range visits {
+		if visit.Status == model.VisitStatusConfirmed {
+			log.Printf("[Stripe Webhook] Visita confirmada %s ficou sem pagamento: autorização %s cancelada", visit.ID.Hex(), pi.ID)
+		}
+	}
+	return nil
+}
+
+// paymentFailed marca o pagamento como recusado e cancela as visitas que ainda aguardavam o enfermeiro.
+func (s *webhookService) paymentFailed(pi stripe.PaymentIntent) error {
+	if _, err := s.visitRepository.UpdateVisitsByPaymentIntentId(pi.ID, map[string]interface{}{
+		"payment_status": model.PaymentStatusFailed,
+	}); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s: %w", pi.ID, err)
+	}
+	if err := s.paymentRepository.UpdateEntryStatus(model.PaymentEntryIntent, pi.ID, model.PaymentEntryStatusFailed); err != nil {
+		return fmt.Errorf("Erro ao atualizar pagamento %s no livro-razão: %w", pi.ID, err)
+	}
+
+	visits, err := s.visitRepository.FindVisitsByPaymentIntentId(pi.ID)
+	if err != nil {
+		return fmt.Errorf("Erro ao buscar visitas do pagamento %s: %w", pi.ID, err)
+	}
+
+	reason := "O pagamento da visita foi recusado."
+	actor := lifecycle.Actor{ID: lifecycle.RoleSystem, Role: lifecycle.RoleSystem}
+	if _, err := s.visitStateMachine.TransitionMany(visits, model.VisitStatusCanceled, actor, reason, map[string]interface{}{"cancel_reason": reason}); err != nil {
+		return fmt.Errorf("Erro ao cancelar visitas do pagamento %s: %w", pi.ID, err)
+	}
+	return nil
+}
+
+// chargeRefunded registra o estorno. O valor estornado só é gravado quando o pagamento
+// pertence a uma única visita; em séries ele não tem como ser dividido com segurança.
+func (s *webhookService) chargeRefunded(charge stripe.Charge) error {
+	if charge.PaymentIntent == nil || charge.PaymentIntent.ID == "" {
+		log.Printf("[Stripe Webhook] Estorno da cobrança %s sem PaymentIntent", charge.ID)
+		return nil
+	}
+	paymentIntentId := charge.PaymentIntent.ID
+
+	status := model.PaymentStatusPartiallyRefunded
+	if charge.Refunded || charge.AmountRefunded >= charge.Amount {
+		status = model.PaymentStatusRefunded
+	}
+
+	visits, err := s.visitRepository.FindVisitsByPaymentIntentId(paymentIntentId)
+	if err != nil {
+		return fmt.Errorf("Erro ao buscar visitas do pagamento %s: %w", paymentIntentId, err)
+	}
+
+	updates := map[string]interface{}{"payment_status": status}
+	if len(visits) == 1 {
+		updates["refund_amount"] = float64(charge.AmountRefunded) / 100
+	}
+
+	if _, err := s.visitRepository.UpdateVisitsByPaymentIntentId(paymentIntentId, updates); err != nil {
+		return fmt.Errorf("Erro ao registrar estorno do pagamento %s: %w", paymentIntentId, err)
+	}
+
+	// Estornos feitos pelo painel do Stripe só chegam por aqui; os feitos pelo backend já
+	// foram registrados e são apenas atualizados.
+	if charge.Refunds == nil {
+		return nil
+	}
+	for _, refund := range charge.Refunds.Data {
+		entry := model.PaymentEntry{
+			Type:            model.PaymentEntryRefund,
+			Status:          refundEntryStatus(refund.Status),
+			AmountInCents:   refund.Amount,
+			Currency:        string(refund.Currency),
+			StripeID:        refund.ID,
+			PaymentIntentID: paymentIntentId,
+		}
+		if len(visits) == 1 {
+			entry.VisitID = visits[0].ID.Hex()
+		}
+		if _, err := s.paymentRepository.RecordEntry(entry); err != nil {
+			return fmt.Errorf("Erro ao registrar estorno %s no livro-razão: %w", refund.ID, err)
+		}
+	}
+	return nil
+}
+
+func refundEntryStatus(status stripe.RefundStatus) string {
+	switch status {
+	case stripe.RefundStatusSucceeded:
+		return model.PaymentEntryStatusSucceeded
+	case stripe.RefundStatusFailed, stripe.RefundStatusCanceled:
+		return model.PaymentEntryStatusFailed
+	}
+	return model.PaymentEntryStatusPending
+}
+
+func (s *webhookService) transferChanged(transfer stripe.Transfer) error {
+	status := model.TransferStatusCreated
+	if transfer.Reversed || transfer.AmountReversed > 0 {
+		status = model.TransferStatusReversed
+	}
+
+	if _, err := s.visitRepository.UpdateVisitsByTransferId(transfer.ID, map[string]interface{}{
+		"transfer_status": status,
+	}); err != nil {
+		return fmt.Errorf("Erro ao atualizar repasse %s: %w", transfer.ID, err)
+	}
+	if status == model.TransferStatusReversed {
+		if err := s.paymentRepository.UpdateEntryStatus(model.PaymentEntryTransfer, transfer.ID, model.PaymentEntryStatusReversed); err != nil {
+			return fmt.Errorf("Erro ao atualizar repasse %s no livro-razão: %w", transfer.ID, err)
+		}
+	}
+	return nil
+}
+
+// accountUpdated marca o cadastro do enfermeiro no Stripe como concluído quando a conta
+// Express já pode receber cobranças e repasses.
+func (s *webhookService) accountUpdated(account stripe.Account) error {
+	complete := account.ChargesEnabled && account.PayoutsEnabled && account.DetailsSubmitted
+
+	if err := s.nurseRepository.UpdateNurseByStripeAccountId(account.ID, map[string]interface{}{
+		"stripe_onboarding_complete": complete,
+	}); err != nil {
+		if errors.Is(err, repository.ErrStripeAccountNotFound) {
+			log.Printf("[Stripe Webhook] Conta %s não pertence a nenhum enfermeiro", account.ID)
+			return nil
+		}
+		return fmt.Errorf("Erro ao atualizar conta Stripe %s: %w", account.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package payment
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dispmocks "medassist/internal/dispute/mocks"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	repmocks "medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+const testWebhookSecret = "whsec_test"
+
+type webhookMocks struct {
+	visitRepo   *repmocks.MockVisitRepository
+	nurseRepo   *repmocks.MockNurseRepository
+	paymentRepo *repmocks.MockPaymentRepository
+	eventRepo   *repmocks.MockStripeEventRepository
+	disputes    *dispmocks.MockDisputeService
+}
+
+func newTestWebhookService(ctrl *gomock.Controller) (*webhookService, webhookMocks) {
+	m := webhookMocks{
+		visitRepo:   repmocks.NewMockVisitRepository(ctrl),
+		nurseRepo:   repmocks.NewMockNurseRepository(ctrl),
+		paymentRepo: repmocks.NewMockPaymentRepository(ctrl),
+		eventRepo:   repmocks.NewMockStripeEventRepository(ctrl),
+		disputes:    dispmocks.NewMockDisputeService(ctrl),
+	}
+	return newWebhookService(m.visitRepo, m.nurseRepo, m.paymentRepo, m.eventRepo, m.disputes, testWebhookSecret), m
+}
+
+// signedFixture lê um evento de testdata e o assina como o Stripe faria.
+func signedFixture(t *testing.T, name string) ([]byte, string) {
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("fixture %s: %v", name, err)
+	}
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: testWebhookSecret})
+	return signed.Payload, signed.Header
+}
+
+func TestWebhookService_HandleEvent(t *testing.T) {
+	t.Run("Erro_AssinaturaInvalida", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, _ := newTestWebhookService(ctrl)
+
+		payload, _ := signedFixture(t, "payment_intent_succeeded.json")
+		signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: "whsec_outro"})
+
+		err := s.HandleEvent(payload, signed.Header)
+
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+	})
+
+	t.Run("Sucesso_EventoRepetidoIgnorado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "payment_intent_succeeded.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_succeeded", "payment_intent.succeeded").Return(false, nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Erro_ProcessamentoLiberaEvento", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "payment_intent_succeeded.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_succeeded", "payment_intent.succeeded").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", gomock.Any(), model.PaymentStatusSucceeded).Return(int64(0), errors.New("db fora do ar"))
+		m.eventRepo.EXPECT().Release("evt_pi_succeeded").Return(nil)
+
+		assert.Error(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_PagamentoConfirmado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "payment_intent_succeeded.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_succeeded", "payment_intent.succeeded").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", []string{"", model.PaymentStatusFailed, model.PaymentStatusAuthorized}, model.PaymentStatusSucceeded).Return(int64(1), nil)
+		m.paymentRepo.EXPECT().RecordEntry(model.PaymentEntry{
+			Type:            model.PaymentEntryCapture,
+			Status:          model.PaymentEntryStatusSucceeded,
+			AmountInCents:   15000,
+			Currency:        "brl",
+			StripeID:        "ch_123",
+			PaymentIntentID: "pi_123",
+			PatientID:       "patient-1",
+		}).Return(model.PaymentEntry{}, nil)
+		m.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryIntent, "pi_123", model.PaymentEntryStatusSucceeded).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_PagamentoRecusadoCancelaPendentes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		pending := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, PaymentIntentID: "pi_123"}
+		confirmed := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusConfirmed, PaymentIntentID: "pi_123"}
+
+		payload, header := signedFixture(t, "payment_intent_payment_failed.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_failed", "payment_intent.payment_failed").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", map[string]interface{}{
+			"payment_status": model.PaymentStatusFailed,
+		}).Return(int64(2), nil)
+		m.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryIntent, "pi_123", model.PaymentEntryStatusFailed).Return(nil)
+		m.visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return([]model.Visit{pending, confirmed}, nil)
+		m.visitRepo.EXPECT().UpdateVisitStatus(pending.ID.Hex(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+				assert.Equal(t, model.VisitStatusCanceled, change.To)
+				assert.Equal(t, "SYSTEM", change.ActorRole)
+				return model.Visit{Status: change.To}, nil
+			})
+		m.visitRepo.EXPECT().ReleaseVisitSlot(pending.ID.Hex()).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_PagamentoAutorizado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "payment_intent_amount_capturable_updated.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_authorized", "payment_intent.amount_capturable_updated").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", []string{"", model.PaymentStatusFailed}, model.PaymentStatusAuthorized).Return(int64(1), nil)
+		m.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryIntent, "pi_123", model.PaymentEntryStatusAuthorized).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_EventoAtrasadoNaoDesfazEstorno", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		// a autorização chega depois do estorno: nenhuma visita está em um status que a precede
+		payload, header := signedFixture(t, "payment_intent_amount_capturable_updated.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_authorized", "payment_intent.amount_capturable_updated").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsPaymentStatus("pi_123", gomock.Any(), model.PaymentStatusAuthorized).DoAndReturn(
+			func(paymentIntentId string, from []string, to string) (int64, error) {
+				for _, status := range []string{model.PaymentStatusRefunded, model.PaymentStatusReleased, model.PaymentStatusDisputed, model.PaymentStatusSucceeded} {
+					assert.NotContains(t, from, status)
+				}
+				return 0, nil
+			})
+		m.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryIntent, "pi_123", model.PaymentEntryStatusAuthorized).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_AutorizacaoExpiradaCancelaPendentes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		pending := model.Visit{ID: primitive.NewObjectID(), Status: model.VisitStatusPending, PaymentIntentID: "pi_123"}
+
+		payload, header := signedFixture(t, "payment_intent_canceled.json")
+		m.eventRepo.EXPECT().Claim("evt_pi_canceled", "payment_intent.canceled").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", map[string]interface{}{
+			"payment_status": model.PaymentStatusReleased,
+		}).Return(int64(1), nil)
+		m.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryIntent, "pi_123", model.PaymentEntryStatusCanceled).Return(nil)
+		m.visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return([]model.Visit{pending}, nil)
+		m.visitRepo.EXPECT().UpdateVisitStatus(pending.ID.Hex(), gomock.Any(), gomock.Any()).Return(model.Visit{Status: model.VisitStatusCanceled}, nil)
+		m.visitRepo.EXPECT().ReleaseVisitSlot(pending.ID.Hex()).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_EstornoParcial", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "charge_refunded.json")
+		m.eventRepo.EXPECT().Claim("evt_charge_refunded", "charge.refunded").Return(true, nil)
+		visit := model.Visit{ID: primitive.NewObjectID(), PaymentIntentID: "pi_123"}
+		m.visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return([]model.Visit{visit}, nil)
+		m.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", map[string]interface{}{
+			"payment_status": model.PaymentStatusPartiallyRefunded,
+			"refund_amount":  75.0,
+		}).Return(int64(1), nil)
+		m.paymentRepo.EXPECT().RecordEntry(model.PaymentEntry{
+			Type:            model.PaymentEntryRefund,
+			Status:          model.PaymentEntryStatusSucceeded,
+			AmountInCents:   7500,
+			Currency:        "brl",
+			StripeID:        "re_123",
+			PaymentIntentID: "pi_123",
+			VisitID:         visit.ID.Hex(),
+		}).Return(model.PaymentEntry{}, nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_EstornoDeSerieNaoGravaValor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "charge_refunded.json")
+		m.eventRepo.EXPECT().Claim("evt_charge_refunded", "charge.refunded").Return(true, nil)
+		m.visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return([]model.Visit{{}, {}}, nil)
+		m.visitRepo.EXPECT().UpdateVisitsByPaymentIntentId("pi_123", map[string]interface{}{
+			"payment_status": model.PaymentStatusPartiallyRefunded,
+		}).Return(int64(2), nil)
+		m.paymentRepo.EXPECT().RecordEntry(gomock.Any()).DoAndReturn(func(entry model.PaymentEntry) (model.PaymentEntry, error) {
+			assert.Empty(t, entry.VisitID)
+			return entry, nil
+		})
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_RepasseRevertido", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "transfer_reversed.json")
+		m.eventRepo.EXPECT().Claim("evt_transfer_reversed", "transfer.reversed").Return(true, nil)
+		m.visitRepo.EXPECT().UpdateVisitsByTransferId("tr_123", map[string]interface{}{
+			"transfer_status": model.TransferStatusReversed,
+		}).Return(int64(1), nil)
+		m.paymentRepo.EXPECT().UpdateEntryStatus(model.PaymentEntryTransfer, "tr_123", model.PaymentEntryStatusReversed).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_ContestacaoEncaminhada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "charge_dispute_created.json")
+		m.eventRepo.EXPECT().Claim("evt_dispute_created", "charge.dispute.created").Return(true, nil)
+		m.disputes.EXPECT().HandleDispute(gomock.Any()).DoAndReturn(func(d stripe.Dispute) error {
+			assert.Equal(t, "dp_123", d.ID)
+			assert.Equal(t, "pi_123", d.PaymentIntent.ID)
+			assert.Equal(t, "ch_123", d.Charge.ID)
+			assert.Equal(t, stripe.DisputeStatusNeedsResponse, d.Status)
+			return nil
+		})
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_ContaExpressConcluida", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "account_updated.json")
+		m.eventRepo.EXPECT().Claim("evt_account_updated", "account.updated").Return(true, nil)
+		m.nurseRepo.EXPECT().UpdateNurseByStripeAccountId("acct_123", map[string]interface{}{
+			"stripe_onboarding_complete": true,
+		}).Return(nil)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+
+	t.Run("Sucesso_ContaDesconhecidaIgnorada", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		s, m := newTestWebhookService(ctrl)
+
+		payload, header := signedFixture(t, "account_updated.json")
+		m.eventRepo.EXPECT().Claim("evt_account_updated", "account.updated").Return(true, nil)
+		m.nurseRepo.EXPECT().UpdateNurseByStripeAccountId("acct_123", gomock.Any()).Return(repository.ErrStripeAccountNotFound)
+
+		assert.NoError(t, s.HandleEvent(payload, header))
+	})
+}
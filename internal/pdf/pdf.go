@@ -0,0 +1,160 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Dimensões de uma página A4 em pontos e margem usada em todas as páginas.
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+)
+
+// Fontes padrão do PDF, que não precisam ser embutidas no arquivo.
+const (
+	fontRegular = "F1" // Helvetica
+	fontBold    = "F2" // Helvetica-Bold
+)
+
+// Document monta um PDF simples de texto, com quebra de página automática. Atende a extratos
+// e recibos, que só precisam de títulos, linhas de texto e tabelas.
+type Document struct {
+	pages [][]string
+	y     float64
+}
+
+// New cria um documento com uma página em branco.
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+// Title escreve um título em negrito.
+func (d *Document) Title(text string) {
+	d.line(24)
+	d.write(fontBold, 16, margin, text)
+}
+
+// Heading escreve um subtítulo em negrito.
+func (d *Document) Heading(text string) {
+	d.line(20)
+	d.write(fontBold, 12, margin, text)
+}
+
+// Text escreve uma linha de texto.
+func (d *Document) Text(text string) {
+	d.line(14)
+	d.write(fontRegular, 10, margin, text)
+}
+
+// Row escreve uma linha de tabela em que cada coluna ocupa a largura, em pontos, informada
+// em widths. Com bold, a linha sai em negrito (ex: cabeçalho da tabela).
+func (d *Document) Row(columns []string, widths []float64, bold bool) {
+	font := fontRegular
+	if bold {
+		font = fontBold
+	}
+
+	d.line(13)
+	x := margin
+	for i, column := range columns {
+		d.write(font, 9, x, column)
+		if i < len(widths) {
+			x += widths[i]
+		}
+	}
+}
+
+// Space deixa uma linha em branco.
+func (d *Document) Space() {
+	d.line(10)
+}
+
+// Bytes gera o arquivo PDF.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// 1: catálogo, 2: árvore de páginas, 3 e 4: fontes; depois, página e conteúdo de cada página
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 6+2*i))
+
+		content := strings.Join(page, "\n")
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, nil)
+	d.y = pageHeight - margin
+}
+
+// line avança para a próxima linha, abrindo uma página nova quando a atual acaba.
+func (d *Document) line(leading float64) {
+	if d.y-leading < margin {
+		d.newPage()
+	}
+	d.y -= leading
+}
+
+func (d *Document) write(font string, size, x float64, text string) {
+	page := len(d.pages) - 1
+	d.pages[page] = append(d.pages[page],
+		fmt.Sprintf("BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET", font, size, x, d.y, encode(text)))
+}
+
+// encode converte o texto para WinAnsiEncoding, que cobre os acentos do português, e escapa
+// os caracteres especiais das strings do PDF. Caracteres fora da codificação viram "?".
+func encode(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		case r == '…':
+			b.WriteString("\\205")
+		case r == '–' || r == '—':
+			b.WriteByte('-')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	t.Run("Sucesso_GeraPdfValido", func(t *testing.T) {
+		doc := New()
+		doc.Title("Extrato de ganhos")
+		doc.Row([]string{"Data", "Valor"}, []float64{100, 100}, true)
+
+		out := doc.Bytes()
+
+		assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+		assert.Contains(t, string(out), "/Count 1")
+		assert.Contains(t, string(out), "(Extrato de ganhos) Tj")
+	})
+
+	t.Run("Sucesso_XrefApontaParaOsObjetos", func(t *testing.T) {
+		out := string(New().Bytes())
+
+		xref := out[strings.Index(out, "xref\n"):]
+		lines := strings.Split(xref, "\n")
+		// a linha 3 é a primeira entrada em uso, a do objeto 1
+		offset, err := strconv.Atoi(strings.Fields(lines[3])[0])
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out[offset:], "1 0 obj"))
+	})
+
+	t.Run("Sucesso_QuebraPagina", func(t *testing.T) {
+		doc := New()
+		for i := 0; i < 100; i++ {
+			doc.Text("linha")
+		}
+
+		assert.Contains(t, string(doc.Bytes()), "/Count 2")
+	})
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, `Ganhos \(l\355quido\) \\ R$ 10,00`, encode(`Ganhos (líquido) \ R$ 10,00`))
+	assert.Equal(t, `Servi\347o ?`, encode("Serviço ✓"))
+}
@@ -0,0 +1,143 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"medassist/internal/scheduling"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Tipos de pedido de visita considerados no cálculo do preço.
+const (
+	RequestScheduled = "SCHEDULED"
+	RequestImmediate = "IMMEDIATE"
+	RequestBroadcast = "BROADCAST" // visita imediata para qualquer enfermeiro próximo
+)
+
+// Nomes dos adicionais aplicados sobre o preço do enfermeiro.
+const (
+	SurchargeImmediate = "IMMEDIATE"
+	SurchargeNight     = "NIGHT"
+)
+
+// Horário noturno (no fuso da plataforma) em que o adicional noturno é cobrado.
+const (
+	nightStartHour = 22
+	nightEndHour   = 6
+)
+
+// ErrPriceUnavailable indica que o enfermeiro ainda não definiu o preço da visita.
+var ErrPriceUnavailable = errors.New("O enfermeiro não possui um preço definido para visitas.")
+
+// ErrInvalidRequestType indica um tipo de pedido de visita desconhecido.
+var ErrInvalidRequestType = errors.New("Tipo de solicitação de visita inválido.")
+
+// Policy define os adicionais cobrados sobre o preço do enfermeiro e o preço das visitas
+// imediatas pedidas a qualquer enfermeiro, quando ainda não se sabe quem vai atender.
+type Policy struct {
+	ImmediateSurchargePercent float64
+	NightSurchargePercent     float64
+	BroadcastBasePrice        float64
+}
+
+// Surcharge é um adicional aplicado ao preço de uma visita.
+type Surcharge struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// VisitPrice é o preço de uma ocorrência.
+type VisitPrice struct {
+	Date       time.Time   `json:"date"`
+	BasePrice  float64     `json:"base_price"`
+	Surcharges []Surcharge `json:"surcharges,omitempty"`
+	Price      float64     `json:"price"`
+}
+
+// Quote é o preço de um pedido de visita, com uma entrada por ocorrência.
+type Quote struct {
+	Visits []VisitPrice `json:"visits"`
+	Total  float64      `json:"total"`
+}
+
+const defaultBroadcastBasePrice = 150
+
+// LoadPolicy lê PRICING_IMMEDIATE_SURCHARGE_PERCENT, PRICING_NIGHT_SURCHARGE_PERCENT e
+// PRICING_BROADCAST_BASE_PRICE. Sem as variáveis não há adicionais e a visita imediata
+// para qualquer enfermeiro custa R$150.
+func LoadPolicy() Policy {
+	policy := Policy{BroadcastBasePrice: defaultBroadcastBasePrice}
+
+	if percent, err := strconv.ParseFloat(os.Getenv("PRICING_IMMEDIATE_SURCHARGE_PERCENT"), 64); err == nil && percent >= 0 {
+		policy.ImmediateSurchargePercent = percent
+	}
+	if percent, err := strconv.ParseFloat(os.Getenv("PRICING_NIGHT_SURCHARGE_PERCENT"), 64); err == nil && percent >= 0 {
+		policy.NightSurchargePercent = percent
+	}
+	if price, err := strconv.ParseFloat(os.Getenv("PRICING_BROADCAST_BASE_PRICE"), 64); err == nil && price > 0 {
+		policy.BroadcastBasePrice = price
+	}
+
+	return policy
+}
+
+// Quote calcula o preço das visitas marcadas em dates a partir do preço do enfermeiro. Em
+// pedidos RequestBroadcast o preço do enfermeiro é ignorado e BroadcastBasePrice é usado.
+func (p Policy) Quote(nursePrice float64, requestType string, dates []time.Time) (Quote, error) {
+	basePrice := nursePrice
+	switch requestType {
+	case RequestScheduled, RequestImmediate:
+	case RequestBroadcast:
+		basePrice = p.BroadcastBasePrice
+	default:
+		return Quote{}, ErrInvalidRequestType
+	}
+	if basePrice <= 0 {
+		return Quote{}, ErrPriceUnavailable
+	}
+
+	quote := Quote{Visits: make([]VisitPrice, 0, len(dates))}
+	for _, date := range dates {
+		visit := VisitPrice{Date: date, BasePrice: basePrice, Price: basePrice}
+
+		if requestType != RequestScheduled && p.ImmediateSurchargePercent > 0 {
+			visit.Surcharges = append(visit.Surcharges, Surcharge{Name: SurchargeImmediate, Amount: percentOf(basePrice, p.ImmediateSurchargePercent)})
+		}
+		if isNight(date) && p.NightSurchargePercent > 0 {
+			visit.Surcharges = append(visit.Surcharges, Surcharge{Name: SurchargeNight, Amount: percentOf(basePrice, p.NightSurchargePercent)})
+		}
+		for _, surcharge := range visit.Surcharges {
+			visit.Price = roundCents(visit.Price + surcharge.Amount)
+		}
+
+		quote.Visits = append(quote.Visits, visit)
+		quote.Total = roundCents(quote.Total + visit.Price)
+	}
+
+	return quote, nil
+}
+
+// AmountInCents retorna o total em centavos, como é cobrado no Stripe.
+func (q Quote) AmountInCents() int64 {
+	return ToCents(q.Total)
+}
+
+// ToCents converte um valor em reais para centavos.
+func ToCents(value float64) int64 {
+	return int64(math.Round(value * 100))
+}
+
+func percentOf(value, percent float64) float64 {
+	return roundCents(value * percent / 100)
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func isNight(date time.Time) bool {
+	hour := date.In(scheduling.Location()).Hour()
+	return hour >= nightStartHour || hour < nightEndHour
+}
@@ -0,0 +1,61 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/scheduling"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Quote(t *testing.T) {
+	policy := Policy{ImmediateSurchargePercent: 20, NightSurchargePercent: 10, BroadcastBasePrice: 150}
+	afternoon := time.Date(2025, 3, 10, 14, 0, 0, 0, scheduling.Location())
+	night := time.Date(2025, 3, 10, 23, 0, 0, 0, scheduling.Location())
+
+	t.Run("Sucesso_AgendadaSemAdicional", func(t *testing.T) {
+		quote, err := policy.Quote(200, RequestScheduled, []time.Time{afternoon})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200.0, quote.Total)
+		assert.Empty(t, quote.Visits[0].Surcharges)
+		assert.Equal(t, int64(20000), quote.AmountInCents())
+	})
+
+	t.Run("Sucesso_ImediataNoturna", func(t *testing.T) {
+		quote, err := policy.Quote(200, RequestImmediate, []time.Time{night})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Surcharge{{Name: SurchargeImmediate, Amount: 40}, {Name: SurchargeNight, Amount: 20}}, quote.Visits[0].Surcharges)
+		assert.Equal(t, 260.0, quote.Total)
+	})
+
+	t.Run("Sucesso_SerieSomaAsOcorrencias", func(t *testing.T) {
+		quote, err := policy.Quote(99.99, RequestScheduled, []time.Time{afternoon, afternoon.AddDate(0, 0, 7), afternoon.AddDate(0, 0, 14)})
+
+		assert.NoError(t, err)
+		assert.Len(t, quote.Visits, 3)
+		assert.Equal(t, 299.97, quote.Total)
+		assert.Equal(t, int64(29997), quote.AmountInCents())
+	})
+
+	t.Run("Sucesso_BroadcastIgnoraPrecoDoEnfermeiro", func(t *testing.T) {
+		quote, err := policy.Quote(0, RequestBroadcast, []time.Time{afternoon})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 180.0, quote.Total)
+	})
+
+	t.Run("Erro_EnfermeiroSemPreco", func(t *testing.T) {
+		_, err := policy.Quote(0, RequestScheduled, []time.Time{afternoon})
+
+		assert.ErrorIs(t, err, ErrPriceUnavailable)
+	})
+
+	t.Run("Erro_TipoDesconhecido", func(t *testing.T) {
+		_, err := policy.Quote(200, "WHENEVER", []time.Time{afternoon})
+
+		assert.ErrorIs(t, err, ErrInvalidRequestType)
+	})
+}
@@ -0,0 +1,113 @@
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/scheduling"
+	"medassist/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrVisitNotCompleted indica que o recibo foi pedido para uma visita que não foi concluída.
+var ErrVisitNotCompleted = errors.New("O recibo só é emitido para visitas concluídas.")
+
+// Issuer emite o recibo das visitas concluídas: gera o PDF, guarda no GridFS, registra na
+// visita e envia por e-mail ao paciente.
+type Issuer interface {
+	Issue(visit model.Visit) (model.VisitReceipt, error)
+}
+
+type issuer struct {
+	userRepository  repository.UserRepository
+	nurseRepository repository.NurseRepository
+	visitRepository repository.VisitRepository
+	now             func() time.Time
+	sendEmail       func(patientEmail, patientName, visitDate, receiptNumber string, receiptPDF []byte) error
+}
+
+func NewIssuer(userRepository repository.UserRepository, nurseRepository repository.NurseRepository, visitRepository repository.VisitRepository) Issuer {
+	return &issuer{
+		userRepository:  userRepository,
+		nurseRepository: nurseRepository,
+		visitRepository: visitRepository,
+		now:             time.Now,
+		sendEmail:       utils.SendEmailVisitReceipt,
+	}
+}
+
+// Issue emite o recibo da visita. Uma visita que já tem recibo não ganha outro, para o número
+// e o arquivo enviados ao paciente continuarem valendo. Falha no envio do e-mail não desfaz a
+// emissão: o recibo continua disponível para download.
+func (i *issuer) Issue(visit model.Visit) (model.VisitReceipt, error) {
+	if visit.Receipt != nil {
+		return *visit.Receipt, nil
+	}
+	if visit.Status != model.VisitStatusCompleted {
+		return model.VisitReceipt{}, ErrVisitNotCompleted
+	}
+
+	patient, err := i.userRepository.FindUserById(visit.PatientId)
+	if err != nil {
+		return model.VisitReceipt{}, fmt.Errorf("Erro ao buscar paciente da visita: %w", err)
+	}
+	nurse, err := i.nurseRepository.FindNurseById(visit.NurseId)
+	if err != nil {
+		return model.VisitReceipt{}, fmt.Errorf("Erro ao buscar enfermeiro da visita: %w", err)
+	}
+
+	issuedAt := i.now()
+	receipt := New(visit, nurse, patient, issuedAt)
+	document := RenderPDF(receipt)
+
+	fileId, err := i.userRepository.UploadFile(bytes.NewReader(document), FileName(receipt.Number), "application/pdf")
+	if err != nil {
+		return model.VisitReceipt{}, fmt.Errorf("Erro ao salvar recibo: %w", err)
+	}
+
+	visitReceipt := model.VisitReceipt{
+		Number:   receipt.Number,
+		FileID:   fileId,
+		IssuedAt: issuedAt,
+	}
+	if _, err := i.visitRepository.RecordVisitReceipt(visit.ID.Hex(), visitReceipt); err != nil {
+		if errors.Is(err, repository.ErrVisitReceiptTaken) {
+			return i.storedReceipt(visit, fileId)
+		}
+		return model.VisitReceipt{}, fmt.Errorf("Erro ao registrar recibo na visita: %w", err)
+	}
+
+	visitDate := receipt.VisitDate.In(scheduling.Location()).Format("02/01/2006 15:04")
+	if err := i.sendEmail(receipt.PatientEmail, receipt.PatientName, visitDate, receipt.Number, document); err != nil {
+		log.Printf("Erro ao enviar recibo %s por e-mail: %v", receipt.Number, err)
+	}
+
+	return visitReceipt, nil
+}
+
+// storedReceipt descarta o arquivo desta emissão quando outra requisição registrou o recibo
+// primeiro e devolve o recibo gravado na visita, que é o enviado ao paciente.
+func (i *issuer) storedReceipt(visit model.Visit, discardedFileId primitive.ObjectID) (model.VisitReceipt, error) {
+	if err := i.userRepository.DeleteFile(discardedFileId); err != nil {
+		log.Printf("Erro ao remover recibo duplicado %s da visita %s: %v", discardedFileId.Hex(), visit.ID.Hex(), err)
+	}
+
+	stored, err := i.visitRepository.FindVisitById(visit.ID.Hex())
+	if err != nil {
+		return model.VisitReceipt{}, fmt.Errorf("Erro ao buscar recibo da visita: %w", err)
+	}
+	if stored.Receipt == nil {
+		return model.VisitReceipt{}, fmt.Errorf("Erro ao buscar recibo da visita: recibo não encontrado")
+	}
+	return *stored.Receipt, nil
+}
+
+// FileName é o nome do arquivo do recibo, usado no GridFS, no anexo do e-mail e no download.
+func FileName(number string) string {
+	return "recibo-" + number + ".pdf"
+}
@@ -0,0 +1,155 @@
+package receipt
+
+import (
+	"fmt"
+	"medassist/internal/model"
+	"medassist/internal/pdf"
+	"medassist/internal/scheduling"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Receipt reúne o que é impresso no recibo de uma visita concluída, usado pelo paciente para
+// pedir o reembolso ao plano de saúde.
+type Receipt struct {
+	Number   string
+	IssuedAt time.Time
+
+	PatientName  string
+	PatientCpf   string
+	PatientEmail string
+
+	NurseName           string
+	NurseCoren          string
+	NurseSpecialization string
+
+	Service   string
+	VisitDate time.Time
+	Address   string
+
+	ValueInCents    int64 // valor da visita
+	DiscountInCents int64 // desconto de cupom, pago pela plataforma
+	PaidInCents     int64 // valor efetivamente pago pelo paciente
+	PaymentMethod   string
+}
+
+// maxLineLength é quanto cabe numa linha do PDF, que não quebra texto longo.
+const maxLineLength = 85
+
+var paymentMethodLabels = map[string]string{
+	model.PaymentMethodCard: "Cartão de crédito",
+	model.PaymentMethodPix:  "PIX",
+}
+
+// Number é o número do recibo da visita. Ele deriva do ID da visita, então é único e o
+// suporte encontra a visita a partir do recibo apresentado pelo paciente.
+func Number(visit model.Visit) string {
+	return "MA-" + strings.ToUpper(visit.ID.Hex())
+}
+
+// New monta o recibo com os dados da visita, do enfermeiro e do paciente.
+func New(visit model.Visit, nurse model.Nurse, patient model.User, issuedAt time.Time) Receipt {
+	paymentMethod := visit.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = model.PaymentMethodCard // visitas anteriores ao PIX só aceitavam cartão
+	}
+
+	service := visit.VisitType
+	if visit.Description != "" {
+		service += " - " + visit.Description
+	}
+
+	address := fmt.Sprintf("%s, %s", visit.Street, visit.Number)
+	if visit.Complement != "" {
+		address += " - " + visit.Complement
+	}
+	address += fmt.Sprintf(", %s", visit.Neighborhood)
+	if patient.City != "" {
+		address += fmt.Sprintf(", %s/%s", patient.City, patient.UF)
+	}
+	address += " - CEP " + visit.CEP
+
+	receipt := Receipt{
+		Number:              Number(visit),
+		IssuedAt:            issuedAt,
+		PatientName:         visit.PatientName,
+		PatientCpf:          patient.Cpf,
+		PatientEmail:        visit.PatientEmail,
+		NurseName:           nurse.Name,
+		NurseCoren:          nurse.Coren,
+		NurseSpecialization: nurse.Specialization,
+		Service:             service,
+		VisitDate:           visit.VisitDate,
+		Address:             address,
+		ValueInCents:        visit.PaidInCents(),
+		PaidInCents:         visit.PaidInCents(),
+		PaymentMethod:       paymentMethod,
+	}
+	if visit.Discount != nil {
+		receipt.ValueInCents += visit.Discount.AmountInCents
+		receipt.DiscountInCents = visit.Discount.AmountInCents
+	}
+	return receipt
+}
+
+// RenderPDF gera o recibo em PDF, com horários no fuso de Brasília.
+func RenderPDF(receipt Receipt) []byte {
+	location := scheduling.Location()
+
+	doc := pdf.New()
+	doc.Title("Recibo de prestação de serviço de enfermagem")
+	doc.Text("Recibo nº " + receipt.Number)
+	doc.Text("Emitido em " + receipt.IssuedAt.In(location).Format("02/01/2006 15:04"))
+
+	doc.Space()
+	doc.Heading("Paciente")
+	doc.Text("Nome: " + receipt.PatientName)
+	if receipt.PatientCpf != "" {
+		doc.Text("CPF: " + receipt.PatientCpf)
+	}
+	doc.Text("E-mail: " + receipt.PatientEmail)
+
+	doc.Space()
+	doc.Heading("Profissional")
+	doc.Text("Nome: " + receipt.NurseName)
+	doc.Text("COREN: " + receipt.NurseCoren)
+	if receipt.NurseSpecialization != "" {
+		doc.Text("Especialização: " + receipt.NurseSpecialization)
+	}
+
+	doc.Space()
+	doc.Heading("Serviço")
+	doc.Text("Descrição: " + truncate(receipt.Service, maxLineLength))
+	doc.Text("Data: " + receipt.VisitDate.In(location).Format("02/01/2006 15:04"))
+	doc.Text("Local: " + truncate(receipt.Address, maxLineLength))
+
+	doc.Space()
+	doc.Heading("Pagamento")
+	doc.Text("Valor do serviço: " + money(receipt.ValueInCents))
+	if receipt.DiscountInCents > 0 {
+		doc.Text("Desconto promocional: " + money(receipt.DiscountInCents))
+	}
+	doc.Text("Valor pago: " + money(receipt.PaidInCents))
+	doc.Text("Forma de pagamento: " + paymentMethodLabels[receipt.PaymentMethod])
+
+	doc.Space()
+	doc.Text(fmt.Sprintf("Recebemos de %s a importância de %s", receipt.PatientName, money(receipt.PaidInCents)))
+	doc.Text(fmt.Sprintf("referente ao atendimento domiciliar de enfermagem prestado em %s", receipt.VisitDate.In(location).Format("02/01/2006")))
+	doc.Text(fmt.Sprintf("por %s, COREN %s.", receipt.NurseName, receipt.NurseCoren))
+
+	return doc.Bytes()
+}
+
+func money(amountInCents int64) string {
+	value := strconv.FormatFloat(float64(amountInCents)/100, 'f', 2, 64)
+	return "R$ " + strings.Replace(value, ".", ",", 1)
+}
+
+func truncate(text string, size int) string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return text
+	}
+	return string(runes[:size-1]) + "…"
+}
@@ -0,0 +1,183 @@
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"medassist/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func completedVisit() model.Visit {
+	return model.Visit{
+		ID:            primitive.NewObjectID(),
+		Status:        model.VisitStatusCompleted,
+		PatientId:     primitive.NewObjectID().Hex(),
+		PatientName:   "Maria",
+		PatientEmail:  "maria@email.com",
+		NurseId:       primitive.NewObjectID().Hex(),
+		VisitType:     "Curativo",
+		Description:   "Troca de curativo",
+		VisitDate:     time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+		VisitValue:    150,
+		PaymentMethod: model.PaymentMethodPix,
+		Street:        "Rua A",
+		Number:        "10",
+		Neighborhood:  "Centro",
+		CEP:           "01000-000",
+	}
+}
+
+func TestNew(t *testing.T) {
+	nurse := model.Nurse{Name: "Ana", Coren: "SP-123456", Specialization: "Feridas"}
+	patient := model.User{Cpf: "123.456.789-00", City: "São Paulo", UF: "SP"}
+	issuedAt := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	t.Run("Sucesso_MontaRecibo", func(t *testing.T) {
+		visit := completedVisit()
+
+		receipt := New(visit, nurse, patient, issuedAt)
+
+		assert.Equal(t, "MA-"+strings.ToUpper(visit.ID.Hex()), receipt.Number)
+		assert.Equal(t, "SP-123456", receipt.NurseCoren)
+		assert.Equal(t, "Curativo - Troca de curativo", receipt.Service)
+		assert.Equal(t, "Rua A, 10, Centro, São Paulo/SP - CEP 01000-000", receipt.Address)
+		assert.Equal(t, int64(15000), receipt.PaidInCents)
+		assert.Equal(t, model.PaymentMethodPix, receipt.PaymentMethod)
+	})
+
+	t.Run("Sucesso_DescontoSeparadoDoValorPago", func(t *testing.T) {
+		visit := completedVisit()
+		visit.PaymentMethod = ""
+		visit.Discount = &model.VisitDiscount{Code: "BEMVINDO", AmountInCents: 2000}
+
+		receipt := New(visit, nurse, patient, issuedAt)
+
+		assert.Equal(t, int64(15000), receipt.ValueInCents)
+		assert.Equal(t, int64(2000), receipt.DiscountInCents)
+		assert.Equal(t, int64(13000), receipt.PaidInCents)
+		assert.Equal(t, model.PaymentMethodCard, receipt.PaymentMethod)
+	})
+
+	t.Run("Sucesso_GeraPdf", func(t *testing.T) {
+		receipt := New(completedVisit(), nurse, patient, issuedAt)
+
+		out := RenderPDF(receipt)
+
+		assert.True(t, bytes.HasPrefix(out, []byte("%PDF-")))
+		assert.Contains(t, string(out), "(COREN: SP-123456) Tj")
+		assert.Contains(t, string(out), "(Valor pago: R$ 150,00) Tj")
+		assert.Contains(t, string(out), "(Data: 10/03/2025 09:00) Tj")
+	})
+}
+
+type issuerMocks struct {
+	users  *mocks.MockUserRepository
+	nurses *mocks.MockNurseRepository
+	visits *mocks.MockVisitRepository
+	sent   []string
+}
+
+func setupIssuer(t *testing.T, emailErr error) (*issuer, *issuerMocks) {
+	ctrl := gomock.NewController(t)
+	m := &issuerMocks{
+		users:  mocks.NewMockUserRepository(ctrl),
+		nurses: mocks.NewMockNurseRepository(ctrl),
+		visits: mocks.NewMockVisitRepository(ctrl),
+	}
+	i := &issuer{
+		userRepository:  m.users,
+		nurseRepository: m.nurses,
+		visitRepository: m.visits,
+		now:             func() time.Time { return time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC) },
+		sendEmail: func(patientEmail, patientName, visitDate, receiptNumber string, receiptPDF []byte) error {
+			m.sent = append(m.sent, patientEmail+" "+visitDate+" "+receiptNumber)
+			return emailErr
+		},
+	}
+	return i, m
+}
+
+func TestIssuer_Issue(t *testing.T) {
+	t.Run("Erro_VisitaNaoConcluida", func(t *testing.T) {
+		i, _ := setupIssuer(t, nil)
+		visit := completedVisit()
+		visit.Status = model.VisitStatusConfirmed
+
+		_, err := i.Issue(visit)
+
+		assert.ErrorIs(t, err, ErrVisitNotCompleted)
+	})
+
+	t.Run("Erro_FalhaAoSalvarArquivo", func(t *testing.T) {
+		i, m := setupIssuer(t, nil)
+		visit := completedVisit()
+		m.users.EXPECT().FindUserById(visit.PatientId).Return(model.User{}, nil)
+		m.nurses.EXPECT().FindNurseById(visit.NurseId).Return(model.Nurse{}, nil)
+		m.users.EXPECT().UploadFile(gomock.Any(), gomock.Any(), "application/pdf").Return(primitive.NilObjectID, errors.New("gridfs"))
+
+		_, err := i.Issue(visit)
+
+		assert.Error(t, err)
+		assert.Empty(t, m.sent)
+	})
+
+	t.Run("Sucesso_VisitaJaTemRecibo", func(t *testing.T) {
+		i, m := setupIssuer(t, nil)
+		visit := completedVisit()
+		visit.Receipt = &model.VisitReceipt{Number: Number(visit), FileID: primitive.NewObjectID()}
+
+		receipt, err := i.Issue(visit)
+
+		assert.NoError(t, err)
+		assert.Equal(t, *visit.Receipt, receipt)
+		assert.Empty(t, m.sent)
+	})
+
+	t.Run("Sucesso_EmiteSalvaEEnvia", func(t *testing.T) {
+		i, m := setupIssuer(t, errors.New("sendgrid")) // falha no e-mail não desfaz a emissão
+		visit := completedVisit()
+		fileId := primitive.NewObjectID()
+		number := Number(visit)
+
+		m.users.EXPECT().FindUserById(visit.PatientId).Return(model.User{Cpf: "123.456.789-00"}, nil)
+		m.nurses.EXPECT().FindNurseById(visit.NurseId).Return(model.Nurse{Name: "Ana", Coren: "SP-123456"}, nil)
+		m.users.EXPECT().UploadFile(gomock.Any(), "recibo-"+number+".pdf", "application/pdf").Return(fileId, nil)
+		m.visits.EXPECT().RecordVisitReceipt(visit.ID.Hex(), model.VisitReceipt{Number: number, FileID: fileId, IssuedAt: i.now()}).Return(visit, nil)
+
+		receipt, err := i.Issue(visit)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fileId, receipt.FileID)
+		assert.Equal(t, []string{"maria@email.com 10/03/2025 09:00 " + number}, m.sent)
+	})
+
+	t.Run("Sucesso_OutraEmissaoRegistrouPrimeiro", func(t *testing.T) {
+		i, m := setupIssuer(t, nil)
+		visit := completedVisit()
+		fileId := primitive.NewObjectID()
+		stored := visit
+		stored.Receipt = &model.VisitReceipt{Number: Number(visit), FileID: primitive.NewObjectID(), IssuedAt: i.now()}
+
+		m.users.EXPECT().FindUserById(visit.PatientId).Return(model.User{}, nil)
+		m.nurses.EXPECT().FindNurseById(visit.NurseId).Return(model.Nurse{}, nil)
+		m.users.EXPECT().UploadFile(gomock.Any(), gomock.Any(), "application/pdf").Return(fileId, nil)
+		m.visits.EXPECT().RecordVisitReceipt(visit.ID.Hex(), gomock.Any()).Return(model.Visit{}, repository.ErrVisitReceiptTaken)
+		m.users.EXPECT().DeleteFile(fileId).Return(nil)
+		m.visits.EXPECT().FindVisitById(visit.ID.Hex()).Return(stored, nil)
+
+		receipt, err := i.Issue(visit)
+
+		assert.NoError(t, err)
+		assert.Equal(t, *stored.Receipt, receipt)
+		assert.Empty(t, m.sent)
+	})
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"medassist/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCommissionRuleNotFound indica que não existe regra de comissão com o ID informado.
+var ErrCommissionRuleNotFound = errors.New("regra de comissão não encontrada")
+
+// CommissionRuleRepository guarda as regras de comissão (coleção "commission_rules").
+type CommissionRuleRepository interface {
+	CreateRule(rule model.CommissionRule) (model.CommissionRule, error)
+	FindAllRules() ([]model.CommissionRule, error)
+	FindActiveRules(at time.Time) ([]model.CommissionRule, error)
+	FindRuleById(id string) (model.CommissionRule, error)
+	UpdateRule(rule model.CommissionRule) (model.CommissionRule, error)
+	DeleteRule(id string) error
+}
+
+type commissionRuleRepository struct {
+	collection *mongo.Collection
+	ctx        context.Context
+}
+
+func NewCommissionRuleRepository(db *mongo.Database) CommissionRuleRepository {
+	return &commissionRuleRepository{
+		collection: db.Collection("commission_rules"),
+		ctx:        context.Background(),
+	}
+}
+
+func (r *commissionRuleRepository) CreateRule(rule model.CommissionRule) (model.CommissionRule, error) {
+	rule.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(r.ctx, rule); err != nil {
+		return model.CommissionRule{}, err
+	}
+	return rule, nil
+}
+
+// FindAllRules lista todas as regras, ativas ou não, das mais recentes para as mais antigas.
+func (r *commissionRuleRepository) FindAllRules() ([]model.CommissionRule, error) {
+	return r.find(bson.M{})
+}
+
+// FindActiveRules retorna as regras ativas cujo período inclui at.
+func (r *commissionRuleRepository) FindActiveRules(at time.Time) ([]model.CommissionRule, error) {
+	// {campo: null} também encontra documentos sem o campo
+	return r.find(bson.M{
+		"active": true,
+		"$and": []bson.M{
+			{"$or": []bson.M{{"starts_at": nil}, {"starts_at": bson.M{"$lte": at}}}},
+			{"$or": []bson.M{{"ends_at": nil}, {"ends_at": bson.M{"$gt": at}}}},
+		},
+	})
+}
+
+func (r *commissionRuleRepository) FindRuleById(id string) (model.CommissionRule, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.CommissionRule{}, ErrCommissionRuleNotFound
+	}
+
+	var rule model.CommissionRule
+	err = r.collection.FindOne(r.ctx, bson.M{"_id": objectId}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.CommissionRule{}, ErrCommissionRuleNotFound
+	}
+	if err != nil {
+		return model.CommissionRule{}, err
+	}
+	return rule, nil
+}
+
+// UpdateRule substitui a regra gravada pela informada, mantendo o ID.
+func (r *commissionRuleRepository) UpdateRule(rule model.CommissionRule) (model.CommissionRule, error) {
+	result, err := r.collection.ReplaceOne(r.ctx, bson.M{"_id": rule.ID}, rule)
+	if err != nil {
+		return model.CommissionRule{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.CommissionRule{}, ErrCommissionRuleNotFound
+	}
+	return rule, nil
+}
+
+func (r *commissionRuleRepository) DeleteRule(id string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCommissionRuleNotFound
+	}
+
+	result, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": objectId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCommissionRuleNotFound
+	}
+	return nil
+}
+
+func (r *commissionRuleRepository) find(query bson.M) ([]model.CommissionRule, error) {
+	cursor, err := r.collection.Find(r.ctx, query, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	rules := []model.CommissionRule{}
+	if err := cursor.All(r.ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
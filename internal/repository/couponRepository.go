@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"medassist/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCouponNotFound indica que não existe cupom com o ID ou código informado.
+var ErrCouponNotFound = errors.New("cupom não encontrado")
+
+// ErrCouponCodeTaken indica que já existe um cupom com o mesmo código.
+var ErrCouponCodeTaken = errors.New("já existe um cupom com este código")
+
+// CouponRepository guarda os cupons promocionais (coleção "coupons") e os seus resgates
+// (coleção "coupon_redemptions").
+type CouponRepository interface {
+	CreateCoupon(coupon model.Coupon) (model.Coupon, error)
+	FindAllCoupons() ([]model.Coupon, error)
+	FindCouponById(id string) (model.Coupon, error)
+	FindCouponByCode(code string) (model.Coupon, error)
+	UpdateCoupon(coupon model.Coupon) (model.Coupon, error)
+	DeleteCoupon(id string) error
+	CountPatientRedemptions(couponId, patientId string) (int, error)
+	RecordRedemption(redemption model.CouponRedemption) (bool, error)
+}
+
+type couponRepository struct {
+	collection            *mongo.Collection
+	redemptionsCollection *mongo.Collection
+	ctx                   context.Context
+}
+
+func NewCouponRepository(db *mongo.Database) CouponRepository {
+	repo := &couponRepository{
+		collection:            db.Collection("coupons"),
+		redemptionsCollection: db.Collection("coupon_redemptions"),
+		ctx:                   context.Background(),
+	}
+
+	_, err := repo.collection.Indexes().CreateOne(repo.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índice dos cupons: %v", err)
+	}
+
+	// um PaymentIntent resgata o cupom uma única vez, mesmo que a criação da visita seja repetida
+	_, err = repo.redemptionsCollection.Indexes().CreateMany(repo.ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "payment_intent_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "coupon_id", Value: 1}, {Key: "patient_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índices dos resgates de cupons: %v", err)
+	}
+
+	return repo
+}
+
+func (r *couponRepository) CreateCoupon(coupon model.Coupon) (model.Coupon, error) {
+	coupon.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(r.ctx, coupon); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.Coupon{}, ErrCouponCodeTaken
+		}
+		return model.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+// FindAllCoupons lista todos os cupons, ativos ou não, dos mais recentes para os mais antigos.
+func (r *couponRepository) FindAllCoupons() ([]model.Coupon, error) {
+	cursor, err := r.collection.Find(r.ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	coupons := []model.Coupon{}
+	if err := cursor.All(r.ctx, &coupons); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (r *couponRepository) FindCouponById(id string) (model.Coupon, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Coupon{}, ErrCouponNotFound
+	}
+	return r.findOne(bson.M{"_id": objectId})
+}
+
+// FindCouponByCode busca o cupom pelo código, já normalizado em maiúsculas.
+func (r *couponRepository) FindCouponByCode(code string) (model.Coupon, error) {
+	return r.findOne(bson.M{"code": code})
+}
+
+// UpdateCoupon grava os dados editáveis do cupom. O contador de resgates não é sobrescrito,
+// pois pode ter mudado desde que o cupom foi lido.
+func (r *couponRepository) UpdateCoupon(coupon model.Coupon) (model.Coupon, error) {
+	set := bson.M{
+		"code":                        coupon.Code,
+		"description":                 coupon.Description,
+		"discount_type":               coupon.DiscountType,
+		"discount_value":              coupon.DiscountValue,
+		"starts_at":                   coupon.StartsAt,
+		"ends_at":                     coupon.EndsAt,
+		"max_redemptions":             coupon.MaxRedemptions,
+		"max_redemptions_per_patient": coupon.MaxRedemptionsPerPatient,
+		"specializations":             coupon.Specializations,
+		"cities":                      coupon.Cities,
+		"active":                      coupon.Active,
+		"updated_at":                  coupon.UpdatedAt,
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated model.Coupon
+	err := r.collection.FindOneAndUpdate(r.ctx, bson.M{"_id": coupon.ID}, bson.M{"$set": set}, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Coupon{}, ErrCouponNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return model.Coupon{}, ErrCouponCodeTaken
+	}
+	if err != nil {
+		return model.Coupon{}, err
+	}
+	return updated, nil
+}
+
+func (r *couponRepository) DeleteCoupon(id string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCouponNotFound
+	}
+
+	result, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": objectId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+// CountPatientRedemptions conta quantas vezes o paciente já resgatou o cupom.
+func (r *couponRepository) CountPatientRedemptions(couponId, patientId string) (int, error) {
+	count, err := r.redemptionsCollection.CountDocuments(r.ctx, bson.M{"coupon_id": couponId, "patient_id": patientId})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// RecordRedemption registra o resgate e incrementa o contador do cupom. Retorna false, sem
+// erro, se o PaymentIntent já tinha resgatado um cupom.
+func (r *couponRepository) RecordRedemption(redemption model.CouponRedemption) (bool, error) {
+	redemption.ID = primitive.NewObjectID()
+	if redemption.CreatedAt.IsZero() {
+		redemption.CreatedAt = time.Now()
+	}
+
+	if _, err := r.redemptionsCollection.InsertOne(r.ctx, redemption); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	couponId, err := primitive.ObjectIDFromHex(redemption.CouponID)
+	if err != nil {
+		return true, ErrCouponNotFound
+	}
+	if _, err := r.collection.UpdateByID(r.ctx, couponId, bson.M{"$inc": bson.M{"times_redeemed": 1}}); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (r *couponRepository) findOne(filter bson.M) (model.Coupon, error) {
+	var coupon model.Coupon
+	err := r.collection.FindOne(r.ctx, filter).Decode(&coupon)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Coupon{}, ErrCouponNotFound
+	}
+	if err != nil {
+		return model.Coupon{}, err
+	}
+	return coupon, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"medassist/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDisputeNotFound indica que não existe contestação com o ID informado.
+var ErrDisputeNotFound = errors.New("contestação não encontrada")
+
+// ErrDisputeExists indica que a contestação do Stripe já foi registrada.
+var ErrDisputeExists = errors.New("contestação já registrada")
+
+// DisputeRepository guarda as contestações de pagamentos (coleção "disputes"), uma por
+// contestação do Stripe.
+type DisputeRepository interface {
+	CreateDispute(dispute model.Dispute) (model.Dispute, error)
+	FindDisputeById(id string) (model.Dispute, error)
+	FindDisputeByStripeId(stripeDisputeId string) (model.Dispute, error)
+	FindDisputes(status string) ([]model.Dispute, error)
+	UpdateDisputeFields(id string, updates map[string]interface{}) (model.Dispute, error)
+	AddTransferReversal(id string, reversal model.DisputeTransferReversal) (model.Dispute, error)
+}
+
+type disputeRepository struct {
+	collection *mongo.Collection
+	ctx        context.Context
+}
+
+func NewDisputeRepository(db *mongo.Database) DisputeRepository {
+	repo := &disputeRepository{
+		collection: db.Collection("disputes"),
+		ctx:        context.Background(),
+	}
+
+	// os eventos de uma mesma contestação podem chegar juntos; só o primeiro cria o registro
+	_, err := repo.collection.Indexes().CreateMany(repo.ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "stripe_dispute_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índices das contestações: %v", err)
+	}
+
+	return repo
+}
+
+// CreateDispute registra a contestação. Retorna ErrDisputeExists se ela já foi registrada.
+func (r *disputeRepository) CreateDispute(dispute model.Dispute) (model.Dispute, error) {
+	dispute.ID = primitive.NewObjectID()
+	now := time.Now()
+	dispute.CreatedAt = now
+	dispute.UpdatedAt = now
+
+	if _, err := r.collection.InsertOne(r.ctx, dispute); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.Dispute{}, ErrDisputeExists
+		}
+		return model.Dispute{}, err
+	}
+	return dispute, nil
+}
+
+func (r *disputeRepository) FindDisputeById(id string) (model.Dispute, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Dispute{}, ErrDisputeNotFound
+	}
+	return r.findOne(bson.M{"_id": objectId})
+}
+
+func (r *disputeRepository) FindDisputeByStripeId(stripeDisputeId string) (model.Dispute, error) {
+	return r.findOne(bson.M{"stripe_dispute_id": stripeDisputeId})
+}
+
+// FindDisputes lista as contestações, das mais recentes para as mais antigas. Com status
+// vazio todas são listadas.
+func (r *disputeRepository) FindDisputes(status string) ([]model.Dispute, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := r.collection.Find(r.ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	disputes := []model.Dispute{}
+	if err := cursor.All(r.ctx, &disputes); err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+func (r *disputeRepository) UpdateDisputeFields(id string, updates map[string]interface{}) (model.Dispute, error) {
+	set := bson.M{"updated_at": time.Now()}
+	for key, value := range updates {
+		set[key] = value
+	}
+	return r.update(id, bson.M{"$set": set})
+}
+
+// AddTransferReversal registra a devolução de um repasse sem sobrescrever as já registradas.
+func (r *disputeRepository) AddTransferReversal(id string, reversal model.DisputeTransferReversal) (model.Dispute, error) {
+	return r.update(id, bson.M{
+		"$push": bson.M{"transfer_reversals": reversal},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+func (r *disputeRepository) update(id string, update bson.M) (model.Dispute, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Dispute{}, ErrDisputeNotFound
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated model.Dispute
+	err = r.collection.FindOneAndUpdate(r.ctx, bson.M{"_id": objectId}, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Dispute{}, ErrDisputeNotFound
+	}
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	return updated, nil
+}
+
+func (r *disputeRepository) findOne(filter bson.M) (model.Dispute, error) {
+	var dispute model.Dispute
+	err := r.collection.FindOne(r.ctx, filter).Decode(&dispute)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Dispute{}, ErrDisputeNotFound
+	}
+	if err != nil {
+		return model.Dispute{}, err
+	}
+	return dispute, nil
+}
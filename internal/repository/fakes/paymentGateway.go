@@ -0,0 +1,763 @@
+package fakes
+
+import (
+	"fmt"
+	"medassist/internal/model"
+	"medassist/internal/repository"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+// PaymentGateway é um repository.PaymentGateway em memória para testes. Os ids são sequenciais
+// por tipo (cus_1, pi_1, pm_1, ch_1, re_1, acct_1, tr_1, trr_1, dp_1) e o relógio é fixo, então o mesmo teste
+// sempre gera os mesmos valores. As regras do Stripe de que a aplicação depende são
+// respeitadas: captura só de valor retido, estorno limitado ao valor cobrado e repasse limitado
+// à cobrança de origem, devolução limitada ao valor repassado, evidências de contestação
+// enviadas uma única vez e cartões salvos usados só pelo cliente dono deles. Como o Stripe sem expand,
+// o PaymentIntent traz só o ID da forma de pagamento. As falhas são *stripe.Error, como no gateway real.
+type PaymentGateway struct {
+	// Now é o horário usado nas datas geradas pelo fake.
+	Now time.Time
+
+	mu             sync.Mutex
+	sequences      map[string]int
+	customers      map[string]model.User
+	paymentMethods []*stripe.PaymentMethod // formas de pagamento na ordem em que foram usadas; as salvas têm Customer
+	defaultCards   map[string]string       // cliente -> cartão padrão
+	intents        map[string]*stripe.PaymentIntent
+	refunds        map[string][]*stripe.Refund // por PaymentIntent
+	refundsByVisit map[string]*stripe.Refund
+	accounts       map[string]string // id da conta -> email
+	transfers      []*stripe.Transfer
+	transferKeys   map[string]*stripe.Transfer         // chave de idempotência -> repasse feito
+	reversals      map[string]*stripe.TransferReversal // chave de idempotência -> devolução feita
+	disputes       map[string]*stripe.Dispute
+	idempotent     map[string]*stripe.PaymentIntent // chave de idempotência -> cobrança feita
+	declined       map[string]stripe.ErrorCode      // cartões recusados em cobranças sem o paciente
+}
+
+var _ repository.PaymentGateway = (*PaymentGateway)(nil)
+
+// NewPaymentGateway cria o fake sem clientes, pagamentos nem contas.
+func NewPaymentGateway() *PaymentGateway {
+	return &PaymentGateway{
+		Now:            time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		sequences:      map[string]int{},
+		customers:      map[string]model.User{},
+		defaultCards:   map[string]string{},
+		intents:        map[string]*stripe.PaymentIntent{},
+		refunds:        map[string][]*stripe.Refund{},
+		refundsByVisit: map[string]*stripe.Refund{},
+		accounts:       map[string]string{},
+		transferKeys:   map[string]*stripe.Transfer{},
+		reversals:      map[string]*stripe.TransferReversal{},
+		disputes:       map[string]*stripe.Dispute{},
+		idempotent:     map[string]*stripe.PaymentIntent{},
+		declined:       map[string]stripe.ErrorCode{},
+	}
+}
+
+func (g *PaymentGateway) CreateCustomer(patientId string, patient model.User) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.nextID("cus")
+	g.customers[id] = patient
+	return id, nil
+}
+
+func (g *PaymentGateway) CreatePaymentIntent(customerId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	captureMethod := stripe.PaymentIntentCaptureMethodAutomatic
+	if manualCapture {
+		captureMethod = stripe.PaymentIntentCaptureMethodManual
+	}
+	pi, err := g.newPaymentIntent(customerId, amountInCents, "card", metadata)
+	if err != nil {
+		return nil, err
+	}
+	pi.CaptureMethod = captureMethod
+	pi.SetupFutureUsage = stripe.PaymentIntentSetupFutureUsageOffSession
+	pi.Status = stripe.PaymentIntentStatusRequiresPaymentMethod
+	return copyIntent(pi), nil
+}
+
+// CreateSavedCardPaymentIntent confirma o pagamento com um cartão salvo do cliente. Cartões
+// marcados com Decline e stripe.ErrorCodeAuthenticationRequired ficam em requires_action, como
+// quando o banco pede autenticação ao paciente; Pay conclui a autenticação. Os demais códigos
+// recusam o cartão.
+func (g *PaymentGateway) CreateSavedCardPaymentIntent(customerId, paymentMethodId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	card, err := g.card(paymentMethodId)
+	if err != nil {
+		return nil, err
+	}
+	if card.Customer == nil || card.Customer.ID != customerId {
+		return nil, apiError("", "o cartão %s não pertence ao cliente %s", paymentMethodId, customerId)
+	}
+	code, declined := g.declined[paymentMethodId]
+	if declined && code != stripe.ErrorCodeAuthenticationRequired {
+		return nil, cardDeclined(paymentMethodId, code)
+	}
+
+	pi, err := g.newPaymentIntent(customerId, amountInCents, "card", metadata)
+	if err != nil {
+		return nil, err
+	}
+	pi.CaptureMethod = stripe.PaymentIntentCaptureMethodAutomatic
+	if manualCapture {
+		pi.CaptureMethod = stripe.PaymentIntentCaptureMethodManual
+	}
+	pi.PaymentMethod = &stripe.PaymentMethod{ID: paymentMethodId}
+	if declined {
+		pi.Status = stripe.PaymentIntentStatusRequiresAction
+		pi.NextAction = &stripe.PaymentIntentNextAction{Type: stripe.PaymentIntentNextActionTypeUseStripeSDK}
+		return copyIntent(pi), nil
+	}
+	g.confirm(pi)
+	return copyIntent(pi), nil
+}
+
+// ChargeSavedCard cobra na hora o cartão salvo. Repetir a chave de idempotência devolve a
+// cobrança já feita; cartões marcados com Decline são recusados com o código informado.
+func (g *PaymentGateway) ChargeSavedCard(customerId, paymentMethodId string, amountInCents int64, idempotencyKey string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if pi, ok := g.idempotent[idempotencyKey]; ok && idempotencyKey != "" {
+		return copyIntent(pi), nil
+	}
+	if _, err := g.card(paymentMethodId); err != nil {
+		return nil, err
+	}
+	if code, ok := g.declined[paymentMethodId]; ok {
+		return nil, cardDeclined(paymentMethodId, code)
+	}
+
+	pi, err := g.newPaymentIntent(customerId, amountInCents, "card", metadata)
+	if err != nil {
+		return nil, err
+	}
+	pi.CaptureMethod = stripe.PaymentIntentCaptureMethodAutomatic
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = amountInCents
+	pi.PaymentMethod = &stripe.PaymentMethod{ID: paymentMethodId}
+	pi.LatestCharge = &stripe.Charge{ID: g.nextID("ch"), Amount: amountInCents, Currency: pi.Currency, Captured: true, Paid: true, PaymentIntent: &stripe.PaymentIntent{ID: pi.ID}}
+	if idempotencyKey != "" {
+		g.idempotent[idempotencyKey] = pi
+	}
+	return copyIntent(pi), nil
+}
+
+// Decline faz as próximas cobranças no cartão falharem com code
+// (ex: stripe.ErrorCodeCardDeclined ou stripe.ErrorCodeAuthenticationRequired).
+func (g *PaymentGateway) Decline(paymentMethodId string, code stripe.ErrorCode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.declined[paymentMethodId] = code
+}
+
+func (g *PaymentGateway) CreatePixPaymentIntent(customerId string, amountInCents int64, expiresAfter time.Duration, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, err := g.newPaymentIntent(customerId, amountInCents, "pix", metadata)
+	if err != nil {
+		return nil, err
+	}
+	pi.CaptureMethod = stripe.PaymentIntentCaptureMethodAutomatic
+	pi.Status = stripe.PaymentIntentStatusRequiresAction
+	pi.NextAction = &stripe.PaymentIntentNextAction{
+		Type: "pix_display_qr_code",
+		PixDisplayQRCode: &stripe.PaymentIntentNextActionPixDisplayQRCode{
+			Data:                  "00020126580014br.gov.bcb.pix-" + pi.ID,
+			ImageURLPNG:           "https://fake.stripe/pix/" + pi.ID + ".png",
+			HostedInstructionsURL: "https://fake.stripe/pix/" + pi.ID,
+			ExpiresAt:             g.Now.Add(expiresAfter).Unix(),
+		},
+	}
+	return copyIntent(pi), nil
+}
+
+// Pay simula o paciente concluindo o pagamento: o cartão com captura manual fica retido
+// (requires_capture); o PIX e o cartão com captura automática ficam cobrados (succeeded).
+func (g *PaymentGateway) Pay(paymentIntentId string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresPaymentMethod && pi.Status != stripe.PaymentIntentStatusRequiresAction {
+		return nil, unexpectedState(pi, "pagar")
+	}
+
+	pi.NextAction = nil
+	switch {
+	case pi.PaymentMethod != nil:
+		// cartão salvo que aguardava a autenticação do paciente
+	case pi.PaymentMethodTypes[0] == "card":
+		card := &stripe.PaymentMethod{
+			ID:      g.nextID("pm"),
+			Type:    stripe.PaymentMethodTypeCard,
+			Card:    &stripe.PaymentMethodCard{Brand: stripe.PaymentMethodCardBrandVisa, Last4: "4242", ExpMonth: 12, ExpYear: 2030},
+			Created: g.Now.Unix(),
+		}
+		if pi.SetupFutureUsage == stripe.PaymentIntentSetupFutureUsageOffSession {
+			card.Customer = &stripe.Customer{ID: pi.Customer.ID}
+		}
+		g.paymentMethods = append(g.paymentMethods, card)
+		pi.PaymentMethod = &stripe.PaymentMethod{ID: card.ID}
+	default:
+		pix := &stripe.PaymentMethod{ID: g.nextID("pm"), Type: stripe.PaymentMethodTypePix, Created: g.Now.Unix()}
+		g.paymentMethods = append(g.paymentMethods, pix)
+		pi.PaymentMethod = &stripe.PaymentMethod{ID: pix.ID}
+	}
+	g.confirm(pi)
+	return copyIntent(pi), nil
+}
+
+// confirm cobra o PaymentIntent, ou só retém o valor se a captura for manual.
+func (g *PaymentGateway) confirm(pi *stripe.PaymentIntent) {
+	pi.LatestCharge = &stripe.Charge{ID: g.nextID("ch"), Amount: pi.Amount, Currency: pi.Currency, PaymentIntent: &stripe.PaymentIntent{ID: pi.ID}}
+	if pi.CaptureMethod == stripe.PaymentIntentCaptureMethodManual {
+		pi.Status = stripe.PaymentIntentStatusRequiresCapture
+		pi.AmountCapturable = pi.Amount
+	} else {
+		pi.Status = stripe.PaymentIntentStatusSucceeded
+		pi.AmountReceived = pi.Amount
+		pi.LatestCharge.Captured = true
+		pi.LatestCharge.Paid = true
+	}
+}
+
+// ListPaymentMethods retorna os cartões do cliente na ordem em que foram salvos, como Pay os
+// salva: pagamentos de cartão criados com CreatePaymentIntent guardam o cartão no cliente.
+func (g *PaymentGateway) ListPaymentMethods(customerId string) ([]*stripe.PaymentMethod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.customers[customerId]; !ok {
+		return nil, missing("cliente", customerId)
+	}
+	var cards []*stripe.PaymentMethod
+	for _, card := range g.paymentMethods {
+		if card.Type == stripe.PaymentMethodTypeCard && card.Customer != nil && card.Customer.ID == customerId {
+			cards = append(cards, copyPaymentMethod(card))
+		}
+	}
+	return cards, nil
+}
+
+func (g *PaymentGateway) GetPaymentMethod(paymentMethodId string) (*stripe.PaymentMethod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	paymentMethod, err := g.paymentMethod(paymentMethodId)
+	if err != nil {
+		return nil, err
+	}
+	return copyPaymentMethod(paymentMethod), nil
+}
+
+func (g *PaymentGateway) GetDefaultPaymentMethod(customerId string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.customers[customerId]; !ok {
+		return "", missing("cliente", customerId)
+	}
+	return g.defaultCards[customerId], nil
+}
+
+// SetDefaultPaymentMethod só aceita cartões salvos no próprio cliente, como o gateway real.
+func (g *PaymentGateway) SetDefaultPaymentMethod(customerId, paymentMethodId string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	card, err := g.card(paymentMethodId)
+	if err != nil {
+		return err
+	}
+	if card.Customer == nil || card.Customer.ID != customerId {
+		return apiError("", "o cartão %s não pertence ao cliente %s", paymentMethodId, customerId)
+	}
+	g.defaultCards[customerId] = paymentMethodId
+	return nil
+}
+
+// DetachPaymentMethod remove o cartão do cliente; se ele era o padrão, o cliente fica sem
+// cartão padrão.
+func (g *PaymentGateway) DetachPaymentMethod(paymentMethodId string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	card, err := g.card(paymentMethodId)
+	if err != nil {
+		return err
+	}
+	if card.Customer == nil {
+		return apiError("", "o cartão %s não está salvo em nenhum cliente", paymentMethodId)
+	}
+	if g.defaultCards[card.Customer.ID] == paymentMethodId {
+		delete(g.defaultCards, card.Customer.ID)
+	}
+	card.Customer = nil
+	return nil
+}
+
+func (g *PaymentGateway) GetPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	return copyIntent(pi), nil
+}
+
+// CapturePaymentIntent segue a chave de idempotência do gateway real: capturar de novo um
+// pagamento já capturado devolve o mesmo resultado.
+func (g *PaymentGateway) CapturePaymentIntent(paymentIntentId string, amountInCents int64) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status == stripe.PaymentIntentStatusSucceeded && pi.CaptureMethod == stripe.PaymentIntentCaptureMethodManual {
+		return copyIntent(pi), nil
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresCapture {
+		return nil, unexpectedState(pi, "capturar")
+	}
+	if amountInCents == 0 {
+		amountInCents = pi.AmountCapturable
+	}
+	if amountInCents < 0 || amountInCents > pi.AmountCapturable {
+		return nil, apiError(stripe.ErrorCodeAmountTooLarge, "valor de captura %d maior que o retido (%d) no payment intent %s", amountInCents, pi.AmountCapturable, pi.ID)
+	}
+
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = amountInCents
+	pi.AmountCapturable = 0
+	pi.LatestCharge.Amount = amountInCents
+	pi.LatestCharge.Captured = true
+	pi.LatestCharge.Paid = true
+	return copyIntent(pi), nil
+}
+
+func (g *PaymentGateway) CancelPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status == stripe.PaymentIntentStatusSucceeded || pi.Status == stripe.PaymentIntentStatusCanceled {
+		return nil, unexpectedState(pi, "cancelar")
+	}
+
+	pi.Status = stripe.PaymentIntentStatusCanceled
+	pi.CancellationReason = stripe.PaymentIntentCancellationReasonRequestedByCustomer
+	pi.AmountCapturable = 0
+	pi.NextAction = nil
+	return copyIntent(pi), nil
+}
+
+// RefundPaymentIntent segue a chave de idempotência do gateway real: repetir o estorno de uma
+// visita devolve o estorno já feito.
+func (g *PaymentGateway) RefundPaymentIntent(paymentIntentId string, amountInCents int64, visitId string) (*stripe.Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if refund, ok := g.refundsByVisit[visitId]; ok && visitId != "" {
+		copied := *refund
+		return &copied, nil
+	}
+
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded {
+		return nil, unexpectedState(pi, "estornar")
+	}
+
+	refundable := pi.AmountReceived - pi.LatestCharge.AmountRefunded
+	if refundable <= 0 {
+		return nil, apiError(stripe.ErrorCodeChargeAlreadyRefunded, "a cobrança do payment intent %s já foi estornada", pi.ID)
+	}
+	if amountInCents == 0 {
+		amountInCents = refundable
+	}
+	if amountInCents < 0 || amountInCents > refundable {
+		return nil, apiError(stripe.ErrorCodeAmountTooLarge, "valor de estorno %d maior que o disponível (%d) no payment intent %s", amountInCents, refundable, pi.ID)
+	}
+
+	refund := &stripe.Refund{
+		ID:            g.nextID("re"),
+		Amount:        amountInCents,
+		Currency:      pi.Currency,
+		Charge:        &stripe.Charge{ID: pi.LatestCharge.ID},
+		PaymentIntent: &stripe.PaymentIntent{ID: pi.ID},
+		Status:        stripe.RefundStatusSucceeded,
+		Created:       g.Now.Unix(),
+		Metadata:      map[string]string{},
+	}
+	if visitId != "" {
+		refund.Metadata["visit_id"] = visitId
+		g.refundsByVisit[visitId] = refund
+	}
+	g.refunds[pi.ID] = append(g.refunds[pi.ID], refund)
+
+	pi.LatestCharge.AmountRefunded += amountInCents
+	pi.LatestCharge.Refunded = pi.LatestCharge.AmountRefunded == pi.AmountReceived
+
+	copied := *refund
+	return &copied, nil
+}
+
+func (g *PaymentGateway) CreateExpressAccount(email string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.nextID("acct")
+	g.accounts[id] = email
+	return id, nil
+}
+
+func (g *PaymentGateway) CreateAccountLink(accountId string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.accounts[accountId]; !ok {
+		return "", missing("conta", accountId)
+	}
+	return "https://fake.stripe/onboarding/" + accountId, nil
+}
+
+// CreateTransfer usa a cobrança do PaymentIntent como origem, como o gateway real: sem cupom,
+// os repasses de um pagamento não podem somar mais que o valor cobrado. Repetir a chave de
+// idempotência devolve o repasse já feito.
+func (g *PaymentGateway) CreateTransfer(amountInCents int64, destinationAccountId string, paymentIntentId string, idempotencyKey string) (*stripe.Transfer, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if transfer, ok := g.transferKeys[idempotencyKey]; ok && idempotencyKey != "" {
+		copied := *transfer
+		return &copied, nil
+	}
+	if _, ok := g.accounts[destinationAccountId]; !ok {
+		return nil, missing("conta", destinationAccountId)
+	}
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	if pi.LatestCharge == nil || pi.Status != stripe.PaymentIntentStatusSucceeded {
+		return nil, unexpectedState(pi, "repassar")
+	}
+
+	transfer := &stripe.Transfer{
+		ID:          g.nextID("tr"),
+		Amount:      amountInCents,
+		Currency:    stripe.CurrencyBRL,
+		Destination: &stripe.Account{ID: destinationAccountId},
+		Created:     g.Now.Unix(),
+	}
+	if pi.Metadata["discount_in_cents"] == "" {
+		available := pi.LatestCharge.Amount
+		for _, existing := range g.transfers {
+			if existing.SourceTransaction != nil && existing.SourceTransaction.ID == pi.LatestCharge.ID {
+				available -= existing.Amount
+			}
+		}
+		if amountInCents > available {
+			return nil, apiError(stripe.ErrorCodeInsufficientFunds, "repasse de %d maior que o disponível (%d) na cobrança %s", amountInCents, available, pi.LatestCharge.ID)
+		}
+		transfer.SourceTransaction = &stripe.Charge{ID: pi.LatestCharge.ID}
+	}
+	g.transfers = append(g.transfers, transfer)
+	if idempotencyKey != "" {
+		g.transferKeys[idempotencyKey] = transfer
+	}
+
+	copied := *transfer
+	return &copied, nil
+}
+
+// ReverseTransfer segue a chave de idempotência do gateway real: repetir a devolução de um
+// repasse para a mesma contestação devolve a devolução já feita.
+func (g *PaymentGateway) ReverseTransfer(transferId string, amountInCents int64, disputeId string) (*stripe.TransferReversal, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := disputeId + "-" + transferId
+	if reversal, ok := g.reversals[key]; ok && disputeId != "" {
+		copied := *reversal
+		return &copied, nil
+	}
+
+	var transfer *stripe.Transfer
+	for _, existing := range g.transfers {
+		if existing.ID == transferId {
+			transfer = existing
+		}
+	}
+	if transfer == nil {
+		return nil, missing("repasse", transferId)
+	}
+
+	reversible := transfer.Amount - transfer.AmountReversed
+	if amountInCents == 0 {
+		amountInCents = reversible
+	}
+	if amountInCents <= 0 || amountInCents > reversible {
+		return nil, apiError(stripe.ErrorCodeAmountTooLarge, "devolução de %d maior que o disponível (%d) no repasse %s", amountInCents, reversible, transferId)
+	}
+
+	reversal := &stripe.TransferReversal{
+		ID:       g.nextID("trr"),
+		Amount:   amountInCents,
+		Currency: transfer.Currency,
+		Transfer: &stripe.Transfer{ID: transferId},
+		Created:  g.Now.Unix(),
+		Metadata: map[string]string{},
+	}
+	if disputeId != "" {
+		reversal.Metadata["dispute_id"] = disputeId
+		g.reversals[key] = reversal
+	}
+	transfer.AmountReversed += amountInCents
+	transfer.Reversed = transfer.AmountReversed == transfer.Amount
+
+	copied := *reversal
+	return &copied, nil
+}
+
+// SubmitDisputeEvidence só aceita contestações aguardando resposta, como o gateway real: depois
+// do envio a contestação fica em análise.
+func (g *PaymentGateway) SubmitDisputeEvidence(stripeDisputeId string, evidence model.DisputeEvidence) (*stripe.Dispute, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	d, ok := g.disputes[stripeDisputeId]
+	if !ok {
+		return nil, missing("contestação", stripeDisputeId)
+	}
+	switch d.Status {
+	case stripe.DisputeStatusNeedsResponse:
+		d.Status = stripe.DisputeStatusUnderReview
+	case stripe.DisputeStatusWarningNeedsResponse:
+		d.Status = stripe.DisputeStatusWarningUnderReview
+	default:
+		return nil, apiError("", "a contestação %s com status %s não aceita evidências", d.ID, d.Status)
+	}
+
+	d.Evidence = &stripe.DisputeEvidence{
+		CustomerName:         evidence.CustomerName,
+		CustomerEmailAddress: evidence.CustomerEmail,
+		ServiceDate:          evidence.ServiceDate,
+		ProductDescription:   evidence.ServiceDescription,
+		AccessActivityLog:    evidence.ConfirmationLog,
+	}
+	d.EvidenceDetails.HasEvidence = true
+	d.EvidenceDetails.SubmissionCount++
+	return copyDispute(d), nil
+}
+
+// OpenDispute simula o paciente contestando no banco o pagamento do PaymentIntent. A
+// contestação é do valor cobrado inteiro e precisa de resposta em 7 dias.
+func (g *PaymentGateway) OpenDispute(paymentIntentId string, reason stripe.DisputeReason) (*stripe.Dispute, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pi, err := g.intent(paymentIntentId)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded {
+		return nil, unexpectedState(pi, "contestar")
+	}
+
+	d := &stripe.Dispute{
+		ID:              g.nextID("dp"),
+		Amount:          pi.AmountReceived,
+		Currency:        pi.Currency,
+		Charge:          &stripe.Charge{ID: pi.LatestCharge.ID},
+		PaymentIntent:   &stripe.PaymentIntent{ID: pi.ID},
+		Reason:          reason,
+		Status:          stripe.DisputeStatusNeedsResponse,
+		Created:         g.Now.Unix(),
+		EvidenceDetails: &stripe.DisputeEvidenceDetails{DueBy: g.Now.Add(7 * 24 * time.Hour).Unix()},
+	}
+	g.disputes[d.ID] = d
+	pi.LatestCharge.Disputed = true
+	return copyDispute(d), nil
+}
+
+// Customer retorna os dados do paciente usados para criar o cliente.
+func (g *PaymentGateway) Customer(customerId string) (model.User, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	patient, ok := g.customers[customerId]
+	return patient, ok
+}
+
+// Refunds retorna os estornos do PaymentIntent na ordem em que foram feitos.
+func (g *PaymentGateway) Refunds(paymentIntentId string) []stripe.Refund {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	refunds := make([]stripe.Refund, len(g.refunds[paymentIntentId]))
+	for i, refund := range g.refunds[paymentIntentId] {
+		refunds[i] = *refund
+	}
+	return refunds
+}
+
+// Transfers retorna os repasses na ordem em que foram feitos.
+func (g *PaymentGateway) Transfers() []stripe.Transfer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transfers := make([]stripe.Transfer, len(g.transfers))
+	for i, transfer := range g.transfers {
+		transfers[i] = *transfer
+	}
+	return transfers
+}
+
+func (g *PaymentGateway) newPaymentIntent(customerId string, amountInCents int64, paymentMethod string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	if _, ok := g.customers[customerId]; !ok {
+		return nil, missing("cliente", customerId)
+	}
+	if amountInCents <= 0 {
+		return nil, apiError(stripe.ErrorCodeAmountTooSmall, "valor %d inválido para o payment intent", amountInCents)
+	}
+
+	id := g.nextID("pi")
+	pi := &stripe.PaymentIntent{
+		ID:                 id,
+		Amount:             amountInCents,
+		Currency:           stripe.CurrencyBRL,
+		Customer:           &stripe.Customer{ID: customerId},
+		ClientSecret:       id + "_secret_fake",
+		PaymentMethodTypes: []string{paymentMethod},
+		Created:            g.Now.Unix(),
+		Metadata:           map[string]string{},
+	}
+	for key, value := range metadata {
+		pi.Metadata[key] = value
+	}
+	g.intents[id] = pi
+	return pi, nil
+}
+
+func (g *PaymentGateway) intent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+	pi, ok := g.intents[paymentIntentId]
+	if !ok {
+		return nil, missing("payment intent", paymentIntentId)
+	}
+	return pi, nil
+}
+
+// card busca um cartão; formas de pagamento PIX não podem ser salvas nem cobradas de novo.
+func (g *PaymentGateway) card(paymentMethodId string) (*stripe.PaymentMethod, error) {
+	paymentMethod, err := g.paymentMethod(paymentMethodId)
+	if err != nil || paymentMethod.Type != stripe.PaymentMethodTypeCard {
+		return nil, missing("cartão", paymentMethodId)
+	}
+	return paymentMethod, nil
+}
+
+func (g *PaymentGateway) paymentMethod(paymentMethodId string) (*stripe.PaymentMethod, error) {
+	for _, paymentMethod := range g.paymentMethods {
+		if paymentMethod.ID == paymentMethodId {
+			return paymentMethod, nil
+		}
+	}
+	return nil, missing("forma de pagamento", paymentMethodId)
+}
+
+func (g *PaymentGateway) nextID(prefix string) string {
+	g.sequences[prefix]++
+	return fmt.Sprintf("%s_%d", prefix, g.sequences[prefix])
+}
+
+// copyIntent evita que quem chama altere o estado guardado no fake.
+func copyIntent(pi *stripe.PaymentIntent) *stripe.PaymentIntent {
+	copied := *pi
+	copied.Metadata = make(map[string]string, len(pi.Metadata))
+	for key, value := range pi.Metadata {
+		copied.Metadata[key] = value
+	}
+	if pi.LatestCharge != nil {
+		charge := *pi.LatestCharge
+		copied.LatestCharge = &charge
+	}
+	return &copied
+}
+
+func copyPaymentMethod(paymentMethod *stripe.PaymentMethod) *stripe.PaymentMethod {
+	copied := *paymentMethod
+	if paymentMethod.Card != nil {
+		details := *paymentMethod.Card
+		copied.Card = &details
+	}
+	if paymentMethod.Customer != nil {
+		copied.Customer = &stripe.Customer{ID: paymentMethod.Customer.ID}
+	}
+	return &copied
+}
+
+func copyDispute(d *stripe.Dispute) *stripe.Dispute {
+	copied := *d
+	details := *d.EvidenceDetails
+	copied.EvidenceDetails = &details
+	if d.Evidence != nil {
+		evidence := *d.Evidence
+		copied.Evidence = &evidence
+	}
+	return &copied
+}
+
+func apiError(code stripe.ErrorCode, format string, args ...interface{}) *stripe.Error {
+	return &stripe.Error{
+		Code:           code,
+		HTTPStatusCode: http.StatusBadRequest,
+		Type:           stripe.ErrorTypeInvalidRequest,
+		Msg:            fmt.Sprintf(format, args...),
+	}
+}
+
+func cardDeclined(paymentMethodId string, code stripe.ErrorCode) *stripe.Error {
+	err := apiError(code, "cartão %s recusado", paymentMethodId)
+	err.Type = stripe.ErrorTypeCard
+	err.HTTPStatusCode = http.StatusPaymentRequired
+	return err
+}
+
+func unexpectedState(pi *stripe.PaymentIntent, action string) error {
+	return apiError(stripe.ErrorCodePaymentIntentUnexpectedState, "não é possível %s o payment intent %s com status %s", action, pi.ID, pi.Status)
+}
+
+func missing(resource, id string) error {
+	err := apiError(stripe.ErrorCodeResourceMissing, "%s %s não encontrado", resource, id)
+	err.HTTPStatusCode = http.StatusNotFound
+	return err
+}
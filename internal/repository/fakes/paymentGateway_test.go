@@ -0,0 +1,287 @@
+package fakes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+)
+
+func stripeCode(t *testing.T, err error) stripe.ErrorCode {
+	var stripeErr *stripe.Error
+	if !assert.True(t, errors.As(err, &stripeErr), "erro não é *stripe.Error: %v", err) {
+		return ""
+	}
+	return stripeErr.Code
+}
+
+func TestPaymentGateway_CardFlow(t *testing.T) {
+	t.Run("Sucesso_Retem_Captura_Estorna_E_Repassa", func(t *testing.T) {
+		g := NewPaymentGateway()
+
+		customerId, err := g.CreateCustomer("patient-1", model.User{Name: "Maria"})
+		assert.NoError(t, err)
+		assert.Equal(t, "cus_1", customerId)
+
+		pi, err := g.CreatePaymentIntent(customerId, 20000, true, map[string]string{"app_patient_id": "patient-1"})
+		assert.NoError(t, err)
+		assert.Equal(t, "pi_1", pi.ID)
+		assert.Equal(t, stripe.PaymentIntentStatusRequiresPaymentMethod, pi.Status)
+		assert.Equal(t, "patient-1", pi.Metadata["app_patient_id"])
+
+		pi, err = g.Pay(pi.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, stripe.PaymentIntentStatusRequiresCapture, pi.Status)
+		assert.Equal(t, int64(20000), pi.AmountCapturable)
+
+		pi, err = g.CapturePaymentIntent(pi.ID, 15000)
+		assert.NoError(t, err)
+		assert.Equal(t, stripe.PaymentIntentStatusSucceeded, pi.Status)
+		assert.Equal(t, int64(15000), pi.AmountReceived)
+
+		// a mesma chave de idempotência devolve a captura já feita
+		again, err := g.CapturePaymentIntent(pi.ID, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15000), again.AmountReceived)
+
+		refund, err := g.RefundPaymentIntent(pi.ID, 5000, "visit-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "re_1", refund.ID)
+		repeated, err := g.RefundPaymentIntent(pi.ID, 5000, "visit-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "re_1", repeated.ID)
+		assert.Len(t, g.Refunds(pi.ID), 1)
+
+		accountId, err := g.CreateExpressAccount("nurse@medassist.com")
+		assert.NoError(t, err)
+		transfer, err := g.CreateTransfer(12000, accountId, pi.ID, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "tr_1", transfer.ID)
+		assert.Equal(t, "ch_1", transfer.SourceTransaction.ID)
+		assert.Len(t, g.Transfers(), 1)
+	})
+
+	t.Run("Erro_Captura_Maior_Que_O_Retido", func(t *testing.T) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePaymentIntent(customerId, 20000, true, nil)
+		_, _ = g.Pay(pi.ID)
+
+		_, err := g.CapturePaymentIntent(pi.ID, 20001)
+
+		assert.Equal(t, stripe.ErrorCodeAmountTooLarge, stripeCode(t, err))
+	})
+
+	t.Run("Erro_Captura_Sem_Pagamento", func(t *testing.T) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePaymentIntent(customerId, 20000, true, nil)
+
+		_, err := g.CapturePaymentIntent(pi.ID, 0)
+
+		assert.Equal(t, stripe.ErrorCodePaymentIntentUnexpectedState, stripeCode(t, err))
+	})
+
+	t.Run("Erro_Estorno_Maior_Que_O_Cobrado", func(t *testing.T) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePaymentIntent(customerId, 20000, false, nil)
+		_, _ = g.Pay(pi.ID)
+		_, err := g.RefundPaymentIntent(pi.ID, 0, "visit-1")
+		assert.NoError(t, err)
+
+		_, err = g.RefundPaymentIntent(pi.ID, 100, "visit-2")
+
+		assert.Equal(t, stripe.ErrorCodeChargeAlreadyRefunded, stripeCode(t, err))
+	})
+
+	t.Run("Erro_Cancelar_Pagamento_Ja_Cobrado", func(t *testing.T) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePaymentIntent(customerId, 20000, false, nil)
+		_, _ = g.Pay(pi.ID)
+
+		_, err := g.CancelPaymentIntent(pi.ID)
+
+		assert.Equal(t, stripe.ErrorCodePaymentIntentUnexpectedState, stripeCode(t, err))
+	})
+
+	t.Run("Erro_Cliente_Inexistente", func(t *testing.T) {
+		g := NewPaymentGateway()
+
+		_, err := g.CreatePaymentIntent("cus_404", 20000, true, nil)
+
+		assert.Equal(t, stripe.ErrorCodeResourceMissing, stripeCode(t, err))
+	})
+}
+
+func TestPaymentGateway_CreateTransfer(t *testing.T) {
+	setup := func(metadata map[string]string) (*PaymentGateway, string, string) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePixPaymentIntent(customerId, 15000, 30*time.Minute, metadata)
+		_, _ = g.Pay(pi.ID)
+		accountId, _ := g.CreateExpressAccount("nurse@medassist.com")
+		return g, pi.ID, accountId
+	}
+
+	t.Run("Erro_Repasses_Maiores_Que_A_Cobranca", func(t *testing.T) {
+		g, piId, accountId := setup(nil)
+		_, err := g.CreateTransfer(10000, accountId, piId, "")
+		assert.NoError(t, err)
+
+		_, err = g.CreateTransfer(6000, accountId, piId, "")
+
+		assert.Equal(t, stripe.ErrorCodeInsufficientFunds, stripeCode(t, err))
+	})
+
+	t.Run("Sucesso_Repasse_Repetido_Devolve_O_Mesmo", func(t *testing.T) {
+		g, piId, accountId := setup(nil)
+
+		transfer, err := g.CreateTransfer(10000, accountId, piId, "transfer-visit-1")
+		assert.NoError(t, err)
+		repeated, err := g.CreateTransfer(10000, accountId, piId, "transfer-visit-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, transfer.ID, repeated.ID)
+		assert.Len(t, g.Transfers(), 1)
+	})
+
+	t.Run("Sucesso_Cupom_Repassa_Pelo_Saldo_Da_Plataforma", func(t *testing.T) {
+		g, piId, accountId := setup(map[string]string{"discount_in_cents": "5000"})
+
+		transfer, err := g.CreateTransfer(18000, accountId, piId, "")
+
+		assert.NoError(t, err)
+		assert.Nil(t, transfer.SourceTransaction)
+	})
+
+	t.Run("Erro_Conta_Inexistente", func(t *testing.T) {
+		g, piId, _ := setup(nil)
+
+		_, err := g.CreateTransfer(1000, "acct_404", piId, "")
+
+		assert.Equal(t, stripe.ErrorCodeResourceMissing, stripeCode(t, err))
+	})
+}
+
+func TestPaymentGateway_Disputes(t *testing.T) {
+	setup := func() (*PaymentGateway, *stripe.PaymentIntent, *stripe.Transfer) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePaymentIntent(customerId, 15000, false, nil)
+		pi, _ = g.Pay(pi.ID)
+		accountId, _ := g.CreateExpressAccount("nurse@medassist.com")
+		transfer, _ := g.CreateTransfer(12000, accountId, pi.ID, "")
+		return g, pi, transfer
+	}
+
+	t.Run("Sucesso_Evidencias_Enviadas_Uma_Vez", func(t *testing.T) {
+		g, pi, _ := setup()
+
+		d, err := g.OpenDispute(pi.ID, stripe.DisputeReasonFraudulent)
+		assert.NoError(t, err)
+		assert.Equal(t, "dp_1", d.ID)
+		assert.Equal(t, int64(15000), d.Amount)
+		assert.Equal(t, stripe.DisputeStatusNeedsResponse, d.Status)
+
+		d, err = g.SubmitDisputeEvidence(d.ID, model.DisputeEvidence{CustomerName: "Maria"})
+		assert.NoError(t, err)
+		assert.Equal(t, stripe.DisputeStatusUnderReview, d.Status)
+		assert.Equal(t, "Maria", d.Evidence.CustomerName)
+
+		_, err = g.SubmitDisputeEvidence(d.ID, model.DisputeEvidence{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Sucesso_Reversao_Repetida_Devolve_A_Mesma", func(t *testing.T) {
+		g, _, transfer := setup()
+
+		reversal, err := g.ReverseTransfer(transfer.ID, 0, "dispute-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "trr_1", reversal.ID)
+		assert.Equal(t, int64(12000), reversal.Amount)
+
+		repeated, err := g.ReverseTransfer(transfer.ID, 0, "dispute-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "trr_1", repeated.ID)
+		assert.True(t, g.Transfers()[0].Reversed)
+	})
+
+	t.Run("Erro_Reversao_Maior_Que_O_Repasse", func(t *testing.T) {
+		g, _, transfer := setup()
+
+		_, err := g.ReverseTransfer(transfer.ID, 12001, "dispute-1")
+
+		assert.Equal(t, stripe.ErrorCodeAmountTooLarge, stripeCode(t, err))
+	})
+}
+
+func TestPaymentGateway_CreatePixPaymentIntent(t *testing.T) {
+	g := NewPaymentGateway()
+	customerId, _ := g.CreateCustomer("patient-1", model.User{})
+
+	pi, err := g.CreatePixPaymentIntent(customerId, 15000, 30*time.Minute, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stripe.PaymentIntentStatusRequiresAction, pi.Status)
+	assert.NotNil(t, pi.NextAction.PixDisplayQRCode)
+	assert.Equal(t, g.Now.Add(30*time.Minute).Unix(), pi.NextAction.PixDisplayQRCode.ExpiresAt)
+
+	// como no Stripe sem expand, o tipo só vem da forma de pagamento buscada pelo ID
+	paid, _ := g.Pay(pi.ID)
+	assert.Empty(t, paid.PaymentMethod.Type)
+	paymentMethod, err := g.GetPaymentMethod(paid.PaymentMethod.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, stripe.PaymentMethodTypePix, paymentMethod.Type)
+	_, err = g.ChargeSavedCard(customerId, paid.PaymentMethod.ID, 2000, "", nil)
+	assert.Error(t, err)
+}
+
+func TestPaymentGateway_SavedCards(t *testing.T) {
+	setup := func() (*PaymentGateway, string, string) {
+		g := NewPaymentGateway()
+		customerId, _ := g.CreateCustomer("patient-1", model.User{})
+		pi, _ := g.CreatePaymentIntent(customerId, 15000, true, nil)
+		paid, _ := g.Pay(pi.ID)
+		return g, customerId, paid.PaymentMethod.ID
+	}
+
+	t.Run("Sucesso_Autenticacao_Concluida_Com_Pay", func(t *testing.T) {
+		g, customerId, card := setup()
+		g.Decline(card, stripe.ErrorCodeAuthenticationRequired)
+
+		pi, err := g.CreateSavedCardPaymentIntent(customerId, card, 20000, true, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, stripe.PaymentIntentStatusRequiresAction, pi.Status)
+
+		paid, err := g.Pay(pi.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, stripe.PaymentIntentStatusRequiresCapture, paid.Status)
+		assert.Equal(t, card, paid.PaymentMethod.ID)
+	})
+
+	t.Run("Erro_Cartao_De_Outro_Cliente", func(t *testing.T) {
+		g, _, card := setup()
+		otherCustomer, _ := g.CreateCustomer("patient-2", model.User{})
+
+		_, err := g.CreateSavedCardPaymentIntent(otherCustomer, card, 20000, true, nil)
+		assert.Error(t, err)
+		assert.Error(t, g.SetDefaultPaymentMethod(otherCustomer, card))
+	})
+
+	t.Run("Erro_Cartao_Removido", func(t *testing.T) {
+		g, customerId, card := setup()
+		assert.NoError(t, g.DetachPaymentMethod(card))
+
+		_, err := g.CreateSavedCardPaymentIntent(customerId, card, 20000, true, nil)
+
+		assert.Error(t, err)
+		cards, _ := g.ListPaymentMethods(customerId)
+		assert.Empty(t, cards)
+	})
+}
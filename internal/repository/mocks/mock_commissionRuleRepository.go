@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/commissionRuleRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/commissionRuleRepository.go -destination=internal/repository/mocks/mock_commissionRuleRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "medassist/internal/model"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCommissionRuleRepository is a mock of CommissionRuleRepository interface.
+type MockCommissionRuleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommissionRuleRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCommissionRuleRepositoryMockRecorder is the mock recorder for MockCommissionRuleRepository.
+type MockCommissionRuleRepositoryMockRecorder struct {
+	mock *MockCommissionRuleRepository
+}
+
+// NewMockCommissionRuleRepository creates a new mock instance.
+func NewMockCommissionRuleRepository(ctrl *gomock.Controller) *MockCommissionRuleRepository {
+	mock := &MockCommissionRuleRepository{ctrl: ctrl}
+	mock.recorder = &MockCommissionRuleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommissionRuleRepository) EXPECT() *MockCommissionRuleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateRule mocks base method.
+func (m *MockCommissionRuleRepository) CreateRule(rule model.CommissionRule) (model.CommissionRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRule", rule)
+	ret0, _ := ret[0].(model.CommissionRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRule indicates an expected call of CreateRule.
+func (mr *MockCommissionRuleRepositoryMockRecorder) CreateRule(rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRule", reflect.TypeOf((*MockCommissionRuleRepository)(nil).CreateRule), rule)
+}
+
+// DeleteRule mocks base method.
+func (m *MockCommissionRuleRepository) DeleteRule(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRule", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRule indicates an expected call of DeleteRule.
+func (mr *MockCommissionRuleRepositoryMockRecorder) DeleteRule(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRule", reflect.TypeOf((*MockCommissionRuleRepository)(nil).DeleteRule), id)
+}
+
+// FindActiveRules mocks base method.
+func (m *MockCommissionRuleRepository) FindActiveRules(at time.Time) ([]model.CommissionRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindActiveRules", at)
+	ret0, _ := ret[0].([]model.CommissionRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindActiveRules indicates an expected call of FindActiveRules.
+func (mr *MockCommissionRuleRepositoryMockRecorder) FindActiveRules(at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActiveRules", reflect.TypeOf((*MockCommissionRuleRepository)(nil).FindActiveRules), at)
+}
+
+// FindAllRules mocks base method.
+func (m *MockCommissionRuleRepository) FindAllRules() ([]model.CommissionRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllRules")
+	ret0, _ := ret[0].([]model.CommissionRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllRules indicates an expected call of FindAllRules.
+func (mr *MockCommissionRuleRepositoryMockRecorder) FindAllRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllRules", reflect.TypeOf((*MockCommissionRuleRepository)(nil).FindAllRules))
+}
+
+// FindRuleById mocks base method.
+func (m *MockCommissionRuleRepository) FindRuleById(id string) (model.CommissionRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRuleById", id)
+	ret0, _ := ret[0].(model.CommissionRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRuleById indicates an expected call of FindRuleById.
+func (mr *MockCommissionRuleRepositoryMockRecorder) FindRuleById(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRuleById", reflect.TypeOf((*MockCommissionRuleRepository)(nil).FindRuleById), id)
+}
+
+// UpdateRule mocks base method.
+func (m *MockCommissionRuleRepository) UpdateRule(rule model.CommissionRule) (model.CommissionRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRule", rule)
+	ret0, _ := ret[0].(model.CommissionRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRule indicates an expected call of UpdateRule.
+func (mr *MockCommissionRuleRepositoryMockRecorder) UpdateRule(rule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRule", reflect.TypeOf((*MockCommissionRuleRepository)(nil).UpdateRule), rule)
+}
@@ -0,0 +1,160 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/couponRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/couponRepository.go -destination=internal/repository/mocks/mock_couponRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "medassist/internal/model"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCouponRepository is a mock of CouponRepository interface.
+type MockCouponRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCouponRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCouponRepositoryMockRecorder is the mock recorder for MockCouponRepository.
+type MockCouponRepositoryMockRecorder struct {
+	mock *MockCouponRepository
+}
+
+// NewMockCouponRepository creates a new mock instance.
+func NewMockCouponRepository(ctrl *gomock.Controller) *MockCouponRepository {
+	mock := &MockCouponRepository{ctrl: ctrl}
+	mock.recorder = &MockCouponRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCouponRepository) EXPECT() *MockCouponRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountPatientRedemptions mocks base method.
+func (m *MockCouponRepository) CountPatientRedemptions(couponId, patientId string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPatientRedemptions", couponId, patientId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPatientRedemptions indicates an expected call of CountPatientRedemptions.
+func (mr *MockCouponRepositoryMockRecorder) CountPatientRedemptions(couponId, patientId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPatientRedemptions", reflect.TypeOf((*MockCouponRepository)(nil).CountPatientRedemptions), couponId, patientId)
+}
+
+// CreateCoupon mocks base method.
+func (m *MockCouponRepository) CreateCoupon(coupon model.Coupon) (model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCoupon", coupon)
+	ret0, _ := ret[0].(model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCoupon indicates an expected call of CreateCoupon.
+func (mr *MockCouponRepositoryMockRecorder) CreateCoupon(coupon any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCoupon", reflect.TypeOf((*MockCouponRepository)(nil).CreateCoupon), coupon)
+}
+
+// DeleteCoupon mocks base method.
+func (m *MockCouponRepository) DeleteCoupon(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCoupon", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCoupon indicates an expected call of DeleteCoupon.
+func (mr *MockCouponRepositoryMockRecorder) DeleteCoupon(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCoupon", reflect.TypeOf((*MockCouponRepository)(nil).DeleteCoupon), id)
+}
+
+// FindAllCoupons mocks base method.
+func (m *MockCouponRepository) FindAllCoupons() ([]model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllCoupons")
+	ret0, _ := ret[0].([]model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllCoupons indicates an expected call of FindAllCoupons.
+func (mr *MockCouponRepositoryMockRecorder) FindAllCoupons() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllCoupons", reflect.TypeOf((*MockCouponRepository)(nil).FindAllCoupons))
+}
+
+// FindCouponByCode mocks base method.
+func (m *MockCouponRepository) FindCouponByCode(code string) (model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponByCode", code)
+	ret0, _ := ret[0].(model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponByCode indicates an expected call of FindCouponByCode.
+func (mr *MockCouponRepositoryMockRecorder) FindCouponByCode(code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponByCode", reflect.TypeOf((*MockCouponRepository)(nil).FindCouponByCode), code)
+}
+
+// FindCouponById mocks base method.
+func (m *MockCouponRepository) FindCouponById(id string) (model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCouponById", id)
+	ret0, _ := ret[0].(model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCouponById indicates an expected call of FindCouponById.
+func (mr *MockCouponRepositoryMockRecorder) FindCouponById(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCouponById", reflect.TypeOf((*MockCouponRepository)(nil).FindCouponById), id)
+}
+
+// RecordRedemption mocks base method.
+func (m *MockCouponRepository) RecordRedemption(redemption model.CouponRedemption) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRedemption", redemption)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordRedemption indicates an expected call of RecordRedemption.
+func (mr *MockCouponRepositoryMockRecorder) RecordRedemption(redemption any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRedemption", reflect.TypeOf((*MockCouponRepository)(nil).RecordRedemption), redemption)
+}
+
+// UpdateCoupon mocks base method.
+func (m *MockCouponRepository) UpdateCoupon(coupon model.Coupon) (model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCoupon", coupon)
+	ret0, _ := ret[0].(model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCoupon indicates an expected call of UpdateCoupon.
+func (mr *MockCouponRepositoryMockRecorder) UpdateCoupon(coupon any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCoupon", reflect.TypeOf((*MockCouponRepository)(nil).UpdateCoupon), coupon)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/disputeRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/disputeRepository.go -destination=internal/repository/mocks/mock_disputeRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "medassist/internal/model"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDisputeRepository is a mock of DisputeRepository interface.
+type MockDisputeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDisputeRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDisputeRepositoryMockRecorder is the mock recorder for MockDisputeRepository.
+type MockDisputeRepositoryMockRecorder struct {
+	mock *MockDisputeRepository
+}
+
+// NewMockDisputeRepository creates a new mock instance.
+func NewMockDisputeRepository(ctrl *gomock.Controller) *MockDisputeRepository {
+	mock := &MockDisputeRepository{ctrl: ctrl}
+	mock.recorder = &MockDisputeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDisputeRepository) EXPECT() *MockDisputeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddTransferReversal mocks base method.
+func (m *MockDisputeRepository) AddTransferReversal(id string, reversal model.DisputeTransferReversal) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTransferReversal", id, reversal)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddTransferReversal indicates an expected call of AddTransferReversal.
+func (mr *MockDisputeRepositoryMockRecorder) AddTransferReversal(id, reversal any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTransferReversal", reflect.TypeOf((*MockDisputeRepository)(nil).AddTransferReversal), id, reversal)
+}
+
+// CreateDispute mocks base method.
+func (m *MockDisputeRepository) CreateDispute(dispute model.Dispute) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDispute", dispute)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDispute indicates an expected call of CreateDispute.
+func (mr *MockDisputeRepositoryMockRecorder) CreateDispute(dispute any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDispute", reflect.TypeOf((*MockDisputeRepository)(nil).CreateDispute), dispute)
+}
+
+// FindDisputeById mocks base method.
+func (m *MockDisputeRepository) FindDisputeById(id string) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDisputeById", id)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDisputeById indicates an expected call of FindDisputeById.
+func (mr *MockDisputeRepositoryMockRecorder) FindDisputeById(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDisputeById", reflect.TypeOf((*MockDisputeRepository)(nil).FindDisputeById), id)
+}
+
+// FindDisputeByStripeId mocks base method.
+func (m *MockDisputeRepository) FindDisputeByStripeId(stripeDisputeId string) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDisputeByStripeId", stripeDisputeId)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDisputeByStripeId indicates an expected call of FindDisputeByStripeId.
+func (mr *MockDisputeRepositoryMockRecorder) FindDisputeByStripeId(stripeDisputeId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDisputeByStripeId", reflect.TypeOf((*MockDisputeRepository)(nil).FindDisputeByStripeId), stripeDisputeId)
+}
+
+// FindDisputes mocks base method.
+func (m *MockDisputeRepository) FindDisputes(status string) ([]model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDisputes", status)
+	ret0, _ := ret[0].([]model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDisputes indicates an expected call of FindDisputes.
+func (mr *MockDisputeRepositoryMockRecorder) FindDisputes(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDisputes", reflect.TypeOf((*MockDisputeRepository)(nil).FindDisputes), status)
+}
+
+// UpdateDisputeFields mocks base method.
+func (m *MockDisputeRepository) UpdateDisputeFields(id string, updates map[string]any) (model.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDisputeFields", id, updates)
+	ret0, _ := ret[0].(model.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDisputeFields indicates an expected call of UpdateDisputeFields.
+func (mr *MockDisputeRepositoryMockRecorder) UpdateDisputeFields(id, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDisputeFields", reflect.TypeOf((*MockDisputeRepository)(nil).UpdateDisputeFields), id, updates)
+}
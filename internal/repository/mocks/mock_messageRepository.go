@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/messageRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/messageRepository.go -destination=internal/repository/mocks/mock_messageRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	dto "medassist/internal/chat/dto"
+	model "medassist/internal/model"
+	reflect "reflect"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageRepository is a mock of MessageRepository interface.
+type MockMessageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockMessageRepositoryMockRecorder is the mock recorder for MockMessageRepository.
+type MockMessageRepositoryMockRecorder struct {
+	mock *MockMessageRepository
+}
+
+// NewMockMessageRepository creates a new mock instance.
+func NewMockMessageRepository(ctrl *gomock.Controller) *MockMessageRepository {
+	mock := &MockMessageRepository{ctrl: ctrl}
+	mock.recorder = &MockMessageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageRepository) EXPECT() *MockMessageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindMessagesBetween mocks base method.
+func (m *MockMessageRepository) FindMessagesBetween(userID, otherUserID primitive.ObjectID) ([]model.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindMessagesBetween", userID, otherUserID)
+	ret0, _ := ret[0].([]model.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindMessagesBetween indicates an expected call of FindMessagesBetween.
+func (mr *MockMessageRepositoryMockRecorder) FindMessagesBetween(userID, otherUserID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMessagesBetween", reflect.TypeOf((*MockMessageRepository)(nil).FindMessagesBetween), userID, otherUserID)
+}
+
+// GetConversationsForNurse mocks base method.
+func (m *MockMessageRepository) GetConversationsForNurse(userID primitive.ObjectID) ([]dto.ConversationDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConversationsForNurse", userID)
+	ret0, _ := ret[0].([]dto.ConversationDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConversationsForNurse indicates an expected call of GetConversationsForNurse.
+func (mr *MockMessageRepositoryMockRecorder) GetConversationsForNurse(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConversationsForNurse", reflect.TypeOf((*MockMessageRepository)(nil).GetConversationsForNurse), userID)
+}
+
+// GetConversationsForPatient mocks base method.
+func (m *MockMessageRepository) GetConversationsForPatient(userID primitive.ObjectID) ([]dto.ConversationDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConversationsForPatient", userID)
+	ret0, _ := ret[0].([]dto.ConversationDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConversationsForPatient indicates an expected call of GetConversationsForPatient.
+func (mr *MockMessageRepositoryMockRecorder) GetConversationsForPatient(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConversationsForPatient", reflect.TypeOf((*MockMessageRepository)(nil).GetConversationsForPatient), userID)
+}
+
+// Save mocks base method.
+func (m *MockMessageRepository) Save(message *model.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockMessageRepositoryMockRecorder) Save(message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockMessageRepository)(nil).Save), message)
+}
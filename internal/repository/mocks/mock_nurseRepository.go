@@ -180,33 +180,33 @@ func (mr *MockNurseRepositoryMockRecorder) FindNurseById(id any) *gomock.Call {
 }
 
 // GetAllNurses mocks base method.
-func (m *MockNurseRepository) GetAllNurses(patientCity string) ([]dto1.AllNursesListDto, error) {
+func (m *MockNurseRepository) GetAllNurses(search dto1.NurseSearchDto) (dto1.NurseListPageDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllNurses", patientCity)
-	ret0, _ := ret[0].([]dto1.AllNursesListDto)
+	ret := m.ctrl.Call(m, "GetAllNurses", search)
+	ret0, _ := ret[0].(dto1.NurseListPageDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllNurses indicates an expected call of GetAllNurses.
-func (mr *MockNurseRepositoryMockRecorder) GetAllNurses(patientCity any) *gomock.Call {
+func (mr *MockNurseRepositoryMockRecorder) GetAllNurses(search any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllNurses", reflect.TypeOf((*MockNurseRepository)(nil).GetAllNurses), patientCity)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllNurses", reflect.TypeOf((*MockNurseRepository)(nil).GetAllNurses), search)
 }
 
 // GetAllOnlineNurses mocks base method.
-func (m *MockNurseRepository) GetAllOnlineNurses(patientCity string, latitude, longitude float64) ([]dto1.AllNursesListDto, error) {
+func (m *MockNurseRepository) GetAllOnlineNurses(search dto1.NurseSearchDto) (dto1.NurseListPageDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllOnlineNurses", patientCity, latitude, longitude)
-	ret0, _ := ret[0].([]dto1.AllNursesListDto)
+	ret := m.ctrl.Call(m, "GetAllOnlineNurses", search)
+	ret0, _ := ret[0].(dto1.NurseListPageDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllOnlineNurses indicates an expected call of GetAllOnlineNurses.
-func (mr *MockNurseRepositoryMockRecorder) GetAllOnlineNurses(patientCity, latitude, longitude any) *gomock.Call {
+func (mr *MockNurseRepositoryMockRecorder) GetAllOnlineNurses(search any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOnlineNurses", reflect.TypeOf((*MockNurseRepository)(nil).GetAllOnlineNurses), patientCity, latitude, longitude)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOnlineNurses", reflect.TypeOf((*MockNurseRepository)(nil).GetAllOnlineNurses), search)
 }
 
 // GetAverageNurseRating mocks base method.
@@ -373,6 +373,20 @@ func (mr *MockNurseRepositoryMockRecorder) UpdateNurse(nurseId, userUpdated any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNurse", reflect.TypeOf((*MockNurseRepository)(nil).UpdateNurse), nurseId, userUpdated)
 }
 
+// UpdateNurseByStripeAccountId mocks base method.
+func (m *MockNurseRepository) UpdateNurseByStripeAccountId(stripeAccountId string, updates map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNurseByStripeAccountId", stripeAccountId, updates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateNurseByStripeAccountId indicates an expected call of UpdateNurseByStripeAccountId.
+func (mr *MockNurseRepositoryMockRecorder) UpdateNurseByStripeAccountId(stripeAccountId, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNurseByStripeAccountId", reflect.TypeOf((*MockNurseRepository)(nil).UpdateNurseByStripeAccountId), stripeAccountId, updates)
+}
+
 // UpdateNurseFields mocks base method.
 func (m *MockNurseRepository) UpdateNurseFields(id string, updates map[string]any) (model.Nurse, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,326 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/paymentGateway.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/paymentGateway.go -destination=internal/repository/mocks/mock_paymentGateway.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "medassist/internal/model"
+	reflect "reflect"
+	time "time"
+
+	stripe "github.com/stripe/stripe-go/v76"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentGateway is a mock of PaymentGateway interface.
+type MockPaymentGateway struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentGatewayMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentGatewayMockRecorder is the mock recorder for MockPaymentGateway.
+type MockPaymentGatewayMockRecorder struct {
+	mock *MockPaymentGateway
+}
+
+// NewMockPaymentGateway creates a new mock instance.
+func NewMockPaymentGateway(ctrl *gomock.Controller) *MockPaymentGateway {
+	mock := &MockPaymentGateway{ctrl: ctrl}
+	mock.recorder = &MockPaymentGatewayMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentGateway) EXPECT() *MockPaymentGatewayMockRecorder {
+	return m.recorder
+}
+
+// CancelPaymentIntent mocks base method.
+func (m *MockPaymentGateway) CancelPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPaymentIntent", paymentIntentId)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPaymentIntent indicates an expected call of CancelPaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) CancelPaymentIntent(paymentIntentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).CancelPaymentIntent), paymentIntentId)
+}
+
+// CapturePaymentIntent mocks base method.
+func (m *MockPaymentGateway) CapturePaymentIntent(paymentIntentId string, amountInCents int64) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CapturePaymentIntent", paymentIntentId, amountInCents)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CapturePaymentIntent indicates an expected call of CapturePaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) CapturePaymentIntent(paymentIntentId, amountInCents any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapturePaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).CapturePaymentIntent), paymentIntentId, amountInCents)
+}
+
+// ChargeSavedCard mocks base method.
+func (m *MockPaymentGateway) ChargeSavedCard(customerId, paymentMethodId string, amountInCents int64, idempotencyKey string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeSavedCard", customerId, paymentMethodId, amountInCents, idempotencyKey, metadata)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChargeSavedCard indicates an expected call of ChargeSavedCard.
+func (mr *MockPaymentGatewayMockRecorder) ChargeSavedCard(customerId, paymentMethodId, amountInCents, idempotencyKey, metadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeSavedCard", reflect.TypeOf((*MockPaymentGateway)(nil).ChargeSavedCard), customerId, paymentMethodId, amountInCents, idempotencyKey, metadata)
+}
+
+// CreateAccountLink mocks base method.
+func (m *MockPaymentGateway) CreateAccountLink(accountId string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountLink", accountId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountLink indicates an expected call of CreateAccountLink.
+func (mr *MockPaymentGatewayMockRecorder) CreateAccountLink(accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountLink", reflect.TypeOf((*MockPaymentGateway)(nil).CreateAccountLink), accountId)
+}
+
+// CreateCustomer mocks base method.
+func (m *MockPaymentGateway) CreateCustomer(patientId string, patient model.User) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCustomer", patientId, patient)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCustomer indicates an expected call of CreateCustomer.
+func (mr *MockPaymentGatewayMockRecorder) CreateCustomer(patientId, patient any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCustomer", reflect.TypeOf((*MockPaymentGateway)(nil).CreateCustomer), patientId, patient)
+}
+
+// CreateExpressAccount mocks base method.
+func (m *MockPaymentGateway) CreateExpressAccount(email string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExpressAccount", email)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExpressAccount indicates an expected call of CreateExpressAccount.
+func (mr *MockPaymentGatewayMockRecorder) CreateExpressAccount(email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExpressAccount", reflect.TypeOf((*MockPaymentGateway)(nil).CreateExpressAccount), email)
+}
+
+// CreatePaymentIntent mocks base method.
+func (m *MockPaymentGateway) CreatePaymentIntent(customerId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentIntent", customerId, amountInCents, manualCapture, metadata)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentIntent indicates an expected call of CreatePaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) CreatePaymentIntent(customerId, amountInCents, manualCapture, metadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).CreatePaymentIntent), customerId, amountInCents, manualCapture, metadata)
+}
+
+// CreatePixPaymentIntent mocks base method.
+func (m *MockPaymentGateway) CreatePixPaymentIntent(customerId string, amountInCents int64, expiresAfter time.Duration, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePixPaymentIntent", customerId, amountInCents, expiresAfter, metadata)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePixPaymentIntent indicates an expected call of CreatePixPaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) CreatePixPaymentIntent(customerId, amountInCents, expiresAfter, metadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePixPaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).CreatePixPaymentIntent), customerId, amountInCents, expiresAfter, metadata)
+}
+
+// CreateSavedCardPaymentIntent mocks base method.
+func (m *MockPaymentGateway) CreateSavedCardPaymentIntent(customerId, paymentMethodId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSavedCardPaymentIntent", customerId, paymentMethodId, amountInCents, manualCapture, metadata)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSavedCardPaymentIntent indicates an expected call of CreateSavedCardPaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) CreateSavedCardPaymentIntent(customerId, paymentMethodId, amountInCents, manualCapture, metadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSavedCardPaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).CreateSavedCardPaymentIntent), customerId, paymentMethodId, amountInCents, manualCapture, metadata)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockPaymentGateway) CreateTransfer(amountInCents int64, destinationAccountId, sourceTransactionId, idempotencyKey string) (*stripe.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", amountInCents, destinationAccountId, sourceTransactionId, idempotencyKey)
+	ret0, _ := ret[0].(*stripe.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockPaymentGatewayMockRecorder) CreateTransfer(amountInCents, destinationAccountId, sourceTransactionId, idempotencyKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockPaymentGateway)(nil).CreateTransfer), amountInCents, destinationAccountId, sourceTransactionId, idempotencyKey)
+}
+
+// DetachPaymentMethod mocks base method.
+func (m *MockPaymentGateway) DetachPaymentMethod(paymentMethodId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetachPaymentMethod", paymentMethodId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachPaymentMethod indicates an expected call of DetachPaymentMethod.
+func (mr *MockPaymentGatewayMockRecorder) DetachPaymentMethod(paymentMethodId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachPaymentMethod", reflect.TypeOf((*MockPaymentGateway)(nil).DetachPaymentMethod), paymentMethodId)
+}
+
+// GetDefaultPaymentMethod mocks base method.
+func (m *MockPaymentGateway) GetDefaultPaymentMethod(customerId string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultPaymentMethod", customerId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDefaultPaymentMethod indicates an expected call of GetDefaultPaymentMethod.
+func (mr *MockPaymentGatewayMockRecorder) GetDefaultPaymentMethod(customerId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultPaymentMethod", reflect.TypeOf((*MockPaymentGateway)(nil).GetDefaultPaymentMethod), customerId)
+}
+
+// GetPaymentIntent mocks base method.
+func (m *MockPaymentGateway) GetPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentIntent", paymentIntentId)
+	ret0, _ := ret[0].(*stripe.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentIntent indicates an expected call of GetPaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) GetPaymentIntent(paymentIntentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).GetPaymentIntent), paymentIntentId)
+}
+
+// GetPaymentMethod mocks base method.
+func (m *MockPaymentGateway) GetPaymentMethod(paymentMethodId string) (*stripe.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentMethod", paymentMethodId)
+	ret0, _ := ret[0].(*stripe.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentMethod indicates an expected call of GetPaymentMethod.
+func (mr *MockPaymentGatewayMockRecorder) GetPaymentMethod(paymentMethodId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentMethod", reflect.TypeOf((*MockPaymentGateway)(nil).GetPaymentMethod), paymentMethodId)
+}
+
+// ListPaymentMethods mocks base method.
+func (m *MockPaymentGateway) ListPaymentMethods(customerId string) ([]*stripe.PaymentMethod, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPaymentMethods", customerId)
+	ret0, _ := ret[0].([]*stripe.PaymentMethod)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPaymentMethods indicates an expected call of ListPaymentMethods.
+func (mr *MockPaymentGatewayMockRecorder) ListPaymentMethods(customerId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentMethods", reflect.TypeOf((*MockPaymentGateway)(nil).ListPaymentMethods), customerId)
+}
+
+// RefundPaymentIntent mocks base method.
+func (m *MockPaymentGateway) RefundPaymentIntent(paymentIntentId string, amountInCents int64, visitId string) (*stripe.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPaymentIntent", paymentIntentId, amountInCents, visitId)
+	ret0, _ := ret[0].(*stripe.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundPaymentIntent indicates an expected call of RefundPaymentIntent.
+func (mr *MockPaymentGatewayMockRecorder) RefundPaymentIntent(paymentIntentId, amountInCents, visitId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPaymentIntent", reflect.TypeOf((*MockPaymentGateway)(nil).RefundPaymentIntent), paymentIntentId, amountInCents, visitId)
+}
+
+// ReverseTransfer mocks base method.
+func (m *MockPaymentGateway) ReverseTransfer(transferId string, amountInCents int64, disputeId string) (*stripe.TransferReversal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransfer", transferId, amountInCents, disputeId)
+	ret0, _ := ret[0].(*stripe.TransferReversal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransfer indicates an expected call of ReverseTransfer.
+func (mr *MockPaymentGatewayMockRecorder) ReverseTransfer(transferId, amountInCents, disputeId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransfer", reflect.TypeOf((*MockPaymentGateway)(nil).ReverseTransfer), transferId, amountInCents, disputeId)
+}
+
+// SetDefaultPaymentMethod mocks base method.
+func (m *MockPaymentGateway) SetDefaultPaymentMethod(customerId, paymentMethodId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDefaultPaymentMethod", customerId, paymentMethodId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDefaultPaymentMethod indicates an expected call of SetDefaultPaymentMethod.
+func (mr *MockPaymentGatewayMockRecorder) SetDefaultPaymentMethod(customerId, paymentMethodId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDefaultPaymentMethod", reflect.TypeOf((*MockPaymentGateway)(nil).SetDefaultPaymentMethod), customerId, paymentMethodId)
+}
+
+// SubmitDisputeEvidence mocks base method.
+func (m *MockPaymentGateway) SubmitDisputeEvidence(stripeDisputeId string, evidence model.DisputeEvidence) (*stripe.Dispute, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitDisputeEvidence", stripeDisputeId, evidence)
+	ret0, _ := ret[0].(*stripe.Dispute)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitDisputeEvidence indicates an expected call of SubmitDisputeEvidence.
+func (mr *MockPaymentGatewayMockRecorder) SubmitDisputeEvidence(stripeDisputeId, evidence any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitDisputeEvidence", reflect.TypeOf((*MockPaymentGateway)(nil).SubmitDisputeEvidence), stripeDisputeId, evidence)
+}
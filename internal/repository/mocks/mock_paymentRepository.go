@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/paymentRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/paymentRepository.go -destination=internal/repository/mocks/mock_paymentRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "medassist/internal/model"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPaymentRepository is a mock of PaymentRepository interface.
+type MockPaymentRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentRepositoryMockRecorder is the mock recorder for MockPaymentRepository.
+type MockPaymentRepositoryMockRecorder struct {
+	mock *MockPaymentRepository
+}
+
+// NewMockPaymentRepository creates a new mock instance.
+func NewMockPaymentRepository(ctrl *gomock.Controller) *MockPaymentRepository {
+	mock := &MockPaymentRepository{ctrl: ctrl}
+	mock.recorder = &MockPaymentRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRepository) EXPECT() *MockPaymentRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindEntries mocks base method.
+func (m *MockPaymentRepository) FindEntries(filter model.PaymentEntryFilter) ([]model.PaymentEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEntries", filter)
+	ret0, _ := ret[0].([]model.PaymentEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEntries indicates an expected call of FindEntries.
+func (mr *MockPaymentRepositoryMockRecorder) FindEntries(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEntries", reflect.TypeOf((*MockPaymentRepository)(nil).FindEntries), filter)
+}
+
+// FindVisitEntries mocks base method.
+func (m *MockPaymentRepository) FindVisitEntries(visitId, paymentIntentId string) ([]model.PaymentEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVisitEntries", visitId, paymentIntentId)
+	ret0, _ := ret[0].([]model.PaymentEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVisitEntries indicates an expected call of FindVisitEntries.
+func (mr *MockPaymentRepositoryMockRecorder) FindVisitEntries(visitId, paymentIntentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVisitEntries", reflect.TypeOf((*MockPaymentRepository)(nil).FindVisitEntries), visitId, paymentIntentId)
+}
+
+// RecordEntry mocks base method.
+func (m *MockPaymentRepository) RecordEntry(entry model.PaymentEntry) (model.PaymentEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordEntry", entry)
+	ret0, _ := ret[0].(model.PaymentEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordEntry indicates an expected call of RecordEntry.
+func (mr *MockPaymentRepositoryMockRecorder) RecordEntry(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEntry", reflect.TypeOf((*MockPaymentRepository)(nil).RecordEntry), entry)
+}
+
+// UpdateEntryStatus mocks base method.
+func (m *MockPaymentRepository) UpdateEntryStatus(entryType, stripeId, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEntryStatus", entryType, stripeId, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEntryStatus indicates an expected call of UpdateEntryStatus.
+func (mr *MockPaymentRepositoryMockRecorder) UpdateEntryStatus(entryType, stripeId, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntryStatus", reflect.TypeOf((*MockPaymentRepository)(nil).UpdateEntryStatus), entryType, stripeId, status)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/stripeEventRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/stripeEventRepository.go -destination=internal/repository/mocks/mock_stripeEventRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStripeEventRepository is a mock of StripeEventRepository interface.
+type MockStripeEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStripeEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStripeEventRepositoryMockRecorder is the mock recorder for MockStripeEventRepository.
+type MockStripeEventRepositoryMockRecorder struct {
+	mock *MockStripeEventRepository
+}
+
+// NewMockStripeEventRepository creates a new mock instance.
+func NewMockStripeEventRepository(ctrl *gomock.Controller) *MockStripeEventRepository {
+	mock := &MockStripeEventRepository{ctrl: ctrl}
+	mock.recorder = &MockStripeEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStripeEventRepository) EXPECT() *MockStripeEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockStripeEventRepository) Claim(eventId, eventType string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", eventId, eventType)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockStripeEventRepositoryMockRecorder) Claim(eventId, eventType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockStripeEventRepository)(nil).Claim), eventId, eventType)
+}
+
+// Release mocks base method.
+func (m *MockStripeEventRepository) Release(eventId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", eventId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockStripeEventRepositoryMockRecorder) Release(eventId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockStripeEventRepository)(nil).Release), eventId)
+}
@@ -61,6 +61,20 @@ func (mr *MockUserRepositoryMockRecorder) CreateUser(user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), user)
 }
 
+// DeleteFile mocks base method.
+func (m *MockUserRepository) DeleteFile(fileID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", fileID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockUserRepositoryMockRecorder) DeleteFile(fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockUserRepository)(nil).DeleteFile), fileID)
+}
+
 // DeleteUser mocks base method.
 func (m *MockUserRepository) DeleteUser(id string) error {
 	m.ctrl.T.Helper()
@@ -12,6 +12,7 @@ package mocks
 import (
 	model "medassist/internal/model"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,6 +41,50 @@ func (m *MockVisitRepository) EXPECT() *MockVisitRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimDispatchedVisit mocks base method.
+func (m *MockVisitRepository) ClaimDispatchedVisit(visitId string, change model.VisitStatusChange, window time.Duration, updates map[string]any) (model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDispatchedVisit", visitId, change, window, updates)
+	ret0, _ := ret[0].(model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDispatchedVisit indicates an expected call of ClaimDispatchedVisit.
+func (mr *MockVisitRepositoryMockRecorder) ClaimDispatchedVisit(visitId, change, window, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDispatchedVisit", reflect.TypeOf((*MockVisitRepository)(nil).ClaimDispatchedVisit), visitId, change, window, updates)
+}
+
+// ClaimPaymentIntent mocks base method.
+func (m *MockVisitRepository) ClaimPaymentIntent(paymentIntentId, patientId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPaymentIntent", paymentIntentId, patientId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimPaymentIntent indicates an expected call of ClaimPaymentIntent.
+func (mr *MockVisitRepositoryMockRecorder) ClaimPaymentIntent(paymentIntentId, patientId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPaymentIntent", reflect.TypeOf((*MockVisitRepository)(nil).ClaimPaymentIntent), paymentIntentId, patientId)
+}
+
+// ClaimVisitTip mocks base method.
+func (m *MockVisitRepository) ClaimVisitTip(visitId string, tip model.VisitTip) (model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimVisitTip", visitId, tip)
+	ret0, _ := ret[0].(model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimVisitTip indicates an expected call of ClaimVisitTip.
+func (mr *MockVisitRepositoryMockRecorder) ClaimVisitTip(visitId, tip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimVisitTip", reflect.TypeOf((*MockVisitRepository)(nil).ClaimVisitTip), visitId, tip)
+}
+
 // CreateVisit mocks base method.
 func (m *MockVisitRepository) CreateVisit(visit model.Visit) error {
 	m.ctrl.T.Helper()
@@ -54,6 +99,20 @@ func (mr *MockVisitRepositoryMockRecorder) CreateVisit(visit any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVisit", reflect.TypeOf((*MockVisitRepository)(nil).CreateVisit), visit)
 }
 
+// CreateVisitReservingSlot mocks base method.
+func (m *MockVisitRepository) CreateVisitReservingSlot(visit model.Visit, window time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVisitReservingSlot", visit, window)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateVisitReservingSlot indicates an expected call of CreateVisitReservingSlot.
+func (mr *MockVisitRepositoryMockRecorder) CreateVisitReservingSlot(visit, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVisitReservingSlot", reflect.TypeOf((*MockVisitRepository)(nil).CreateVisitReservingSlot), visit, window)
+}
+
 // DeleteVisit mocks base method.
 func (m *MockVisitRepository) DeleteVisit(visitId string) error {
 	m.ctrl.T.Helper()
@@ -68,6 +127,21 @@ func (mr *MockVisitRepositoryMockRecorder) DeleteVisit(visitId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVisit", reflect.TypeOf((*MockVisitRepository)(nil).DeleteVisit), visitId)
 }
 
+// FindActiveVisitsForNurseBetween mocks base method.
+func (m *MockVisitRepository) FindActiveVisitsForNurseBetween(nurseId string, from, to time.Time) ([]model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindActiveVisitsForNurseBetween", nurseId, from, to)
+	ret0, _ := ret[0].([]model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindActiveVisitsForNurseBetween indicates an expected call of FindActiveVisitsForNurseBetween.
+func (mr *MockVisitRepositoryMockRecorder) FindActiveVisitsForNurseBetween(nurseId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActiveVisitsForNurseBetween", reflect.TypeOf((*MockVisitRepository)(nil).FindActiveVisitsForNurseBetween), nurseId, from, to)
+}
+
 // FindAllCompletedVisitsForPatient mocks base method.
 func (m *MockVisitRepository) FindAllCompletedVisitsForPatient(patientId string) ([]model.Visit, error) {
 	m.ctrl.T.Helper()
@@ -143,6 +217,51 @@ func (mr *MockVisitRepositoryMockRecorder) FindAllVisitsForPatient(patientId any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllVisitsForPatient", reflect.TypeOf((*MockVisitRepository)(nil).FindAllVisitsForPatient), patientId)
 }
 
+// FindAuthorizedVisitsCreatedBefore mocks base method.
+func (m *MockVisitRepository) FindAuthorizedVisitsCreatedBefore(createdBefore time.Time) ([]model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAuthorizedVisitsCreatedBefore", createdBefore)
+	ret0, _ := ret[0].([]model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthorizedVisitsCreatedBefore indicates an expected call of FindAuthorizedVisitsCreatedBefore.
+func (mr *MockVisitRepositoryMockRecorder) FindAuthorizedVisitsCreatedBefore(createdBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthorizedVisitsCreatedBefore", reflect.TypeOf((*MockVisitRepository)(nil).FindAuthorizedVisitsCreatedBefore), createdBefore)
+}
+
+// FindEarningVisitsForNurse mocks base method.
+func (m *MockVisitRepository) FindEarningVisitsForNurse(nurseId string, from, to time.Time) ([]model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEarningVisitsForNurse", nurseId, from, to)
+	ret0, _ := ret[0].([]model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEarningVisitsForNurse indicates an expected call of FindEarningVisitsForNurse.
+func (mr *MockVisitRepositoryMockRecorder) FindEarningVisitsForNurse(nurseId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEarningVisitsForNurse", reflect.TypeOf((*MockVisitRepository)(nil).FindEarningVisitsForNurse), nurseId, from, to)
+}
+
+// FindStalePendingVisits mocks base method.
+func (m *MockVisitRepository) FindStalePendingVisits(now, createdBefore, immediateCreatedBefore time.Time) ([]model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStalePendingVisits", now, createdBefore, immediateCreatedBefore)
+	ret0, _ := ret[0].([]model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStalePendingVisits indicates an expected call of FindStalePendingVisits.
+func (mr *MockVisitRepositoryMockRecorder) FindStalePendingVisits(now, createdBefore, immediateCreatedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStalePendingVisits", reflect.TypeOf((*MockVisitRepository)(nil).FindStalePendingVisits), now, createdBefore, immediateCreatedBefore)
+}
+
 // FindVisitById mocks base method.
 func (m *MockVisitRepository) FindVisitById(id string) (model.Visit, error) {
 	m.ctrl.T.Helper()
@@ -158,6 +277,36 @@ func (mr *MockVisitRepositoryMockRecorder) FindVisitById(id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVisitById", reflect.TypeOf((*MockVisitRepository)(nil).FindVisitById), id)
 }
 
+// FindVisitsByPaymentIntentId mocks base method.
+func (m *MockVisitRepository) FindVisitsByPaymentIntentId(paymentIntentId string) ([]model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVisitsByPaymentIntentId", paymentIntentId)
+	ret0, _ := ret[0].([]model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVisitsByPaymentIntentId indicates an expected call of FindVisitsByPaymentIntentId.
+func (mr *MockVisitRepositoryMockRecorder) FindVisitsByPaymentIntentId(paymentIntentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVisitsByPaymentIntentId", reflect.TypeOf((*MockVisitRepository)(nil).FindVisitsByPaymentIntentId), paymentIntentId)
+}
+
+// FindVisitsBySeriesId mocks base method.
+func (m *MockVisitRepository) FindVisitsBySeriesId(seriesId string) ([]model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVisitsBySeriesId", seriesId)
+	ret0, _ := ret[0].([]model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVisitsBySeriesId indicates an expected call of FindVisitsBySeriesId.
+func (mr *MockVisitRepositoryMockRecorder) FindVisitsBySeriesId(seriesId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVisitsBySeriesId", reflect.TypeOf((*MockVisitRepository)(nil).FindVisitsBySeriesId), seriesId)
+}
+
 // GetCompletedVisitsCountLast30Days mocks base method.
 func (m *MockVisitRepository) GetCompletedVisitsCountLast30Days() (int64, error) {
 	m.ctrl.T.Helper()
@@ -218,6 +367,92 @@ func (mr *MockVisitRepositoryMockRecorder) GetVisitsTodayCount() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVisitsTodayCount", reflect.TypeOf((*MockVisitRepository)(nil).GetVisitsTodayCount))
 }
 
+// RecordVisitReceipt mocks base method.
+func (m *MockVisitRepository) RecordVisitReceipt(visitId string, receipt model.VisitReceipt) (model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordVisitReceipt", visitId, receipt)
+	ret0, _ := ret[0].(model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordVisitReceipt indicates an expected call of RecordVisitReceipt.
+func (mr *MockVisitRepositoryMockRecorder) RecordVisitReceipt(visitId, receipt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordVisitReceipt", reflect.TypeOf((*MockVisitRepository)(nil).RecordVisitReceipt), visitId, receipt)
+}
+
+// ReleasePaymentIntent mocks base method.
+func (m *MockVisitRepository) ReleasePaymentIntent(paymentIntentId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleasePaymentIntent", paymentIntentId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleasePaymentIntent indicates an expected call of ReleasePaymentIntent.
+func (mr *MockVisitRepositoryMockRecorder) ReleasePaymentIntent(paymentIntentId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleasePaymentIntent", reflect.TypeOf((*MockVisitRepository)(nil).ReleasePaymentIntent), paymentIntentId)
+}
+
+// ReleaseVisitSlot mocks base method.
+func (m *MockVisitRepository) ReleaseVisitSlot(visitId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseVisitSlot", visitId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseVisitSlot indicates an expected call of ReleaseVisitSlot.
+func (mr *MockVisitRepositoryMockRecorder) ReleaseVisitSlot(visitId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseVisitSlot", reflect.TypeOf((*MockVisitRepository)(nil).ReleaseVisitSlot), visitId)
+}
+
+// ReleaseVisitTip mocks base method.
+func (m *MockVisitRepository) ReleaseVisitTip(visitId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseVisitTip", visitId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseVisitTip indicates an expected call of ReleaseVisitTip.
+func (mr *MockVisitRepositoryMockRecorder) ReleaseVisitTip(visitId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseVisitTip", reflect.TypeOf((*MockVisitRepository)(nil).ReleaseVisitTip), visitId)
+}
+
+// RescheduleVisit mocks base method.
+func (m *MockVisitRepository) RescheduleVisit(visit model.Visit, newDate time.Time, window time.Duration, updates map[string]any) (model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleVisit", visit, newDate, window, updates)
+	ret0, _ := ret[0].(model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RescheduleVisit indicates an expected call of RescheduleVisit.
+func (mr *MockVisitRepositoryMockRecorder) RescheduleVisit(visit, newDate, window, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleVisit", reflect.TypeOf((*MockVisitRepository)(nil).RescheduleVisit), visit, newDate, window, updates)
+}
+
+// UpdateDispatch mocks base method.
+func (m *MockVisitRepository) UpdateDispatch(visitId string, dispatch model.VisitDispatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDispatch", visitId, dispatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDispatch indicates an expected call of UpdateDispatch.
+func (mr *MockVisitRepositoryMockRecorder) UpdateDispatch(visitId, dispatch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDispatch", reflect.TypeOf((*MockVisitRepository)(nil).UpdateDispatch), visitId, dispatch)
+}
+
 // UpdateVisitFields mocks base method.
 func (m *MockVisitRepository) UpdateVisitFields(id string, updates map[string]any) (model.Visit, error) {
 	m.ctrl.T.Helper()
@@ -232,3 +467,63 @@ func (mr *MockVisitRepositoryMockRecorder) UpdateVisitFields(id, updates any) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisitFields", reflect.TypeOf((*MockVisitRepository)(nil).UpdateVisitFields), id, updates)
 }
+
+// UpdateVisitStatus mocks base method.
+func (m *MockVisitRepository) UpdateVisitStatus(id string, change model.VisitStatusChange, updates map[string]any) (model.Visit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisitStatus", id, change, updates)
+	ret0, _ := ret[0].(model.Visit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisitStatus indicates an expected call of UpdateVisitStatus.
+func (mr *MockVisitRepositoryMockRecorder) UpdateVisitStatus(id, change, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisitStatus", reflect.TypeOf((*MockVisitRepository)(nil).UpdateVisitStatus), id, change, updates)
+}
+
+// UpdateVisitsByPaymentIntentId mocks base method.
+func (m *MockVisitRepository) UpdateVisitsByPaymentIntentId(paymentIntentId string, updates map[string]any) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisitsByPaymentIntentId", paymentIntentId, updates)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisitsByPaymentIntentId indicates an expected call of UpdateVisitsByPaymentIntentId.
+func (mr *MockVisitRepositoryMockRecorder) UpdateVisitsByPaymentIntentId(paymentIntentId, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisitsByPaymentIntentId", reflect.TypeOf((*MockVisitRepository)(nil).UpdateVisitsByPaymentIntentId), paymentIntentId, updates)
+}
+
+// UpdateVisitsByTransferId mocks base method.
+func (m *MockVisitRepository) UpdateVisitsByTransferId(transferId string, updates map[string]any) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisitsByTransferId", transferId, updates)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisitsByTransferId indicates an expected call of UpdateVisitsByTransferId.
+func (mr *MockVisitRepositoryMockRecorder) UpdateVisitsByTransferId(transferId, updates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisitsByTransferId", reflect.TypeOf((*MockVisitRepository)(nil).UpdateVisitsByTransferId), transferId, updates)
+}
+
+// UpdateVisitsPaymentStatus mocks base method.
+func (m *MockVisitRepository) UpdateVisitsPaymentStatus(paymentIntentId string, from []string, to string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVisitsPaymentStatus", paymentIntentId, from, to)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVisitsPaymentStatus indicates an expected call of UpdateVisitsPaymentStatus.
+func (mr *MockVisitRepositoryMockRecorder) UpdateVisitsPaymentStatus(paymentIntentId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVisitsPaymentStatus", reflect.TypeOf((*MockVisitRepository)(nil).UpdateVisitsPaymentStatus), paymentIntentId, from, to)
+}
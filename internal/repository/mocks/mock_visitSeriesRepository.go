@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/visitSeriesRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/visitSeriesRepository.go -destination=internal/repository/mocks/mock_visitSeriesRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "medassist/internal/model"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockVisitSeriesRepository is a mock of VisitSeriesRepository interface.
+type MockVisitSeriesRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockVisitSeriesRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockVisitSeriesRepositoryMockRecorder is the mock recorder for MockVisitSeriesRepository.
+type MockVisitSeriesRepositoryMockRecorder struct {
+	mock *MockVisitSeriesRepository
+}
+
+// NewMockVisitSeriesRepository creates a new mock instance.
+func NewMockVisitSeriesRepository(ctrl *gomock.Controller) *MockVisitSeriesRepository {
+	mock := &MockVisitSeriesRepository{ctrl: ctrl}
+	mock.recorder = &MockVisitSeriesRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVisitSeriesRepository) EXPECT() *MockVisitSeriesRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSeries mocks base method.
+func (m *MockVisitSeriesRepository) CreateSeries(series model.VisitSeries) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSeries", series)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSeries indicates an expected call of CreateSeries.
+func (mr *MockVisitSeriesRepositoryMockRecorder) CreateSeries(series any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSeries", reflect.TypeOf((*MockVisitSeriesRepository)(nil).CreateSeries), series)
+}
+
+// DeleteSeries mocks base method.
+func (m *MockVisitSeriesRepository) DeleteSeries(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSeries", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSeries indicates an expected call of DeleteSeries.
+func (mr *MockVisitSeriesRepositoryMockRecorder) DeleteSeries(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSeries", reflect.TypeOf((*MockVisitSeriesRepository)(nil).DeleteSeries), id)
+}
+
+// FindSeriesById mocks base method.
+func (m *MockVisitSeriesRepository) FindSeriesById(id string) (model.VisitSeries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSeriesById", id)
+	ret0, _ := ret[0].(model.VisitSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSeriesById indicates an expected call of FindSeriesById.
+func (mr *MockVisitSeriesRepositoryMockRecorder) FindSeriesById(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSeriesById", reflect.TypeOf((*MockVisitSeriesRepository)(nil).FindSeriesById), id)
+}
+
+// UpdateSeriesStatus mocks base method.
+func (m *MockVisitSeriesRepository) UpdateSeriesStatus(id string, status model.VisitSeriesStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSeriesStatus", id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSeriesStatus indicates an expected call of UpdateSeriesStatus.
+func (mr *MockVisitSeriesRepositoryMockRecorder) UpdateSeriesStatus(id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSeriesStatus", reflect.TypeOf((*MockVisitSeriesRepository)(nil).UpdateSeriesStatus), id, status)
+}
@@ -3,12 +3,17 @@ package repository
 import (
 	// "bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math"
 	"medassist/internal/auth/dto"
 	"medassist/internal/model"
 	nurseDTO "medassist/internal/nurse/dto"
+	"medassist/internal/scheduling"
 	userDTO "medassist/internal/user/dto"
 	"medassist/utils"
 	"time"
@@ -38,11 +43,12 @@ type NurseRepository interface {
 	UpdatePasswordByNurseID(userID string, hashedPassword string) error
 	UpdatePasswordLoggedByNurseID(userID string, hashedPassword string, twoFactor bool) error
 	GetIdsNursesPendents() ([]string, error)
-	GetAllNurses(patientCity string) ([]userDTO.AllNursesListDto, error)
+	GetAllNurses(search userDTO.NurseSearchDto) (userDTO.NurseListPageDto, error)
 	UpdateNurseFields(id string, updates map[string]interface{}) (model.Nurse, error)
 	DeleteNurse(id string) error
-	GetAllOnlineNurses(patientCity string, latitude float64, longitude float64) ([]userDTO.AllNursesListDto, error)
+	GetAllOnlineNurses(search userDTO.NurseSearchDto) (userDTO.NurseListPageDto, error)
 	UpdateStripeAccountId(nurseId string, stripeAccountId string) error
+	UpdateNurseByStripeAccountId(stripeAccountId string, updates map[string]interface{}) error
 
 	GetTotalNursesCount() (int64, error)
 	GetPendingApprovalsCount() (int64, error)
@@ -66,11 +72,49 @@ func NewNurseRepository(db *mongo.Database) NurseRepository {
 		panic(err)
 	}
 
-	return &nurseRepository{
+	repo := &nurseRepository{
 		collection: db.Collection("nurses"),
 		ctx:        context.Background(),
 		bucket:     bucket,
 	}
+
+	ensureGeoLocationIndex(repo.ctx, repo.collection)
+
+	_, err = repo.collection.Indexes().CreateMany(repo.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "online", Value: 1}, {Key: "rating", Value: -1}}},
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "online", Value: 1}, {Key: "price", Value: 1}}},
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "online", Value: 1}, {Key: "years_experience", Value: -1}}},
+		{Keys: bson.D{{Key: "specialization", Value: 1}}},
+		{Keys: bson.D{{Key: "services", Value: 1}}},
+		{Keys: bson.D{{Key: "available_neighborhoods", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índices da listagem de enfermeiros: %v", err)
+	}
+
+	return repo
+}
+
+// ensureGeoLocationIndex preenche geo_location a partir de latitude/longitude nos documentos
+// cadastrados antes da busca por proximidade e cria o índice 2dsphere usado pelo $geoNear.
+func ensureGeoLocationIndex(ctx context.Context, collection *mongo.Collection) {
+	backfill := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"geo_location": bson.M{"type": "Point", "coordinates": bson.A{"$longitude", "$latitude"}},
+	}}}}
+	filter := bson.M{
+		"geo_location": bson.M{"$exists": false},
+		"$or":          bson.A{bson.M{"latitude": bson.M{"$ne": 0}}, bson.M{"longitude": bson.M{"$ne": 0}}},
+	}
+	if _, err := collection.UpdateMany(ctx, filter, backfill); err != nil {
+		log.Printf("Erro ao preencher geo_location em %s: %v", collection.Name(), err)
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "geo_location", Value: "2dsphere"}},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índice 2dsphere em %s: %v", collection.Name(), err)
+	}
 }
 
 func (r *nurseRepository) FindNurseByEmail(email string) (dto.AuthUser, error) {
@@ -344,105 +388,229 @@ func (r *nurseRepository) FindAllNursesNotVerified() ([]model.Nurse, error) {
 	return nurses, nil
 }
 
-func (r *nurseRepository) GetAllNurses(patientCity string) ([]userDTO.AllNursesListDto, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (r *nurseRepository) GetAllNurses(search userDTO.NurseSearchDto) (userDTO.NurseListPageDto, error) {
+	return r.searchNurses(search)
+}
 
-	filter := bson.M{
-		"hidden":            false,
-		"verification_seal": true,
-		"city":              patientCity,
-		"stripe_account_id": bson.M{"$ne": ""},
-	}
+func (r *nurseRepository) GetAllOnlineNurses(search userDTO.NurseSearchDto) (userDTO.NurseListPageDto, error) {
+	online := true
+	search.Online = &online
+
+	return r.searchNurses(search)
+}
+
+// Campos aceitos em NurseSearchDto.SortField.
+const (
+	NurseSortDistance   = "distance"
+	NurseSortPrice      = "price"
+	NurseSortRating     = "rating"
+	NurseSortExperience = "experience"
+)
+
+var nurseSortColumns = map[string]string{
+	NurseSortDistance:   "distance",
+	NurseSortPrice:      "price",
+	NurseSortRating:     "rating",
+	NurseSortExperience: "years_experience",
+}
+
+// ErrInvalidCursor indica um cursor de paginação malformado ou gerado para outra ordenação.
+var ErrInvalidCursor = errors.New("cursor de paginação inválido")
+
+// nurseCursor aponta para o último enfermeiro da página: o valor do campo ordenado e o _id para desempate.
+type nurseCursor struct {
+	Sort  string  `json:"s"`
+	Value float64 `json:"v"`
+	ID    string  `json:"id"`
+}
 
-	cursor, err := r.collection.Find(ctx, filter)
+func encodeNurseCursor(cursor nurseCursor) string {
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeNurseCursor(encoded string) (nurseCursor, primitive.ObjectID, error) {
+	var cursor nurseCursor
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
 	if err != nil {
-		fmt.Printf("Erro ao buscar enfermeiros no MongoDB: %v", err)
-		return nil, err
+		return cursor, primitive.NilObjectID, ErrInvalidCursor
 	}
-	defer cursor.Close(ctx)
-
-	var nursesDto []userDTO.AllNursesListDto
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, primitive.NilObjectID, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return cursor, primitive.NilObjectID, ErrInvalidCursor
+	}
+	return cursor, id, nil
+}
 
-	for cursor.Next(ctx) {
-		var nurseModel model.Nurse
-		if err := cursor.Decode(&nurseModel); err != nil {
-			fmt.Printf("Erro ao decodificar enfermeiro: %v", err)
-			continue
-		}
+// nurseWithDistance recebe o resultado do $geoNear: o enfermeiro e a distância em metros até o paciente.
+type nurseWithDistance struct {
+	model.Nurse `bson:",inline"`
+	Distance    *float64 `bson:"distance,omitempty"`
+}
 
-		if nurseModel.MaxPatientsPerDay == 0 ||
-			len(nurseModel.DaysAvailable) == 0 ||
-			len(nurseModel.Services) == 0 ||
-			len(nurseModel.AvailableNeighborhoods) == 0 {
-			continue
+func (n nurseWithDistance) sortValue(column string) float64 {
+	switch column {
+	case "distance":
+		if n.Distance != nil {
+			return *n.Distance
 		}
+	case "price":
+		return n.Price
+	case "rating":
+		return n.Rating
+	case "years_experience":
+		return float64(n.YearsExperience)
+	}
+	return 0
+}
 
-		nurseDto := userDTO.AllNursesListDto{
-			ID:                     nurseModel.ID.Hex(),
-			Name:                   nurseModel.Name,
-			Specialization:         nurseModel.Specialization,
-			YearsExperience:        nurseModel.YearsExperience,
-			Price:                  float32(nurseModel.Price),
-			Image:                  nurseModel.ProfileImageID.Hex(),
-			Shift:                  nurseModel.Shift,
-			Department:             nurseModel.Department,
-			Available:              nurseModel.Online,
-			Location:               nurseModel.Address,
-			City:                   nurseModel.City,
-			UF:                     nurseModel.UF,
-			Neighborhood:           nurseModel.Neighborhood,
-			Street:                 nurseModel.Street,
-			MaxPatientsPerDay:      nurseModel.MaxPatientsPerDay,
-			DaysAvailable:          nurseModel.DaysAvailable,
-			Services:               nurseModel.Services,
-			AvailableNeighborhoods: nurseModel.AvailableNeighborhoods,
-		}
+// nurseSearchFilter monta o filtro com os enfermeiros aptos a receber visitas (cadastro completo,
+// verificados e com conta de pagamento) e os filtros pedidos na busca.
+func nurseSearchFilter(search userDTO.NurseSearchDto, geoSearch bool) bson.M {
+	filter := bson.M{
+		"hidden":                    false,
+		"verification_seal":         true,
+		"stripe_account_id":         bson.M{"$ne": ""},
+		"max_patients_per_day":      bson.M{"$gt": 0},
+		"days_available.0":          bson.M{"$exists": true},
+		"services.0":                bson.M{"$exists": true},
+		"available_neighborhoods.0": bson.M{"$exists": true},
+	}
 
-		nursesDto = append(nursesDto, nurseDto)
+	if !geoSearch || search.RadiusKm <= 0 {
+		filter["city"] = search.City
+	}
+	if search.Online != nil {
+		filter["online"] = *search.Online
+	}
+	if search.Specialization != "" {
+		filter["specialization"] = search.Specialization
+	}
+	if search.Service != "" {
+		filter["services"] = search.Service
+	}
+	if search.Neighborhood != "" {
+		filter["available_neighborhoods"] = search.Neighborhood
+	}
+	if search.Day != nil {
+		filter["days_available"] = primitive.Regex{Pattern: scheduling.WeekdayPattern(*search.Day), Options: "i"}
+	}
+	if search.MinRating > 0 {
+		filter["rating"] = bson.M{"$gte": search.MinRating}
 	}
 
-	if err := cursor.Err(); err != nil {
-		fmt.Printf("Erro no cursor do MongoDB: %v", err)
-		return nil, err
+	price := bson.M{}
+	if search.MinPrice > 0 {
+		price["$gte"] = search.MinPrice
+	}
+	if search.MaxPrice > 0 {
+		price["$lte"] = search.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["price"] = price
 	}
 
-	return nursesDto, nil
+	return filter
 }
 
-func (r *nurseRepository) GetAllOnlineNurses(patientCity string, latitude float64, longitude float64) ([]userDTO.AllNursesListDto, error) {
+// searchNurses busca uma página de enfermeiros. Quando o paciente tem coordenadas usa $geoNear,
+// que calcula a distância de cada enfermeiro (limitada a RadiusKm, se informado, sem restringir a
+// cidade). A ordenação desempata pelo _id para que o cursor seja estável entre as páginas.
+func (r *nurseRepository) searchNurses(search userDTO.NurseSearchDto) (userDTO.NurseListPageDto, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"hidden": false, "verification_seal": true, "city": patientCity, "online": true, "stripe_account_id": bson.M{"$ne": ""}}
+	origin := model.NewGeoPoint(search.Latitude, search.Longitude)
+	filter := nurseSearchFilter(search, origin != nil)
+
+	sortField := search.SortField
+	sortDesc := search.SortDesc
+	if sortField == "" || (sortField == NurseSortDistance && origin == nil) {
+		sortField, sortDesc = NurseSortRating, true
+		if origin != nil {
+			sortField, sortDesc = NurseSortDistance, false
+		}
+	}
+	column := nurseSortColumns[sortField]
+	direction := 1
+	comparison := "$gt"
+	if sortDesc {
+		direction, comparison = -1, "$lt"
+	}
 
-	cursor, err := r.collection.Find(ctx, filter)
+	after := bson.M{}
+	if search.Cursor != "" {
+		cursor, lastID, err := decodeNurseCursor(search.Cursor)
+		if err != nil || cursor.Sort != sortField {
+			return userDTO.NurseListPageDto{}, ErrInvalidCursor
+		}
+		after = bson.M{"$or": bson.A{
+			bson.M{column: bson.M{comparison: cursor.Value}},
+			bson.M{column: cursor.Value, "_id": bson.M{comparison: lastID}},
+		}}
+	}
+	sort := bson.D{{Key: column, Value: direction}, {Key: "_id", Value: direction}}
+
+	var cursor *mongo.Cursor
+	var err error
+	if origin != nil {
+		geoNear := bson.M{
+			"near":          origin,
+			"distanceField": "distance",
+			"spherical":     true,
+			"query":         filter,
+		}
+		if search.RadiusKm > 0 {
+			geoNear["maxDistance"] = search.RadiusKm * 1000
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$geoNear", Value: geoNear}},
+			{{Key: "$match", Value: after}},
+			{{Key: "$sort", Value: sort}},
+		}
+		if search.Limit > 0 {
+			pipeline = append(pipeline, bson.D{{Key: "$limit", Value: search.Limit + 1}})
+		}
+		cursor, err = r.collection.Aggregate(ctx, pipeline)
+	} else {
+		if len(after) > 0 {
+			filter["$and"] = bson.A{after}
+		}
+		opts := options.Find().SetSort(sort)
+		if search.Limit > 0 {
+			opts.SetLimit(int64(search.Limit + 1))
+		}
+		cursor, err = r.collection.Find(ctx, filter, opts)
+	}
 	if err != nil {
 		fmt.Printf("Erro ao buscar enfermeiros no MongoDB: %v", err)
-		return nil, err
+		return userDTO.NurseListPageDto{}, err
 	}
 	defer cursor.Close(ctx)
 
-	var nursesDto []userDTO.AllNursesListDto
+	var results []nurseWithDistance
+	if err := cursor.All(ctx, &results); err != nil {
+		fmt.Printf("Erro ao decodificar enfermeiros: %v", err)
+		return userDTO.NurseListPageDto{}, err
+	}
 
-	for cursor.Next(ctx) {
-		var nurseModel model.Nurse
-		if err := cursor.Decode(&nurseModel); err != nil {
-			fmt.Printf("Erro ao decodificar enfermeiro: %v", err)
-			continue
-		}
+	page := userDTO.NurseListPageDto{Nurses: make([]userDTO.AllNursesListDto, 0, len(results))}
+	if search.Limit > 0 && len(results) > search.Limit {
+		results = results[:search.Limit]
+		last := results[len(results)-1]
+		page.NextCursor = encodeNurseCursor(nurseCursor{Sort: sortField, Value: last.sortValue(column), ID: last.ID.Hex()})
+	}
 
-		if nurseModel.MaxPatientsPerDay == 0 ||
-			len(nurseModel.DaysAvailable) == 0 ||
-			len(nurseModel.Services) == 0 ||
-			len(nurseModel.AvailableNeighborhoods) == 0 {
-			continue
-		}
+	patientLocation := userDTO.Location{
+		Latitude:  search.Latitude,
+		Longitude: search.Longitude,
+	}
 
-		patientLocation := userDTO.Location{
-			Latitude:  latitude,
-			Longitude: longitude,
-		}
+	for _, result := range results {
+		nurseModel := result.Nurse
 
 		nurseDto := userDTO.AllNursesListDto{
 			ID:                     nurseModel.ID.Hex(),
@@ -450,6 +618,7 @@ func (r *nurseRepository) GetAllOnlineNurses(patientCity string, latitude float6
 			Specialization:         nurseModel.Specialization,
 			YearsExperience:        nurseModel.YearsExperience,
 			Price:                  float32(nurseModel.Price),
+			Rating:                 nurseModel.Rating,
 			Image:                  nurseModel.ProfileImageID.Hex(),
 			Shift:                  nurseModel.Shift,
 			Department:             nurseModel.Department,
@@ -467,16 +636,15 @@ func (r *nurseRepository) GetAllOnlineNurses(patientCity string, latitude float6
 			Services:               nurseModel.Services,
 			AvailableNeighborhoods: nurseModel.AvailableNeighborhoods,
 		}
+		if result.Distance != nil {
+			distanceKm := math.Round(*result.Distance/10) / 100
+			nurseDto.DistanceKm = &distanceKm
+		}
 
-		nursesDto = append(nursesDto, nurseDto)
-	}
-
-	if err := cursor.Err(); err != nil {
-		fmt.Printf("Erro no cursor do MongoDB: %v", err)
-		return nil, err
+		page.Nurses = append(page.Nurses, nurseDto)
 	}
 
-	return nursesDto, nil
+	return page, nil
 }
 
 func (r *nurseRepository) UpdateNurseFields(id string, updates map[string]interface{}) (model.Nurse, error) {
@@ -659,3 +827,24 @@ func (r *nurseRepository) UpdateStripeAccountId(nurseId string, stripeAccountId
 
 	return nil
 }
+
+// ErrStripeAccountNotFound indica que nenhum enfermeiro está vinculado à conta Stripe informada.
+var ErrStripeAccountNotFound = errors.New("nenhum enfermeiro vinculado a esta conta Stripe")
+
+// UpdateNurseByStripeAccountId atualiza o enfermeiro dono da conta Express informada.
+func (r *nurseRepository) UpdateNurseByStripeAccountId(stripeAccountId string, updates map[string]interface{}) error {
+	setUpdates := bson.M{"updated_at": time.Now()}
+	for key, value := range updates {
+		setUpdates[key] = value
+	}
+
+	result, err := r.collection.UpdateOne(r.ctx, bson.M{"stripe_account_id": stripeAccountId}, bson.M{"$set": setUpdates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrStripeAccountNotFound
+	}
+
+	return nil
+}
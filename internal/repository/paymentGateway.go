@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"medassist/internal/model"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+)
+
+// PaymentGateway reúne as operações feitas no provedor de pagamentos: clientes e cartões salvos,
+// cobranças, capturas, estornos, contas dos enfermeiros, repasses e contestações. Em produção é o Stripe
+// (NewStripePaymentGateway); nos testes, o fake em memória do pacote fakes.
+type PaymentGateway interface {
+	CreateCustomer(patientId string, patient model.User) (string, error)
+	CreatePaymentIntent(customerId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error)
+	ChargeSavedCard(customerId, paymentMethodId string, amountInCents int64, idempotencyKey string, metadata map[string]string) (*stripe.PaymentIntent, error)
+	CreateSavedCardPaymentIntent(customerId, paymentMethodId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error)
+	ListPaymentMethods(customerId string) ([]*stripe.PaymentMethod, error)
+	GetPaymentMethod(paymentMethodId string) (*stripe.PaymentMethod, error)
+	GetDefaultPaymentMethod(customerId string) (string, error)
+	SetDefaultPaymentMethod(customerId, paymentMethodId string) error
+	DetachPaymentMethod(paymentMethodId string) error
+	CreatePixPaymentIntent(customerId string, amountInCents int64, expiresAfter time.Duration, metadata map[string]string) (*stripe.PaymentIntent, error)
+	GetPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error)
+	CapturePaymentIntent(paymentIntentId string, amountInCents int64) (*stripe.PaymentIntent, error)
+	CancelPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error)
+	RefundPaymentIntent(paymentIntentId string, amountInCents int64, visitId string) (*stripe.Refund, error)
+	CreateExpressAccount(email string) (string, error)
+	CreateAccountLink(accountId string) (string, error)
+	CreateTransfer(amountInCents int64, destinationAccountId string, sourceTransactionId string, idempotencyKey string) (*stripe.Transfer, error)
+	ReverseTransfer(transferId string, amountInCents int64, disputeId string) (*stripe.TransferReversal, error)
+	SubmitDisputeEvidence(stripeDisputeId string, evidence model.DisputeEvidence) (*stripe.Dispute, error)
+}
@@ -2,11 +2,21 @@ package repository
 
 import (
 	"context"
+	"log"
+	"medassist/internal/model"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// PaymentRepository mantém o livro-razão de pagamentos (coleção "payments").
 type PaymentRepository interface {
+	RecordEntry(entry model.PaymentEntry) (model.PaymentEntry, error)
+	UpdateEntryStatus(entryType string, stripeId string, status string) error
+	FindEntries(filter model.PaymentEntryFilter) ([]model.PaymentEntry, error)
+	FindVisitEntries(visitId string, paymentIntentId string) ([]model.PaymentEntry, error)
 }
 
 type paymentRepository struct {
@@ -15,8 +25,126 @@ type paymentRepository struct {
 }
 
 func NewPaymentRepository(db *mongo.Database) PaymentRepository {
-	return &reviewRepository{
+	repo := &paymentRepository{
 		collection: db.Collection("payments"),
 		ctx:        context.Background(),
 	}
+
+	_, err := repo.collection.Indexes().CreateMany(repo.ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "stripe_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "payment_intent_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "visit_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índices do livro-razão de pagamentos: %v", err)
+	}
+
+	return repo
+}
+
+// RecordEntry grava o lançamento, ou atualiza o existente com o mesmo tipo e StripeID. Campos
+// vazios não sobrescrevem o que já foi registrado, assim um evento repetido ou com menos
+// informação não apaga a visita ou as partes do lançamento.
+func (r *paymentRepository) RecordEntry(entry model.PaymentEntry) (model.PaymentEntry, error) {
+	now := time.Now()
+	if entry.Currency == "" {
+		entry.Currency = "brl"
+	}
+
+	set := bson.M{
+		"status":          entry.Status,
+		"amount_in_cents": entry.AmountInCents,
+		"currency":        entry.Currency,
+		"updated_at":      now,
+	}
+	optional := map[string]string{
+		"payment_intent_id": entry.PaymentIntentID,
+		"visit_id":          entry.VisitID,
+		"patient_id":        entry.PatientID,
+		"nurse_id":          entry.NurseID,
+	}
+	for key, value := range optional {
+		if value != "" {
+			set[key] = value
+		}
+	}
+
+	filter := bson.M{"type": entry.Type, "stripe_id": entry.StripeID}
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.PaymentEntry
+	if err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&saved); err != nil {
+		return model.PaymentEntry{}, err
+	}
+	return saved, nil
+}
+
+// UpdateEntryStatus altera a situação de um lançamento já registrado. Lançamentos que não
+// existem são ignorados.
+func (r *paymentRepository) UpdateEntryStatus(entryType string, stripeId string, status string) error {
+	_, err := r.collection.UpdateOne(r.ctx,
+		bson.M{"type": entryType, "stripe_id": stripeId},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// FindEntries lista os lançamentos mais recentes que atendem ao filtro.
+func (r *paymentRepository) FindEntries(filter model.PaymentEntryFilter) ([]model.PaymentEntry, error) {
+	query := bson.M{}
+	fields := map[string]string{
+		"type":              filter.Type,
+		"status":            filter.Status,
+		"visit_id":          filter.VisitID,
+		"payment_intent_id": filter.PaymentIntentID,
+		"patient_id":        filter.PatientID,
+		"nurse_id":          filter.NurseID,
+	}
+	for key, value := range fields {
+		if value != "" {
+			query[key] = value
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	return r.find(query, opts)
+}
+
+// FindVisitEntries retorna os lançamentos de uma visita em ordem cronológica: os que são
+// dela e os do pagamento que ela compartilha com as outras visitas da série.
+func (r *paymentRepository) FindVisitEntries(visitId string, paymentIntentId string) ([]model.PaymentEntry, error) {
+	query := bson.M{"visit_id": visitId}
+	if paymentIntentId != "" {
+		query = bson.M{"$or": []bson.M{
+			{"visit_id": visitId},
+			{"payment_intent_id": paymentIntentId, "visit_id": bson.M{"$exists": false}},
+		}}
+	}
+
+	return r.find(query, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+}
+
+func (r *paymentRepository) find(query bson.M, opts *options.FindOptions) ([]model.PaymentEntry, error) {
+	cursor, err := r.collection.Find(r.ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	entries := []model.PaymentEntry{}
+	if err := cursor.All(r.ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StripeEventRepository guarda os IDs dos eventos de webhook já recebidos, para que reenvios
+// do Stripe não sejam processados duas vezes.
+type StripeEventRepository interface {
+	Claim(eventId string, eventType string) (bool, error)
+	Release(eventId string) error
+}
+
+type stripeEvent struct {
+	ID         string    `bson:"_id"`
+	Type       string    `bson:"type"`
+	ReceivedAt time.Time `bson:"received_at"`
+}
+
+type stripeEventRepository struct {
+	collection *mongo.Collection
+	ctx        context.Context
+}
+
+func NewStripeEventRepository(db *mongo.Database) StripeEventRepository {
+	return &stripeEventRepository{
+		collection: db.Collection("stripe_events"),
+		ctx:        context.Background(),
+	}
+}
+
+// Claim registra o evento e retorna false se ele já tinha sido recebido antes.
+func (r *stripeEventRepository) Claim(eventId string, eventType string) (bool, error) {
+	_, err := r.collection.InsertOne(r.ctx, stripeEvent{ID: eventId, Type: eventType, ReceivedAt: time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release apaga o registro do evento para que o próximo reenvio do Stripe seja processado.
+func (r *stripeEventRepository) Release(eventId string) error {
+	_, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": eventId})
+	return err
+}
@@ -0,0 +1,443 @@
+package repository
+
+import (
+    "medassist/internal/model"
+    "os"
+    "time"
+    "github.com/stripe/stripe-go/v76"
+    "github.com/stripe/stripe-go/v76/account"
+    "github.com/stripe/stripe-go/v76/accountlink"
+    "github.com/stripe/stripe-go/v76/customer"
+    "github.com/stripe/stripe-go/v76/dispute"
+    "github.com/stripe/stripe-go/v76/transfer"
+    "github.com/stripe/stripe-go/v76/transferreversal"
+    "github.com/stripe/stripe-go/v76/paymentintent"
+    "github.com/stripe/stripe-go/v76/paymentmethod"
+    "github.com/stripe/stripe-go/v76/refund"
+    "fmt"
+    "strings"
+)
+
+type stripePaymentGateway struct {
+}
+
+// NewStripePaymentGateway cria o PaymentGateway que fala com o Stripe usando STRIPE_SECRET_KEY.
+func NewStripePaymentGateway() PaymentGateway {
+    stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
+    return &stripePaymentGateway{}
+}
+
+// Cria o cliente do paciente no Stripe, identificado pelo id do paciente na aplicação.
+func (r *stripePaymentGateway) CreateCustomer(patientId string, patient model.User) (string, error) {
+    params := &stripe.CustomerParams{
+        Name:  stripe.String(patient.Name),
+        Email: stripe.String(patient.Email),
+        Phone: stripe.String(patient.Phone),
+        Address: &stripe.AddressParams{
+            Line1:      stripe.String(patient.Address),
+            City:       stripe.String(patient.City),
+            State:      stripe.String(patient.UF),
+            PostalCode: stripe.String(patient.CEP),
+            Country:    stripe.String("Brasil"),
+        },
+    }
+    params.AddMetadata("app_patient_id", patientId)
+
+    c, err := customer.New(params)
+    if err != nil {
+        return "", fmt.Errorf("erro ao criar cliente no Stripe: %w", err)
+    }
+
+    return c.ID, nil
+}
+
+// Cria o PaymentIntent de cartão que o frontend confirma com o client secret. O cartão fica
+// salvo para cobranças futuras sem o paciente presente. Com manualCapture o valor só é retido
+// e precisa ser capturado depois com CapturePaymentIntent.
+func (r *stripePaymentGateway) CreatePaymentIntent(customerId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error) {
+    params := &stripe.PaymentIntentParams{
+        Amount:             stripe.Int64(amountInCents),
+        Currency:           stripe.String(string(stripe.CurrencyBRL)),
+        Customer:           stripe.String(customerId),
+        SetupFutureUsage:   stripe.String(string(stripe.PaymentIntentSetupFutureUsageOffSession)),
+        PaymentMethodTypes: []*string{stripe.String("card")},
+    }
+    if manualCapture {
+        params.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+    }
+    for key, value := range metadata {
+        params.AddMetadata(key, value)
+    }
+
+    pi, err := paymentintent.New(params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao criar PaymentIntent no Stripe: %w", err)
+    }
+
+    return pi, nil
+}
+
+func (r *stripePaymentGateway) CreateExpressAccount(email string) (string, error) {
+    params := &stripe.AccountParams{
+        Type:    stripe.String(string(stripe.AccountTypeExpress)),
+        Email:   stripe.String(email),
+        Country: stripe.String("BR"),
+        Capabilities: &stripe.AccountCapabilitiesParams{
+            CardPayments: &stripe.AccountCapabilitiesCardPaymentsParams{Requested: stripe.Bool(true)},
+            Transfers:    &stripe.AccountCapabilitiesTransfersParams{Requested: stripe.Bool(true)},
+        },
+    }
+    
+    newAccount, err := account.New(params)
+    if err != nil {
+        return "", err
+    }
+    
+    return newAccount.ID, nil
+}
+
+// Cria um link de onboarding de uso único
+func (r *stripePaymentGateway) CreateAccountLink(accountId string) (string, error) {
+    // Você pode pegar essas URLs do .env também
+    refreshURL := os.Getenv("STRIPE_REFRESH_URL")
+    returnURL  := os.Getenv("STRIPE_RETURN_URL") 
+
+    linkParams := &stripe.AccountLinkParams{
+        Account:    stripe.String(accountId),
+        RefreshURL: stripe.String(refreshURL),
+        ReturnURL:  stripe.String(returnURL),
+        Type:       stripe.String("account_onboarding"),
+    }
+    
+    link, err := accountlink.New(linkParams)
+    if err != nil {
+        return "", err
+    }
+    
+    return link.URL, nil // Retorna a URL completa
+}
+
+
+// Repassa ao enfermeiro parte da cobrança do PaymentIntent. Repetir a chamada com a mesma
+// chave de idempotência devolve o repasse já feito em vez de pagar de novo.
+func (r *stripePaymentGateway) CreateTransfer(amountInCents int64, destinationAccountId string, paymentIntentId string, idempotencyKey string) (*stripe.Transfer, error) {
+    
+    // 1. Buscar o PaymentIntent no Stripe usando o ID (pi_...)
+    // (Esta parte estava correta)
+    pi, err := paymentintent.Get(paymentIntentId, nil)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao buscar payment intent no Stripe: %w", err)
+    }
+
+    // --- MUDANÇA (A CORREÇÃO FINAL) ---
+    // 2. O campo correto é 'LatestCharge' (singular)
+    
+    // Verificação de segurança (Boa Prática) para o ponteiro
+    if pi.LatestCharge == nil {
+        return nil, fmt.Errorf("payment intent %s não possui uma cobrança (charge) associada (status: %s)", pi.ID, pi.Status)
+    }
+
+    // O ID da cobrança (ch_...) está dentro do objeto 'LatestCharge'
+    latestChargeID := pi.LatestCharge.ID
+    if latestChargeID == "" {
+        return nil, fmt.Errorf("ID da cobrança (charge) associada está vazio")
+    }
+
+    // 3. USAR O 'latestChargeID' (o 'ch_...') como SourceTransaction
+    params := &stripe.TransferParams{
+        Amount:      stripe.Int64(amountInCents),
+        Currency:    stripe.String(string(stripe.CurrencyBRL)),
+        Destination: stripe.String(destinationAccountId),
+    }
+
+    // Aqui usamos a variável correta que acabamos de pegar. Com cupom, os repasses podem somar
+    // mais que a cobrança, que o Stripe não aceita com SourceTransaction: a diferença sai do
+    // saldo da plataforma, que absorve o desconto.
+    if pi.Metadata["discount_in_cents"] == "" {
+        params.SourceTransaction = stripe.String(latestChargeID)
+    }
+    if idempotencyKey != "" {
+        params.SetIdempotencyKey(idempotencyKey)
+    }
+
+    t, err := transfer.New(params)
+    if err != nil {
+        return nil, err
+    }
+
+    return t, nil
+}
+
+// Estorna o pagamento do PaymentIntent. Com amountInCents igual a 0 o valor inteiro é devolvido.
+// O visitId identifica o estorno no Stripe, então repetir a chamada para a mesma visita não
+// devolve o dinheiro duas vezes.
+func (r *stripePaymentGateway) RefundPaymentIntent(paymentIntentId string, amountInCents int64, visitId string) (*stripe.Refund, error) {
+    params := &stripe.RefundParams{
+        PaymentIntent: stripe.String(paymentIntentId),
+    }
+    if amountInCents > 0 {
+        params.Amount = stripe.Int64(amountInCents)
+    }
+    if visitId != "" {
+        params.AddMetadata("visit_id", visitId)
+        params.SetIdempotencyKey("refund-visit-" + visitId)
+    }
+
+    rf, err := refund.New(params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao estornar payment intent %s: %w", paymentIntentId, err)
+    }
+
+    return rf, nil
+}
+
+// Busca o PaymentIntent no Stripe para conferir dono, valor e situação do pagamento.
+func (r *stripePaymentGateway) GetPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+    pi, err := paymentintent.Get(paymentIntentId, nil)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao buscar payment intent %s no Stripe: %w", paymentIntentId, err)
+    }
+
+    return pi, nil
+}
+
+// Captura o valor retido no PaymentIntent criado com captura manual. Com amountInCents igual
+// a 0 o valor autorizado inteiro é capturado; o restante de uma captura parcial é liberado.
+func (r *stripePaymentGateway) CapturePaymentIntent(paymentIntentId string, amountInCents int64) (*stripe.PaymentIntent, error) {
+    params := &stripe.PaymentIntentCaptureParams{}
+    if amountInCents > 0 {
+        params.AmountToCapture = stripe.Int64(amountInCents)
+    }
+    params.SetIdempotencyKey("capture-" + paymentIntentId)
+
+    pi, err := paymentintent.Capture(paymentIntentId, params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao capturar payment intent %s: %w", paymentIntentId, err)
+    }
+
+    return pi, nil
+}
+
+// Cancela o PaymentIntent, liberando o valor retido no cartão do paciente.
+func (r *stripePaymentGateway) CancelPaymentIntent(paymentIntentId string) (*stripe.PaymentIntent, error) {
+    params := &stripe.PaymentIntentCancelParams{
+        CancellationReason: stripe.String(string(stripe.PaymentIntentCancellationReasonRequestedByCustomer)),
+    }
+
+    pi, err := paymentintent.Cancel(paymentIntentId, params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao cancelar payment intent %s: %w", paymentIntentId, err)
+    }
+
+    return pi, nil
+}
+
+// Cobra na hora, sem o paciente presente, o cartão salvo pelo PaymentIntent de uma visita
+// (SetupFutureUsage off_session). A chave de idempotência evita cobrar duas vezes a mesma
+// operação; se o banco exigir autenticação, o Stripe recusa com authentication_required.
+func (r *stripePaymentGateway) ChargeSavedCard(customerId, paymentMethodId string, amountInCents int64, idempotencyKey string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+    params := &stripe.PaymentIntentParams{
+        Amount:             stripe.Int64(amountInCents),
+        Currency:           stripe.String(string(stripe.CurrencyBRL)),
+        Customer:           stripe.String(customerId),
+        PaymentMethod:      stripe.String(paymentMethodId),
+        PaymentMethodTypes: []*string{stripe.String("card")},
+        OffSession:         stripe.Bool(true),
+        Confirm:            stripe.Bool(true),
+    }
+    for key, value := range metadata {
+        params.AddMetadata(key, value)
+    }
+    params.SetIdempotencyKey(idempotencyKey)
+
+    pi, err := paymentintent.New(params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao cobrar cartão salvo no Stripe: %w", err)
+    }
+
+    return pi, nil
+}
+
+// Cria e confirma, com o paciente presente, um PaymentIntent de cartão com um cartão já salvo,
+// sem que ele digite os dados de novo. Se o banco pedir autenticação o PaymentIntent fica em
+// requires_action e o frontend conclui a confirmação com o client secret.
+func (r *stripePaymentGateway) CreateSavedCardPaymentIntent(customerId, paymentMethodId string, amountInCents int64, manualCapture bool, metadata map[string]string) (*stripe.PaymentIntent, error) {
+    params := &stripe.PaymentIntentParams{
+        Amount:             stripe.Int64(amountInCents),
+        Currency:           stripe.String(string(stripe.CurrencyBRL)),
+        Customer:           stripe.String(customerId),
+        PaymentMethod:      stripe.String(paymentMethodId),
+        PaymentMethodTypes: []*string{stripe.String("card")},
+        Confirm:            stripe.Bool(true),
+        UseStripeSDK:       stripe.Bool(true),
+    }
+    if manualCapture {
+        params.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+    }
+    for key, value := range metadata {
+        params.AddMetadata(key, value)
+    }
+
+    pi, err := paymentintent.New(params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao pagar com cartão salvo no Stripe: %w", err)
+    }
+
+    return pi, nil
+}
+
+// Lista os cartões salvos no cliente do paciente.
+func (r *stripePaymentGateway) ListPaymentMethods(customerId string) ([]*stripe.PaymentMethod, error) {
+    params := &stripe.PaymentMethodListParams{
+        Customer: stripe.String(customerId),
+        Type:     stripe.String(string(stripe.PaymentMethodTypeCard)),
+    }
+
+    var methods []*stripe.PaymentMethod
+    iter := paymentmethod.List(params)
+    for iter.Next() {
+        methods = append(methods, iter.PaymentMethod())
+    }
+    if err := iter.Err(); err != nil {
+        return nil, fmt.Errorf("erro ao listar cartões do cliente %s no Stripe: %w", customerId, err)
+    }
+
+    return methods, nil
+}
+
+func (r *stripePaymentGateway) GetPaymentMethod(paymentMethodId string) (*stripe.PaymentMethod, error) {
+    pm, err := paymentmethod.Get(paymentMethodId, nil)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao buscar cartão %s no Stripe: %w", paymentMethodId, err)
+    }
+
+    return pm, nil
+}
+
+// Retorna o id do cartão padrão do cliente, ou "" se ele não tiver um.
+func (r *stripePaymentGateway) GetDefaultPaymentMethod(customerId string) (string, error) {
+    c, err := customer.Get(customerId, nil)
+    if err != nil {
+        return "", fmt.Errorf("erro ao buscar cliente %s no Stripe: %w", customerId, err)
+    }
+    if c.InvoiceSettings == nil || c.InvoiceSettings.DefaultPaymentMethod == nil {
+        return "", nil
+    }
+
+    return c.InvoiceSettings.DefaultPaymentMethod.ID, nil
+}
+
+func (r *stripePaymentGateway) SetDefaultPaymentMethod(customerId, paymentMethodId string) error {
+    params := &stripe.CustomerParams{
+        InvoiceSettings: &stripe.CustomerInvoiceSettingsParams{
+            DefaultPaymentMethod: stripe.String(paymentMethodId),
+        },
+    }
+
+    if _, err := customer.Update(customerId, params); err != nil {
+        return fmt.Errorf("erro ao definir cartão padrão do cliente %s no Stripe: %w", customerId, err)
+    }
+
+    return nil
+}
+
+// Remove o cartão do cliente. Se ele era o padrão, o Stripe deixa o cliente sem cartão padrão.
+func (r *stripePaymentGateway) DetachPaymentMethod(paymentMethodId string) error {
+    if _, err := paymentmethod.Detach(paymentMethodId, nil); err != nil {
+        return fmt.Errorf("erro ao remover cartão %s no Stripe: %w", paymentMethodId, err)
+    }
+
+    return nil
+}
+
+// Cria e confirma um PaymentIntent PIX. O QR code e o código "copia e cola" vêm em
+// NextAction.PixDisplayQRCode; se o paciente não pagar em expiresAfter o Stripe cancela o
+// pagamento e envia payment_intent.canceled.
+func (r *stripePaymentGateway) CreatePixPaymentIntent(customerId string, amountInCents int64, expiresAfter time.Duration, metadata map[string]string) (*stripe.PaymentIntent, error) {
+    params := &stripe.PaymentIntentParams{
+        Amount:             stripe.Int64(amountInCents),
+        Currency:           stripe.String(string(stripe.CurrencyBRL)),
+        Customer:           stripe.String(customerId),
+        PaymentMethodTypes: []*string{stripe.String("pix")},
+        PaymentMethodData: &stripe.PaymentIntentPaymentMethodDataParams{
+            Type: stripe.String("pix"),
+        },
+        PaymentMethodOptions: &stripe.PaymentIntentPaymentMethodOptionsParams{
+            Pix: &stripe.PaymentIntentPaymentMethodOptionsPixParams{
+                ExpiresAfterSeconds: stripe.Int64(int64(expiresAfter.Seconds())),
+            },
+        },
+        Confirm: stripe.Bool(true),
+    }
+    for key, value := range metadata {
+        params.AddMetadata(key, value)
+    }
+
+    pi, err := paymentintent.New(params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao criar pagamento PIX no Stripe: %w", err)
+    }
+
+    return pi, nil
+}
+
+// Devolve o repasse feito ao enfermeiro. Com amountInCents igual a 0 o repasse inteiro é
+// devolvido. O disputeId identifica a devolução no Stripe, então repetir a chamada para a
+// mesma contestação não devolve o repasse duas vezes.
+func (r *stripePaymentGateway) ReverseTransfer(transferId string, amountInCents int64, disputeId string) (*stripe.TransferReversal, error) {
+    params := &stripe.TransferReversalParams{
+        ID: stripe.String(transferId),
+    }
+    if amountInCents > 0 {
+        params.Amount = stripe.Int64(amountInCents)
+    }
+    if disputeId != "" {
+        params.AddMetadata("dispute_id", disputeId)
+        params.SetIdempotencyKey("reversal-dispute-" + disputeId + "-" + transferId)
+    }
+
+    reversal, err := transferreversal.New(params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao reverter repasse %s: %w", transferId, err)
+    }
+
+    return reversal, nil
+}
+
+// Envia ao banco as evidências da contestação. O Stripe aceita um único envio, então a
+// contestação fica em análise (under_review) e não pode mais ser alterada.
+func (r *stripePaymentGateway) SubmitDisputeEvidence(stripeDisputeId string, evidence model.DisputeEvidence) (*stripe.Dispute, error) {
+    params := &stripe.DisputeParams{
+        Evidence: &stripe.DisputeEvidenceParams{
+            CustomerName:         stripe.String(evidence.CustomerName),
+            CustomerEmailAddress: stripe.String(evidence.CustomerEmail),
+            ServiceDate:          stripe.String(evidence.ServiceDate),
+            ProductDescription:   stripe.String(evidence.ServiceDescription),
+            AccessActivityLog:    stripe.String(evidence.ConfirmationLog),
+            UncategorizedText:    stripe.String(uncategorizedEvidence(evidence)),
+        },
+        Submit: stripe.Bool(true),
+    }
+
+    d, err := dispute.Update(stripeDisputeId, params)
+    if err != nil {
+        return nil, fmt.Errorf("erro ao enviar evidências da contestação %s: %w", stripeDisputeId, err)
+    }
+
+    return d, nil
+}
+
+// O Stripe não tem campos próprios para prescrições e conversas; elas vão juntas no texto livre.
+func uncategorizedEvidence(evidence model.DisputeEvidence) string {
+    var sections []string
+    if evidence.Notes != "" {
+        sections = append(sections, evidence.Notes)
+    }
+    if len(evidence.Prescriptions) > 0 {
+        sections = append(sections, "Prescrições registradas pelo enfermeiro:\n- "+strings.Join(evidence.Prescriptions, "\n- "))
+    }
+    if evidence.ChatTranscript != "" {
+        sections = append(sections, "Conversa entre paciente e enfermeiro pelo aplicativo:\n"+evidence.ChatTranscript)
+    }
+    return strings.Join(sections, "\n\n")
+}
@@ -35,6 +35,7 @@ type UserRepository interface {
 	DownloadFileByID(fileID primitive.ObjectID) (*gridfs.DownloadStream, error)
 	FindFileByID(ctx context.Context, id primitive.ObjectID) (*dto.FileData, error)
 	UploadFile(file io.Reader, fileName string, contentType string) (primitive.ObjectID, error)
+	DeleteFile(fileID primitive.ObjectID) error
 	DeleteUser(id string) error
 
 	GetTotalPatientsCount() (int64, error)
@@ -54,11 +55,15 @@ func NewUserRepository(db *mongo.Database) UserRepository {
 	if err != nil {
 		panic(err)
 	}
-	return &userRepository{
+	repo := &userRepository{
 		collection: db.Collection("users"),
 		ctx:        context.Background(),
 		bucket:     bucket,
 	}
+
+	ensureGeoLocationIndex(repo.ctx, repo.collection)
+
+	return repo
 }
 
 func (r *userRepository) FindUserByEmail(email string) (dto.AuthUser, error) {
@@ -323,6 +328,11 @@ func (r *userRepository) DownloadFileByID(fileID primitive.ObjectID) (*gridfs.Do
 	return downloadStream, nil
 }
 
+// DeleteFile remove do GridFS um arquivo e seus blocos.
+func (r *userRepository) DeleteFile(fileID primitive.ObjectID) error {
+	return r.bucket.Delete(fileID)
+}
+
 func (r *userRepository) FindFileByID(ctx context.Context, id primitive.ObjectID) (*dto.FileData, error) {
 	downloadStream, err := r.bucket.OpenDownloadStream(id)
 	if err != nil {
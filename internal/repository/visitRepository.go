@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"medassist/internal/model"
 	"medassist/utils"
+	"slices"
 	"strings"
 	nurseDTO "medassist/internal/nurse/dto"
 	"time"
@@ -13,16 +15,37 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type VisitRepository interface {
 	CreateVisit(visit model.Visit) error
+	CreateVisitReservingSlot(visit model.Visit, window time.Duration) error
+	RescheduleVisit(visit model.Visit, newDate time.Time, window time.Duration, updates map[string]interface{}) (model.Visit, error)
+	ReleaseVisitSlot(visitId string) error
+	UpdateDispatch(visitId string, dispatch model.VisitDispatch) error
+	ClaimDispatchedVisit(visitId string, change model.VisitStatusChange, window time.Duration, updates map[string]interface{}) (model.Visit, error)
 	FindAllVisitsForPatient(patientId string) ([]model.Visit, error)
 	FindAllVisitsForNurse(nurseId string) ([]model.Visit, error)
 	FindAllPendingVisitsForNurse(nurseId string) ([]model.Visit, error)
+	FindActiveVisitsForNurseBetween(nurseId string, from, to time.Time) ([]model.Visit, error)
+	FindEarningVisitsForNurse(nurseId string, from, to time.Time) ([]model.Visit, error)
+	FindVisitsBySeriesId(seriesId string) ([]model.Visit, error)
+	FindVisitsByPaymentIntentId(paymentIntentId string) ([]model.Visit, error)
+	UpdateVisitsByPaymentIntentId(paymentIntentId string, updates map[string]interface{}) (int64, error)
+	UpdateVisitsPaymentStatus(paymentIntentId string, from []string, to string) (int64, error)
+	UpdateVisitsByTransferId(transferId string, updates map[string]interface{}) (int64, error)
+	FindStalePendingVisits(now, createdBefore, immediateCreatedBefore time.Time) ([]model.Visit, error)
+	FindAuthorizedVisitsCreatedBefore(createdBefore time.Time) ([]model.Visit, error)
 	FindAllVisits() ([]model.Visit, error)
 	FindVisitById(id string) (model.Visit, error)
 	UpdateVisitFields(id string, updates map[string]interface{}) (model.Visit, error)
+	UpdateVisitStatus(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error)
+	ClaimVisitTip(visitId string, tip model.VisitTip) (model.Visit, error)
+	ReleaseVisitTip(visitId string) error
+	RecordVisitReceipt(visitId string, receipt model.VisitReceipt) (model.Visit, error)
+	ClaimPaymentIntent(paymentIntentId, patientId string) error
+	ReleasePaymentIntent(paymentIntentId string) error
 	DeleteVisit(visitId string) error
 	FindAllCompletedVisitsForPatient(patientId string) ([]model.Visit, error)
 
@@ -32,16 +55,89 @@ type VisitRepository interface {
     GetTotalRevenueLast30Days() (float64, error)
 }
 
+// ErrVisitStatusChanged indica que o status da visita foi alterado por outra
+// requisição entre a leitura e a gravação.
+var ErrVisitStatusChanged = errors.New("o status da visita foi alterado por outra operação")
+
+// ErrRescheduleNotPending indica que a visita saiu de PENDING/CONFIRMED ou que o pedido de
+// remarcação foi respondido por outra requisição antes de a nova data ser gravada.
+var ErrRescheduleNotPending = errors.New("o pedido de remarcação não está mais pendente")
+
+// ErrSlotAlreadyBooked indica que outra visita já reservou parte do intervalo pedido na agenda do enfermeiro.
+var ErrSlotAlreadyBooked = errors.New("o enfermeiro já possui uma visita reservada neste horário")
+
+// ErrVisitAlreadyClaimed indica que a visita oferecida a vários enfermeiros já foi aceita por outro.
+var ErrVisitAlreadyClaimed = errors.New("esta visita já foi aceita por outro enfermeiro")
+
+// ErrNotDispatchCandidate indica que o enfermeiro tentou aceitar uma visita imediata que não foi oferecida a ele.
+var ErrNotDispatchCandidate = errors.New("esta visita não foi oferecida a você")
+
+// ErrVisitTipTaken indica que a visita já recebeu gorjeta ou não está mais concluída.
+var ErrVisitTipTaken = errors.New("a visita já recebeu gorjeta ou não está concluída")
+
+// ErrVisitReceiptTaken indica que outra requisição já registrou o recibo da visita.
+var ErrVisitReceiptTaken = errors.New("a visita já possui recibo")
+
+// ErrPaymentIntentClaimed indica que o PaymentIntent já pagou outra solicitação de visita.
+var ErrPaymentIntentClaimed = errors.New("o pagamento já foi usado em outra solicitação de visita")
+
+// slotGranularity é o tamanho de cada bloco reservado na agenda do enfermeiro. Duas visitas cujos
+// intervalos se sobrepõem sempre disputam ao menos um bloco em comum.
+const slotGranularity = 15 * time.Minute
+
+type visitSlotReservation struct {
+	NurseId string    `bson:"nurse_id"`
+	Slot    time.Time `bson:"slot"`
+	VisitId string    `bson:"visit_id"`
+}
+
+// paymentIntentClaim marca o PaymentIntent como usado por uma solicitação de visita. As
+// ocorrências de uma série dividem o mesmo PaymentIntent, então a unicidade fica aqui e não
+// nas visitas.
+type paymentIntentClaim struct {
+	PaymentIntentID string    `bson:"_id"`
+	PatientId       string    `bson:"patient_id"`
+	ClaimedAt       time.Time `bson:"claimed_at"`
+}
+
 type visitRepository struct {
-	collection *mongo.Collection
-	ctx        context.Context
+	collection             *mongo.Collection
+	reservationsCollection *mongo.Collection
+	paymentClaims          *mongo.Collection
+	ctx                    context.Context
 }
 
 func NewVisitRepository(db *mongo.Database) VisitRepository {
-	return &visitRepository{
-		collection: db.Collection("visits"),
-		ctx:        context.Background(),
+	repo := &visitRepository{
+		collection:             db.Collection("visits"),
+		reservationsCollection: db.Collection("visit_slot_reservations"),
+		paymentClaims:          db.Collection("visit_payment_claims"),
+		ctx:                    context.Background(),
 	}
+
+	_, err := repo.reservationsCollection.Indexes().CreateMany(repo.ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "nurse_id", Value: 1}, {Key: "slot", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "visit_id", Value: 1}},
+		},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índices de reserva de horários: %v", err)
+	}
+
+	_, err = repo.collection.Indexes().CreateMany(repo.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "payment_intent_id", Value: 1}}},
+		{Keys: bson.D{{Key: "transfer_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar índices das visitas: %v", err)
+	}
+
+	return repo
 }
 
 func (r *visitRepository) CreateVisit(visit model.Visit) error {
@@ -49,6 +145,208 @@ func (r *visitRepository) CreateVisit(visit model.Visit) error {
 	return err
 }
 
+// CreateVisitReservingSlot reserva os blocos da agenda do enfermeiro entre VisitDate e VisitDate+window
+// e só então grava a visita. O índice único em (nurse_id, slot) garante que, entre requisições
+// concorrentes para o mesmo intervalo, apenas uma consiga a reserva; as demais recebem ErrSlotAlreadyBooked.
+func (r *visitRepository) CreateVisitReservingSlot(visit model.Visit, window time.Duration) error {
+	visitId := visit.ID.Hex()
+
+	if err := r.reserveSlots(visit.NurseId, visitId, visit.VisitDate, window); err != nil {
+		return err
+	}
+
+	if _, err := r.collection.InsertOne(r.ctx, visit); err != nil {
+		r.ReleaseVisitSlot(visitId)
+		return err
+	}
+
+	return nil
+}
+
+// RescheduleVisit move a reserva da visita para newDate e grava a nova data junto com updates.
+// Se o novo horário já estiver ocupado, a reserva antiga é restaurada e ErrSlotAlreadyBooked é retornado.
+// A data só é gravada enquanto a visita estiver PENDING ou CONFIRMED e o pedido de remarcação
+// continuar pendente; caso contrário a nova reserva é desfeita e ErrRescheduleNotPending é retornado.
+func (r *visitRepository) RescheduleVisit(visit model.Visit, newDate time.Time, window time.Duration, updates map[string]interface{}) (model.Visit, error) {
+	visitId := visit.ID.Hex()
+
+	// libera antes de reservar para que blocos em comum entre a data antiga e a nova não conflitem
+	if err := r.ReleaseVisitSlot(visitId); err != nil {
+		return model.Visit{}, err
+	}
+
+	if err := r.reserveSlots(visit.NurseId, visitId, newDate, window); err != nil {
+		if restoreErr := r.reserveSlots(visit.NurseId, visitId, visit.VisitDate, window); restoreErr != nil {
+			log.Printf("Erro ao restaurar reserva da visita %s: %v", visitId, restoreErr)
+		}
+		return model.Visit{}, err
+	}
+
+	setUpdates := bson.M{}
+	for key, value := range updates {
+		if value != nil {
+			setUpdates[key] = value
+		}
+	}
+	setUpdates["visit_date"] = newDate
+	setUpdates["updated_at"] = time.Now()
+
+	filter := bson.M{
+		"_id":               visit.ID,
+		"status":            bson.M{"$in": []model.VisitStatus{model.VisitStatusPending, model.VisitStatusConfirmed}},
+		"reschedule.status": model.RescheduleStatusPending,
+	}
+	result, err := r.collection.UpdateOne(r.ctx, filter, bson.M{"$set": setUpdates})
+	if err != nil {
+		return model.Visit{}, err
+	}
+	if result.MatchedCount == 0 {
+		r.restoreVisitSlots(visitId, window)
+		return model.Visit{}, ErrRescheduleNotPending
+	}
+
+	return r.FindVisitById(visitId)
+}
+
+// restoreVisitSlots desfaz a reserva feita por uma remarcação que não pôde ser gravada e, se a
+// visita ainda ocupar a agenda, volta a reservar o horário que está salvo nela.
+func (r *visitRepository) restoreVisitSlots(visitId string, window time.Duration) {
+	if err := r.ReleaseVisitSlot(visitId); err != nil {
+		log.Printf("Erro ao liberar reserva da visita %s: %v", visitId, err)
+		return
+	}
+
+	current, err := r.FindVisitById(visitId)
+	if err != nil {
+		log.Printf("Erro ao consultar visita %s para restaurar a reserva: %v", visitId, err)
+		return
+	}
+	if current.Status != model.VisitStatusPending && current.Status != model.VisitStatusConfirmed {
+		return
+	}
+
+	if err := r.reserveSlots(current.NurseId, visitId, current.VisitDate, window); err != nil {
+		log.Printf("Erro ao restaurar reserva da visita %s: %v", visitId, err)
+	}
+}
+
+func (r *visitRepository) reserveSlots(nurseId, visitId string, start time.Time, window time.Duration) error {
+	end := start.UTC().Add(window)
+
+	var reservations []interface{}
+	for slot := start.UTC().Truncate(slotGranularity); slot.Before(end); slot = slot.Add(slotGranularity) {
+		reservations = append(reservations, visitSlotReservation{
+			NurseId: nurseId,
+			Slot:    slot,
+			VisitId: visitId,
+		})
+	}
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	_, err := r.reservationsCollection.InsertMany(r.ctx, reservations, options.InsertMany().SetOrdered(true))
+	if err != nil {
+		// Desfaz os blocos que chegaram a ser inseridos antes do conflito
+		r.releaseNurseSlots(visitId, nurseId)
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrSlotAlreadyBooked
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseVisitSlot libera os blocos reservados por uma visita que deixou de ocupar a agenda.
+func (r *visitRepository) ReleaseVisitSlot(visitId string) error {
+	_, err := r.reservationsCollection.DeleteMany(r.ctx, bson.M{"visit_id": visitId})
+	return err
+}
+
+// releaseNurseSlots libera apenas os blocos que a visita reservou na agenda de um enfermeiro,
+// para que tentativas concorrentes de outros enfermeiros não apaguem a reserva de quem venceu.
+func (r *visitRepository) releaseNurseSlots(visitId, nurseId string) error {
+	_, err := r.reservationsCollection.DeleteMany(r.ctx, bson.M{"visit_id": visitId, "nurse_id": nurseId})
+	return err
+}
+
+// UpdateDispatch grava uma nova rodada de oferta de uma visita imediata que ainda não foi aceita.
+func (r *visitRepository) UpdateDispatch(visitId string, dispatch model.VisitDispatch) error {
+	objID, err := primitive.ObjectIDFromHex(visitId)
+	if err != nil {
+		return fmt.Errorf("ID inválido")
+	}
+
+	filter := bson.M{"_id": objID, "status": model.VisitStatusPending, "nurse_id": ""}
+	update := bson.M{"$set": bson.M{"dispatch": dispatch, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(r.ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrVisitAlreadyClaimed
+	}
+	return nil
+}
+
+// ClaimDispatchedVisit atribui uma visita oferecida a vários enfermeiros ao primeiro que aceitar.
+// A agenda do enfermeiro é reservada antes e o filtro por nurse_id vazio garante que apenas
+// um aceite seja gravado; os demais recebem ErrVisitAlreadyClaimed. Enfermeiros que não
+// estão entre os candidatos da rodada atual recebem ErrNotDispatchCandidate.
+func (r *visitRepository) ClaimDispatchedVisit(visitId string, change model.VisitStatusChange, window time.Duration, updates map[string]interface{}) (model.Visit, error) {
+	visit, err := r.FindVisitById(visitId)
+	if err != nil {
+		return model.Visit{}, err
+	}
+	if visit.NurseId != "" || visit.Status != model.VisitStatusPending || visit.Dispatch == nil {
+		return model.Visit{}, ErrVisitAlreadyClaimed
+	}
+
+	nurseId := change.ActorID
+	if !slices.Contains(visit.Dispatch.Candidates, nurseId) {
+		return model.Visit{}, ErrNotDispatchCandidate
+	}
+
+	if err := r.reserveSlots(nurseId, visitId, visit.VisitDate, window); err != nil {
+		return model.Visit{}, err
+	}
+
+	setUpdates := bson.M{}
+	for key, value := range updates {
+		if value != nil {
+			setUpdates[key] = value
+		}
+	}
+	setUpdates["nurse_id"] = nurseId
+	setUpdates["status"] = change.To
+	setUpdates["updated_at"] = change.ChangedAt
+
+	filter := bson.M{
+		"_id":                 visit.ID,
+		"status":              model.VisitStatusPending,
+		"nurse_id":            "",
+		"dispatch.candidates": nurseId,
+	}
+	update := bson.M{
+		"$set":  setUpdates,
+		"$push": bson.M{"status_history": change},
+	}
+
+	result, err := r.collection.UpdateOne(r.ctx, filter, update)
+	if err != nil {
+		r.releaseNurseSlots(visitId, nurseId)
+		return model.Visit{}, err
+	}
+	if result.MatchedCount == 0 {
+		r.releaseNurseSlots(visitId, nurseId)
+		return model.Visit{}, ErrVisitAlreadyClaimed
+	}
+
+	return r.FindVisitById(visitId)
+}
+
 func (r *visitRepository) FindAllVisitsForNurse(nurseId string) ([]model.Visit, error) {
 	cursor, err := r.collection.Find(r.ctx, bson.M{"nurse_id": nurseId})
 	if err != nil {
@@ -64,7 +362,7 @@ func (r *visitRepository) FindAllVisitsForNurse(nurseId string) ([]model.Visit,
 }
 
 func (r *visitRepository) FindAllPendingVisitsForNurse(nurseId string) ([]model.Visit, error) {
-	cursor, err := r.collection.Find(r.ctx, bson.M{"nurse_id": nurseId, "status": "PENDING"})
+	cursor, err := r.collection.Find(r.ctx, bson.M{"nurse_id": nurseId, "status": model.VisitStatusPending})
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +375,175 @@ func (r *visitRepository) FindAllPendingVisitsForNurse(nurseId string) ([]model.
 	return visits, nil
 }
 
+// FindActiveVisitsForNurseBetween retorna as visitas PENDING e CONFIRMED do enfermeiro com VisitDate em [from, to).
+func (r *visitRepository) FindActiveVisitsForNurseBetween(nurseId string, from, to time.Time) ([]model.Visit, error) {
+	filter := bson.M{
+		"nurse_id":   nurseId,
+		"status":     bson.M{"$in": []model.VisitStatus{model.VisitStatusPending, model.VisitStatusConfirmed}},
+		"visit_date": bson.M{"$gte": from, "$lt": to},
+	}
+
+	cursor, err := r.collection.Find(r.ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	var visits []model.Visit
+	if err := cursor.All(r.ctx, &visits); err != nil {
+		return nil, err
+	}
+	return visits, nil
+}
+
+// FindEarningVisitsForNurse retorna as visitas CONFIRMED e COMPLETED do enfermeiro com VisitDate em
+// [from, to), em ordem cronológica: as que já geraram ou ainda vão gerar repasse.
+func (r *visitRepository) FindEarningVisitsForNurse(nurseId string, from, to time.Time) ([]model.Visit, error) {
+	filter := bson.M{
+		"nurse_id":   nurseId,
+		"status":     bson.M{"$in": []model.VisitStatus{model.VisitStatusConfirmed, model.VisitStatusCompleted}},
+		"visit_date": bson.M{"$gte": from, "$lt": to},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "visit_date", Value: 1}})
+
+	cursor, err := r.collection.Find(r.ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	var visits []model.Visit
+	if err := cursor.All(r.ctx, &visits); err != nil {
+		return nil, err
+	}
+	return visits, nil
+}
+
+// FindVisitsBySeriesId retorna as ocorrências de uma série em ordem cronológica.
+func (r *visitRepository) FindVisitsBySeriesId(seriesId string) ([]model.Visit, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "visit_date", Value: 1}})
+
+	cursor, err := r.collection.Find(r.ctx, bson.M{"series_id": seriesId}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	var visits []model.Visit
+	if err := cursor.All(r.ctx, &visits); err != nil {
+		return nil, err
+	}
+	return visits, nil
+}
+
+// FindVisitsByPaymentIntentId retorna as visitas pagas pelo PaymentIntent. Uma série de visitas
+// compartilha o mesmo pagamento.
+func (r *visitRepository) FindVisitsByPaymentIntentId(paymentIntentId string) ([]model.Visit, error) {
+	cursor, err := r.collection.Find(r.ctx, bson.M{"payment_intent_id": paymentIntentId})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.ctx)
+
+	var visits []model.Visit
+	if err := cursor.All(r.ctx, &visits); err != nil {
+		return nil, err
+	}
+	return visits, nil
+}
+
+func (r *visitRepository) UpdateVisitsByPaymentIntentId(paymentIntentId string, updates map[string]interface{}) (int64, error) {
+	return r.updateVisitsWhere(bson.M{"payment_intent_id": paymentIntentId}, updates)
+}
+
+// UpdateVisitsPaymentStatus muda o payment_status das visitas do pagamento para to apenas
+// onde o status atual está em from. Os eventos do Stripe podem chegar fora de ordem e um
+// evento atrasado não pode desfazer um estorno ou uma contestação. "" em from representa as
+// visitas ainda sem payment_status.
+func (r *visitRepository) UpdateVisitsPaymentStatus(paymentIntentId string, from []string, to string) (int64, error) {
+	current := make([]interface{}, 0, len(from)+1)
+	for _, status := range from {
+		current = append(current, status)
+		if status == "" {
+			current = append(current, nil)
+		}
+	}
+
+	return r.updateVisitsWhere(bson.M{
+		"payment_intent_id": paymentIntentId,
+		"payment_status":    bson.M{"$in": current},
+	}, map[string]interface{}{"payment_status": to})
+}
+
+func (r *visitRepository) UpdateVisitsByTransferId(transferId string, updates map[string]interface{}) (int64, error) {
+	return r.updateVisitsWhere(bson.M{"transfer_id": transferId}, updates)
+}
+
+func (r *visitRepository) updateVisitsWhere(filter bson.M, updates map[string]interface{}) (int64, error) {
+	setUpdates := bson.M{"updated_at": time.Now()}
+	for key, value := range updates {
+		setUpdates[key] = value
+	}
+
+	result, err := r.collection.UpdateMany(r.ctx, filter, bson.M{"$set": setUpdates})
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
+
+// FindStalePendingVisits retorna as visitas ainda PENDING sem resposta desde antes do limite do
+// seu tipo (IMMEDIATE usa immediateCreatedBefore) e as agendadas cuja data já passou. Visitas
+// IMMEDIATE são criadas com a data do pedido, então só expiram pela janela de resposta.
+func (r *visitRepository) FindStalePendingVisits(now, createdBefore, immediateCreatedBefore time.Time) ([]model.Visit, error) {
+	var visits []model.Visit
+
+	filter := bson.M{
+		"status": model.VisitStatusPending,
+		"$or": []bson.M{
+			{"visit_request_type": "IMMEDIATE", "created_at": bson.M{"$lte": immediateCreatedBefore}},
+			{"visit_request_type": bson.M{"$ne": "IMMEDIATE"}, "created_at": bson.M{"$lte": createdBefore}},
+			{"visit_request_type": bson.M{"$ne": "IMMEDIATE"}, "visit_date": bson.M{"$lte": now}},
+		},
+	}
+
+	cursor, err := r.collection.Find(r.ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar visitas pendentes expiradas: %w", err)
+	}
+	defer cursor.Close(r.ctx)
+
+	if err := cursor.All(r.ctx, &visits); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar visitas pendentes expiradas: %w", err)
+	}
+
+	return visits, nil
+}
+
+// FindAuthorizedVisitsCreatedBefore retorna as visitas ainda em andamento (PENDING ou
+// CONFIRMED) cujo pagamento está só autorizado desde antes de createdBefore.
+func (r *visitRepository) FindAuthorizedVisitsCreatedBefore(createdBefore time.Time) ([]model.Visit, error) {
+	var visits []model.Visit
+
+	filter := bson.M{
+		"status":         bson.M{"$in": []model.VisitStatus{model.VisitStatusPending, model.VisitStatusConfirmed}},
+		"payment_status": model.PaymentStatusAuthorized,
+		"created_at":     bson.M{"$lte": createdBefore},
+	}
+
+	cursor, err := r.collection.Find(r.ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar visitas com pagamento autorizado: %w", err)
+	}
+	defer cursor.Close(r.ctx)
+
+	if err := cursor.All(r.ctx, &visits); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar visitas com pagamento autorizado: %w", err)
+	}
+
+	return visits, nil
+}
+
 func (r *visitRepository) FindAllVisitsForPatient(patientId string) ([]model.Visit, error) {
 	cursor, err := r.collection.Find(r.ctx, bson.M{"patient_id": patientId})
 	if err != nil {
@@ -92,7 +559,7 @@ func (r *visitRepository) FindAllVisitsForPatient(patientId string) ([]model.Vis
 }
 
 func (r *visitRepository) FindAllCompletedVisitsForPatient(patientId string) ([]model.Visit, error) {
-	cursor, err := r.collection.Find(r.ctx, bson.M{"patient_id": patientId, "status": "COMPLETED"})
+	cursor, err := r.collection.Find(r.ctx, bson.M{"patient_id": patientId, "status": model.VisitStatusCompleted})
 	if err != nil {
 		return nil, err
 	}
@@ -155,6 +622,123 @@ func (r *visitRepository) UpdateVisitFields(id string, updates map[string]interf
 	return r.FindVisitById(id)
 }
 
+// ClaimVisitTip grava a gorjeta na visita somente se ela estiver concluída e ainda não tiver
+// gorjeta, para que duas requisições simultâneas não cobrem o paciente duas vezes.
+func (r *visitRepository) ClaimVisitTip(visitId string, tip model.VisitTip) (model.Visit, error) {
+	objID, err := primitive.ObjectIDFromHex(visitId)
+	if err != nil {
+		return model.Visit{}, fmt.Errorf("ID inválido")
+	}
+
+	filter := bson.M{
+		"_id":    objID,
+		"status": model.VisitStatusCompleted,
+		"tip":    nil, // sem o campo ou liberado por ReleaseVisitTip
+	}
+	update := bson.M{"$set": bson.M{"tip": tip, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(r.ctx, filter, update)
+	if err != nil {
+		return model.Visit{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.Visit{}, ErrVisitTipTaken
+	}
+
+	return r.FindVisitById(visitId)
+}
+
+// ReleaseVisitTip remove a gorjeta que não pôde ser cobrada, permitindo uma nova tentativa.
+func (r *visitRepository) ReleaseVisitTip(visitId string) error {
+	objID, err := primitive.ObjectIDFromHex(visitId)
+	if err != nil {
+		return fmt.Errorf("ID inválido")
+	}
+
+	_, err = r.collection.UpdateByID(r.ctx, objID, bson.M{
+		"$unset": bson.M{"tip": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	})
+	return err
+}
+
+// ClaimPaymentIntent reserva o PaymentIntent para a solicitação de visita em andamento. O _id
+// é o próprio PaymentIntent, então entre solicitações concorrentes com o mesmo pagamento só
+// uma consegue a reserva; as demais recebem ErrPaymentIntentClaimed.
+func (r *visitRepository) ClaimPaymentIntent(paymentIntentId, patientId string) error {
+	_, err := r.paymentClaims.InsertOne(r.ctx, paymentIntentClaim{
+		PaymentIntentID: paymentIntentId,
+		PatientId:       patientId,
+		ClaimedAt:       time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPaymentIntentClaimed
+	}
+	return err
+}
+
+// ReleasePaymentIntent desfaz a reserva de um PaymentIntent cuja visita não chegou a ser criada.
+func (r *visitRepository) ReleasePaymentIntent(paymentIntentId string) error {
+	_, err := r.paymentClaims.DeleteOne(r.ctx, bson.M{"_id": paymentIntentId})
+	return err
+}
+
+// RecordVisitReceipt grava o recibo na visita somente se ela ainda não tiver um, para que
+// emissões simultâneas não sobrescrevam o número e o arquivo já enviados ao paciente.
+func (r *visitRepository) RecordVisitReceipt(visitId string, receipt model.VisitReceipt) (model.Visit, error) {
+	objID, err := primitive.ObjectIDFromHex(visitId)
+	if err != nil {
+		return model.Visit{}, fmt.Errorf("ID inválido")
+	}
+
+	filter := bson.M{"_id": objID, "receipt": nil}
+	update := bson.M{"$set": bson.M{"receipt": receipt, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(r.ctx, filter, update)
+	if err != nil {
+		return model.Visit{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.Visit{}, ErrVisitReceiptTaken
+	}
+
+	return r.FindVisitById(visitId)
+}
+
+// UpdateVisitStatus grava a transição somente se a visita ainda estiver no status
+// de origem, acrescentando a mudança ao status_history na mesma operação.
+func (r *visitRepository) UpdateVisitStatus(id string, change model.VisitStatusChange, updates map[string]interface{}) (model.Visit, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Visit{}, fmt.Errorf("ID inválido")
+	}
+
+	setUpdates := bson.M{}
+	for key, value := range updates {
+		if value != nil {
+			setUpdates[key] = value
+		}
+	}
+	setUpdates["status"] = change.To
+	setUpdates["updated_at"] = change.ChangedAt
+
+	filter := bson.M{"_id": objID, "status": change.From}
+	update := bson.M{
+		"$set":  setUpdates,
+		"$push": bson.M{"status_history": change},
+	}
+
+	result, err := r.collection.UpdateOne(r.ctx, filter, update)
+	if err != nil {
+		return model.Visit{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.Visit{}, ErrVisitStatusChanged
+	}
+
+	return r.FindVisitById(id)
+}
+
 func (r *visitRepository) FindAllVisits() ([]model.Visit, error) {
 	var visits []model.Visit
 
@@ -221,7 +805,11 @@ func (r *visitRepository) DeleteVisit(visitId string) error {
 	}
 
 	_, err = r.collection.DeleteOne(r.ctx, bson.M{"_id": objID})
-	return err
+	if err != nil {
+		return err
+	}
+
+	return r.ReleaseVisitSlot(visitId)
 }
 
 func (r *visitRepository) GetTotalVisitsCount() (int64, error) {
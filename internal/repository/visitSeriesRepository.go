@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"medassist/internal/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type VisitSeriesRepository interface {
+	CreateSeries(series model.VisitSeries) error
+	FindSeriesById(id string) (model.VisitSeries, error)
+	UpdateSeriesStatus(id string, status model.VisitSeriesStatus) error
+	DeleteSeries(id string) error
+}
+
+type visitSeriesRepository struct {
+	collection *mongo.Collection
+	ctx        context.Context
+}
+
+func NewVisitSeriesRepository(db *mongo.Database) VisitSeriesRepository {
+	return &visitSeriesRepository{
+		collection: db.Collection("visit_series"),
+		ctx:        context.Background(),
+	}
+}
+
+func (r *visitSeriesRepository) CreateSeries(series model.VisitSeries) error {
+	_, err := r.collection.InsertOne(r.ctx, series)
+	return err
+}
+
+func (r *visitSeriesRepository) FindSeriesById(id string) (model.VisitSeries, error) {
+	var series model.VisitSeries
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return series, fmt.Errorf("ID inválido: %w", err)
+	}
+
+	err = r.collection.FindOne(r.ctx, bson.M{"_id": objectID}).Decode(&series)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.VisitSeries{}, fmt.Errorf("série de visitas não encontrada")
+		}
+		return model.VisitSeries{}, err
+	}
+
+	return series, nil
+}
+
+func (r *visitSeriesRepository) UpdateSeriesStatus(id string, status model.VisitSeriesStatus) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID inválido")
+	}
+
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+	_, err = r.collection.UpdateByID(r.ctx, objectID, update)
+	return err
+}
+
+func (r *visitSeriesRepository) DeleteSeries(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("ID inválido")
+	}
+
+	_, err = r.collection.DeleteOne(r.ctx, bson.M{"_id": objectID})
+	return err
+}
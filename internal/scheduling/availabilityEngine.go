@@ -0,0 +1,193 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"medassist/internal/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultVisitDuration é o tempo reservado na agenda do enfermeiro para cada visita.
+const DefaultVisitDuration = time.Hour
+
+// TravelBuffer é o intervalo mínimo entre o fim de uma visita e o início da próxima, para deslocamento.
+const TravelBuffer = 30 * time.Minute
+
+// SlotStep é o intervalo entre os horários de início oferecidos ao paciente.
+const SlotStep = 30 * time.Minute
+
+// ReservedWindow é o tempo que uma visita bloqueia na agenda a partir do seu início.
+func ReservedWindow(duration time.Duration) time.Duration {
+	return duration + TravelBuffer
+}
+
+// ErrSlotUnavailable indica que o horário pedido não está livre na agenda do enfermeiro.
+var ErrSlotUnavailable = errors.New("horário indisponível para o enfermeiro")
+
+type Slot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Location retorna o fuso usado para interpretar dias e horários de trabalho.
+func Location() *time.Location {
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+var weekdaysByName = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "domingo": time.Sunday, "dom": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "segunda": time.Monday, "seg": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "terca": time.Tuesday, "ter": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "quarta": time.Wednesday, "qua": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "quinta": time.Thursday, "qui": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "sexta": time.Friday, "sex": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "sabado": time.Saturday, "sab": time.Saturday,
+}
+
+// ParseWeekday aceita nomes de dias em inglês ou português (ex: "Monday", "segunda-feira", "Sábado").
+func ParseWeekday(day string) (time.Weekday, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(day))
+	normalized = strings.TrimSuffix(normalized, "-feira")
+	normalized = strings.TrimSuffix(normalized, " feira")
+	normalized = strings.NewReplacer("ç", "c", "á", "a").Replace(normalized)
+
+	weekday, ok := weekdaysByName[normalized]
+	return weekday, ok
+}
+
+// WeekdayPattern retorna uma expressão regular (para usar sem distinção de maiúsculas) que
+// reconhece os mesmos nomes aceitos por ParseWeekday para o dia informado, com ou sem acento.
+func WeekdayPattern(weekday time.Weekday) string {
+	var names []string
+	for name, day := range weekdaysByName {
+		if day == weekday {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	pattern := strings.NewReplacer("c", "[cç]", "a", "[aá]").Replace(strings.Join(names, "|"))
+	return `^\s*(` + pattern + `)(-feira| feira)?\s*$`
+}
+
+func workingDays(nurse model.Nurse) map[time.Weekday]bool {
+	days := make(map[time.Weekday]bool)
+	for _, day := range nurse.DaysAvailable {
+		if weekday, ok := ParseWeekday(day); ok {
+			days[weekday] = true
+		}
+	}
+	return days
+}
+
+// workingHours retorna o início e o fim do expediente do enfermeiro no dia informado.
+func workingHours(nurse model.Nurse, day time.Time) (time.Time, time.Time, error) {
+	start, err := time.Parse("15:04", strings.TrimSpace(nurse.StartTime))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Horário de início do enfermeiro inválido.")
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(nurse.EndTime))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Horário de término do enfermeiro inválido.")
+	}
+
+	location := day.Location()
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	workStart := dayStart.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute)
+	workEnd := dayStart.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute)
+
+	return workStart, workEnd, nil
+}
+
+func isActive(visit model.Visit) bool {
+	return visit.Status == model.VisitStatusPending || visit.Status == model.VisitStatusConfirmed
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+// CheckSlot valida se uma visita de duração duration começando em start cabe na agenda do enfermeiro,
+// considerando dias e horário de trabalho, visitas PENDING/CONFIRMED existentes (com o TravelBuffer
+// de deslocamento entre elas) e o limite diário.
+func CheckSlot(nurse model.Nurse, visits []model.Visit, start time.Time, duration time.Duration, now time.Time) error {
+	location := Location()
+	start = start.In(location)
+	end := start.Add(duration)
+
+	if !start.After(now) {
+		return fmt.Errorf("%w: o horário precisa ser no futuro", ErrSlotUnavailable)
+	}
+
+	if !workingDays(nurse)[start.Weekday()] {
+		return fmt.Errorf("%w: o enfermeiro não atende neste dia da semana", ErrSlotUnavailable)
+	}
+
+	workStart, workEnd, err := workingHours(nurse, start)
+	if err != nil {
+		return err
+	}
+	if start.Before(workStart) || end.After(workEnd) {
+		return fmt.Errorf("%w: fora do horário de atendimento (%s às %s)", ErrSlotUnavailable, nurse.StartTime, nurse.EndTime)
+	}
+
+	visitsOfDay := 0
+	for _, visit := range visits {
+		if !isActive(visit) {
+			continue
+		}
+
+		visitStart := visit.VisitDate.In(location)
+		visitEnd := visitStart.Add(ReservedWindow(duration))
+
+		if sameDay(visitStart, start) {
+			visitsOfDay++
+		}
+		if start.Before(visitEnd) && visitStart.Before(end.Add(TravelBuffer)) {
+			return fmt.Errorf("%w: já existe uma visita neste horário", ErrSlotUnavailable)
+		}
+	}
+
+	if nurse.MaxPatientsPerDay > 0 && visitsOfDay >= nurse.MaxPatientsPerDay {
+		return fmt.Errorf("%w: limite diário de pacientes atingido", ErrSlotUnavailable)
+	}
+
+	return nil
+}
+
+// FreeSlots lista os horários livres do enfermeiro entre from e to (inclusive), com inícios a cada SlotStep.
+func FreeSlots(nurse model.Nurse, visits []model.Visit, from, to time.Time, duration time.Duration, now time.Time) ([]Slot, error) {
+	location := Location()
+	from = from.In(location)
+	to = to.In(location)
+
+	slots := make([]Slot, 0)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+	lastDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, location)
+
+	for ; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		workStart, workEnd, err := workingHours(nurse, day)
+		if err != nil {
+			return nil, err
+		}
+
+		for start := workStart; !start.Add(duration).After(workEnd); start = start.Add(SlotStep) {
+			if CheckSlot(nurse, visits, start, duration, now) == nil {
+				slots = append(slots, Slot{Start: start, End: start.Add(duration)})
+			}
+		}
+	}
+
+	return slots, nil
+}
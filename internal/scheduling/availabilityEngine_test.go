@@ -0,0 +1,138 @@
+package scheduling
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWeekday(t *testing.T) {
+	cases := map[string]time.Weekday{
+		"Monday":        time.Monday,
+		"segunda-feira": time.Monday,
+		"Terça":         time.Tuesday,
+		"sábado":        time.Saturday,
+		" sun ":         time.Sunday,
+	}
+	for name, expected := range cases {
+		weekday, ok := ParseWeekday(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, expected, weekday, name)
+	}
+
+	_, ok := ParseWeekday("feriado")
+	assert.False(t, ok)
+}
+
+func TestFreeSlots(t *testing.T) {
+	location := Location()
+	// 2030-01-07 é uma segunda-feira
+	monday := time.Date(2030, 1, 7, 0, 0, 0, 0, location)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, location)
+
+	nurse := model.Nurse{
+		DaysAvailable:     []string{"Monday"},
+		StartTime:         "08:00",
+		EndTime:           "12:00",
+		MaxPatientsPerDay: 3,
+	}
+
+	t.Run("Sucesso_Apenas_Dias_De_Trabalho", func(t *testing.T) {
+		slots, err := FreeSlots(nurse, nil, monday, monday.AddDate(0, 0, 6), DefaultVisitDuration, now)
+
+		assert.NoError(t, err)
+		assert.Len(t, slots, 7)
+		assert.Equal(t, monday.Add(8*time.Hour), slots[0].Start)
+		assert.Equal(t, monday.Add(12*time.Hour), slots[6].End)
+	})
+
+	t.Run("Sucesso_Remove_Horarios_Ocupados", func(t *testing.T) {
+		visits := []model.Visit{
+			{Status: model.VisitStatusConfirmed, VisitDate: monday.Add(9 * time.Hour)},
+			{Status: model.VisitStatusRejected, VisitDate: monday.Add(10 * time.Hour)},
+		}
+
+		slots, err := FreeSlots(nurse, visits, monday, monday, DefaultVisitDuration, now)
+
+		// A visita das 09:00 bloqueia até 10:30 por causa do deslocamento, e quem começa
+		// antes dela precisa terminar com folga para o deslocamento.
+		assert.NoError(t, err)
+		assert.Len(t, slots, 2)
+		assert.Equal(t, monday.Add(10*time.Hour+30*time.Minute), slots[0].Start)
+		assert.Equal(t, monday.Add(11*time.Hour), slots[1].Start)
+	})
+
+	t.Run("Sucesso_Limite_Diario_Atingido", func(t *testing.T) {
+		visits := []model.Visit{
+			{Status: model.VisitStatusPending, VisitDate: monday.Add(8 * time.Hour)},
+			{Status: model.VisitStatusConfirmed, VisitDate: monday.Add(9 * time.Hour)},
+			{Status: model.VisitStatusPending, VisitDate: monday.Add(10 * time.Hour)},
+		}
+
+		slots, err := FreeSlots(nurse, visits, monday, monday, DefaultVisitDuration, now)
+
+		assert.NoError(t, err)
+		assert.Empty(t, slots)
+	})
+
+	t.Run("Erro_Horario_Invalido", func(t *testing.T) {
+		invalid := nurse
+		invalid.StartTime = "oito horas"
+
+		_, err := FreeSlots(invalid, nil, monday, monday, DefaultVisitDuration, now)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckSlot(t *testing.T) {
+	location := Location()
+	monday := time.Date(2030, 1, 7, 0, 0, 0, 0, location)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, location)
+
+	nurse := model.Nurse{
+		DaysAvailable:     []string{"Monday"},
+		StartTime:         "08:00",
+		EndTime:           "12:00",
+		MaxPatientsPerDay: 3,
+	}
+	visits := []model.Visit{{Status: model.VisitStatusPending, VisitDate: monday.Add(9 * time.Hour)}}
+
+	tests := map[string]struct {
+		start time.Time
+		free  bool
+	}{
+		"Sucesso_Horario_Livre":    {monday.Add(10*time.Hour + 30*time.Minute), true},
+		"Erro_Sobreposicao":        {monday.Add(9*time.Hour + 30*time.Minute), false},
+		"Erro_Fora_Do_Expediente":  {monday.Add(11*time.Hour + 30*time.Minute), false},
+		"Erro_Dia_Sem_Atendimento": {monday.AddDate(0, 0, 1).Add(9 * time.Hour), false},
+		"Erro_Horario_No_Passado":  {now.Add(-time.Hour), false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckSlot(nurse, visits, tc.start, DefaultVisitDuration, now)
+			if tc.free {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrSlotUnavailable))
+			}
+		})
+	}
+}
+
+func TestWeekdayPattern(t *testing.T) {
+	pattern := regexp.MustCompile("(?i)" + WeekdayPattern(time.Tuesday))
+
+	for _, day := range []string{"Tuesday", "terça-feira", "Terca", "TER", " tue "} {
+		assert.True(t, pattern.MatchString(day), day)
+	}
+	for _, day := range []string{"Thursday", "quarta", "terçafeira"} {
+		assert.False(t, pattern.MatchString(day), day)
+	}
+}
@@ -0,0 +1,78 @@
+package scheduling
+
+import (
+	"fmt"
+	"medassist/internal/model"
+	"sort"
+	"time"
+)
+
+// MaxSeriesOccurrences limita quantas visitas uma única série pode gerar.
+const MaxSeriesOccurrences = 60
+
+// ExpandRecurrence gera as datas das ocorrências de uma série a partir da primeira visita.
+// O horário de first é mantido em todas as ocorrências; se a regra não informar dias da
+// semana, é usado o dia da semana de first.
+func ExpandRecurrence(rule model.RecurrenceRule, first time.Time) ([]time.Time, error) {
+	if rule.Count <= 0 && rule.EndDate == nil {
+		return nil, fmt.Errorf("Informe a quantidade de ocorrências ou a data final da série.")
+	}
+	if rule.Count > MaxSeriesOccurrences {
+		return nil, fmt.Errorf("Uma série pode ter no máximo %d visitas.", MaxSeriesOccurrences)
+	}
+
+	interval := rule.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	location := Location()
+	first = first.In(location)
+
+	weekdays := make([]time.Weekday, 0, len(rule.Weekdays))
+	for _, day := range rule.Weekdays {
+		weekday, ok := ParseWeekday(day)
+		if !ok {
+			return nil, fmt.Errorf("Dia da semana inválido: %s", day)
+		}
+		weekdays = append(weekdays, weekday)
+	}
+	if len(weekdays) == 0 {
+		weekdays = append(weekdays, first.Weekday())
+	}
+	sort.Slice(weekdays, func(i, j int) bool { return weekdays[i] < weekdays[j] })
+
+	// A data final é inclusiva: vale qualquer horário do último dia
+	var limit time.Time
+	if rule.EndDate != nil {
+		end := rule.EndDate.In(location)
+		limit = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+	}
+
+	// Domingo da semana da primeira visita, no mesmo horário
+	weekStart := first.AddDate(0, 0, -int(first.Weekday()))
+
+	occurrences := make([]time.Time, 0)
+	for week := 0; ; week += interval {
+		for _, weekday := range weekdays {
+			occurrence := weekStart.AddDate(0, 0, week*7+int(weekday))
+			if occurrence.Before(first) {
+				continue
+			}
+			if rule.EndDate != nil && !occurrence.Before(limit) {
+				if len(occurrences) == 0 {
+					return nil, fmt.Errorf("A data final da série deve ser posterior à primeira visita.")
+				}
+				return occurrences, nil
+			}
+
+			occurrences = append(occurrences, occurrence)
+			if rule.Count > 0 && len(occurrences) == rule.Count {
+				return occurrences, nil
+			}
+			if len(occurrences) > MaxSeriesOccurrences {
+				return nil, fmt.Errorf("Uma série pode ter no máximo %d visitas.", MaxSeriesOccurrences)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"medassist/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandRecurrence(t *testing.T) {
+	location := Location()
+	// 2030-01-07 é uma segunda-feira
+	first := time.Date(2030, 1, 7, 9, 0, 0, 0, location)
+
+	t.Run("Sucesso_Tres_Vezes_Por_Semana_Com_Quantidade", func(t *testing.T) {
+		rule := model.RecurrenceRule{Weekdays: []string{"Friday", "Monday", "Wednesday"}, Count: 5}
+
+		occurrences, err := ExpandRecurrence(rule, first)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{
+			first,
+			first.AddDate(0, 0, 2),
+			first.AddDate(0, 0, 4),
+			first.AddDate(0, 0, 7),
+			first.AddDate(0, 0, 9),
+		}, occurrences)
+	})
+
+	t.Run("Sucesso_Quinzenal_Ate_Data_Final_Inclusiva", func(t *testing.T) {
+		endDate := time.Date(2030, 2, 4, 0, 0, 0, 0, location)
+		rule := model.RecurrenceRule{Interval: 2, EndDate: &endDate}
+
+		occurrences, err := ExpandRecurrence(rule, first)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{first, first.AddDate(0, 0, 14), first.AddDate(0, 0, 28)}, occurrences)
+	})
+
+	t.Run("Sucesso_Ignora_Dias_Anteriores_A_Primeira_Visita", func(t *testing.T) {
+		wednesday := first.AddDate(0, 0, 2)
+		rule := model.RecurrenceRule{Weekdays: []string{"segunda", "quarta"}, Count: 2}
+
+		occurrences, err := ExpandRecurrence(rule, wednesday)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Time{wednesday, first.AddDate(0, 0, 7)}, occurrences)
+	})
+
+	t.Run("Erro_Sem_Fim_Definido", func(t *testing.T) {
+		_, err := ExpandRecurrence(model.RecurrenceRule{Weekdays: []string{"Monday"}}, first)
+		assert.Error(t, err)
+	})
+
+	t.Run("Erro_Serie_Muito_Longa", func(t *testing.T) {
+		endDate := first.AddDate(2, 0, 0)
+		_, err := ExpandRecurrence(model.RecurrenceRule{EndDate: &endDate, Weekdays: []string{"Monday", "Tuesday"}}, first)
+		assert.Error(t, err)
+	})
+
+	t.Run("Erro_Dia_Invalido", func(t *testing.T) {
+		_, err := ExpandRecurrence(model.RecurrenceRule{Weekdays: []string{"Someday"}, Count: 2}, first)
+		assert.Error(t, err)
+	})
+}
@@ -25,9 +25,9 @@ type CreateVisitDto struct {
 	NurseId         string `json:"nurse_id" binding:"required"`
 	PaymentIntentID string `json:"payment_intent_id" binding:"required"`
 
-	VisitValue float64   `json:"value" binding:"required"`
-	VisitType  string    `json:"visit_type" binding:"required"`
-	VisitDate  time.Time `json:"date" binding:"required"`
+	// o valor é calculado no servidor a partir do preço do enfermeiro e conferido no PaymentIntent
+	VisitType string    `json:"visit_type" binding:"required"`
+	VisitDate time.Time `json:"date" binding:"required"`
 }
 
 type ImmediateVisitDTO struct {
@@ -43,11 +43,95 @@ type ImmediateVisitDTO struct {
 	NurseId         string `json:"nurse_id" binding:"required"`
 	PaymentIntentID string `json:"payment_intent_id" binding:"required"`
 
-	VisitValue float64   `json:"value" binding:"required"`
-	VisitType  string    `json:"visit_type" binding:"required"`
-	VisitDate  time.Time `json:"date" binding:"required"`
+	// o valor é calculado no servidor a partir do preço do enfermeiro e conferido no PaymentIntent
+	VisitType string    `json:"visit_type" binding:"required"`
+	VisitDate time.Time `json:"date" binding:"required"`
+}
+
+// BroadcastVisitDTO é a visita imediata pedida para "qualquer enfermeiro agora": o enfermeiro
+// e o valor são definidos por quem aceitar primeiro.
+type BroadcastVisitDTO struct {
+	Description string `json:"description" binding:"required"`
+	Reason      string `json:"reason" binding:"required"`
+
+	CEP          string `json:"cep" binding:"required"`
+	Street       string `json:"street" binding:"required"`
+	Number       string `json:"number" binding:"required"`
+	Complement   string `json:"complement"`
+	Neighborhood string `json:"neighborhood" binding:"required"`
+
+	PaymentIntentID string `json:"payment_intent_id" binding:"required"`
+	VisitType       string `json:"visit_type" binding:"required"`
 }
 
 type DeleteAccountPasswordDto struct {
 	Password string `json:"password" binding:"required"`
 }
+
+type RecurrenceDto struct {
+	Weekdays []string   `json:"weekdays"`
+	Interval int        `json:"interval"`
+	EndDate  *time.Time `json:"end_date"`
+	Count    int        `json:"count"`
+}
+
+// CreateVisitSeriesDto usa os dados de CreateVisitDto para todas as ocorrências; date é a primeira visita.
+type CreateVisitSeriesDto struct {
+	CreateVisitDto
+	Recurrence RecurrenceDto `json:"recurrence" binding:"required"`
+}
+
+type CancelVisitSeriesDto struct {
+	VisitId string `json:"visit_id"`
+	Scope   string `json:"scope" binding:"required,oneof=OCCURRENCE FOLLOWING"`
+	Reason  string `json:"reason"`
+}
+
+type CancelVisitDto struct {
+	Reason string `json:"reason"`
+}
+
+type RescheduleVisitDto struct {
+	VisitDate time.Time `json:"date" binding:"required"`
+	Reason    string    `json:"reason"`
+}
+
+// NurseListQueryDto são os filtros, a ordenação e a paginação aceitos nas listagens de enfermeiros.
+// sort aceita distance, price, rating ou experience, com "-" na frente para ordem decrescente.
+type NurseListQueryDto struct {
+	Specialization string  `form:"specialization"`
+	Service        string  `form:"service"`
+	MinPrice       float64 `form:"min_price"`
+	MaxPrice       float64 `form:"max_price"`
+	MinRating      float64 `form:"min_rating"`
+	Neighborhood   string  `form:"neighborhood"`
+	Day            string  `form:"day"`
+	Online         *bool   `form:"online"`
+	RadiusKm       float64 `form:"radius"`
+	Sort           string  `form:"sort"`
+	Cursor         string  `form:"cursor"`
+	Limit          int     `form:"limit"`
+}
+
+// NurseSearchDto define onde e como buscar enfermeiros. Com coordenadas, a busca é feita por
+// proximidade; RadiusKm > 0 limita o raio e dispensa o filtro por cidade. Campos vazios não filtram.
+type NurseSearchDto struct {
+	City      string
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+
+	Specialization string
+	Service        string
+	MinPrice       float64
+	MaxPrice       float64
+	MinRating      float64
+	Neighborhood   string
+	Day            *time.Weekday
+	Online         *bool
+
+	SortField string
+	SortDesc  bool
+	Cursor    string
+	Limit     int
+}
@@ -19,6 +19,7 @@ type AllNursesListDto struct {
 	YearsExperience        int      `json:"years_experience"`
 	PatientLocation        Location `json:"patient_location"`
 	Price                  float32  `json:"price"`
+	Rating                 float64  `json:"rating"`
 	Shift                  string   `json:"shift"`
 	Department             string   `json:"department"`
 	Image                  string   `json:"image"`
@@ -30,12 +31,19 @@ type AllNursesListDto struct {
 	Street                 string   `json:"street"`
 	Latitude               float64  `json:"latitude"`
 	Longitude              float64  `json:"longitude"`
+	DistanceKm             *float64 `json:"distance_km,omitempty"`
 	MaxPatientsPerDay      int      `json:"max_patients_per_day"`
 	DaysAvailable          []string `json:"days_available"`
 	Services               []string `json:"services"`
 	AvailableNeighborhoods []string `json:"available_neighborhoods"`
 }
 
+// NurseListPageDto é uma página da listagem de enfermeiros. NextCursor vazio indica a última página.
+type NurseListPageDto struct {
+	Nurses     []AllNursesListDto `json:"nurses"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
 type ReviewDTO struct {
 	Rating  int    `json:"rating"`
 	Comment string `json:"comment"`
@@ -131,6 +139,7 @@ type VisitInfoDto struct {
 	Prescriptions    []string `json:"prescriptions"`
 	UpdatedAt        string   `json:"updated_at"`
 	ConfirmationCode string   `json:"confirmation_code"`
+	ReceiptNumber    string   `json:"receipt_number,omitempty"` // preenchido quando a visita concluída já tem recibo
 }
 
 type NurseInfoDto struct {
@@ -164,3 +173,27 @@ type PatientProfileResponseDTO struct {
 	ProfileImageID string             `json:"profile_image_id"`
 	Reviews        []Reviews          `json:"reviews"`
 }
+
+type SlotDto struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type NurseSlotsResponseDto struct {
+	NurseId         string    `json:"nurse_id"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Slots           []SlotDto `json:"slots"`
+}
+
+type VisitSeriesResponseDto struct {
+	SeriesId string      `json:"series_id"`
+	Visits   []time.Time `json:"visits"`
+}
+
+type CancelVisitResponseDto struct {
+	VisitId         string  `json:"visit_id"`
+	RefundAmount    float64 `json:"refund_amount"`
+	CancellationFee float64 `json:"cancellation_fee"`
+}
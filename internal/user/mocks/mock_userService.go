@@ -15,6 +15,7 @@ import (
 	dto0 "medassist/internal/auth/dto"
 	dto1 "medassist/internal/user/dto"
 	reflect "reflect"
+	time "time"
 
 	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 	gomock "go.uber.org/mock/gomock"
@@ -58,18 +59,49 @@ func (mr *MockUserServiceMockRecorder) AddReview(userId, visitId, reviewDto any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReview", reflect.TypeOf((*MockUserService)(nil).AddReview), userId, visitId, reviewDto)
 }
 
-// ConfirmVisitService mocks base method.
-func (m *MockUserService) ConfirmVisitService(visitId, patientId string) error {
+// BroadcastVisitSolicitation mocks base method.
+func (m *MockUserService) BroadcastVisitSolicitation(patientId string, broadcastDto dto1.BroadcastVisitDTO) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ConfirmVisitService", visitId, patientId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "BroadcastVisitSolicitation", patientId, broadcastDto)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BroadcastVisitSolicitation indicates an expected call of BroadcastVisitSolicitation.
+func (mr *MockUserServiceMockRecorder) BroadcastVisitSolicitation(patientId, broadcastDto any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastVisitSolicitation", reflect.TypeOf((*MockUserService)(nil).BroadcastVisitSolicitation), patientId, broadcastDto)
 }
 
-// ConfirmVisitService indicates an expected call of ConfirmVisitService.
-func (mr *MockUserServiceMockRecorder) ConfirmVisitService(visitId, patientId any) *gomock.Call {
+// CancelVisit mocks base method.
+func (m *MockUserService) CancelVisit(patientId, visitId, reason string) (dto1.CancelVisitResponseDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelVisit", patientId, visitId, reason)
+	ret0, _ := ret[0].(dto1.CancelVisitResponseDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelVisit indicates an expected call of CancelVisit.
+func (mr *MockUserServiceMockRecorder) CancelVisit(patientId, visitId, reason any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmVisitService", reflect.TypeOf((*MockUserService)(nil).ConfirmVisitService), visitId, patientId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelVisit", reflect.TypeOf((*MockUserService)(nil).CancelVisit), patientId, visitId, reason)
+}
+
+// CancelVisitSeries mocks base method.
+func (m *MockUserService) CancelVisitSeries(patientId, seriesId string, cancelDto dto1.CancelVisitSeriesDto) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelVisitSeries", patientId, seriesId, cancelDto)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelVisitSeries indicates an expected call of CancelVisitSeries.
+func (mr *MockUserServiceMockRecorder) CancelVisitSeries(patientId, seriesId, cancelDto any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelVisitSeries", reflect.TypeOf((*MockUserService)(nil).CancelVisitSeries), patientId, seriesId, cancelDto)
 }
 
 // ContactUsMessage mocks base method.
@@ -116,18 +148,18 @@ func (mr *MockUserServiceMockRecorder) FindAllVisits(patientId any) *gomock.Call
 }
 
 // GetAllNurses mocks base method.
-func (m *MockUserService) GetAllNurses(patientId string) ([]dto1.AllNursesListDto, error) {
+func (m *MockUserService) GetAllNurses(patientId string, query dto1.NurseListQueryDto) (dto1.NurseListPageDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllNurses", patientId)
-	ret0, _ := ret[0].([]dto1.AllNursesListDto)
+	ret := m.ctrl.Call(m, "GetAllNurses", patientId, query)
+	ret0, _ := ret[0].(dto1.NurseListPageDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllNurses indicates an expected call of GetAllNurses.
-func (mr *MockUserServiceMockRecorder) GetAllNurses(patientId any) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetAllNurses(patientId, query any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllNurses", reflect.TypeOf((*MockUserService)(nil).GetAllNurses), patientId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllNurses", reflect.TypeOf((*MockUserService)(nil).GetAllNurses), patientId, query)
 }
 
 // GetFileByID mocks base method.
@@ -160,19 +192,34 @@ func (mr *MockUserServiceMockRecorder) GetNurseProfile(nurseId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNurseProfile", reflect.TypeOf((*MockUserService)(nil).GetNurseProfile), nurseId)
 }
 
+// GetNurseSlots mocks base method.
+func (m *MockUserService) GetNurseSlots(nurseId string, from, to time.Time) (dto1.NurseSlotsResponseDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNurseSlots", nurseId, from, to)
+	ret0, _ := ret[0].(dto1.NurseSlotsResponseDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNurseSlots indicates an expected call of GetNurseSlots.
+func (mr *MockUserServiceMockRecorder) GetNurseSlots(nurseId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNurseSlots", reflect.TypeOf((*MockUserService)(nil).GetNurseSlots), nurseId, from, to)
+}
+
 // GetOnlineNurses mocks base method.
-func (m *MockUserService) GetOnlineNurses(userId string) ([]dto1.AllNursesListDto, error) {
+func (m *MockUserService) GetOnlineNurses(userId string, query dto1.NurseListQueryDto) (dto1.NurseListPageDto, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOnlineNurses", userId)
-	ret0, _ := ret[0].([]dto1.AllNursesListDto)
+	ret := m.ctrl.Call(m, "GetOnlineNurses", userId, query)
+	ret0, _ := ret[0].(dto1.NurseListPageDto)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOnlineNurses indicates an expected call of GetOnlineNurses.
-func (mr *MockUserServiceMockRecorder) GetOnlineNurses(userId any) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetOnlineNurses(userId, query any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOnlineNurses", reflect.TypeOf((*MockUserService)(nil).GetOnlineNurses), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOnlineNurses", reflect.TypeOf((*MockUserService)(nil).GetOnlineNurses), userId, query)
 }
 
 // GetPatientProfile mocks base method.
@@ -205,6 +252,21 @@ func (mr *MockUserServiceMockRecorder) GetPatientVisitInfo(patientId, visitId an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientVisitInfo", reflect.TypeOf((*MockUserService)(nil).GetPatientVisitInfo), patientId, visitId)
 }
 
+// GetVisitReceipt mocks base method.
+func (m *MockUserService) GetVisitReceipt(patientId, visitId string) (*dto0.FileData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVisitReceipt", patientId, visitId)
+	ret0, _ := ret[0].(*dto0.FileData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVisitReceipt indicates an expected call of GetVisitReceipt.
+func (mr *MockUserServiceMockRecorder) GetVisitReceipt(patientId, visitId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVisitReceipt", reflect.TypeOf((*MockUserService)(nil).GetVisitReceipt), patientId, visitId)
+}
+
 // ImmediateVisitSolicitation mocks base method.
 func (m *MockUserService) ImmediateVisitSolicitation(patientId string, immediateVisitDto dto1.ImmediateVisitDTO) (string, error) {
 	m.ctrl.T.Helper()
@@ -220,6 +282,20 @@ func (mr *MockUserServiceMockRecorder) ImmediateVisitSolicitation(patientId, imm
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImmediateVisitSolicitation", reflect.TypeOf((*MockUserService)(nil).ImmediateVisitSolicitation), patientId, immediateVisitDto)
 }
 
+// ProposeReschedule mocks base method.
+func (m *MockUserService) ProposeReschedule(patientId, visitId string, rescheduleDto dto1.RescheduleVisitDto) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProposeReschedule", patientId, visitId, rescheduleDto)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProposeReschedule indicates an expected call of ProposeReschedule.
+func (mr *MockUserServiceMockRecorder) ProposeReschedule(patientId, visitId, rescheduleDto any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProposeReschedule", reflect.TypeOf((*MockUserService)(nil).ProposeReschedule), patientId, visitId, rescheduleDto)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUserService) UpdateUser(userId string, updates map[string]any) (dto.UserTypeResponse, error) {
 	m.ctrl.T.Helper()
@@ -235,6 +311,21 @@ func (mr *MockUserServiceMockRecorder) UpdateUser(userId, updates any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), userId, updates)
 }
 
+// VisitSeriesSolicitation mocks base method.
+func (m *MockUserService) VisitSeriesSolicitation(patientId string, createVisitSeriesDto dto1.CreateVisitSeriesDto) (dto1.VisitSeriesResponseDto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VisitSeriesSolicitation", patientId, createVisitSeriesDto)
+	ret0, _ := ret[0].(dto1.VisitSeriesResponseDto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VisitSeriesSolicitation indicates an expected call of VisitSeriesSolicitation.
+func (mr *MockUserServiceMockRecorder) VisitSeriesSolicitation(patientId, createVisitSeriesDto any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VisitSeriesSolicitation", reflect.TypeOf((*MockUserService)(nil).VisitSeriesSolicitation), patientId, createVisitSeriesDto)
+}
+
 // VisitSolicitation mocks base method.
 func (m *MockUserService) VisitSolicitation(userId string, createVisitDto dto1.CreateVisitDto) error {
 	m.ctrl.T.Helper()
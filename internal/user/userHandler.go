@@ -1,9 +1,13 @@
 package user
 
 import (
+	"errors"
+	"medassist/internal/lifecycle"
+	"medassist/internal/scheduling"
 	"medassist/internal/user/dto"
 	"medassist/utils"
 	"net/http"
+	"time"
 
 	"fmt"
 	"strings"
@@ -21,27 +25,59 @@ func NewUserHandler(userService UserService) *UserHandler {
 }
 
 // @Summary Lista todos os enfermeiros (para agendar)
-// @Description Retorna todos os enfermeiros na cidade do paciente logado. Requer autenticação de Paciente.
+// @Description Retorna os enfermeiros na cidade do paciente logado, ordenados pela distância até ele. Com radius, retorna os enfermeiros dentro do raio mesmo que sejam de outra cidade. Requer autenticação de Paciente.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} utils.SuccessAllNursesResponse "Enfermeiros listados com sucesso"
+// @Param specialization query string false "Especialização"
+// @Param service query string false "Serviço oferecido"
+// @Param min_price query number false "Preço mínimo"
+// @Param max_price query number false "Preço máximo"
+// @Param min_rating query number false "Avaliação mínima (0 a 5)"
+// @Param neighborhood query string false "Bairro atendido"
+// @Param day query string false "Dia disponível (ex: segunda, monday)"
+// @Param online query bool false "Apenas enfermeiros online"
+// @Param radius query number false "Raio de busca em km (máximo 100)"
+// @Param sort query string false "distance, price, rating ou experience; prefixe com - para ordem decrescente"
+// @Param cursor query string false "Cursor retornado em next_cursor da página anterior"
+// @Param limit query int false "Itens por página (padrão 20, máximo 50)"
+// @Success 200 {object} dto.NurseListPageDto "Enfermeiros listados com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "Filtros de busca inválidos"
 // @Failure 401 {object} utils.ErrorResponse "Token inválido"
 // @Failure 500 {object} utils.ErrorResponse "Erro ao buscar enfermeiros"
 // @Router /user/all_nurses [get]
 func (h *UserHandler) GetAllNurses(c *gin.Context) {
 	patientId := utils.GetUserId(c)
 
-	nurses, err := h.userService.GetAllNurses(patientId)
+	query, ok := bindNurseListQuery(c)
+	if !ok {
+		return
+	}
+
+	nurses, err := h.userService.GetAllNurses(patientId, query)
 	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidNurseQuery) {
+			status = http.StatusBadRequest
+		}
+		utils.SendErrorResponse(c, err.Error(), status)
 		return
 	}
 
 	utils.SendSuccessResponse(c, "Enfermeiros listados com sucesso.", nurses)
 }
 
+// bindNurseListQuery lê os filtros da listagem de enfermeiros e responde 400 quando algum é inválido.
+func bindNurseListQuery(c *gin.Context) (dto.NurseListQueryDto, bool) {
+	var query dto.NurseListQueryDto
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.SendErrorResponse(c, "Filtros de busca inválidos: verifique os valores numéricos informados.", http.StatusBadRequest)
+		return query, false
+	}
+	return query, true
+}
+
 // @Summary Exibe um arquivo (ex: imagem de perfil)
 // @Description Retorna um arquivo do GridFS (como uma imagem de perfil) para ser exibido 'inline' no navegador. (Endpoint atualmente público).
 // @Tags User
@@ -120,6 +156,53 @@ func (h *UserHandler) GetNurseProfile(c *gin.Context) {
 	utils.SendSuccessResponse(c, "Perfil completo de enfermeiro(a) listado com sucesso", nurseProfile)
 }
 
+// @Summary Horários livres do Enfermeiro
+// @Description Retorna os horários disponíveis para agendamento com o enfermeiro no intervalo informado (padrão: próximos 7 dias, máximo 31). Requer autenticação de Paciente.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID do Enfermeiro"
+// @Param from query string false "Data inicial (YYYY-MM-DD)"
+// @Param to query string false "Data final, inclusiva (YYYY-MM-DD)"
+// @Success 200 {object} dto.NurseSlotsResponseDto "Horários disponíveis listados com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "Datas inválidas ou erro ao buscar a agenda"
+// @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Router /user/nurse/{id}/slots [get]
+func (h *UserHandler) GetNurseSlots(c *gin.Context) {
+	nurseId := c.Param("id")
+
+	location := scheduling.Location()
+	now := time.Now().In(location)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	to := from.AddDate(0, 0, 6)
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromParam, location)
+		if err != nil {
+			utils.SendErrorResponse(c, "Data inicial inválida, use o formato YYYY-MM-DD.", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+		to = from.AddDate(0, 0, 6)
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toParam, location)
+		if err != nil {
+			utils.SendErrorResponse(c, "Data final inválida, use o formato YYYY-MM-DD.", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	slots, err := h.userService.GetNurseSlots(nurseId, from, to)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	utils.SendSuccessResponse(c, "Horários disponíveis listados com sucesso", slots)
+}
+
 // @Summary Solicita uma visita agendada
 // @Description Cria uma nova solicitação de visita agendada para um enfermeiro específico. Requer autenticação de Paciente.
 // @Tags User
@@ -130,6 +213,7 @@ func (h *UserHandler) GetNurseProfile(c *gin.Context) {
 // @Success 200 {object} utils.SuccessResponseNoData "Visita agendada com sucesso"
 // @Failure 400 {object} utils.ErrorResponse "JSON inválido ou erro na solicitação"
 // @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Failure 409 {object} utils.ErrorResponse "Horário indisponível ou já reservado por outro paciente"
 // @Router /user/visit [post]
 func (h *UserHandler) VisitSolicitation(c *gin.Context) {
 	patientId := utils.GetUserId(c)
@@ -142,12 +226,171 @@ func (h *UserHandler) VisitSolicitation(c *gin.Context) {
 
 	err := h.userService.VisitSolicitation(patientId, createVisitDto)
 	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
 	}
 
 	utils.SendSuccessResponse(c, "Visita agendada com sucesso.", http.StatusOK)
 }
 
+// @Summary Solicita uma série de visitas recorrentes
+// @Description Cria uma série de visitas com o mesmo enfermeiro a partir de uma regra de recorrência (dias da semana, intervalo em semanas e data final ou quantidade). O campo date é a primeira visita. Nenhuma visita é criada se alguma ocorrência não couber na agenda. Requer autenticação de Paciente.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body dto.CreateVisitSeriesDto true "Detalhes da visita e regra de recorrência"
+// @Success 200 {object} dto.VisitSeriesResponseDto "Série de visitas agendada com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "JSON inválido ou regra de recorrência inválida"
+// @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Failure 409 {object} utils.ErrorResponse "Alguma ocorrência não está disponível na agenda do enfermeiro"
+// @Router /user/visit-series [post]
+func (h *UserHandler) VisitSeriesSolicitation(c *gin.Context) {
+	patientId := utils.GetUserId(c)
+
+	var createVisitSeriesDto dto.CreateVisitSeriesDto
+	if err := c.ShouldBindJSON(&createVisitSeriesDto); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := h.userService.VisitSeriesSolicitation(patientId, createVisitSeriesDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Série de visitas agendada com sucesso.", series)
+}
+
+// @Summary Cancela ocorrências de uma série (Paciente)
+// @Description Cancela uma ocorrência (scope OCCURRENCE) ou a ocorrência informada e todas as seguintes (scope FOLLOWING) de uma série de visitas. Sem visit_id, FOLLOWING cancela todas as visitas futuras. Requer autenticação de Paciente.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da série"
+// @Param payload body dto.CancelVisitSeriesDto true "Escopo e motivo do cancelamento"
+// @Success 200 {object} utils.SuccessResponseString "Visitas da série canceladas com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "JSON inválido ou série inválida"
+// @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
+// @Router /user/visit-series/{id}/cancel [patch]
+func (h *UserHandler) CancelVisitSeries(c *gin.Context) {
+	patientId := utils.GetUserId(c)
+	seriesId := c.Param("id")
+
+	var cancelDto dto.CancelVisitSeriesDto
+	if err := c.ShouldBindJSON(&cancelDto); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	canceled, err := h.userService.CancelVisitSeries(patientId, seriesId, cancelDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Visitas da série canceladas com sucesso.", fmt.Sprintf("%d visitas canceladas.", canceled))
+}
+
+// @Summary Cancela uma visita (Paciente)
+// @Description Cancela uma visita PENDING ou CONFIRMED. Pela política de cancelamento, o reembolso é integral até algumas horas antes da visita (CANCELLATION_FREE_HOURS) e, depois disso, é cobrada uma taxa (CANCELLATION_LATE_FEE_PERCENT). Requer autenticação de Paciente.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da Visita"
+// @Param payload body dto.CancelVisitDto false "Motivo do cancelamento"
+// @Success 200 {object} dto.CancelVisitResponseDto "Visita cancelada com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "ID inválido ou horário da visita já passou"
+// @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Failure 409 {object} utils.ErrorResponse "Transição de status não permitida"
+// @Router /user/visit/{id}/cancel [patch]
+func (h *UserHandler) CancelVisit(c *gin.Context) {
+	patientId := utils.GetUserId(c)
+	visitId := c.Param("id")
+
+	// permite que o campo 'reason' venha vazio
+	var cancelDto dto.CancelVisitDto
+	if err := c.ShouldBindJSON(&cancelDto); err != nil {
+		if err.Error() != "EOF" {
+			utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	response, err := h.userService.CancelVisit(patientId, visitId, cancelDto.Reason)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Visita cancelada com sucesso.", response)
+}
+
+// @Summary Propõe uma nova data para a visita (Paciente)
+// @Description Registra um pedido de remarcação para uma visita PENDING ou CONFIRMED. A nova data precisa estar livre na agenda do enfermeiro e só vale depois que ele aceitar. Requer autenticação de Paciente.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "ID da Visita"
+// @Param payload body dto.RescheduleVisitDto true "Nova data e motivo"
+// @Success 200 {object} utils.SuccessResponseNoData "Pedido de remarcação enviado com sucesso"
+// @Failure 400 {object} utils.ErrorResponse "JSON inválido ou visita não pode ser remarcada"
+// @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Failure 409 {object} utils.ErrorResponse "Nova data indisponível na agenda do enfermeiro"
+// @Router /user/visit/{id}/reschedule [post]
+func (h *UserHandler) ProposeReschedule(c *gin.Context) {
+	patientId := utils.GetUserId(c)
+	visitId := c.Param("id")
+
+	var rescheduleDto dto.RescheduleVisitDto
+	if err := c.ShouldBindJSON(&rescheduleDto); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.userService.ProposeReschedule(patientId, visitId, rescheduleDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Pedido de remarcação enviado com sucesso.", http.StatusOK)
+}
+
+// @Summary Solicita uma visita imediata a qualquer enfermeiro próximo
+// @Description Oferece a visita aos enfermeiros online mais próximos do paciente ao mesmo tempo; o primeiro que aceitar fica com a visita. Sem aceite, o raio de busca é ampliado a cada rodada e, ao fim, o paciente é avisado pelo WebSocket (IMMEDIATE_VISIT_UNAVAILABLE). Requer autenticação de Paciente.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param payload body dto.BroadcastVisitDTO true "Detalhes da visita imediata"
+// @Success 200 {object} utils.SuccessResponseString "Visita oferecida aos enfermeiros próximos (retorna o ID da visita)"
+// @Failure 400 {object} utils.ErrorResponse "JSON inválido ou nenhum enfermeiro disponível"
+// @Failure 401 {object} utils.ErrorResponse "Token inválido"
+// @Router /user/immediate-visit/broadcast [post]
+func (h *UserHandler) BroadcastVisitSolicitation(c *gin.Context) {
+	patientId := utils.GetUserId(c)
+
+	var broadcastDto dto.BroadcastVisitDTO
+	if err := c.ShouldBindJSON(&broadcastDto); err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visitId, err := h.userService.BroadcastVisitSolicitation(patientId, broadcastDto)
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	utils.SendSuccessResponse(c, "Visita imediata oferecida aos enfermeiros próximos.", visitId)
+}
+
 // @Summary Lista todas as visitas do Paciente
 // @Description Retorna um histórico de todas as visitas (pendentes, concluídas, etc.) do paciente logado. Requer autenticação de Paciente.
 // @Tags User
@@ -244,38 +487,24 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	utils.SendSuccessResponse(c, "Usuário deletado com sucesso.", http.StatusOK)
 }
 
-// @Summary Confirma a conclusão de um serviço (Paciente)
-// @Description O paciente confirma que o serviço da visita foi concluído. Requer autenticação de Paciente.
-// @Tags User
-// @Accept json
-// @Produce json
-// @Security ApiKeyAuth
-// @Param id path string true "ID da Visita a ser concluída"
-// @Success 200 {object} utils.SuccessResponseNoData "Serviço concluído com sucesso"
-// @Failure 400 {object} utils.ErrorResponse "ID inválido ou erro na confirmação"
-// @Failure 401 {object} utils.ErrorResponse "Token inválido"
-// @Router /user/visit/{id} [patch]
-func (h *UserHandler) ConfirmVisitService(c *gin.Context) {
-	visitId := c.Param("id")
-
-	patientId := utils.GetUserId(c)
-
-	err := h.userService.ConfirmVisitService(visitId, patientId)
-	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	utils.SendSuccessResponse(c, "Serviço concluído com sucesso.", http.StatusOK)
-}
-
 // @Summary Lista enfermeiros online
-// @Description Retorna todos os enfermeiros que estão online e na cidade do paciente logado. Requer autenticação de Paciente.
+// @Description Retorna os enfermeiros online na cidade do paciente logado, ordenados pela distância até ele. Com radius, retorna os enfermeiros dentro do raio mesmo que sejam de outra cidade. Requer autenticação de Paciente.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} utils.SuccessAllNursesResponse "Lista de enfermeiros online listada com sucesso"
+// @Param specialization query string false "Especialização"
+// @Param service query string false "Serviço oferecido"
+// @Param min_price query number false "Preço mínimo"
+// @Param max_price query number false "Preço máximo"
+// @Param min_rating query number false "Avaliação mínima (0 a 5)"
+// @Param neighborhood query string false "Bairro atendido"
+// @Param day query string false "Dia disponível (ex: segunda, monday)"
+// @Param radius query number false "Raio de busca em km (máximo 100)"
+// @Param sort query string false "distance, price, rating ou experience; prefixe com - para ordem decrescente"
+// @Param cursor query string false "Cursor retornado em next_cursor da página anterior"
+// @Param limit query int false "Itens por página (padrão 20, máximo 50)"
+// @Success 200 {object} dto.NurseListPageDto "Lista de enfermeiros online listada com sucesso"
 // @Failure 400 {object} utils.ErrorResponse "Erro ao buscar enfermeiros"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Paciente)"
@@ -283,7 +512,12 @@ func (h *UserHandler) ConfirmVisitService(c *gin.Context) {
 func (h *UserHandler) GetOnlineNurses(c *gin.Context) {
 	userId := utils.GetUserId(c)
 
-	response, err := h.userService.GetOnlineNurses(userId)
+	query, ok := bindNurseListQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.userService.GetOnlineNurses(userId, query)
 	if err != nil {
 		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
 		return
@@ -318,6 +552,29 @@ func (h *UserHandler) GetPatientVisitInfo(c *gin.Context) {
 	utils.SendSuccessResponse(c, "Informações de visita para paciente listadas com sucesso.", visitInfo)
 }
 
+// @Summary Recibo da visita (Paciente)
+// @Description Baixa o recibo em PDF de uma visita concluída, com os dados do enfermeiro (incluindo COREN), do serviço e do pagamento, para pedir reembolso ao plano de saúde. Requer autenticação de Paciente.
+// @Tags User
+// @Produce application/pdf
+// @Security ApiKeyAuth
+// @Param id path string true "ID da Visita"
+// @Success 200 {file} file "Recibo em PDF"
+// @Failure 400 {object} utils.ErrorResponse "ID inválido, visita de outro paciente, visita não concluída ou erro ao emitir recibo"
+// @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
+// @Router /user/visit-info/{id}/receipt [get]
+func (h *UserHandler) GetVisitReceipt(c *gin.Context) {
+	patientId := utils.GetUserId(c)
+
+	fileData, err := h.userService.GetVisitReceipt(patientId, c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+fileData.Filename+"\"")
+	c.Data(http.StatusOK, fileData.ContentType, fileData.Data)
+}
+
 // @Summary Adiciona review para um Enfermeiro
 // @Description Permite ao paciente avaliar um enfermeiro (com nota e comentário) após uma visita. Requer autenticação de Paciente.
 // @Tags User
@@ -362,6 +619,7 @@ func (h *UserHandler) AddReview(c *gin.Context) {
 // @Failure 400 {object} utils.ErrorResponse "JSON inválido, enfermeiro offline ou erro na solicitação"
 // @Failure 401 {object} utils.ErrorResponse "Não autorizado (Token JWT inválido ou ausente)"
 // @Failure 403 {object} utils.ErrorResponse "Proibido (Usuário não é Paciente)"
+// @Failure 409 {object} utils.ErrorResponse "Enfermeiro já possui uma visita reservada neste horário"
 // @Router /user/immediate-visit [post]
 func (h *UserHandler) ImmediateVisitSolicitation(c *gin.Context) {
 	patientId := utils.GetUserId(c)
@@ -374,7 +632,7 @@ func (h *UserHandler) ImmediateVisitSolicitation(c *gin.Context) {
 
 	userId, err := h.userService.ImmediateVisitSolicitation(patientId, immediateVisitDto)
 	if err != nil {
-		utils.SendErrorResponse(c, err.Error(), http.StatusBadRequest)
+		utils.SendErrorResponse(c, err.Error(), lifecycle.ErrorStatusCode(err, http.StatusBadRequest))
 		return
 	}
 
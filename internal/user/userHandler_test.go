@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"medassist/internal/repository"
 	"medassist/internal/user/dto"
 	"medassist/internal/user/mocks"
 
@@ -34,7 +36,7 @@ func TestUserHandler_GetAllNurses(t *testing.T) {
 		})
 		router.GET("/user/all_nurses", handler.GetAllNurses)
 
-		mockUserService.EXPECT().GetAllNurses("id-paciente-123").Return([]dto.AllNursesListDto{}, fmt.Errorf("Erro simulado"))
+		mockUserService.EXPECT().GetAllNurses("id-paciente-123", dto.NurseListQueryDto{}).Return(dto.NurseListPageDto{}, fmt.Errorf("Erro simulado"))
 
 		req, _ := http.NewRequest(http.MethodGet, "/user/all_nurses", nil)
 		w := httptest.NewRecorder()
@@ -61,9 +63,10 @@ func TestUserHandler_GetAllNurses(t *testing.T) {
 		router.GET("/user/all_nurses", handler.GetAllNurses)
 
 		mockResponse := []dto.AllNursesListDto{{ID: "123", Name: "Nurse 1"}}
-		mockUserService.EXPECT().GetAllNurses("id-paciente-123").Return(mockResponse, nil)
+		online := true
+		mockUserService.EXPECT().GetAllNurses("id-paciente-123", dto.NurseListQueryDto{RadiusKm: 7.5, Online: &online, Sort: "-rating", Limit: 10}).Return(dto.NurseListPageDto{Nurses: mockResponse}, nil)
 
-		req, _ := http.NewRequest(http.MethodGet, "/user/all_nurses", nil)
+		req, _ := http.NewRequest(http.MethodGet, "/user/all_nurses?radius=7.5&online=true&sort=-rating&limit=10", nil)
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -72,6 +75,27 @@ func TestUserHandler_GetAllNurses(t *testing.T) {
 		json.Unmarshal(w.Body.Bytes(), &response)
 		assert.Equal(t, true, response["success"])
 	})
+
+	t.Run("Erro_400_Raio_Invalido", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUserService := mocks.NewMockUserService(ctrl)
+		handler := NewUserHandler(mockUserService)
+		router := gin.Default()
+
+		router.Use(func(c *gin.Context) {
+			c.Set("claims", jwt.MapClaims{"sub": "id-paciente-123"})
+			c.Next()
+		})
+		router.GET("/user/all_nurses", handler.GetAllNurses)
+
+		req, _ := http.NewRequest(http.MethodGet, "/user/all_nurses?radius=abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
 
 func TestUserHandler_GetNurseProfile(t *testing.T) {
@@ -163,3 +187,39 @@ func TestUserHandler_AddReview(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
 }
+
+func TestUserHandler_VisitSolicitation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Erro_409_Horario_Ja_Reservado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUserService := mocks.NewMockUserService(ctrl)
+		handler := NewUserHandler(mockUserService)
+		router := gin.Default()
+
+		router.Use(func(c *gin.Context) {
+			c.Set("claims", jwt.MapClaims{"sub": "id-paciente"})
+			c.Next()
+		})
+		router.POST("/user/visit", handler.VisitSolicitation)
+
+		createVisitDto := dto.CreateVisitDto{
+			Description: "Curativo", Reason: "Pós-operatório",
+			CEP: "01001-000", Street: "Rua A", Number: "10", Neighborhood: "Centro",
+			NurseId: "nurse-1", PaymentIntentID: "pi_123",
+			VisitType: "Curativo", VisitDate: time.Now().Add(24 * time.Hour).Truncate(time.Second),
+		}
+		mockUserService.EXPECT().VisitSolicitation("id-paciente", gomock.Any()).Return(repository.ErrSlotAlreadyBooked)
+
+		body, _ := json.Marshal(createVisitDto)
+		req, _ := http.NewRequest(http.MethodPost, "/user/visit", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
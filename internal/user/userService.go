@@ -3,11 +3,19 @@ package user
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	adminDTO "medassist/internal/admin/dto"
 	"medassist/internal/auth/dto"
+	chatDTO "medassist/internal/chat/dto"
+	"medassist/internal/coupon"
+	"medassist/internal/dispatch"
+	"medassist/internal/lifecycle"
 	"medassist/internal/model"
+	"medassist/internal/pricing"
+	"medassist/internal/receipt"
 	"medassist/internal/repository"
+	"medassist/internal/scheduling"
 	userDTO "medassist/internal/user/dto"
 	"medassist/utils"
 	"strconv"
@@ -17,14 +25,14 @@ import (
 	"medassist/internal/chat"
 	"strings"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/stripe/stripe-go/v76"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserService interface {
-	GetAllNurses(patientId string) ([]userDTO.AllNursesListDto, error)
+	GetAllNurses(patientId string, query userDTO.NurseListQueryDto) (userDTO.NurseListPageDto, error)
 	GetFileByID(ctx context.Context, id primitive.ObjectID) (*dto.FileData, error)
 	ContactUsMessage(contactUsDto userDTO.ContactUsDTO) error
 	GetNurseProfile(nurseId string) (userDTO.NurseProfileResponseDTO, error)
@@ -32,20 +40,35 @@ type UserService interface {
 	FindAllVisits(patientId string) (userDTO.VisitsResponseDto, error)
 	UpdateUser(userId string, updates map[string]interface{}) (adminDTO.UserTypeResponse, error)
 	DeleteUser(patientId string, deleteAccountPasswordDto userDTO.DeleteAccountPasswordDto) error
-	ConfirmVisitService(visitId, patientId string) error
-	GetOnlineNurses(userId string) ([]userDTO.AllNursesListDto, error)
+	GetOnlineNurses(userId string, query userDTO.NurseListQueryDto) (userDTO.NurseListPageDto, error)
 	GetPatientVisitInfo(patientId, visitId string) (userDTO.PatientVisitInfo, error)
+	GetVisitReceipt(patientId, visitId string) (*dto.FileData, error)
 	AddReview(userId, visitId string, reviewDto userDTO.ReviewDTO) error
 	ImmediateVisitSolicitation(patientId string, immediateVisitDto userDTO.ImmediateVisitDTO) (string, error)
 	GetPatientProfile(patientId string) (userDTO.PatientProfileResponseDTO, error)
+	GetNurseSlots(nurseId string, from, to time.Time) (userDTO.NurseSlotsResponseDto, error)
+	VisitSeriesSolicitation(patientId string, createVisitSeriesDto userDTO.CreateVisitSeriesDto) (userDTO.VisitSeriesResponseDto, error)
+	CancelVisitSeries(patientId, seriesId string, cancelDto userDTO.CancelVisitSeriesDto) (int, error)
+	CancelVisit(patientId, visitId, reason string) (userDTO.CancelVisitResponseDto, error)
+	ProposeReschedule(patientId, visitId string, rescheduleDto userDTO.RescheduleVisitDto) error
+	BroadcastVisitSolicitation(patientId string, broadcastDto userDTO.BroadcastVisitDTO) (string, error)
 }
 
 type userService struct {
-	userRepository   repository.UserRepository
-	nurseRepository  repository.NurseRepository
-	visitRepository  repository.VisitRepository
-	reviewRepository repository.ReviewRepository
-	visitHub         *chat.Hub
+	userRepository        repository.UserRepository
+	nurseRepository       repository.NurseRepository
+	visitRepository       repository.VisitRepository
+	reviewRepository      repository.ReviewRepository
+	visitSeriesRepository repository.VisitSeriesRepository
+	paymentGateway        repository.PaymentGateway
+	couponService         coupon.CouponService
+	visitHub              *chat.Hub
+	visitStateMachine     lifecycle.VisitStateMachine
+	visitSeriesManager    lifecycle.VisitSeriesManager
+	cancellationPolicy    lifecycle.CancellationPolicy
+	pricingPolicy         pricing.Policy
+	dispatcher            dispatch.Dispatcher
+	receiptIssuer         receipt.Issuer
 }
 
 func NewUserService(
@@ -53,25 +76,120 @@ func NewUserService(
 	nurseRepository repository.NurseRepository,
 	visitRepository repository.VisitRepository,
 	reviewRepository repository.ReviewRepository,
+	visitSeriesRepository repository.VisitSeriesRepository,
+	paymentGateway repository.PaymentGateway,
+	couponService coupon.CouponService,
+	refunder lifecycle.Refunder,
 	visitHub *chat.Hub,
+	dispatcher dispatch.Dispatcher,
+	receiptIssuer receipt.Issuer,
 ) UserService {
+	visitStateMachine := lifecycle.NewVisitStateMachine(visitRepository, refunder)
 	return &userService{
-		userRepository:   userRepository,
-		nurseRepository:  nurseRepository,
-		visitRepository:  visitRepository,
-		reviewRepository: reviewRepository,
-		visitHub:         visitHub,
+		userRepository:        userRepository,
+		nurseRepository:       nurseRepository,
+		visitRepository:       visitRepository,
+		reviewRepository:      reviewRepository,
+		visitSeriesRepository: visitSeriesRepository,
+		paymentGateway:        paymentGateway,
+		couponService:         couponService,
+		visitHub:              visitHub,
+		visitStateMachine:     visitStateMachine,
+		visitSeriesManager:    lifecycle.NewVisitSeriesManager(visitRepository, visitSeriesRepository, visitStateMachine),
+		cancellationPolicy:    lifecycle.LoadCancellationPolicy(),
+		pricingPolicy:         pricing.LoadPolicy(),
+		dispatcher:            dispatcher,
+		receiptIssuer:         receiptIssuer,
 	}
 }
 
-func (s *userService) GetAllNurses(patientId string) ([]userDTO.AllNursesListDto, error) {
+// MaxSearchRadiusKm é o maior raio aceito na busca de enfermeiros por proximidade.
+const MaxSearchRadiusKm = 100
+
+// Tamanho de página padrão e máximo das listagens de enfermeiros.
+const (
+	DefaultNursePageSize = 20
+	MaxNursePageSize     = 50
+)
+
+// ErrInvalidNurseQuery indica filtros, ordenação ou cursor inválidos na listagem de enfermeiros.
+var ErrInvalidNurseQuery = errors.New("Filtros de busca inválidos")
+
+func invalidNurseQuery(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidNurseQuery, fmt.Sprintf(format, args...))
+}
+
+// nurseSearch valida os parâmetros da listagem e monta a busca a partir do endereço do paciente.
+// Sem coordenadas cadastradas, o raio é ignorado e a busca fica restrita à cidade.
+func nurseSearch(patient model.User, query userDTO.NurseListQueryDto) (userDTO.NurseSearchDto, error) {
+	if query.RadiusKm < 0 || query.RadiusKm > MaxSearchRadiusKm {
+		return userDTO.NurseSearchDto{}, invalidNurseQuery("o raio de busca deve estar entre 0 e %d km.", MaxSearchRadiusKm)
+	}
+	if query.MinPrice < 0 || query.MaxPrice < 0 || (query.MaxPrice > 0 && query.MinPrice > query.MaxPrice) {
+		return userDTO.NurseSearchDto{}, invalidNurseQuery("faixa de preço inválida.")
+	}
+	if query.MinRating < 0 || query.MinRating > 5 {
+		return userDTO.NurseSearchDto{}, invalidNurseQuery("a avaliação mínima deve estar entre 0 e 5.")
+	}
+	if query.Limit < 0 || query.Limit > MaxNursePageSize {
+		return userDTO.NurseSearchDto{}, invalidNurseQuery("o limite deve estar entre 1 e %d.", MaxNursePageSize)
+	}
+
+	search := userDTO.NurseSearchDto{
+		City:      patient.City,
+		Latitude:  patient.Latitude,
+		Longitude: patient.Longitude,
+		RadiusKm:  query.RadiusKm,
+
+		Specialization: strings.TrimSpace(query.Specialization),
+		Service:        strings.TrimSpace(query.Service),
+		MinPrice:       query.MinPrice,
+		MaxPrice:       query.MaxPrice,
+		MinRating:      query.MinRating,
+		Neighborhood:   strings.TrimSpace(query.Neighborhood),
+		Online:         query.Online,
+
+		SortField: strings.TrimPrefix(query.Sort, "-"),
+		SortDesc:  strings.HasPrefix(query.Sort, "-"),
+		Cursor:    query.Cursor,
+		Limit:     query.Limit,
+	}
+	if search.Limit == 0 {
+		search.Limit = DefaultNursePageSize
+	}
+
+	switch search.SortField {
+	case "", repository.NurseSortDistance, repository.NurseSortPrice, repository.NurseSortRating, repository.NurseSortExperience:
+	default:
+		return userDTO.NurseSearchDto{}, invalidNurseQuery("ordenação deve ser distance, price, rating ou experience.")
+	}
+
+	if query.Day != "" {
+		weekday, ok := scheduling.ParseWeekday(query.Day)
+		if !ok {
+			return userDTO.NurseSearchDto{}, invalidNurseQuery("dia da semana inválido.")
+		}
+		search.Day = &weekday
+	}
+
+	return search, nil
+}
+
+func (s *userService) GetAllNurses(patientId string, query userDTO.NurseListQueryDto) (userDTO.NurseListPageDto, error) {
 	patient, err := s.userRepository.FindUserById(patientId)
 	if err != nil {
-		return []userDTO.AllNursesListDto{}, fmt.Errorf("Erro ao buscar id de paciente.")
+		return userDTO.NurseListPageDto{}, fmt.Errorf("Erro ao buscar id de paciente.")
 	}
-	nurses, err := s.nurseRepository.GetAllNurses(patient.City)
+	search, err := nurseSearch(patient, query)
 	if err != nil {
-		return nil, err
+		return userDTO.NurseListPageDto{}, err
+	}
+	nurses, err := s.nurseRepository.GetAllNurses(search)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return userDTO.NurseListPageDto{}, invalidNurseQuery("cursor de paginação inválido.")
+		}
+		return userDTO.NurseListPageDto{}, err
 	}
 
 	return nurses, nil
@@ -165,14 +283,28 @@ func (h *userService) VisitSolicitation(patientId string, createVisitDto userDTO
 		return err
 	}
 
+	if err := h.checkNurseSlot(nurse, createVisitDto.VisitDate, ""); err != nil {
+		return err
+	}
+
+	quote, err := h.pricingPolicy.Quote(nurse.Price, pricing.RequestScheduled, []time.Time{createVisitDto.VisitDate})
+	if err != nil {
+		return err
+	}
+	payment, err := h.verifyPayment(patientId, createVisitDto.PaymentIntentID, createVisitDto.NurseId, pricing.RequestScheduled, quote)
+	if err != nil {
+		return err
+	}
+
 	confirmationCode, err := utils.GenerateAuthCode()
 	if err != nil {
+		h.releasePayment(createVisitDto.PaymentIntentID)
 		return fmt.Errorf("Erro ao gerar codigo de confirmação: %w", err)
 	}
 
 	visit := model.Visit{
 		ID:               primitive.NewObjectID(),
-		Status:           "PENDING",
+		Status:           model.VisitStatusPending,
 		ConfirmationCode: strconv.Itoa(confirmationCode),
 
 		PatientId:    patientId,
@@ -193,19 +325,24 @@ func (h *userService) VisitSolicitation(patientId string, createVisitDto userDTO
 
 		VisitType:        createVisitDto.VisitType,
 		VisitDate:        createVisitDto.VisitDate,
-		VisitValue:       createVisitDto.VisitValue,
+		VisitValue:       quote.Total,
 		VisitRequestType: "SCHEDULED",
 
 		PaymentIntentID: createVisitDto.PaymentIntentID,
+		PaymentStatus:   payment.status,
+		PaymentMethod:   payment.method,
+		Discount:        payment.discount(payment.discountInCents),
 
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err = h.visitRepository.CreateVisit(visit)
+	err = h.visitRepository.CreateVisitReservingSlot(visit, scheduling.ReservedWindow(scheduling.DefaultVisitDuration))
 	if err != nil {
+		h.releasePayment(createVisitDto.PaymentIntentID)
 		return err
 	}
+	h.redeemCoupon(patientId, createVisitDto.PaymentIntentID, payment)
 
 	//utils.SendEmailVisitSolicitation(nurse.Email, patient.Name, createVisitDto.VisitDate.String(), "100", patient.Address)
 	utils.SendEmailVisitSolicitation(nurse.Email, patient.Name, createVisitDto.VisitDate.String(), visit.VisitValue, patient.Address)
@@ -213,6 +350,345 @@ func (h *userService) VisitSolicitation(patientId string, createVisitDto userDTO
 	return nil
 }
 
+// VisitSeriesSolicitation cria uma série recorrente: todas as ocorrências precisam caber na
+// agenda do enfermeiro, senão nenhuma é criada.
+func (h *userService) VisitSeriesSolicitation(patientId string, createVisitSeriesDto userDTO.CreateVisitSeriesDto) (userDTO.VisitSeriesResponseDto, error) {
+	createVisitDto := createVisitSeriesDto.CreateVisitDto
+	rule := model.RecurrenceRule{
+		Weekdays: createVisitSeriesDto.Recurrence.Weekdays,
+		Interval: createVisitSeriesDto.Recurrence.Interval,
+		EndDate:  createVisitSeriesDto.Recurrence.EndDate,
+		Count:    createVisitSeriesDto.Recurrence.Count,
+	}
+
+	occurrences, err := scheduling.ExpandRecurrence(rule, createVisitDto.VisitDate)
+	if err != nil {
+		return userDTO.VisitSeriesResponseDto{}, err
+	}
+
+	patient, err := h.userRepository.FindUserById(patientId)
+	if err != nil {
+		return userDTO.VisitSeriesResponseDto{}, err
+	}
+
+	nurse, err := h.nurseRepository.FindNurseById(createVisitDto.NurseId)
+	if err != nil {
+		return userDTO.VisitSeriesResponseDto{}, err
+	}
+
+	for _, occurrence := range occurrences {
+		if err := h.checkNurseSlot(nurse, occurrence, ""); err != nil {
+			return userDTO.VisitSeriesResponseDto{}, fmt.Errorf("Visita de %s: %w", occurrence.Format("02/01/2006 15:04"), err)
+		}
+	}
+
+	// um único pagamento cobre todas as ocorrências da série
+	quote, err := h.pricingPolicy.Quote(nurse.Price, pricing.RequestScheduled, occurrences)
+	if err != nil {
+		return userDTO.VisitSeriesResponseDto{}, err
+	}
+	payment, err := h.verifyPayment(patientId, createVisitDto.PaymentIntentID, createVisitDto.NurseId, pricing.RequestScheduled, quote)
+	if err != nil {
+		return userDTO.VisitSeriesResponseDto{}, err
+	}
+
+	prices := make([]int64, len(quote.Visits))
+	for i, visitQuote := range quote.Visits {
+		prices[i] = pricing.ToCents(visitQuote.Price)
+	}
+	discounts := coupon.Split(payment.discountInCents, prices)
+
+	series := model.VisitSeries{
+		ID:         primitive.NewObjectID(),
+		PatientId:  patientId,
+		NurseId:    createVisitDto.NurseId,
+		Recurrence: rule,
+		Status:     model.VisitSeriesStatusActive,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := h.visitSeriesRepository.CreateSeries(series); err != nil {
+		h.releasePayment(createVisitDto.PaymentIntentID)
+		return userDTO.VisitSeriesResponseDto{}, fmt.Errorf("Erro ao criar série de visitas: %w", err)
+	}
+
+	created := make([]model.Visit, 0, len(occurrences))
+	for i, occurrence := range occurrences {
+		confirmationCode, err := utils.GenerateAuthCode()
+		if err != nil {
+			h.rollbackVisitSeries(series.ID.Hex(), createVisitDto.PaymentIntentID, created)
+			return userDTO.VisitSeriesResponseDto{}, fmt.Errorf("Erro ao gerar codigo de confirmação: %w", err)
+		}
+
+		visit := model.Visit{
+			ID:               primitive.NewObjectID(),
+			Status:           model.VisitStatusPending,
+			ConfirmationCode: strconv.Itoa(confirmationCode),
+
+			PatientId:    patientId,
+			PatientName:  patient.Name,
+			PatientEmail: patient.Email,
+
+			CEP:          createVisitDto.CEP,
+			Street:       createVisitDto.Street,
+			Number:       createVisitDto.Number,
+			Complement:   createVisitDto.Complement,
+			Neighborhood: createVisitDto.Neighborhood,
+
+			Description: createVisitDto.Description,
+			Reason:      createVisitDto.Reason,
+
+			NurseId:   createVisitDto.NurseId,
+			NurseName: nurse.Name,
+
+			VisitType:        createVisitDto.VisitType,
+			VisitDate:        occurrence,
+			VisitValue:       quote.Visits[i].Price,
+			VisitRequestType: "SCHEDULED",
+
+			PaymentIntentID: createVisitDto.PaymentIntentID,
+			PaymentStatus:   payment.status,
+			PaymentMethod:   payment.method,
+			Discount:        payment.discount(discounts[i]),
+
+			SeriesId:         series.ID.Hex(),
+			SeriesOccurrence: i + 1,
+
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		if err := h.visitRepository.CreateVisitReservingSlot(visit, scheduling.ReservedWindow(scheduling.DefaultVisitDuration)); err != nil {
+			h.rollbackVisitSeries(series.ID.Hex(), createVisitDto.PaymentIntentID, created)
+			return userDTO.VisitSeriesResponseDto{}, fmt.Errorf("Visita de %s: %w", occurrence.Format("02/01/2006 15:04"), err)
+		}
+		created = append(created, visit)
+	}
+	h.redeemCoupon(patientId, createVisitDto.PaymentIntentID, payment)
+
+	utils.SendEmailVisitSolicitation(nurse.Email, patient.Name, fmt.Sprintf("%s (série de %d visitas)", occurrences[0].Format("02/01/2006 15:04"), len(occurrences)), quote.Total, patient.Address)
+
+	return userDTO.VisitSeriesResponseDto{SeriesId: series.ID.Hex(), Visits: occurrences}, nil
+}
+
+// rollbackVisitSeries desfaz uma série criada pela metade e libera o pagamento dela.
+func (h *userService) rollbackVisitSeries(seriesId, paymentIntentId string, created []model.Visit) {
+	for _, visit := range created {
+		if err := h.visitRepository.DeleteVisit(visit.ID.Hex()); err != nil {
+			log.Printf("Erro ao desfazer visita %s da série %s: %v", visit.ID.Hex(), seriesId, err)
+		}
+	}
+	if err := h.visitSeriesRepository.DeleteSeries(seriesId); err != nil {
+		log.Printf("Erro ao desfazer série %s: %v", seriesId, err)
+	}
+	h.releasePayment(paymentIntentId)
+}
+
+func (h *userService) CancelVisitSeries(patientId, seriesId string, cancelDto userDTO.CancelVisitSeriesDto) (int, error) {
+	actor := lifecycle.Actor{ID: patientId, Role: lifecycle.RolePatient}
+
+	canceled, err := h.visitSeriesManager.Cancel(seriesId, cancelDto.VisitId, cancelDto.Scope, actor, cancelDto.Reason, h.cancellationUpdates)
+	for _, visit := range canceled {
+		h.visitHub.StopLocationSharing(visit)
+	}
+	if err != nil {
+		return len(canceled), err
+	}
+
+	if len(canceled) > 0 {
+		nurse, err := h.nurseRepository.FindNurseById(canceled[0].NurseId)
+		if err == nil {
+			utils.SendEmailVisitCanceledByPatient(nurse.Email, canceled[0].PatientName, canceled[0].VisitDate.Format("02/01/2006 15:04"), cancelDto.Reason)
+		}
+	}
+
+	return len(canceled), nil
+}
+
+// CancelVisit cancela uma visita PENDING ou CONFIRMED a pedido do paciente, aplicando a
+// política de cancelamento para definir o valor a ser reembolsado.
+func (h *userService) CancelVisit(patientId, visitId, reason string) (userDTO.CancelVisitResponseDto, error) {
+	visit, err := h.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		return userDTO.CancelVisitResponseDto{}, err
+	}
+
+	actor := lifecycle.Actor{ID: patientId, Role: lifecycle.RolePatient}
+	if err := lifecycle.Validate(visit, model.VisitStatusCanceled, actor); err != nil {
+		return userDTO.CancelVisitResponseDto{}, err
+	}
+
+	quote, err := h.cancellationPolicy.Quote(visit, time.Now())
+	if err != nil {
+		return userDTO.CancelVisitResponseDto{}, err
+	}
+
+	visitUpdates := map[string]interface{}{
+		"cancel_reason":    reason,
+		"cancellation_fee": quote.Fee,
+		"refund_amount":    quote.RefundAmount,
+	}
+
+	visit, err = h.visitStateMachine.Transition(visit, model.VisitStatusCanceled, actor, reason, visitUpdates)
+	if err != nil {
+		return userDTO.CancelVisitResponseDto{}, err
+	}
+	h.visitHub.StopLocationSharing(visit)
+
+	visitDate := visit.VisitDate.In(scheduling.Location()).Format("02/01/2006 15:04")
+	if nurse, err := h.nurseRepository.FindNurseById(visit.NurseId); err == nil {
+		utils.SendEmailVisitCanceledByPatient(nurse.Email, visit.PatientName, visitDate, reason)
+	}
+	h.visitHub.NotifyVisitEvent(visit.NurseId, chatDTO.VisitEventDTO{
+		Type:    "VISIT_CANCELED",
+		VisitID: visitId,
+		Message: fmt.Sprintf("%s cancelou a visita de %s.", visit.PatientName, visitDate),
+	})
+
+	return userDTO.CancelVisitResponseDto{
+		VisitId:         visitId,
+		RefundAmount:    quote.RefundAmount,
+		CancellationFee: quote.Fee,
+	}, nil
+}
+
+// cancellationUpdates aplica a política de cancelamento, como em CancelVisit, a cada ocorrência
+// de uma série cancelada pelo paciente.
+func (h *userService) cancellationUpdates(visit model.Visit) (map[string]interface{}, error) {
+	quote, err := h.cancellationPolicy.Quote(visit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"cancellation_fee": quote.Fee,
+		"refund_amount":    quote.RefundAmount,
+	}, nil
+}
+
+// ProposeReschedule registra o pedido do paciente para mover a visita. A nova data precisa
+// estar livre na agenda do enfermeiro e só passa a valer depois que ele aceitar.
+func (h *userService) ProposeReschedule(patientId, visitId string, rescheduleDto userDTO.RescheduleVisitDto) error {
+	visit, err := h.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		return err
+	}
+
+	if visit.PatientId != patientId {
+		return fmt.Errorf("Essa visita é pertencente à outro paciente.")
+	}
+	if visit.Status != model.VisitStatusPending && visit.Status != model.VisitStatusConfirmed {
+		return fmt.Errorf("Apenas visitas pendentes ou confirmadas podem ser remarcadas.")
+	}
+	if !time.Now().Before(visit.VisitDate) {
+		return fmt.Errorf("Não é possível remarcar uma visita cujo horário já passou.")
+	}
+
+	nurse, err := h.nurseRepository.FindNurseById(visit.NurseId)
+	if err != nil {
+		return err
+	}
+
+	if err := h.checkNurseSlot(nurse, rescheduleDto.VisitDate, visitId); err != nil {
+		return err
+	}
+
+	proposal := model.RescheduleProposal{
+		ProposedDate: rescheduleDto.VisitDate,
+		PreviousDate: visit.VisitDate,
+		Reason:       rescheduleDto.Reason,
+		Status:       model.RescheduleStatusPending,
+		ProposedAt:   time.Now(),
+	}
+
+	if _, err := h.visitRepository.UpdateVisitFields(visitId, map[string]interface{}{"reschedule": proposal}); err != nil {
+		return fmt.Errorf("Erro ao registrar pedido de remarcação: %w", err)
+	}
+
+	location := scheduling.Location()
+	currentDate := visit.VisitDate.In(location).Format("02/01/2006 15:04")
+	proposedDate := rescheduleDto.VisitDate.In(location).Format("02/01/2006 15:04")
+
+	utils.SendEmailRescheduleProposal(nurse.Email, visit.PatientName, currentDate, proposedDate, rescheduleDto.Reason)
+	h.visitHub.NotifyVisitEvent(visit.NurseId, chatDTO.VisitEventDTO{
+		Type:    "VISIT_RESCHEDULE_PROPOSED",
+		VisitID: visitId,
+		Message: fmt.Sprintf("%s pediu para remarcar a visita de %s para %s.", visit.PatientName, currentDate, proposedDate),
+		Data:    proposal,
+	})
+
+	return nil
+}
+
+// checkNurseSlot garante que a visita pedida cabe em um horário livre da agenda do enfermeiro.
+// ignoreVisitId desconsidera uma visita já existente (ex: a própria visita sendo remarcada).
+func (h *userService) checkNurseSlot(nurse model.Nurse, visitDate time.Time, ignoreVisitId string) error {
+	location := scheduling.Location()
+	day := visitDate.In(location)
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+
+	// Inclui a visita que começa antes da meia-noite e ainda ocupa o início do dia.
+	visits, err := h.visitRepository.FindActiveVisitsForNurseBetween(nurse.ID.Hex(), dayStart.Add(-scheduling.ReservedWindow(scheduling.DefaultVisitDuration)), dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return fmt.Errorf("Erro ao buscar a agenda do enfermeiro: %w", err)
+	}
+
+	if ignoreVisitId != "" {
+		others := make([]model.Visit, 0, len(visits))
+		for _, visit := range visits {
+			if visit.ID.Hex() != ignoreVisitId {
+				others = append(others, visit)
+			}
+		}
+		visits = others
+	}
+
+	return scheduling.CheckSlot(nurse, visits, visitDate, scheduling.DefaultVisitDuration, time.Now())
+}
+
+func (h *userService) GetNurseSlots(nurseId string, from, to time.Time) (userDTO.NurseSlotsResponseDto, error) {
+	if to.Before(from) {
+		return userDTO.NurseSlotsResponseDto{}, fmt.Errorf("A data final deve ser posterior à data inicial.")
+	}
+	if to.Sub(from) > 31*24*time.Hour {
+		return userDTO.NurseSlotsResponseDto{}, fmt.Errorf("O intervalo máximo de consulta é de 31 dias.")
+	}
+
+	nurse, err := h.nurseRepository.FindNurseById(nurseId)
+	if err != nil {
+		return userDTO.NurseSlotsResponseDto{}, err
+	}
+
+	location := scheduling.Location()
+	from = from.In(location)
+	to = to.In(location)
+	rangeStart := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+	rangeEnd := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+
+	visits, err := h.visitRepository.FindActiveVisitsForNurseBetween(nurseId, rangeStart.Add(-scheduling.ReservedWindow(scheduling.DefaultVisitDuration)), rangeEnd)
+	if err != nil {
+		return userDTO.NurseSlotsResponseDto{}, fmt.Errorf("Erro ao buscar a agenda do enfermeiro: %w", err)
+	}
+
+	slots, err := scheduling.FreeSlots(nurse, visits, rangeStart, to, scheduling.DefaultVisitDuration, time.Now())
+	if err != nil {
+		return userDTO.NurseSlotsResponseDto{}, err
+	}
+
+	response := userDTO.NurseSlotsResponseDto{
+		NurseId:         nurseId,
+		From:            rangeStart,
+		To:              rangeEnd,
+		DurationMinutes: int(scheduling.DefaultVisitDuration.Minutes()),
+		Slots:           make([]userDTO.SlotDto, 0, len(slots)),
+	}
+	for _, slot := range slots {
+		response.Slots = append(response.Slots, userDTO.SlotDto{Start: slot.Start, End: slot.End})
+	}
+
+	return response, nil
+}
+
 func (h *userService) FindAllVisits(patientId string) (userDTO.VisitsResponseDto, error) {
 
 	responseDto := userDTO.VisitsResponseDto{
@@ -263,7 +739,7 @@ func (h *userService) FindAllVisits(patientId string) (userDTO.VisitsResponseDto
 			VisitType:   visit.VisitType,
 			CreatedAt:   visit.CreatedAt.Format("02/01/2006 15:04"),
 			Date:        visit.VisitDate.Format("02/01/2006 15:04"),
-			Status:      visit.Status,
+			Status:      string(visit.Status),
 			// Se 'visitReview' estiver vazio (devido ao ErrNoDocuments),
 			// 'visitReview.Rating' será o valor zero (ex: 0), que é o correto.
 			Rating: visitReview.Rating,
@@ -278,7 +754,7 @@ func (h *userService) FindAllVisits(patientId string) (userDTO.VisitsResponseDto
 		responseDto.AllVisits = append(responseDto.AllVisits, visitDto)
 
 		visitDate := visit.VisitDate.In(location) // Garante que a data da visita está no mesmo fuso
-		isConfirmed := visit.Status == "CONFIRMED"
+		isConfirmed := visit.Status == model.VisitStatusConfirmed
 		isToday := (visitDate.Equal(todayStart) || visitDate.After(todayStart)) && visitDate.Before(tomorrowStart)
 
 		if isConfirmed && isToday {
@@ -355,48 +831,23 @@ func (s *userService) DeleteUser(patientId string, deleteAccountPasswordDto user
 	return nil
 }
 
-func (s *userService) ConfirmVisitService(visitId, patientId string) error {
-	visit, err := s.visitRepository.FindVisitById(visitId)
-	if err != nil {
-		return fmt.Errorf("Erro ao buscar visita")
-	}
-
-	if visit.PatientId != patientId {
-		return fmt.Errorf("Visita pertence à outro paciente.")
-	}
-
-	// logica de liberar o dinheiro retido para o enfermeiro
-
-	if visit.Status != "CONFIRMED" {
-		return fmt.Errorf("O status da visita deve estar com status confirmada para ser completada.")
-	}
-
-	visitUpdate := bson.M{
-		"status":     "COMPLETED",
-		"updated_at": time.Now(),
-	}
-
-	//salve user com status true/false
-	visit, err = s.visitRepository.UpdateVisitFields(visitId, visitUpdate)
+func (s *userService) GetOnlineNurses(userId string, query userDTO.NurseListQueryDto) (userDTO.NurseListPageDto, error) {
+	patient, err := s.userRepository.FindUserById(userId)
 	if err != nil {
-		return fmt.Errorf("Erro ao atualizar status de visita: %w", err)
+		return userDTO.NurseListPageDto{Nurses: []userDTO.AllNursesListDto{}}, nil
 	}
 
-	return nil
-}
-
-func (s *userService) GetOnlineNurses(userId string) ([]userDTO.AllNursesListDto, error) {
-	patient, err := s.userRepository.FindUserById(userId)
+	search, err := nurseSearch(patient, query)
 	if err != nil {
-		return []userDTO.AllNursesListDto{}, nil
+		return userDTO.NurseListPageDto{}, err
 	}
 
-	latitude := patient.Latitude
-	longitude := patient.Longitude
-
-	onlineNurses, err := s.nurseRepository.GetAllOnlineNurses(patient.City, latitude, longitude)
+	onlineNurses, err := s.nurseRepository.GetAllOnlineNurses(search)
 	if err != nil {
-		return []userDTO.AllNursesListDto{}, nil
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return userDTO.NurseListPageDto{}, invalidNurseQuery("cursor de paginação inválido.")
+		}
+		return userDTO.NurseListPageDto{Nurses: []userDTO.AllNursesListDto{}}, nil
 	}
 
 	return onlineNurses, nil
@@ -431,7 +882,7 @@ func (s *userService) GetPatientVisitInfo(patientId, visitId string) (userDTO.Pa
 
 	visitDto := userDTO.VisitInfoDto{
 		ID:               visit.ID.Hex(),
-		Status:           visit.Status,
+		Status:           string(visit.Status),
 		Description:      visit.Description,
 		Reason:           visit.Reason,
 		CancelReason:     visit.CancelReason,
@@ -445,6 +896,9 @@ func (s *userService) GetPatientVisitInfo(patientId, visitId string) (userDTO.Pa
 		UpdatedAt:        visit.UpdatedAt.String(),
 		ConfirmationCode: visit.ConfirmationCode,
 	}
+	if visit.Receipt != nil {
+		visitDto.ReceiptNumber = visit.Receipt.Number
+	}
 
 	nurseDto := userDTO.NurseInfoDto{
 		ID:              nurse.ID.Hex(),
@@ -466,6 +920,32 @@ func (s *userService) GetPatientVisitInfo(patientId, visitId string) (userDTO.Pa
 	return patientVisitInfo, nil
 }
 
+// GetVisitReceipt retorna o PDF do recibo de uma visita concluída do paciente. Se a emissão
+// falhou na conclusão da visita, o recibo é emitido agora.
+func (s *userService) GetVisitReceipt(patientId, visitId string) (*dto.FileData, error) {
+	visit, err := s.visitRepository.FindVisitById(visitId)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar id da visita.")
+	}
+
+	if visit.PatientId != patientId {
+		return nil, fmt.Errorf("Essa visita é pertencente à outro paciente.")
+	}
+
+	visitReceipt, err := s.receiptIssuer.Issue(visit)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := s.userRepository.FindFileByID(context.Background(), visitReceipt.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao buscar recibo: %w", err)
+	}
+	fileData.Filename = receipt.FileName(visitReceipt.Number)
+
+	return fileData, nil
+}
+
 func (s *userService) AddReview(userId, visitId string, reviewDto userDTO.ReviewDTO) error {
 
 	visit, err := s.visitRepository.FindVisitById(visitId)
@@ -475,7 +955,7 @@ func (s *userService) AddReview(userId, visitId string, reviewDto userDTO.Review
 
 	//valida se a visita ja foi avaliada.
 
-	if visit.Status != "COMPLETED" {
+	if visit.Status != model.VisitStatusCompleted {
 		return fmt.Errorf("A visita ainda não foi completada. Portanto não é possível deixar uma avaliação.")
 	}
 
@@ -530,12 +1010,22 @@ func (s *userService) ImmediateVisitSolicitation(patientId string, immediateVisi
 		return "", fmt.Errorf("O(A) enfermeiro(a) %s não está online no momento e não pode receber solicitações imediatas", nurse.Name)
 	}
 
+	visitDate := time.Now()
+	quote, err := s.pricingPolicy.Quote(nurse.Price, pricing.RequestImmediate, []time.Time{visitDate})
+	if err != nil {
+		return "", err
+	}
+	payment, err := s.verifyPayment(patientId, immediateVisitDto.PaymentIntentID, immediateVisitDto.NurseId, pricing.RequestImmediate, quote)
+	if err != nil {
+		return "", err
+	}
+
 	codeInt, _ := utils.GenerateAuthCode()
 	code := strconv.Itoa(codeInt)
 
 	visit := model.Visit{
 		ID:               primitive.NewObjectID(),
-		Status:           "PENDING",
+		Status:           model.VisitStatusPending,
 		ConfirmationCode: code,
 
 		PatientId:    patient.ID.Hex(),
@@ -555,22 +1045,27 @@ func (s *userService) ImmediateVisitSolicitation(patientId string, immediateVisi
 		NurseName: nurse.Name,
 
 		PaymentIntentID: immediateVisitDto.PaymentIntentID,
+		PaymentStatus:   payment.status,
+		PaymentMethod:   payment.method,
+		Discount:        payment.discount(payment.discountInCents),
 
-		VisitValue: nurse.Price,
+		VisitValue: quote.Total,
 
 		VisitRequestType: "IMMEDIATE",
 		VisitType:        immediateVisitDto.VisitType,
-		VisitDate:        time.Now(),
+		VisitDate:        visitDate,
 
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err = s.visitRepository.CreateVisit(visit)
+	err = s.visitRepository.CreateVisitReservingSlot(visit, scheduling.ReservedWindow(scheduling.DefaultVisitDuration))
 	if err != nil {
+		s.releasePayment(immediateVisitDto.PaymentIntentID)
 		return "", fmt.Errorf("Erro ao criar visita: %w", err)
 
 	}
+	s.redeemCoupon(patientId, immediateVisitDto.PaymentIntentID, payment)
 
 	// ===================================================================
 	// 6. LÓGICA DE NOTIFICAÇÃO VIA WEBSOCKET (NOVA)
@@ -678,3 +1173,196 @@ func (s *userService) GetPatientProfile(patientId string) (userDTO.PatientProfil
 
 	return patientProfile, nil
 }
+
+// BroadcastVisitSolicitation pede uma visita imediata a qualquer enfermeiro online próximo.
+// A visita é criada sem enfermeiro e fica com o primeiro que aceitar.
+func (s *userService) BroadcastVisitSolicitation(patientId string, broadcastDto userDTO.BroadcastVisitDTO) (string, error) {
+	patient, err := s.userRepository.FindUserById(patientId)
+	if err != nil {
+		return "", fmt.Errorf("Erro ao buscar id de paciente.")
+	}
+
+	visitDate := time.Now()
+	quote, err := s.pricingPolicy.Quote(0, pricing.RequestBroadcast, []time.Time{visitDate})
+	if err != nil {
+		return "", err
+	}
+	payment, err := s.verifyPayment(patientId, broadcastDto.PaymentIntentID, "", pricing.RequestBroadcast, quote)
+	if err != nil {
+		return "", err
+	}
+
+	codeInt, err := utils.GenerateAuthCode()
+	if err != nil {
+		s.releasePayment(broadcastDto.PaymentIntentID)
+		return "", fmt.Errorf("Erro ao gerar codigo de confirmação: %w", err)
+	}
+
+	visit := model.Visit{
+		ID:               primitive.NewObjectID(),
+		Status:           model.VisitStatusPending,
+		ConfirmationCode: strconv.Itoa(codeInt),
+
+		PatientId:    patient.ID.Hex(),
+		PatientName:  patient.Name,
+		PatientEmail: patient.Email,
+
+		CEP:          broadcastDto.CEP,
+		Street:       broadcastDto.Street,
+		Number:       broadcastDto.Number,
+		Complement:   broadcastDto.Complement,
+		Neighborhood: broadcastDto.Neighborhood,
+
+		Description: broadcastDto.Description,
+		Reason:      broadcastDto.Reason,
+
+		PaymentIntentID: broadcastDto.PaymentIntentID,
+		PaymentStatus:   payment.status,
+		PaymentMethod:   payment.method,
+		Discount:        payment.discount(payment.discountInCents),
+		VisitValue:      quote.Total,
+
+		VisitRequestType: "IMMEDIATE",
+		VisitType:        broadcastDto.VisitType,
+		VisitDate:        visitDate,
+
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	visit, err = s.dispatcher.Start(visit, patient)
+	if err != nil {
+		s.releasePayment(broadcastDto.PaymentIntentID)
+		return "", err
+	}
+	s.redeemCoupon(patientId, broadcastDto.PaymentIntentID, payment)
+
+	return visit.ID.Hex(), nil
+}
+
+// ErrPaymentNotVerified indica que o PaymentIntent informado não paga a visita solicitada.
+var ErrPaymentNotVerified = errors.New("Pagamento não confirmado.")
+
+// payableStatuses são as situações do PaymentIntent em que o paciente já autorizou o
+// pagamento, com a situação inicial do pagamento da visita. Pagamentos ainda em processamento
+// ficam sem situação até o webhook confirmá-los.
+var payableStatuses = map[stripe.PaymentIntentStatus]string{
+	stripe.PaymentIntentStatusSucceeded:       model.PaymentStatusSucceeded,
+	stripe.PaymentIntentStatusProcessing:      "",
+	stripe.PaymentIntentStatusRequiresCapture: model.PaymentStatusAuthorized,
+}
+
+// verifiedPayment é a situação e a forma do pagamento conferido, gravadas na visita, e o
+// desconto de cupom aplicado quando o PaymentIntent foi criado.
+type verifiedPayment struct {
+	status          string
+	method          string
+	couponId        string
+	couponCode      string
+	discountInCents int64
+}
+
+// discount retorna a parte do desconto do cupom que coube a uma visita, ou nil sem cupom.
+func (p verifiedPayment) discount(shareInCents int64) *model.VisitDiscount {
+	if p.couponId == "" {
+		return nil
+	}
+	return &model.VisitDiscount{CouponID: p.couponId, Code: p.couponCode, AmountInCents: shareInCents}
+}
+
+// verifyPayment confere no Stripe que o PaymentIntent foi criado para o paciente, para o
+// mesmo enfermeiro e tipo de solicitação, tem o valor calculado para a visita, já foi
+// autorizado e ainda não pagou outra visita. Visitas para qualquer enfermeiro usam nurseId vazio.
+// O PaymentIntent conferido fica reservado para a solicitação; se a visita não for criada,
+// quem chamou libera a reserva com releasePayment.
+func (s *userService) verifyPayment(patientId, paymentIntentId, nurseId, requestType string, quote pricing.Quote) (verifiedPayment, error) {
+	used, err := s.visitRepository.FindVisitsByPaymentIntentId(paymentIntentId)
+	if err != nil {
+		return verifiedPayment{}, fmt.Errorf("Erro ao verificar pagamento: %w", err)
+	}
+	if len(used) > 0 {
+		return verifiedPayment{}, paymentNotVerified("Este pagamento já foi usado em outra visita.")
+	}
+
+	pi, err := s.paymentGateway.GetPaymentIntent(paymentIntentId)
+	if err != nil {
+		log.Printf("Erro ao buscar PaymentIntent %s: %v", paymentIntentId, err)
+		return verifiedPayment{}, paymentNotVerified("Pagamento não encontrado.")
+	}
+
+	if pi.Metadata["app_patient_id"] != patientId {
+		return verifiedPayment{}, paymentNotVerified("Este pagamento pertence a outro paciente.")
+	}
+	if pi.Metadata["nurse_id"] != nurseId || pi.Metadata["request_type"] != requestType {
+		return verifiedPayment{}, paymentNotVerified("Este pagamento foi feito para outro tipo de visita ou outro enfermeiro.")
+	}
+	// o desconto foi calculado pelo backend na criação do PaymentIntent e só pode ser alterado com a chave secreta
+	var discountInCents int64
+	if raw := pi.Metadata["discount_in_cents"]; raw != "" {
+		discountInCents, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || discountInCents < 0 {
+			return verifiedPayment{}, paymentNotVerified("O desconto do pagamento é inválido.")
+		}
+	}
+	expectedInCents := quote.AmountInCents() - discountInCents
+	if pi.Currency != stripe.CurrencyBRL || pi.Amount != expectedInCents {
+		return verifiedPayment{}, paymentNotVerified(fmt.Sprintf("O valor pago (R$ %.2f) não corresponde ao valor da visita (R$ %.2f).", float64(pi.Amount)/100, float64(expectedInCents)/100))
+	}
+	paymentStatus, ok := payableStatuses[pi.Status]
+	if !ok {
+		if pi.Metadata["payment_method"] == model.PaymentMethodPix {
+			return verifiedPayment{}, paymentNotVerified("O PIX ainda não foi pago.")
+		}
+		return verifiedPayment{}, paymentNotVerified("O pagamento ainda não foi autorizado.")
+	}
+
+	method := pi.Metadata["payment_method"]
+	if method == "" {
+		method = model.PaymentMethodCard
+	}
+	payment := verifiedPayment{status: paymentStatus, method: method}
+	if discountInCents > 0 {
+		payment.couponId = pi.Metadata["coupon_id"]
+		payment.couponCode = pi.Metadata["coupon_code"]
+		payment.discountInCents = discountInCents
+	}
+
+	// a busca acima não impede duas solicitações simultâneas com o mesmo pagamento; a reserva sim
+	if err := s.visitRepository.ClaimPaymentIntent(paymentIntentId, patientId); err != nil {
+		if errors.Is(err, repository.ErrPaymentIntentClaimed) {
+			return verifiedPayment{}, paymentNotVerified("Este pagamento já foi usado em outra visita.")
+		}
+		return verifiedPayment{}, fmt.Errorf("Erro ao verificar pagamento: %w", err)
+	}
+	return payment, nil
+}
+
+// releasePayment libera o PaymentIntent reservado por verifyPayment quando a visita não é
+// criada, para o paciente poder usá-lo numa nova solicitação.
+func (s *userService) releasePayment(paymentIntentId string) {
+	if err := s.visitRepository.ReleasePaymentIntent(paymentIntentId); err != nil {
+		log.Printf("Erro ao liberar pagamento %s: %v", paymentIntentId, err)
+	}
+}
+
+// redeemCoupon conta o uso do cupom do pagamento depois que a visita foi criada. A visita já
+// existe, então uma falha aqui é apenas logada.
+func (s *userService) redeemCoupon(patientId, paymentIntentId string, payment verifiedPayment) {
+	if payment.couponId == "" {
+		return
+	}
+
+	if err := s.couponService.Redeem(model.CouponRedemption{
+		CouponID:        payment.couponId,
+		Code:            payment.couponCode,
+		PatientID:       patientId,
+		PaymentIntentID: paymentIntentId,
+		DiscountInCents: payment.discountInCents,
+	}); err != nil {
+		log.Printf("Erro ao registrar uso do cupom %s no pagamento %s: %v", payment.couponCode, paymentIntentId, err)
+	}
+}
+
+func paymentNotVerified(message string) error {
+	return fmt.Errorf("%w %s", ErrPaymentNotVerified, message)
+}
@@ -2,13 +2,24 @@ package user
 
 import (
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	authDTO "medassist/internal/auth/dto"
+	"medassist/internal/dispatch"
+	"medassist/internal/lifecycle"
 	"medassist/internal/model"
+	"medassist/internal/pricing"
+	receiptmocks "medassist/internal/receipt/mocks"
+	"medassist/internal/repository"
 	repmocks "medassist/internal/repository/mocks"
+	"medassist/internal/scheduling"
 	"medassist/internal/user/dto"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v76"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/mock/gomock"
 )
 
@@ -22,11 +33,11 @@ func TestUserService_GetAllNurses(t *testing.T) {
 		visitRepo := repmocks.NewMockVisitRepository(ctrl)
 		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
 
-		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil)
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
 
 		userRepo.EXPECT().FindUserById("id-invalido").Return(model.User{}, fmt.Errorf("Erro db"))
 
-		_, err := service.GetAllNurses("id-invalido")
+		_, err := service.GetAllNurses("id-invalido", dto.NurseListQueryDto{})
 		
 		assert.Error(t, err)
 		assert.EqualError(t, err, "Erro ao buscar id de paciente.")
@@ -41,19 +52,102 @@ func TestUserService_GetAllNurses(t *testing.T) {
 		visitRepo := repmocks.NewMockVisitRepository(ctrl)
 		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
 
-		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil)
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
 
 		fakeUser := model.User{City: "São Paulo"}
 		userRepo.EXPECT().FindUserById("paciente-sp").Return(fakeUser, nil)
 
 		fakeNurses := []dto.AllNursesListDto{{Name: "Nurse 1"}}
-		nurseRepo.EXPECT().GetAllNurses("São Paulo").Return(fakeNurses, nil)
+		nurseRepo.EXPECT().GetAllNurses(dto.NurseSearchDto{City: "São Paulo", Limit: DefaultNursePageSize}).Return(dto.NurseListPageDto{Nurses: fakeNurses}, nil)
 
-		resp, err := service.GetAllNurses("paciente-sp")
+		resp, err := service.GetAllNurses("paciente-sp", dto.NurseListQueryDto{})
 		
 		assert.NoError(t, err)
-		assert.Len(t, resp, 1)
-		assert.Equal(t, "Nurse 1", resp[0].Name)
+		assert.Len(t, resp.Nurses, 1)
+		assert.Equal(t, "Nurse 1", resp.Nurses[0].Name)
+	})
+
+	t.Run("Erro_Raio_Acima_Do_Maximo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		userRepo.EXPECT().FindUserById("paciente-sp").Return(model.User{City: "São Paulo"}, nil)
+
+		_, err := service.GetAllNurses("paciente-sp", dto.NurseListQueryDto{RadiusKm: MaxSearchRadiusKm + 1})
+
+		assert.ErrorIs(t, err, ErrInvalidNurseQuery)
+	})
+
+	t.Run("Sucesso_Repassa_Filtros_E_Ordenacao", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		userRepo.EXPECT().FindUserById("paciente-sp").Return(model.User{City: "São Paulo"}, nil)
+
+		tuesday := time.Tuesday
+		nurseRepo.EXPECT().GetAllNurses(dto.NurseSearchDto{
+			City: "São Paulo", Service: "Curativo", MinPrice: 50, MaxPrice: 150, MinRating: 4,
+			Day: &tuesday, SortField: "price", SortDesc: true, Cursor: "abc", Limit: 10,
+		}).Return(dto.NurseListPageDto{NextCursor: "def"}, nil)
+
+		resp, err := service.GetAllNurses("paciente-sp", dto.NurseListQueryDto{
+			Service: " Curativo ", MinPrice: 50, MaxPrice: 150, MinRating: 4,
+			Day: "terça-feira", Sort: "-price", Cursor: "abc", Limit: 10,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "def", resp.NextCursor)
+	})
+
+	t.Run("Erro_Ordenacao_Invalida", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		userRepo.EXPECT().FindUserById("paciente-sp").Return(model.User{City: "São Paulo"}, nil)
+
+		_, err := service.GetAllNurses("paciente-sp", dto.NurseListQueryDto{Sort: "name"})
+
+		assert.ErrorIs(t, err, ErrInvalidNurseQuery)
+	})
+
+	t.Run("Erro_Cursor_Invalido", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		userRepo.EXPECT().FindUserById("paciente-sp").Return(model.User{City: "São Paulo"}, nil)
+		nurseRepo.EXPECT().GetAllNurses(gomock.Any()).Return(dto.NurseListPageDto{}, repository.ErrInvalidCursor)
+
+		_, err := service.GetAllNurses("paciente-sp", dto.NurseListQueryDto{Cursor: "xyz"})
+
+		assert.ErrorIs(t, err, ErrInvalidNurseQuery)
 	})
 }
 
@@ -67,14 +161,14 @@ func TestUserService_GetOnlineNurses(t *testing.T) {
 		visitRepo := repmocks.NewMockVisitRepository(ctrl)
 		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
 
-		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil)
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
 
 		userRepo.EXPECT().FindUserById("id-invalido").Return(model.User{}, fmt.Errorf("Erro db"))
 
-		resp, err := service.GetOnlineNurses("id-invalido")
+		resp, err := service.GetOnlineNurses("id-invalido", dto.NurseListQueryDto{})
 		
 		assert.NoError(t, err)
-		assert.Len(t, resp, 0)
+		assert.Len(t, resp.Nurses, 0)
 	})
 
 	t.Run("Sucesso_Retorna_Enfermeiros_Online_Na_Area", func(t *testing.T) {
@@ -86,19 +180,19 @@ func TestUserService_GetOnlineNurses(t *testing.T) {
 		visitRepo := repmocks.NewMockVisitRepository(ctrl)
 		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
 
-		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil)
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
 
 		fakeUser := model.User{City: "Santos", Latitude: 12.3, Longitude: 45.6}
 		userRepo.EXPECT().FindUserById("paciente-santos").Return(fakeUser, nil)
 
 		fakeNurses := []dto.AllNursesListDto{{Name: "Nurse Online"}}
-		nurseRepo.EXPECT().GetAllOnlineNurses("Santos", 12.3, 45.6).Return(fakeNurses, nil)
+		nurseRepo.EXPECT().GetAllOnlineNurses(dto.NurseSearchDto{City: "Santos", Latitude: 12.3, Longitude: 45.6, RadiusKm: 10, Limit: DefaultNursePageSize}).Return(dto.NurseListPageDto{Nurses: fakeNurses}, nil)
 
-		resp, err := service.GetOnlineNurses("paciente-santos")
+		resp, err := service.GetOnlineNurses("paciente-santos", dto.NurseListQueryDto{RadiusKm: 10})
 		
 		assert.NoError(t, err)
-		assert.Len(t, resp, 1)
-		assert.Equal(t, "Nurse Online", resp[0].Name)
+		assert.Len(t, resp.Nurses, 1)
+		assert.Equal(t, "Nurse Online", resp.Nurses[0].Name)
 	})
 }
 
@@ -112,7 +206,7 @@ func TestUserService_GetNurseProfile(t *testing.T) {
 		visitRepo := repmocks.NewMockVisitRepository(ctrl)
 		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
 
-		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil)
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
 
 		fakeNurse := model.Nurse{
 			Name: "Incompleto",
@@ -136,7 +230,7 @@ func TestUserService_GetNurseProfile(t *testing.T) {
 		visitRepo := repmocks.NewMockVisitRepository(ctrl)
 		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
 
-		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil)
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
 
 		fakeNurse := model.Nurse{
 			Name: "Nurse Completo",
@@ -161,3 +255,416 @@ func TestUserService_GetNurseProfile(t *testing.T) {
 		assert.Equal(t, "John", resp.Reviews[0].PatientName)
 	})
 }
+
+func TestUserService_VisitSolicitation(t *testing.T) {
+	t.Run("Erro_Horario_Ocupado", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		visitDate := time.Now().AddDate(0, 0, 1).Truncate(time.Hour)
+		fakeNurse := model.Nurse{
+			Name:              "Nurse",
+			MaxPatientsPerDay: 5,
+			DaysAvailable:     []string{visitDate.In(scheduling.Location()).Weekday().String()},
+			StartTime:         "00:00",
+			EndTime:           "23:59",
+		}
+		existing := []model.Visit{{Status: model.VisitStatusConfirmed, VisitDate: visitDate}}
+
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{Name: "Paciente"}, nil)
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(fakeNurse, nil)
+		visitRepo.EXPECT().FindActiveVisitsForNurseBetween(gomock.Any(), gomock.Any(), gomock.Any()).Return(existing, nil)
+
+		err := service.VisitSolicitation("patient-1", dto.CreateVisitDto{NurseId: "nurse-1", VisitDate: visitDate})
+
+		assert.ErrorIs(t, err, scheduling.ErrSlotUnavailable)
+	})
+}
+
+func TestUserService_VisitSolicitation_Payment(t *testing.T) {
+	visitDate := time.Now().AddDate(0, 0, 1).Truncate(time.Hour)
+	fakeNurse := model.Nurse{
+		Name:              "Nurse",
+		Price:             200,
+		MaxPatientsPerDay: 5,
+		DaysAvailable:     []string{visitDate.In(scheduling.Location()).Weekday().String()},
+		StartTime:         "00:00",
+		EndTime:           "23:59",
+	}
+	createVisitDto := dto.CreateVisitDto{NurseId: "nurse-1", PaymentIntentID: "pi_123", VisitDate: visitDate}
+
+	setup := func(t *testing.T) (UserService, *repmocks.MockVisitRepository, *repmocks.MockPaymentGateway) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		gateway := repmocks.NewMockPaymentGateway(ctrl)
+
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{Name: "Paciente"}, nil)
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(fakeNurse, nil)
+		visitRepo.EXPECT().FindActiveVisitsForNurseBetween(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, repmocks.NewMockReviewRepository(ctrl), nil, gateway, nil, nil, nil, nil, nil)
+		return service, visitRepo, gateway
+	}
+
+	paymentIntent := func(patientId string, amount int64, status stripe.PaymentIntentStatus) *stripe.PaymentIntent {
+		return &stripe.PaymentIntent{
+			ID:       "pi_123",
+			Amount:   amount,
+			Currency: stripe.CurrencyBRL,
+			Status:   status,
+			Metadata: map[string]string{"app_patient_id": patientId, "nurse_id": "nurse-1", "request_type": pricing.RequestScheduled},
+		}
+	}
+
+	t.Run("Erro_Pagamento_Ja_Usado", func(t *testing.T) {
+		service, visitRepo, _ := setup(t)
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return([]model.Visit{{}}, nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+	})
+
+	t.Run("Erro_Pagamento_De_Outro_Paciente", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(paymentIntent("patient-2", 20000, stripe.PaymentIntentStatusSucceeded), nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+	})
+
+	t.Run("Erro_Pagamento_De_Outro_Enfermeiro", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		other := paymentIntent("patient-1", 20000, stripe.PaymentIntentStatusSucceeded)
+		other.Metadata["nurse_id"] = "nurse-2"
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(other, nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+	})
+
+	t.Run("Erro_Pagamento_De_Outro_Tipo_De_Visita", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		immediate := paymentIntent("patient-1", 20000, stripe.PaymentIntentStatusSucceeded)
+		immediate.Metadata["request_type"] = pricing.RequestImmediate
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(immediate, nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+	})
+
+	t.Run("Erro_Valor_Menor_Que_O_Da_Visita", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(paymentIntent("patient-1", 100, stripe.PaymentIntentStatusSucceeded), nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+		assert.Contains(t, err.Error(), "R$ 200.00")
+	})
+
+	t.Run("Erro_Pagamento_Nao_Autorizado", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(paymentIntent("patient-1", 20000, stripe.PaymentIntentStatusRequiresPaymentMethod), nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+	})
+
+	t.Run("Erro_Pix_Ainda_Nao_Pago", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		pix := paymentIntent("patient-1", 20000, stripe.PaymentIntentStatusRequiresAction)
+		pix.Metadata["payment_method"] = model.PaymentMethodPix
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(pix, nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+		assert.Contains(t, err.Error(), "PIX")
+	})
+
+	t.Run("Erro_Valor_Cheio_Com_Desconto_Do_Cupom", func(t *testing.T) {
+		service, visitRepo, gateway := setup(t)
+		discounted := paymentIntent("patient-1", 20000, stripe.PaymentIntentStatusSucceeded)
+		discounted.Metadata["discount_in_cents"] = "5000"
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(discounted, nil)
+
+		err := service.VisitSolicitation("patient-1", createVisitDto)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+		assert.Contains(t, err.Error(), "R$ 150.00")
+	})
+}
+
+func TestUserService_VerifyPayment(t *testing.T) {
+	quote := pricing.Quote{Total: 200, Visits: []pricing.VisitPrice{{Price: 200}}}
+
+	setup := func(t *testing.T, pi *stripe.PaymentIntent) *userService {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		gateway := repmocks.NewMockPaymentGateway(ctrl)
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil)
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(pi, nil)
+		visitRepo.EXPECT().ClaimPaymentIntent("pi_123", "patient-1").Return(nil).MaxTimes(1)
+
+		return NewUserService(nil, nil, visitRepo, nil, nil, gateway, nil, nil, nil, nil, nil).(*userService)
+	}
+
+	t.Run("Sucesso_Desconto_Do_Cupom", func(t *testing.T) {
+		service := setup(t, &stripe.PaymentIntent{
+			ID: "pi_123", Amount: 15000, Currency: stripe.CurrencyBRL, Status: stripe.PaymentIntentStatusRequiresCapture,
+			Metadata: map[string]string{"app_patient_id": "patient-1", "nurse_id": "nurse-1", "request_type": pricing.RequestScheduled, "coupon_id": "coupon-1", "coupon_code": "BEMVINDO", "discount_in_cents": "5000"},
+		})
+
+		payment, err := service.verifyPayment("patient-1", "pi_123", "nurse-1", pricing.RequestScheduled, quote)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PaymentStatusAuthorized, payment.status)
+		assert.Equal(t, &model.VisitDiscount{CouponID: "coupon-1", Code: "BEMVINDO", AmountInCents: 5000}, payment.discount(payment.discountInCents))
+	})
+
+	t.Run("Sucesso_Sem_Cupom", func(t *testing.T) {
+		service := setup(t, &stripe.PaymentIntent{
+			ID: "pi_123", Amount: 20000, Currency: stripe.CurrencyBRL, Status: stripe.PaymentIntentStatusSucceeded,
+			Metadata: map[string]string{"app_patient_id": "patient-1", "nurse_id": "nurse-1", "request_type": pricing.RequestScheduled},
+		})
+
+		payment, err := service.verifyPayment("patient-1", "pi_123", "nurse-1", pricing.RequestScheduled, quote)
+
+		assert.NoError(t, err)
+		assert.Nil(t, payment.discount(0))
+	})
+
+	t.Run("Erro_Desconto_Invalido", func(t *testing.T) {
+		service := setup(t, &stripe.PaymentIntent{
+			ID: "pi_123", Amount: 15000, Currency: stripe.CurrencyBRL, Status: stripe.PaymentIntentStatusSucceeded,
+			Metadata: map[string]string{"app_patient_id": "patient-1", "nurse_id": "nurse-1", "request_type": pricing.RequestScheduled, "discount_in_cents": "-5000"},
+		})
+
+		_, err := service.verifyPayment("patient-1", "pi_123", "nurse-1", pricing.RequestScheduled, quote)
+
+		assert.ErrorIs(t, err, ErrPaymentNotVerified)
+	})
+}
+
+// stubDispatcher registra as visitas imediatas que o serviço pediu para oferecer.
+type stubDispatcher struct {
+	mu      sync.Mutex
+	started []model.Visit
+	err     error
+}
+
+func (d *stubDispatcher) Start(visit model.Visit, patient model.User) (model.Visit, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return model.Visit{}, d.err
+	}
+	d.started = append(d.started, visit)
+	return visit, nil
+}
+
+func (d *stubDispatcher) Accept(visitId, nurseId string) (model.Visit, error) {
+	return model.Visit{}, nil
+}
+
+func TestUserService_BroadcastVisitSolicitation_Payment(t *testing.T) {
+	broadcastDto := dto.BroadcastVisitDTO{PaymentIntentID: "pi_123"}
+	pi := &stripe.PaymentIntent{
+		ID: "pi_123", Amount: 15000, Currency: stripe.CurrencyBRL, Status: stripe.PaymentIntentStatusRequiresCapture,
+		Metadata: map[string]string{"app_patient_id": "patient-1", "request_type": pricing.RequestBroadcast},
+	}
+
+	setup := func(t *testing.T, dispatcher *stubDispatcher) (UserService, *repmocks.MockVisitRepository) {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		gateway := repmocks.NewMockPaymentGateway(ctrl)
+
+		userRepo.EXPECT().FindUserById("patient-1").Return(model.User{ID: primitive.NewObjectID(), Name: "Paciente"}, nil).AnyTimes()
+		// as duas solicitações leem antes de qualquer visita ser criada
+		visitRepo.EXPECT().FindVisitsByPaymentIntentId("pi_123").Return(nil, nil).AnyTimes()
+		gateway.EXPECT().GetPaymentIntent("pi_123").Return(pi, nil).AnyTimes()
+
+		service := NewUserService(userRepo, nil, visitRepo, nil, nil, gateway, nil, nil, nil, dispatcher, nil)
+		service.(*userService).pricingPolicy = pricing.Policy{BroadcastBasePrice: 150}
+		return service, visitRepo
+	}
+
+	t.Run("Erro_Pagamento_Enviado_Duas_Vezes_Cria_Uma_Visita", func(t *testing.T) {
+		dispatcher := &stubDispatcher{}
+		service, visitRepo := setup(t, dispatcher)
+
+		var mu sync.Mutex
+		claimed := false
+		visitRepo.EXPECT().ClaimPaymentIntent("pi_123", "patient-1").DoAndReturn(func(paymentIntentId, patientId string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if claimed {
+				return repository.ErrPaymentIntentClaimed
+			}
+			claimed = true
+			return nil
+		}).Times(2)
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = service.BroadcastVisitSolicitation("patient-1", broadcastDto)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Len(t, dispatcher.started, 1)
+		if errs[0] == nil {
+			assert.ErrorIs(t, errs[1], ErrPaymentNotVerified)
+		} else {
+			assert.ErrorIs(t, errs[0], ErrPaymentNotVerified)
+			assert.NoError(t, errs[1])
+		}
+	})
+
+	t.Run("Erro_Visita_Nao_Criada_Libera_Pagamento", func(t *testing.T) {
+		dispatcher := &stubDispatcher{err: dispatch.ErrNoNurseAvailable}
+		service, visitRepo := setup(t, dispatcher)
+		visitRepo.EXPECT().ClaimPaymentIntent("pi_123", "patient-1").Return(nil)
+		visitRepo.EXPECT().ReleasePaymentIntent("pi_123").Return(nil)
+
+		_, err := service.BroadcastVisitSolicitation("patient-1", broadcastDto)
+
+		assert.ErrorIs(t, err, dispatch.ErrNoNurseAvailable)
+	})
+}
+
+func TestUserService_CancelVisit(t *testing.T) {
+	t.Run("Erro_Visita_De_Outro_Paciente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		visit := model.Visit{Status: model.VisitStatusConfirmed, PatientId: "patient-2", VisitDate: time.Now().Add(48 * time.Hour)}
+		visitRepo.EXPECT().FindVisitById("visit-1").Return(visit, nil)
+
+		_, err := service.CancelVisit("patient-1", "visit-1", "")
+
+		assert.EqualError(t, err, "Essa visita é pertencente à outro paciente.")
+	})
+
+	t.Run("Erro_Visita_Concluida", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		visit := model.Visit{Status: model.VisitStatusCompleted, PatientId: "patient-1"}
+		visitRepo.EXPECT().FindVisitById("visit-1").Return(visit, nil)
+
+		_, err := service.CancelVisit("patient-1", "visit-1", "")
+
+		assert.ErrorIs(t, err, lifecycle.ErrInvalidTransition)
+	})
+}
+
+func TestUserService_ProposeReschedule(t *testing.T) {
+	t.Run("Erro_Nova_Data_Fora_Da_Agenda", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		nurseRepo := repmocks.NewMockNurseRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		reviewRepo := repmocks.NewMockReviewRepository(ctrl)
+
+		service := NewUserService(userRepo, nurseRepo, visitRepo, reviewRepo, nil, nil, nil, nil, nil, nil, nil)
+
+		visit := model.Visit{Status: model.VisitStatusConfirmed, PatientId: "patient-1", NurseId: "nurse-1", VisitDate: time.Now().Add(48 * time.Hour)}
+		fakeNurse := model.Nurse{DaysAvailable: []string{}, StartTime: "08:00", EndTime: "18:00"}
+
+		visitRepo.EXPECT().FindVisitById("visit-1").Return(visit, nil)
+		nurseRepo.EXPECT().FindNurseById("nurse-1").Return(fakeNurse, nil)
+		visitRepo.EXPECT().FindActiveVisitsForNurseBetween(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		err := service.ProposeReschedule("patient-1", "visit-1", dto.RescheduleVisitDto{VisitDate: time.Now().Add(72 * time.Hour)})
+
+		assert.ErrorIs(t, err, scheduling.ErrSlotUnavailable)
+	})
+}
+
+func TestUserService_GetVisitReceipt(t *testing.T) {
+	t.Run("Erro_VisitaDeOutroPaciente", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		issuer := receiptmocks.NewMockIssuer(ctrl)
+		service := NewUserService(nil, nil, visitRepo, nil, nil, nil, nil, nil, nil, nil, issuer)
+
+		visitRepo.EXPECT().FindVisitById("visita-1").Return(model.Visit{PatientId: "outro-paciente"}, nil)
+
+		_, err := service.GetVisitReceipt("paciente-1", "visita-1")
+
+		assert.EqualError(t, err, "Essa visita é pertencente à outro paciente.")
+	})
+
+	t.Run("Sucesso_RetornaPdfDoRecibo", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userRepo := repmocks.NewMockUserRepository(ctrl)
+		visitRepo := repmocks.NewMockVisitRepository(ctrl)
+		issuer := receiptmocks.NewMockIssuer(ctrl)
+		service := NewUserService(userRepo, nil, visitRepo, nil, nil, nil, nil, nil, nil, nil, issuer)
+
+		visit := model.Visit{PatientId: "paciente-1", Status: model.VisitStatusCompleted}
+		visitReceipt := model.VisitReceipt{Number: "MA-1", FileID: primitive.NewObjectID()}
+		visitRepo.EXPECT().FindVisitById("visita-1").Return(visit, nil)
+		issuer.EXPECT().Issue(visit).Return(visitReceipt, nil)
+		userRepo.EXPECT().FindFileByID(gomock.Any(), visitReceipt.FileID).Return(&authDTO.FileData{Data: []byte("%PDF-"), ContentType: "application/pdf"}, nil)
+
+		fileData, err := service.GetVisitReceipt("paciente-1", "visita-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "recibo-MA-1.pdf", fileData.Filename)
+		assert.Equal(t, "application/pdf", fileData.ContentType)
+	})
+}
@@ -23,5 +23,17 @@ func SetupAdminRoutes(r *gin.RouterGroup, container *di.Container) {
 		admin.PATCH("visit/:id", middleware.AuthAdmin(), container.AdminHandler.UpdateVisit)
 		admin.DELETE("/user/:id", middleware.AuthAdmin(), container.AdminHandler.DeleteUser)
 		admin.DELETE("/visit/:id", middleware.AuthAdmin(), container.AdminHandler.DeleteVisit)
+		admin.GET("/commission-rules", middleware.AuthAdmin(), container.CommissionHandler.ListRules)
+		admin.POST("/commission-rules", middleware.AuthAdmin(), container.CommissionHandler.CreateRule)
+		admin.PUT("/commission-rules/:id", middleware.AuthAdmin(), container.CommissionHandler.UpdateRule)
+		admin.DELETE("/commission-rules/:id", middleware.AuthAdmin(), container.CommissionHandler.DeleteRule)
+		admin.GET("/coupons", middleware.AuthAdmin(), container.CouponHandler.ListCoupons)
+		admin.POST("/coupons", middleware.AuthAdmin(), container.CouponHandler.CreateCoupon)
+		admin.PUT("/coupons/:id", middleware.AuthAdmin(), container.CouponHandler.UpdateCoupon)
+		admin.DELETE("/coupons/:id", middleware.AuthAdmin(), container.CouponHandler.DeleteCoupon)
+		admin.GET("/disputes", middleware.AuthAdmin(), container.DisputeHandler.ListDisputes)
+		admin.GET("/disputes/:id", middleware.AuthAdmin(), container.DisputeHandler.GetDispute)
+		admin.POST("/disputes/:id/evidence", middleware.AuthAdmin(), container.DisputeHandler.SubmitEvidence)
+		admin.POST("/disputes/:id/reverse-transfers", middleware.AuthAdmin(), container.DisputeHandler.ReverseTransfers)
 	}
 }
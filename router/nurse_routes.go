@@ -15,6 +15,7 @@ func SetupNurseRoutes(r *gin.RouterGroup, container *di.Container) {
 		nurse.PATCH("/online", middleware.AuthNurse(), container.NurseHandler.ChangeOnlineNurse)       // ativa online de nurse para receber chamadas de visitas DONE
 		nurse.GET("/visits", middleware.AuthNurse(), container.NurseHandler.GetAllVisits)              // retorna todas visitas possiveis / marcadas TODO
 		nurse.PATCH("/visit/:id", middleware.AuthNurse(), container.NurseHandler.ConfirmOrCancelVisit) // confirma que uma enfermeira ira para a visita
+		nurse.PATCH("/visit/:id/reschedule", middleware.AuthNurse(), container.NurseHandler.AnswerReschedule)
 		nurse.GET("/patient/:id", middleware.AuthUserOrNurse(), container.NurseHandler.GetPatientProfile)
 		nurse.PATCH("/update", middleware.AuthNurse(), container.NurseHandler.UpdateNurseProfile)
 		nurse.DELETE("/delete", middleware.AuthNurse(), container.NurseHandler.DeleteNurseProfile)
@@ -26,7 +27,11 @@ func SetupNurseRoutes(r *gin.RouterGroup, container *di.Container) {
 		nurse.PATCH("/service-confirmation/:id", middleware.AuthNurse(), container.NurseHandler.VisitServiceConfirmation)
 		nurse.PATCH("/offline", middleware.AuthNurse(), container.NurseHandler.TurnOfflineOnLogout)
 		nurse.PATCH("/reject-visit/:id", middleware.AuthNurse(), container.NurseHandler.RejectVisit)
+		nurse.PATCH("/dispatch/:id/accept", middleware.AuthNurse(), container.NurseHandler.AcceptBroadcastVisit)
+		nurse.PATCH("/visit-series/:id/cancel", middleware.AuthNurse(), container.NurseHandler.CancelVisitSeries)
 		nurse.POST("/review/:id", middleware.AuthNurse(), container.NurseHandler.AddReview)
+		nurse.GET("/earnings", middleware.AuthNurse(), container.NurseHandler.GetEarnings)
+		nurse.GET("/earnings/statement", middleware.AuthNurse(), container.NurseHandler.DownloadEarningsStatement)
 		nurse.POST("/stripe-onboarding", middleware.AuthNurse(), container.NurseHandler.SetupStripeOnboarding)
 	}
 }
@@ -11,5 +11,12 @@ func SetupPaymentRoutes(r *gin.RouterGroup, container *di.Container) {
 	payment := r.Group("/payment")
 	{
 		payment.POST("/create-intent",middleware.AuthUser(), container.PaymentHandler.CreatePaymentIntent)
+		payment.POST("/webhook", container.PaymentHandler.Webhook)
+		payment.GET("/ledger", middleware.AuthAdmin(), container.PaymentHandler.ListPayments)
+		payment.GET("/visit/:id", middleware.AuthUserOrNurse(), container.PaymentHandler.GetVisitPayments)
+		payment.POST("/visit/:id/tip", middleware.AuthUser(), container.PaymentHandler.TipVisit)
+		payment.GET("/methods", middleware.AuthUser(), container.PaymentHandler.ListPaymentMethods)
+		payment.PUT("/methods/:id/default", middleware.AuthUser(), container.PaymentHandler.SetDefaultPaymentMethod)
+		payment.DELETE("/methods/:id", middleware.AuthUser(), container.PaymentHandler.DetachPaymentMethod)
 	}
 }
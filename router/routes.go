@@ -15,7 +15,9 @@ import (
 func InitializeRoutes() *gin.Engine {
 	container := di.NewContainer()
 	router := gin.Default()
-	go container.ChatHub.Run() //Isso inicia a execução de container.ChatHub.Run() em uma nova goroutine (de forma assíncrona e não bloqueante).
+	go container.ChatHub.Run()        //Isso inicia a execução de container.ChatHub.Run() em uma nova goroutine (de forma assíncrona e não bloqueante).
+	go container.ExpirySweeper.Run()  // Expira em segundo plano as visitas pendentes sem resposta.
+	go container.CaptureSweeper.Run() // Captura os pagamentos autorizados antes que a autorização expire.
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins: []string{
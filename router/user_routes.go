@@ -15,15 +15,21 @@ func SetupUserRoutes(r *gin.RouterGroup, container *di.Container) {
 		user.GET("/online_nurses", middleware.AuthUser(), container.UserHandler.GetOnlineNurses)
 		user.POST("/visit", middleware.AuthUser(), container.UserHandler.VisitSolicitation) // agendamento de visita TODO
 		user.POST("/immediate-visit", middleware.AuthUser(), container.UserHandler.ImmediateVisitSolicitation)
-		user.PATCH("/visit/:id", middleware.AuthUser(), container.UserHandler.ConfirmVisitService)
+		user.POST("/immediate-visit/broadcast", middleware.AuthUser(), container.UserHandler.BroadcastVisitSolicitation)
+		user.POST("/visit-series", middleware.AuthUser(), container.UserHandler.VisitSeriesSolicitation)
+		user.PATCH("/visit-series/:id/cancel", middleware.AuthUser(), container.UserHandler.CancelVisitSeries)
+		user.PATCH("/visit/:id/cancel", middleware.AuthUser(), container.UserHandler.CancelVisit)
+		user.POST("/visit/:id/reschedule", middleware.AuthUser(), container.UserHandler.ProposeReschedule)
 		user.GET("/visits", middleware.AuthUser(), container.UserHandler.GetAllVisits)
 		user.GET("/file/:id", container.UserHandler.GetFileByID)
 		user.POST("/contact", container.UserHandler.ContactUsMessage)
 		user.GET("/nurse/:id", middleware.AuthUserOrNurse(), container.UserHandler.GetNurseProfile)
+		user.GET("/nurse/:id/slots", middleware.AuthUser(), container.UserHandler.GetNurseSlots)
 		user.GET("/my-profile", middleware.AuthUser(), container.UserHandler.GetMyUserProfile)
 		user.PATCH("/update", middleware.AuthUser(), container.UserHandler.UpdateUser)
 		user.DELETE("/delete", middleware.AuthUser(), container.UserHandler.DeleteUser)
 		user.GET("/visit-info/:id", middleware.AuthUser(), container.UserHandler.GetPatientVisitInfo)
+		user.GET("/visit-info/:id/receipt", middleware.AuthUser(), container.UserHandler.GetVisitReceipt)
 		user.POST("/review/:id", middleware.AuthUser(), container.UserHandler.AddReview)
 	}
 }
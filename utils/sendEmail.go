@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/base64"
 	"fmt"
 	"medassist/internal/user/dto"
 	"os"
@@ -23,6 +24,26 @@ func sendEmailWithSendGrid(toEmail, subject, plainTextContent, htmlContent, repl
 		message.SetReplyTo(replyTo)
 	}
 
+	return sendMessage(message, toEmail, subject)
+}
+
+// sendEmailWithAttachment envia o e-mail com um arquivo anexo (ex: recibo em PDF).
+func sendEmailWithAttachment(toEmail, subject, plainTextContent, htmlContent, fileName, contentType string, content []byte) error {
+	from := mail.NewEmail("MEDASSIST", os.Getenv("EMAIL_SENDER"))
+	to := mail.NewEmail("", toEmail)
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	attachment := mail.NewAttachment().
+		SetContent(base64.StdEncoding.EncodeToString(content)).
+		SetType(contentType).
+		SetFilename(fileName).
+		SetDisposition("attachment")
+	message.AddAttachment(attachment)
+
+	return sendMessage(message, toEmail, subject)
+}
+
+func sendMessage(message *mail.SGMailV3, toEmail, subject string) error {
 	client := sendgrid.NewSendClient(os.Getenv("SENDGRID_API_KEY"))
 	response, err := client.Send(message)
 
@@ -1140,3 +1161,258 @@ func CreateVisitCanceledWithReasonHTML(nurseName string, visitDate string, cance
     </html>
     `, nurseName, visitDate, nurseName, visitDate, cancelReason)
 }
+
+func SendEmailVisitCanceledByPatient(nurseEmail string, patientName string, visitDate string, cancelReason string) error {
+	subject := "❌ Visita Cancelada pelo Paciente"
+
+	htmlContent := CreateVisitCanceledByPatientHTML(patientName, visitDate, cancelReason)
+
+	plainTextContent := fmt.Sprintf(
+		"O paciente %s cancelou a visita agendada para %s. Motivo: %s",
+		patientName,
+		visitDate,
+		cancelReason,
+	)
+
+	return sendEmailWithSendGrid(nurseEmail, subject, plainTextContent, htmlContent, "")
+}
+
+func CreateVisitCanceledByPatientHTML(patientName string, visitDate string, cancelReason string) string {
+	return fmt.Sprintf(`
+    <!DOCTYPE html>
+    <html lang="pt-BR">
+    <head>
+        <meta charset="UTF-8">
+        <title>Visita Cancelada</title>
+    </head>
+    <body>
+        <div class="container">
+            <h2>❌ Cancelamento de Visita</h2>
+            <p>Olá,</p>
+            <p>O paciente <strong>%s</strong> cancelou a visita agendada para <strong>%s</strong>. O horário foi liberado na sua agenda.</p>
+            <p>Motivo informado:</p>
+            <div class="reason-box">
+                %s
+            </div>
+            <div class="footer">
+                <p>Este é um e-mail automático. Por favor, não responda.</p>
+            </div>
+        </div>
+    </body>
+    </html>
+    `, patientName, visitDate, cancelReason)
+}
+
+func SendEmailRescheduleProposal(nurseEmail string, patientName string, currentDate string, proposedDate string, reason string) error {
+	subject := "📅 Pedido de Remarcação de Visita"
+
+	htmlContent := CreateRescheduleProposalHTML(patientName, currentDate, proposedDate, reason)
+
+	plainTextContent := fmt.Sprintf(
+		"O paciente %s pediu para remarcar a visita de %s para %s. Motivo: %s. Acesse a plataforma para aceitar ou recusar.",
+		patientName,
+		currentDate,
+		proposedDate,
+		reason,
+	)
+
+	return sendEmailWithSendGrid(nurseEmail, subject, plainTextContent, htmlContent, "")
+}
+
+func CreateRescheduleProposalHTML(patientName string, currentDate string, proposedDate string, reason string) string {
+	return fmt.Sprintf(`
+    <!DOCTYPE html>
+    <html lang="pt-BR">
+    <head>
+        <meta charset="UTF-8">
+        <title>Pedido de Remarcação</title>
+    </head>
+    <body>
+        <div class="container">
+            <h2>📅 Pedido de Remarcação</h2>
+            <p>Olá,</p>
+            <p>O paciente <strong>%s</strong> pediu para remarcar uma visita.</p>
+            <div class="details-box">
+                <div class="detail-item"><strong>Data atual:</strong> %s</div>
+                <div class="detail-item"><strong>Nova data proposta:</strong> %s</div>
+            </div>
+            <p>Motivo informado:</p>
+            <div class="reason-box">
+                %s
+            </div>
+            <p>Acesse a plataforma para aceitar ou recusar a nova data.</p>
+            <div class="footer">
+                <p>Este é um e-mail automático. Por favor, não responda.</p>
+            </div>
+        </div>
+    </body>
+    </html>
+    `, patientName, currentDate, proposedDate, reason)
+}
+
+func SendEmailRescheduleAnswered(patientEmail string, nurseName string, proposedDate string, accepted bool) error {
+	subject := "📅 Remarcação Recusada"
+	answer := "recusou"
+	if accepted {
+		subject = "✅ Remarcação Aceita"
+		answer = "aceitou"
+	}
+
+	htmlContent := CreateRescheduleAnsweredHTML(nurseName, proposedDate, answer)
+
+	plainTextContent := fmt.Sprintf(
+		"O enfermeiro(a) %s %s a remarcação da sua visita para %s.",
+		nurseName,
+		answer,
+		proposedDate,
+	)
+
+	return sendEmailWithSendGrid(patientEmail, subject, plainTextContent, htmlContent, "")
+}
+
+func CreateRescheduleAnsweredHTML(nurseName string, proposedDate string, answer string) string {
+	return fmt.Sprintf(`
+    <!DOCTYPE html>
+    <html lang="pt-BR">
+    <head>
+        <meta charset="UTF-8">
+        <title>Remarcação de Visita</title>
+    </head>
+    <body>
+        <div class="container">
+            <h2>📅 Remarcação de Visita</h2>
+            <p>Olá,</p>
+            <p>O enfermeiro(a) <strong>%s</strong> %s a remarcação da sua visita para <strong>%s</strong>.</p>
+            <div class="footer">
+                <p>Este é um e-mail automático. Por favor, não responda.</p>
+            </div>
+        </div>
+    </body>
+    </html>
+    `, nurseName, answer, proposedDate)
+}
+
+func SendEmailVisitExpired(patientEmail string, patientName string, visitDate string, refundAmount float64) error {
+	subject := "⌛ Visita Expirada"
+
+	htmlContent := CreateVisitExpiredHTML(patientName, visitDate, fmt.Sprintf("R$ %.2f", refundAmount))
+
+	plainTextContent := fmt.Sprintf(
+		"Olá %s, sua solicitação de visita para %s não foi respondida a tempo e expirou. O valor de R$ %.2f será estornado para o seu meio de pagamento.",
+		patientName,
+		visitDate,
+		refundAmount,
+	)
+
+	return sendEmailWithSendGrid(patientEmail, subject, plainTextContent, htmlContent, "")
+}
+
+func CreateVisitExpiredHTML(patientName string, visitDate string, refundAmount string) string {
+	return fmt.Sprintf(`
+    <!DOCTYPE html>
+    <html lang="pt-BR">
+    <head>
+        <meta charset="UTF-8">
+        <title>Visita Expirada</title>
+    </head>
+    <body>
+        <div class="container">
+            <h2>⌛ Visita Expirada</h2>
+            <p>Olá %s,</p>
+            <p>Sua solicitação de visita para <strong>%s</strong> não foi respondida a tempo e expirou.</p>
+            <div class="details-box">
+                <div class="detail-item"><strong>Valor estornado:</strong> %s</div>
+            </div>
+            <p>O estorno pode levar alguns dias para aparecer no seu meio de pagamento. Você pode solicitar uma nova visita a qualquer momento pela plataforma.</p>
+            <div class="footer">
+                <p>Este é um e-mail automático. Por favor, não responda.</p>
+            </div>
+        </div>
+    </body>
+    </html>
+    `, patientName, visitDate, refundAmount)
+}
+
+func SendEmailRefundConfirmation(patientEmail string, patientName string, visitDate string, refundAmount float64) error {
+	subject := "💸 Estorno Confirmado"
+
+	htmlContent := CreateRefundConfirmationHTML(patientName, visitDate, fmt.Sprintf("R$ %.2f", refundAmount))
+
+	plainTextContent := fmt.Sprintf(
+		"Olá %s, o estorno de R$ %.2f referente à visita de %s foi solicitado ao seu meio de pagamento.",
+		patientName,
+		refundAmount,
+		visitDate,
+	)
+
+	return sendEmailWithSendGrid(patientEmail, subject, plainTextContent, htmlContent, "")
+}
+
+func CreateRefundConfirmationHTML(patientName string, visitDate string, refundAmount string) string {
+	return fmt.Sprintf(`
+    <!DOCTYPE html>
+    <html lang="pt-BR">
+    <head>
+        <meta charset="UTF-8">
+        <title>Estorno Confirmado</title>
+    </head>
+    <body>
+        <div class="container">
+            <h2>💸 Estorno Confirmado</h2>
+            <p>Olá %s,</p>
+            <p>Estornamos o pagamento da sua visita de <strong>%s</strong>, que não chegou a ser realizada.</p>
+            <div class="details-box">
+                <div class="detail-item"><strong>Valor estornado:</strong> %s</div>
+            </div>
+            <p>O estorno pode levar alguns dias para aparecer no seu meio de pagamento.</p>
+            <div class="footer">
+                <p>Este é um e-mail automático. Por favor, não responda.</p>
+            </div>
+        </div>
+    </body>
+    </html>
+    `, patientName, visitDate, refundAmount)
+}
+
+// SendEmailVisitReceipt envia ao paciente o recibo da visita concluída, em PDF anexo, para
+// pedir o reembolso ao plano de saúde.
+func SendEmailVisitReceipt(patientEmail string, patientName string, visitDate string, receiptNumber string, receiptPDF []byte) error {
+	subject := "🧾 Recibo da sua visita"
+
+	htmlContent := CreateVisitReceiptHTML(patientName, visitDate, receiptNumber)
+
+	plainTextContent := fmt.Sprintf(
+		"Olá %s, segue em anexo o recibo nº %s da visita de %s. Ele também pode ser baixado pela plataforma, nos detalhes da visita.",
+		patientName,
+		receiptNumber,
+		visitDate,
+	)
+
+	return sendEmailWithAttachment(patientEmail, subject, plainTextContent, htmlContent, "recibo-"+receiptNumber+".pdf", "application/pdf", receiptPDF)
+}
+
+func CreateVisitReceiptHTML(patientName string, visitDate string, receiptNumber string) string {
+	return fmt.Sprintf(`
+    <!DOCTYPE html>
+    <html lang="pt-BR">
+    <head>
+        <meta charset="UTF-8">
+        <title>Recibo da Visita</title>
+    </head>
+    <body>
+        <div class="container">
+            <h2>🧾 Recibo da Visita</h2>
+            <p>Olá %s,</p>
+            <p>Sua visita de <strong>%s</strong> foi concluída. O recibo segue em anexo e pode ser usado para pedir o reembolso ao seu plano de saúde.</p>
+            <div class="details-box">
+                <div class="detail-item"><strong>Recibo nº:</strong> %s</div>
+            </div>
+            <p>Você também pode baixar o recibo a qualquer momento pela plataforma, nos detalhes da visita.</p>
+            <div class="footer">
+                <p>Este é um e-mail automático. Por favor, não responda.</p>
+            </div>
+        </div>
+    </body>
+    </html>
+    `, patientName, visitDate, receiptNumber)
+}